@@ -16,7 +16,7 @@ import (
 	"github.com/rousage/shortener/internal/server"
 )
 
-func gracefulShutdown(ctx context.Context, logger *slog.Logger, apiServer *http.Server, done chan bool) {
+func gracefulShutdown(ctx context.Context, logger *slog.Logger, apiServer *http.Server, serverShutdown func(context.Context) error, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -28,12 +28,16 @@ func gracefulShutdown(ctx context.Context, logger *slog.Logger, apiServer *http.
 	stop() // Allow Ctrl+C to force shutdown
 
 	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	// the request it is currently handling and to drain the queued click events.
+	// It must not inherit the cancellation of the signal context, which is already done
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
 	defer cancel()
 	if err := apiServer.Shutdown(ctx); err != nil {
 		logger.DebugContext(ctx, "server forced to shutdown with error", "error", err)
 	}
+	if err := serverShutdown(ctx); err != nil {
+		logger.DebugContext(ctx, "background workers and click events forced to shutdown with error", "error", err)
+	}
 
 	logger.InfoContext(ctx, "server exiting")
 
@@ -51,7 +55,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	srv := server.New(cfg)
+	srv, serverShutdown := server.New(cfg)
 
 	otelShutdown, err := otel.SetupOTelSDK(ctx, logger, cfg.Otel)
 	if err != nil {
@@ -67,7 +71,7 @@ func main() {
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(ctx, logger, srv, done)
+	go gracefulShutdown(ctx, logger, srv, serverShutdown, done)
 
 	err = srv.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
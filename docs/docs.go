@@ -26,29 +26,86 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v1/admin/urls": {
+        "/v1/admin/audit": {
             "get": {
-                "description": "Retrieves a paginated list of all URLs created by users",
+                "description": "Retrieves a paginated list of the privileged actions made by admins and by the server itself, newest first. Entries cannot be changed or removed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Get all URLs",
+                "summary": "Get Audit Log",
                 "parameters": [
                     {
-                        "type": "boolean",
-                        "description": "Get custom URLs only",
-                        "name": "isCustom",
+                        "maxLength": 50,
+                        "minLength": 1,
+                        "type": "string",
+                        "description": "Only actions made by a specific user",
+                        "name": "actorId",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "url.update",
+                            "url.delete",
+                            "url.restore",
+                            "user.urls.delete",
+                            "user.block",
+                            "user.unblock",
+                            "url.quarantine",
+                            "reports.resolve",
+                            "domain_rule.create",
+                            "domain_rule.update",
+                            "domain_rule.delete",
+                            "domain_rule.disable_urls"
+                        ],
+                        "type": "string",
+                        "description": "Only actions of a specific type",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "url",
+                            "user",
+                            "domain_rule"
+                        ],
+                        "type": "string",
+                        "description": "Only actions on a specific type of target",
+                        "name": "targetType",
                         "in": "query"
                     },
                     {
                         "maxLength": 50,
                         "minLength": 1,
                         "type": "string",
-                        "description": "Get URLs created by a specific user",
-                        "name": "userId",
+                        "description": "Only actions on a specific target",
+                        "name": "targetId",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "success",
+                            "failure"
+                        ],
+                        "type": "string",
+                        "description": "Only successful or only failed actions",
+                        "name": "outcome",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Only actions made at or after this time (RFC 3339)",
+                        "name": "createdFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Only actions made before this time (RFC 3339)",
+                        "name": "createdTo",
                         "in": "query"
                     },
                     {
@@ -74,9 +131,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Paginated list of URLs",
+                        "description": "Paginated list of audit log entries",
                         "schema": {
-                            "$ref": "#/definitions/server.PaginatedURLs"
+                            "$ref": "#/definitions/server.PaginatedAuditLog"
                         }
                     },
                     "400": {
@@ -111,32 +168,53 @@ const docTemplate = `{
                 ]
             }
         },
-        "/v1/admin/urls/user/{userId}": {
-            "delete": {
-                "description": "Delete all URLs created by a user. Also removes them from cache.",
+        "/v1/admin/domain-rules": {
+            "get": {
+                "description": "Retrieves a paginated list of the rules the destinations of new and edited links are checked against, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Delete URLs created by a user",
+                "summary": "Get Domain Rules",
                 "parameters": [
                     {
-                        "maxLength": 50,
-                        "minLength": 1,
+                        "enum": [
+                            "block",
+                            "allow"
+                        ],
                         "type": "string",
-                        "description": "ID of the user",
-                        "name": "userId",
-                        "in": "path",
+                        "description": "Only the rules of a specific list",
+                        "name": "list",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Number of URLs deleted",
+                        "description": "Paginated list of domain rules",
                         "schema": {
-                            "$ref": "#/definitions/server.DeleteUserURLsResponse"
+                            "$ref": "#/definitions/server.PaginatedDomainRules"
                         }
                     },
                     "400": {
@@ -169,31 +247,36 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ]
-            }
-        },
-        "/v1/admin/urls/{code}": {
-            "delete": {
-                "description": "Deletes a URL. Also removes it from cache.",
+            },
+            "post": {
+                "description": "Adds a rule the destinations of new and edited links are checked against. Allow rules take precedence over block rules. The rule applies to all replicas within a few seconds, existing links are not affected until they are scanned.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Delete URL",
+                "summary": "Create Domain Rule",
                 "parameters": [
                     {
-                        "maxLength": 16,
-                        "type": "string",
-                        "description": "Short code of the URL",
-                        "name": "code",
-                        "in": "path",
-                        "required": true
+                        "description": "Rule to add",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.DomainRuleDTO"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content - URL successfully deleted"
+                    "201": {
+                        "description": "Created domain rule",
+                        "schema": {
+                            "$ref": "#/definitions/repository.DomainRule"
+                        }
                     },
                     "400": {
                         "description": "Validation failed",
@@ -213,8 +296,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.HTTPError"
                         }
                     },
-                    "404": {
-                        "description": "Short URL not found",
+                    "409": {
+                        "description": "Domain rule already exists",
                         "schema": {
                             "$ref": "#/definitions/server.HTTPError"
                         }
@@ -233,9 +316,9 @@ const docTemplate = `{
                 ]
             }
         },
-        "/v1/admin/users/block/{userId}": {
-            "post": {
-                "description": "Block a user in the system, preventing them from accessing their account.",
+        "/v1/admin/domain-rules/{id}": {
+            "put": {
+                "description": "Replaces a domain rule. The change applies to all replicas within a few seconds.",
                 "consumes": [
                     "application/json"
                 ],
@@ -245,32 +328,31 @@ const docTemplate = `{
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Block a user",
+                "summary": "Update Domain Rule",
                 "parameters": [
                     {
-                        "maxLength": 50,
-                        "minLength": 1,
-                        "type": "string",
-                        "description": "ID of the user",
-                        "name": "userId",
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID of the domain rule",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Block user request body",
+                        "description": "New rule",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.BlockUserDTO"
+                            "$ref": "#/definitions/server.DomainRuleDTO"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User Block entity",
+                    "200": {
+                        "description": "Updated domain rule",
                         "schema": {
-                            "$ref": "#/definitions/repository.UserBlock"
+                            "$ref": "#/definitions/repository.DomainRule"
                         }
                     },
                     "400": {
@@ -292,7 +374,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Domain rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Domain rule already exists",
                         "schema": {
                             "$ref": "#/definitions/server.HTTPError"
                         }
@@ -309,46 +397,29 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ]
-            }
-        },
-        "/v1/admin/users/blocks": {
-            "get": {
-                "description": "Retrieves a paginated list of all User Blocks created by admins",
+            },
+            "delete": {
+                "description": "Removes a domain rule. The change applies to all replicas within a few seconds, links disabled by a scan of the rule stay disabled.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Get all User Blocks",
+                "summary": "Delete Domain Rule",
                 "parameters": [
                     {
-                        "maximum": 10000,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "maximum": 100,
                         "minimum": 1,
                         "type": "integer",
-                        "default": 20,
-                        "description": "Page size",
-                        "name": "pageSize",
-                        "in": "query",
+                        "description": "ID of the domain rule",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Paginated list of User Blocks",
-                        "schema": {
-                            "$ref": "#/definitions/server.PaginatedUserBlocks"
-                        }
+                    "204": {
+                        "description": "No Content - Domain rule successfully deleted"
                     },
                     "400": {
                         "description": "Validation failed",
@@ -368,6 +439,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.HTTPError"
                         }
                     },
+                    "404": {
+                        "description": "Domain rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -382,36 +459,46 @@ const docTemplate = `{
                 ]
             }
         },
-        "/v1/admin/users/unblock/{userId}": {
+        "/v1/admin/domain-rules/{id}/scan": {
             "post": {
-                "description": "Unblock a user in the system, allowing them to access their account.",
+                "description": "Lists the existing links that point to a host matching a block rule, e.g. after the rule was added. Hosts matching an allow rule are skipped. With disable, the matching links stop resolving, like when an admin disables a reported link.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Admin"
                 ],
-                "summary": "Unblock a user",
+                "summary": "Scan Domain Rule",
                 "parameters": [
                     {
-                        "maxLength": 50,
-                        "minLength": 1,
-                        "type": "string",
-                        "description": "ID of the user",
-                        "name": "userId",
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID of the domain rule",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Whether to disable the matching links",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/server.ScanDomainRuleDTO"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Updated User Block entity",
+                        "description": "Matching links",
                         "schema": {
-                            "$ref": "#/definitions/repository.UserBlock"
+                            "$ref": "#/definitions/server.ScanDomainRuleResponse"
                         }
                     },
                     "400": {
-                        "description": "Validation failed",
+                        "description": "Validation failed or not a block rule",
                         "schema": {
                             "$ref": "#/definitions/server.HTTPValidationError"
                         }
@@ -429,7 +516,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Domain rule not found",
                         "schema": {
                             "$ref": "#/definitions/server.HTTPError"
                         }
@@ -448,43 +535,23 @@ const docTemplate = `{
                 ]
             }
         },
-        "/v1/health": {
-            "get": {
-                "description": "Returns basic health status of the application",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Health"
-                ],
-                "summary": "Simple Health Check",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/server.HealthResponse"
-                        }
-                    },
-                    "503": {
-                        "description": "Service Unavailable",
-                        "schema": {
-                            "$ref": "#/definitions/server.HealthResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/v1/urls": {
+        "/v1/admin/reports": {
             "get": {
-                "description": "Retrieves a paginated list of URLs created by the authenticated user",
+                "description": "Retrieves a paginated list of the links with open abuse reports, the most reported first. Reports are counted per link and per category.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "URLs"
+                    "Admin"
                 ],
-                "summary": "Get User URLs",
+                "summary": "Get Abuse Report Queue",
                 "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only quarantined (true) or only not quarantined (false) links",
+                        "name": "quarantined",
+                        "in": "query"
+                    },
                     {
                         "maximum": 10000,
                         "minimum": 1,
@@ -508,9 +575,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Paginated list of user URLs",
+                        "description": "Paginated list of reported links",
                         "schema": {
-                            "$ref": "#/definitions/server.PaginatedUserURLs"
+                            "$ref": "#/definitions/server.PaginatedAbuseReportQueue"
                         }
                     },
                     "400": {
@@ -543,9 +610,11 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ]
-            },
+            }
+        },
+        "/v1/admin/reports/{code}/resolve": {
             "post": {
-                "description": "Creates a shortened URL. Authenticated users can provide a custom short code (5-16 characters). Otherwise, a random code is generated.",
+                "description": "Resolves all open reports of a short URL and lifts its quarantine. dismiss keeps the link, disable stops it from resolving for good, delete moves it to the trash (requires delete:urls) and block_owner blocks the owner of the link (requires user:block).",
                 "consumes": [
                     "application/json"
                 ],
@@ -553,25 +622,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "URLs"
+                    "Admin"
                 ],
-                "summary": "Create Short URL",
+                "summary": "Resolve Abuse Reports",
                 "parameters": [
                     {
-                        "description": "URL and optional custom short code",
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Resolve reports request body",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/server.CreateShortUrlDTO"
+                            "$ref": "#/definitions/server.ResolveReportsDTO"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created short URL",
+                    "200": {
+                        "description": "Resolved reports",
                         "schema": {
-                            "$ref": "#/definitions/repository.Url"
+                            "$ref": "#/definitions/server.ResolveReportsResponse"
                         }
                     },
                     "400": {
@@ -580,17 +657,28 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.HTTPValidationError"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
                     "403": {
-                        "description": "Custom short codes require authentication",
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found or has no open reports",
                         "schema": {
                             "$ref": "#/definitions/server.HTTPError"
                         }
                     },
                     "409": {
-                        "description": "Short code already taken or validation failed",
+                        "description": "Short URL has no owner to block",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/server.HTTPError"
                         }
                     },
                     "500": {
@@ -607,31 +695,51 @@ const docTemplate = `{
                 ]
             }
         },
-        "/v1/urls/{code}": {
+        "/v1/admin/trash/urls": {
             "get": {
-                "description": "Retrieves the original long URL for a given short code. Checks cache first, then database.",
+                "description": "Retrieves a paginated list of all URLs in the trash that can still be restored",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "URLs"
+                    "Admin"
                 ],
-                "summary": "Get Long URL",
+                "summary": "Get all deleted URLs",
                 "parameters": [
                     {
-                        "maxLength": 16,
+                        "maxLength": 50,
+                        "minLength": 1,
                         "type": "string",
-                        "description": "Short code",
-                        "name": "code",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
+                        "description": "Get URLs created by a specific user",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
                     "200": {
-                        "description": "longUrl",
+                        "description": "Paginated list of deleted URLs",
                         "schema": {
-                            "$ref": "#/definitions/server.GetLongUrlResponse"
+                            "$ref": "#/definitions/server.PaginatedDeletedURLs"
                         }
                     },
                     "400": {
@@ -640,8 +748,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.HTTPValidationError"
                         }
                     },
-                    "404": {
-                        "description": "Short URL not found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/server.HTTPError"
                         }
@@ -658,29 +772,40 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ]
-            },
-            "delete": {
-                "description": "Deletes a short URL owned by the authenticated user. Also removes it from cache.",
+            }
+        },
+        "/v1/admin/trash/urls/{code}/restore": {
+            "post": {
+                "description": "Restores any deleted URL from the trash, so the short code resolves again.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "URLs"
+                    "Admin"
                 ],
-                "summary": "Delete Short URL",
+                "summary": "Restore URL",
                 "parameters": [
                     {
                         "maxLength": 16,
                         "type": "string",
-                        "description": "Short code to delete",
+                        "description": "Short code of the URL",
                         "name": "code",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content - URL successfully deleted"
+                    "200": {
+                        "description": "Restored short URL",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateShortUrlResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the restored URL"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Validation failed",
@@ -701,7 +826,180 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Short URL not found or not owned by user",
+                        "description": "Short URL not found in the trash",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Short code is not available",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/admin/urls": {
+            "get": {
+                "description": "Retrieves a paginated list of all URLs created by users. Click counts are updated periodically, so they can lag behind the latest clicks.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get all URLs",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Get custom URLs only",
+                        "name": "isCustom",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 50,
+                        "minLength": 1,
+                        "type": "string",
+                        "description": "Get URLs created by a specific user",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "createdAt",
+                            "clickCount",
+                            "lastClickedAt"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, newest first by default",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paginated list of URLs",
+                        "schema": {
+                            "$ref": "#/definitions/server.PaginatedURLs"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/admin/urls/trending": {
+            "get": {
+                "description": "Returns the URLs with the most clicks in the last hour, day or week, e.g. to spot links that suddenly go viral",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get Trending URLs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week"
+                        ],
+                        "type": "string",
+                        "description": "Rolling period, the last day by default",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of URLs",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trending URLs",
+                        "schema": {
+                            "$ref": "#/definitions/server.TrendingURLs"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/server.HTTPError"
                         }
@@ -713,168 +1011,3540 @@ const docTemplate = `{
                         }
                     }
                 },
-                "security": [
-                    {
-                        "BearerAuth": []
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/admin/urls/user/{userId}": {
+            "delete": {
+                "description": "Moves all URLs created by a user to the trash. Also removes them from cache. The URLs can be restored until they are purged after the trash retention period.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete URLs created by a user",
+                "parameters": [
+                    {
+                        "maxLength": 50,
+                        "minLength": 1,
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of URLs deleted",
+                        "schema": {
+                            "$ref": "#/definitions/server.DeleteUserURLsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/admin/urls/{code}": {
+            "delete": {
+                "description": "Moves a URL to the trash. Also removes it from cache. The short code stays reserved until the URL is restored or purged after the trash retention period.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code of the URL",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content - URL successfully deleted"
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "patch": {
+                "description": "Changes the long URL of any short URL, keeping the short code. Also removes it from cache. Supports optimistic concurrency with the If-Match header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code of the URL",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the URL the update is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "New long URL",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UpdateShortUrlDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated short URL",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateShortUrlResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the updated URL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "412": {
+                        "description": "Short URL has been modified",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/admin/users/block/{userId}": {
+            "post": {
+                "description": "Block a user in the system, preventing them from accessing their account. Blocks with an until time are lifted automatically once it has passed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Block a user",
+                "parameters": [
+                    {
+                        "maxLength": 50,
+                        "minLength": 1,
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Block user request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.BlockUserDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User Block entity",
+                        "schema": {
+                            "$ref": "#/definitions/repository.UserBlock"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "User is already blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/admin/users/blocks": {
+            "get": {
+                "description": "Retrieves a paginated list of all User Blocks created by admins, newest first. Every block of a user is kept, so a user can have several blocks but only one active block.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get all User Blocks",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only active (true) or only lifted and expired (false) blocks",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 50,
+                        "minLength": 1,
+                        "type": "string",
+                        "description": "Only blocks created by a specific admin",
+                        "name": "blockedBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Only blocks created at or after this time (RFC 3339)",
+                        "name": "blockedFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Only blocks created before this time (RFC 3339)",
+                        "name": "blockedTo",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 255,
+                        "minLength": 1,
+                        "type": "string",
+                        "description": "Search in the email of the blocked user",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paginated list of User Blocks",
+                        "schema": {
+                            "$ref": "#/definitions/server.PaginatedUserBlocks"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/admin/users/unblock/{userId}": {
+            "post": {
+                "description": "Unblock a user in the system, allowing them to access their account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Unblock a user",
+                "parameters": [
+                    {
+                        "maxLength": 50,
+                        "minLength": 1,
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated User Block entity",
+                        "schema": {
+                            "$ref": "#/definitions/repository.UserBlock"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/admin/users/{userId}/blocks": {
+            "get": {
+                "description": "Retrieves all blocks of a user, newest first, including lifted and expired ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get User Block History",
+                "parameters": [
+                    {
+                        "maxLength": 50,
+                        "minLength": 1,
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Blocks of the user",
+                        "schema": {
+                            "$ref": "#/definitions/server.UserBlockHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/api-keys": {
+            "get": {
+                "description": "Retrieves the API keys of the authenticated user that have not been revoked, including expired ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Get User API Keys",
+                "responses": {
+                    "200": {
+                        "description": "API keys of the user",
+                        "schema": {
+                            "$ref": "#/definitions/server.APIKeysResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Issues a personal API key for machine use, e.g. in CI jobs. Send it as a Bearer token instead of a JWT. The key acts on behalf of the authenticated user with a subset of their permissions and expires after the given number of days. The key is only returned once and cannot be created with another API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Create API Key",
+                "parameters": [
+                    {
+                        "description": "Name, permissions and lifetime of the key",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateAPIKeyDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created API key",
+                        "schema": {
+                            "$ref": "#/definitions/server.APIKeyWithKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "API key limit reached",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/api-keys/{id}": {
+            "delete": {
+                "description": "Revokes an API key of the authenticated user, requests with the key are rejected right away",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Revoke API Key",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID of the API key",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content - API key successfully revoked"
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/health": {
+            "get": {
+                "description": "Returns basic health status of the application",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Simple Health Check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/server.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/settings": {
+            "get": {
+                "description": "Retrieves the settings of the authenticated user, the defaults are returned if they have never been saved",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Settings"
+                ],
+                "summary": "Get User Settings",
+                "responses": {
+                    "200": {
+                        "description": "Settings of the user",
+                        "schema": {
+                            "$ref": "#/definitions/repository.UserSetting"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Saves the settings of the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Settings"
+                ],
+                "summary": "Update User Settings",
+                "parameters": [
+                    {
+                        "description": "New settings",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UserSettingsDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated settings",
+                        "schema": {
+                            "$ref": "#/definitions/repository.UserSetting"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/trash/urls": {
+            "get": {
+                "description": "Retrieves a paginated list of deleted URLs of the authenticated user that can still be restored. Deleted URLs keep their short code reserved until they are purged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trash"
+                ],
+                "summary": "Get User Trash",
+                "parameters": [
+                    {
+                        "maximum": 10000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paginated list of deleted user URLs",
+                        "schema": {
+                            "$ref": "#/definitions/server.PaginatedTrashedURLs"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/trash/urls/{code}/restore": {
+            "post": {
+                "description": "Restores a deleted short URL owned by the authenticated user from the trash, so the short code resolves again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trash"
+                ],
+                "summary": "Restore Short URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code to restore",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restored short URL",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateShortUrlResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the restored URL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found in the trash or not owned by user",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Short code is not available",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/urls": {
+            "get": {
+                "description": "Retrieves a paginated list of URLs created by the authenticated user. Click counts are updated periodically, so they can lag behind the latest clicks.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get User URLs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "createdAt",
+                            "clickCount",
+                            "lastClickedAt"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, newest first by default",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paginated list of user URLs",
+                        "schema": {
+                            "$ref": "#/definitions/server.PaginatedUserURLs"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Creates a shortened URL. Authenticated users can provide a custom short code (5-16 characters). Otherwise, a random code is generated. The link can optionally expire at a given time (expiresAt) or after a given number of seconds (expiresIn), and can be limited to a number of clicks (maxClicks). Authenticated users can protect the link with a password, and reuse their existing link for the same destination (dedupe). Retries with the same Idempotency-Key return the link created by the first request instead of creating another one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Create Short URL",
+                "parameters": [
+                    {
+                        "maxLength": 255,
+                        "type": "string",
+                        "description": "Unique key of the request, retries with the same key and body return the same link for 24 hours",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "URL and optional custom short code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateShortUrlDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Existing short URL reused",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateShortUrlResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the URL"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Created short URL",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateShortUrlResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the URL"
+                            },
+                            "Idempotent-Replayed": {
+                                "type": "string",
+                                "description": "true if the URL was created by an earlier request with the same Idempotency-Key"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "403": {
+                        "description": "Custom short codes and passwords require authentication",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Short code already taken or validation failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/urls/trending": {
+            "get": {
+                "description": "Returns the URLs of the authenticated user with the most clicks in the last hour, day or week",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get User Trending URLs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week"
+                        ],
+                        "type": "string",
+                        "description": "Rolling period, the last day by default",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of URLs",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trending URLs of the user",
+                        "schema": {
+                            "$ref": "#/definitions/server.UserTrendingURLs"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/urls/{code}": {
+            "get": {
+                "description": "Retrieves the original long URL for a given short code. Checks cache first, then database. Password-protected links require the password in the X-Link-Password header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get Long URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password of a password-protected link",
+                        "name": "X-Link-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "longUrl",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetLongUrlResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Password required or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Short URL has been disabled, is under review or its owner is blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "410": {
+                        "description": "Short URL has expired, reached its click limit or has been deleted",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong password attempts",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "503": {
+                        "description": "Password cannot be checked right now",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Moves a short URL owned by the authenticated user to the trash. Also removes it from cache. The short code stays reserved and resolves to 410 Gone until the URL is restored or purged after the trash retention period.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Delete Short URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code to delete",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content - URL successfully deleted"
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found or not owned by user",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "patch": {
+                "description": "Changes the long URL of a short URL owned by the authenticated user, keeping the short code. Also removes it from cache. Supports optimistic concurrency with the If-Match header, the current ETag is returned when the URL is created or updated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Update Short URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code to update",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the URL the update is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "New long URL",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UpdateShortUrlDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated short URL",
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateShortUrlResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the updated URL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found or not owned by user",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "412": {
+                        "description": "Short URL has been modified",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/urls/{code}/report": {
+            "post": {
+                "description": "Reports a short URL for abuse. Anyone can report a link, the reports are reviewed by admins. A link reported by enough distinct signed-in users is quarantined and does not resolve until it is reviewed, anonymous reports are only reviewed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Report Short URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Report request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ReportURLDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted - report received"
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/urls/{code}/stats": {
+            "get": {
+                "description": "Returns click analytics of a short URL: total clicks, approximate unique visitors and breakdowns by day, referrer host, browser and device. Available to the owner of the URL and to users with the get:url-stats permission.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Get Short URL Stats",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 365,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Only count clicks of the last number of days",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Click stats",
+                        "schema": {
+                            "$ref": "#/definitions/server.UrlStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found or not owned by user",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/urls/{code}/unlock": {
+            "post": {
+                "description": "Retrieves the original long URL of a password-protected short code. Wrong password attempts are rate limited per short code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Unlock Long URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Password of the link",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.UnlockLongUrlDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "longUrl",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetLongUrlResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Short URL has been disabled, is under review or its owner is blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "410": {
+                        "description": "Short URL has expired, reached its click limit or has been deleted",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong password attempts",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "503": {
+                        "description": "Password cannot be checked right now",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/webhooks": {
+            "get": {
+                "description": "Retrieves the webhooks of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get User Webhooks",
+                "responses": {
+                    "200": {
+                        "description": "Webhooks of the user",
+                        "schema": {
+                            "$ref": "#/definitions/server.WebhooksResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Registers an endpoint that receives the subscribed events about the links of the authenticated user. Deliveries are signed with HMAC-SHA256 in the X-Webhook-Signature header (t=\u003cunix timestamp\u003e,v1=\u003chex signature of \"\u003ctimestamp\u003e.\u003cbody\u003e\"\u003e). Failed deliveries are retried with exponential backoff and kept as dead letters after the last attempt. The secret is only returned once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Create Webhook",
+                "parameters": [
+                    {
+                        "description": "Endpoint and subscribed events",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CreateWebhookDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created webhook",
+                        "schema": {
+                            "$ref": "#/definitions/server.WebhookWithSecret"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Webhook limit reached",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/webhooks/{id}": {
+            "delete": {
+                "description": "Deletes a webhook of the authenticated user together with its pending deliveries and dead letters",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete Webhook",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content - Webhook successfully deleted"
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/webhooks/{id}/dead-letters": {
+            "get": {
+                "description": "Retrieves a paginated list of the deliveries of the webhook that failed permanently, the most recent first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get Webhook Dead Letters",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 10000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paginated list of dead letters",
+                        "schema": {
+                            "$ref": "#/definitions/server.PaginatedWebhookDeadLetters"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/webhooks/{id}/dead-letters/{deadLetterId}/replay": {
+            "post": {
+                "description": "Schedules a failed delivery to be sent again with the same event ID and payload. The delivery gets a fresh set of attempts.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Replay Webhook Dead Letter",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID of the dead letter",
+                        "name": "deadLetterId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted - Delivery scheduled"
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook or dead letter not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/webhooks/{id}/rotate-secret": {
+            "post": {
+                "description": "Replaces the signing secret of the webhook. Deliveries are signed with the new secret right away. The new secret is only returned once.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Rotate Webhook Secret",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook with the new secret",
+                        "schema": {
+                            "$ref": "#/definitions/server.WebhookWithSecret"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/webhooks/{id}/test": {
+            "post": {
+                "description": "Sends a signed webhook.test event to the endpoint of the webhook and returns the result. Test events are not retried.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Test Webhook",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Result of the delivery",
+                        "schema": {
+                            "$ref": "#/definitions/server.TestWebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/{code}": {
+            "get": {
+                "description": "Redirects to the original long URL for a given short code. Checks cache first, then database. The redirect status code is configurable (301, 302, 307 or 308). HEAD requests do not consume clicks of click-limited links. Password-protected links render an HTML password prompt unless the password is sent in the X-Link-Password header.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Redirect"
+                ],
+                "summary": "Redirect to Long URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password of a password-protected link",
+                        "name": "X-Link-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the long URL"
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Password prompt"
+                    },
+                    "403": {
+                        "description": "Short URL has been disabled, is under review or its owner is blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "410": {
+                        "description": "Short URL has expired, reached its click limit or has been deleted",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Password prompt with too many wrong attempts error"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "503": {
+                        "description": "Password cannot be checked right now",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Submits the password of a password-protected short code from the HTML prompt and redirects to the long URL with 303 See Other. A wrong password renders the prompt again. Wrong password attempts are rate limited per short code.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Redirect"
+                ],
+                "summary": "Unlock and Redirect to Long URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maxLength": 72,
+                        "type": "string",
+                        "description": "Password of the link",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "Redirect to the long URL"
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Password prompt with invalid password error"
+                    },
+                    "403": {
+                        "description": "Short URL has been disabled, is under review or its owner is blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "410": {
+                        "description": "Short URL has expired, reached its click limit or has been deleted",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Password prompt with too many wrong attempts error"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "503": {
+                        "description": "Password cannot be checked right now",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "description": "Redirects to the original long URL for a given short code. Checks cache first, then database. The redirect status code is configurable (301, 302, 307 or 308). HEAD requests do not consume clicks of click-limited links. Password-protected links render an HTML password prompt unless the password is sent in the X-Link-Password header.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Redirect"
+                ],
+                "summary": "Redirect to Long URL",
+                "parameters": [
+                    {
+                        "maxLength": 16,
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password of a password-protected link",
+                        "name": "X-Link-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the long URL"
+                    },
+                    "400": {
+                        "description": "Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Password prompt"
+                    },
+                    "403": {
+                        "description": "Short URL has been disabled, is under review or its owner is blocked",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Short URL not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "410": {
+                        "description": "Short URL has expired, reached its click limit or has been deleted",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Password prompt with too many wrong attempts error"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    },
+                    "503": {
+                        "description": "Password cannot be checked right now",
+                        "schema": {
+                            "$ref": "#/definitions/server.HTTPError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "appvalidator.ValidationError": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "repository.AdminAuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actorId": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "outcome": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                },
+                "targetId": {
+                    "type": "string"
+                },
+                "targetType": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.ApiKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "keyPrefix": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.DomainRule": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "list": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "pattern": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.Url": {
+            "type": "object",
+            "properties": {
+                "clickCount": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isCustom": {
+                    "type": "boolean"
+                },
+                "lastClickedAt": {
+                    "type": "string"
+                },
+                "longUrl": {
+                    "type": "string"
+                },
+                "maxClicks": {
+                    "type": "integer"
+                },
+                "originalUrl": {
+                    "type": "string"
+                },
+                "remainingClicks": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.UserBlock": {
+            "type": "object",
+            "properties": {
+                "blockedAt": {
+                    "type": "string"
+                },
+                "blockedBy": {
+                    "type": "string"
+                },
+                "blockedUntil": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "unblockedAt": {
+                    "type": "string"
+                },
+                "unblockedBy": {
+                    "type": "string"
+                },
+                "userEmail": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.UserSetting": {
+            "type": "object",
+            "properties": {
+                "dedupeUrls": {
+                    "type": "boolean"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.Webhook": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.APIKeyWithKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "The key to send as a Bearer token, only returned when the key is created",
+                    "type": "string",
+                    "example": "shk_UKGCSOLNWSJX7DMDQJ5XLJKWAA"
+                },
+                "keyPrefix": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.APIKeysResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.ApiKey"
+                    }
+                }
+            }
+        },
+        "server.AbuseReportCategoryCounts": {
+            "type": "object",
+            "properties": {
+                "illegal": {
+                    "type": "integer"
+                },
+                "malware": {
+                    "type": "integer"
+                },
+                "phishing": {
+                    "type": "integer"
+                },
+                "spam": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.AbuseReportQueueItem": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "$ref": "#/definitions/server.AbuseReportCategoryCounts"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "disabledReason": {
+                    "description": "DisabledReason is quarantined or abuse if the link is disabled, null otherwise",
+                    "type": "string"
+                },
+                "firstReportedAt": {
+                    "type": "string"
+                },
+                "lastReportedAt": {
+                    "type": "string"
+                },
+                "longUrl": {
+                    "type": "string"
+                },
+                "reportCount": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.BlockUserDTO": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1
+                },
+                "until": {
+                    "description": "Time at which the user is unblocked automatically, the block is permanent if omitted",
+                    "type": "string",
+                    "example": "2026-12-31T23:59:59Z"
+                }
+            }
+        },
+        "server.ClicksByDay": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "day": {
+                    "type": "string",
+                    "example": "2026-01-31T00:00:00Z"
+                },
+                "uniqueVisitors": {
+                    "description": "Approximate number of distinct visitors on the day",
+                    "type": "integer"
+                }
+            }
+        },
+        "server.ClicksByKey": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.CreateAPIKeyDTO": {
+            "type": "object",
+            "required": [
+                "expiresInDays",
+                "name",
+                "permissions"
+            ],
+            "properties": {
+                "expiresInDays": {
+                    "description": "Lifetime of the key in days",
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1,
+                    "example": 90
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "CI pipeline"
+                },
+                "permissions": {
+                    "description": "Permissions of the key, must be a subset of the permissions of the user",
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "create:urls"
+                    ]
+                }
+            }
+        },
+        "server.CreateShortUrlDTO": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "dedupe": {
+                    "description": "Return the existing link of the authenticated user for the same canonical destination instead of creating a new one,\ndefaults to the dedupeUrls setting of the user. Only links without a custom short code, an expiration, a click limit\nor a password are reused, and only for requests without them",
+                    "type": "boolean"
+                },
+                "expiresAt": {
+                    "description": "Absolute expiration time of the link, cannot be used together with expiresIn",
+                    "type": "string",
+                    "example": "2026-12-31T23:59:59Z"
+                },
+                "expiresIn": {
+                    "description": "Lifetime of the link in seconds, cannot be used together with expiresAt",
+                    "type": "integer",
+                    "maximum": 315360000,
+                    "minimum": 60,
+                    "example": 86400
+                },
+                "maxClicks": {
+                    "description": "Number of successful resolutions after which the link stops working, 1 makes it a one-time link",
+                    "type": "integer",
+                    "maximum": 1000000,
+                    "minimum": 1,
+                    "example": 1
+                },
+                "password": {
+                    "description": "Password required to resolve the link, only available to authenticated users",
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 4
+                },
+                "shortCode": {
+                    "type": "string",
+                    "maxLength": 16,
+                    "minLength": 5
+                },
+                "stripTrackingParams": {
+                    "description": "Strips the tracking params, e.g. utm_source, from the destination, they are kept by default so that campaigns can be measured",
+                    "type": "boolean"
+                },
+                "url": {
+                    "description": "Destination of the link, at most 2048 characters. Our short links and the links of known shorteners are followed\nthrough at most 3 hops and replaced with where they end, loops and longer chains are rejected.\nInternationalized hosts are converted to punycode,\nhosts that are or resolve to loopback, link-local, private or reserved addresses are rejected",
+                    "type": "string",
+                    "maxLength": 2048
+                }
+            }
+        },
+        "server.CreateShortUrlResponse": {
+            "type": "object",
+            "properties": {
+                "clickCount": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isCustom": {
+                    "type": "boolean"
+                },
+                "lastClickedAt": {
+                    "type": "string"
+                },
+                "longUrl": {
+                    "type": "string"
+                },
+                "maxClicks": {
+                    "type": "integer"
+                },
+                "originalUrl": {
+                    "type": "string"
+                },
+                "passwordProtected": {
+                    "type": "boolean"
+                },
+                "remainingClicks": {
+                    "type": "integer"
+                },
+                "shortUrl": {
+                    "type": "string",
+                    "example": "https://sho.rt/abc123XY"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.CreateWebhookDTO": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "description": "Event types to subscribe to",
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "link.created",
+                            "link.updated",
+                            "link.deleted",
+                            "link.clicked"
+                        ]
+                    }
+                },
+                "url": {
+                    "description": "Endpoint the events are posted to, redirects are not followed",
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://example.com/webhooks/shortener"
+                }
+            }
+        },
+        "server.DeleteUserURLsResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.DomainRuleDTO": {
+            "type": "object",
+            "required": [
+                "kind",
+                "list",
+                "pattern"
+            ],
+            "properties": {
+                "kind": {
+                    "description": "exact matches the host only, suffix matches the domain and its subdomains, regex has to match the whole host",
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "suffix",
+                        "regex"
+                    ]
+                },
+                "list": {
+                    "description": "Block rules deny the matching destinations, allow rules exempt them from the block rules",
+                    "type": "string",
+                    "enum": [
+                        "block",
+                        "allow"
+                    ]
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "pattern": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "*.example.com"
+                }
+            }
+        },
+        "server.DomainRuleMatch": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "disabledReason": {
+                    "description": "Reason the link is disabled for, missing if it is not disabled",
+                    "type": "string"
+                },
+                "longUrl": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.GetLongUrlResponse": {
+            "type": "object",
+            "properties": {
+                "longUrl": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.HTTPError": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "error message"
+                }
+            }
+        },
+        "server.HTTPValidationError": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/appvalidator.ValidationError"
+                        }
+                    ],
+                    "example": {
+                        "{\"field\"": "\"field error message\"}"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "error message"
+                }
+            }
+        },
+        "server.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "environment": {
+                    "type": "string",
+                    "example": "production"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "server.PaginatedAbuseReportQueue": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.AbuseReportQueueItem"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/server.Pagination"
+                }
+            }
+        },
+        "server.PaginatedAuditLog": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.AdminAuditLog"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/server.Pagination"
+                }
+            }
+        },
+        "server.PaginatedDeletedURLs": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TrashedURL"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/server.Pagination"
+                }
+            }
+        },
+        "server.PaginatedDomainRules": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.DomainRule"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/server.Pagination"
+                }
+            }
+        },
+        "server.PaginatedTrashedURLs": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TrashedURLResponse"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/server.Pagination"
+                }
+            }
+        },
+        "server.PaginatedURLs": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.Url"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/server.Pagination"
+                }
+            }
+        },
+        "server.PaginatedUserBlocks": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.UserBlock"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/server.Pagination"
+                }
+            }
+        },
+        "server.PaginatedUserURLs": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.URLResponse"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/server.Pagination"
+                }
+            }
+        },
+        "server.PaginatedWebhookDeadLetters": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.WebhookDeadLetter"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/server.Pagination"
+                }
+            }
+        },
+        "server.Pagination": {
+            "type": "object",
+            "properties": {
+                "hasNext": {
+                    "type": "boolean"
+                },
+                "hasPrevious": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "totalItems": {
+                    "type": "integer"
+                },
+                "totalPages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.ReportURLDTO": {
+            "type": "object",
+            "required": [
+                "category"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "enum": [
+                        "phishing",
+                        "malware",
+                        "spam",
+                        "illegal"
+                    ]
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "minLength": 1
+                }
+            }
+        },
+        "server.ResolveReportsDTO": {
+            "type": "object",
+            "required": [
+                "action"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "dismiss",
+                        "disable",
+                        "delete",
+                        "block_owner"
+                    ]
+                },
+                "reason": {
+                    "description": "Reason of the block, only used with the block_owner action",
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1
+                }
+            }
+        },
+        "server.ResolveReportsResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "resolved": {
+                    "description": "Number of reports resolved",
+                    "type": "integer"
+                }
+            }
+        },
+        "server.ScanDomainRuleDTO": {
+            "type": "object",
+            "properties": {
+                "disable": {
+                    "description": "Disable the matching links, like the disable action of the abuse report queue",
+                    "type": "boolean"
+                }
+            }
+        },
+        "server.ScanDomainRuleResponse": {
+            "type": "object",
+            "properties": {
+                "disabled": {
+                    "description": "Number of links disabled by the scan",
+                    "type": "integer"
+                },
+                "matches": {
+                    "description": "Links that are not deleted and point to a host matching the rule, unless the host is allowlisted",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.DomainRuleMatch"
                     }
-                ]
+                }
             }
-        }
-    },
-    "definitions": {
-        "appvalidator.ValidationError": {
+        },
+        "server.TestWebhookResponse": {
             "type": "object",
-            "additionalProperties": {
-                "type": "string"
+            "properties": {
+                "delivered": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "statusCode": {
+                    "description": "Status code of the response, missing if the endpoint could not be reached",
+                    "type": "integer"
+                }
             }
         },
-        "repository.Url": {
+        "server.TrashedURL": {
             "type": "object",
             "properties": {
+                "clickCount": {
+                    "type": "integer"
+                },
                 "createdAt": {
                     "type": "string"
                 },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
                 "isCustom": {
                     "type": "boolean"
                 },
+                "lastClickedAt": {
+                    "type": "string"
+                },
                 "longUrl": {
                     "type": "string"
                 },
+                "maxClicks": {
+                    "type": "integer"
+                },
+                "originalUrl": {
+                    "type": "string"
+                },
+                "purgeAt": {
+                    "description": "Time after which the URL is permanently removed and can no longer be restored",
+                    "type": "string"
+                },
+                "remainingClicks": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
                 "userId": {
                     "type": "string"
                 }
             }
         },
-        "repository.UserBlock": {
+        "server.TrashedURLResponse": {
             "type": "object",
             "properties": {
-                "blockedAt": {
+                "clickCount": {
+                    "description": "Number of clicks, updated periodically",
+                    "type": "integer"
+                },
+                "createdAt": {
                     "type": "string"
                 },
-                "blockedBy": {
+                "deletedAt": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
+                "expiresAt": {
+                    "type": "string"
                 },
-                "reason": {
+                "id": {
                     "type": "string"
                 },
-                "unblockedAt": {
+                "isCustom": {
+                    "type": "boolean"
+                },
+                "lastClickedAt": {
                     "type": "string"
                 },
-                "unblockedBy": {
+                "longUrl": {
                     "type": "string"
                 },
-                "userEmail": {
+                "maxClicks": {
+                    "type": "integer"
+                },
+                "passwordProtected": {
+                    "type": "boolean"
+                },
+                "purgeAt": {
+                    "description": "Time after which the URL is permanently removed and can no longer be restored",
                     "type": "string"
                 },
-                "userId": {
+                "remainingClicks": {
+                    "type": "integer"
+                },
+                "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "server.BlockUserDTO": {
+        "server.TrendingURL": {
             "type": "object",
             "properties": {
-                "reason": {
-                    "type": "string",
-                    "maxLength": 255,
-                    "minLength": 1
+                "clicks": {
+                    "description": "Number of clicks in the period",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "longUrl": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
                 }
             }
         },
-        "server.CreateShortUrlDTO": {
+        "server.TrendingURLResponse": {
             "type": "object",
-            "required": [
-                "url"
-            ],
             "properties": {
-                "shortCode": {
-                    "type": "string",
-                    "maxLength": 16,
-                    "minLength": 5
+                "clicks": {
+                    "description": "Number of clicks in the period",
+                    "type": "integer"
                 },
-                "url": {
+                "id": {
+                    "type": "string"
+                },
+                "longUrl": {
                     "type": "string"
                 }
             }
         },
-        "server.DeleteUserURLsResponse": {
+        "server.TrendingURLs": {
             "type": "object",
             "properties": {
-                "deleted": {
-                    "type": "integer"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TrendingURL"
+                    }
+                },
+                "period": {
+                    "type": "string",
+                    "enum": [
+                        "hour",
+                        "day",
+                        "week"
+                    ]
                 }
             }
         },
-        "server.GetLongUrlResponse": {
+        "server.URLResponse": {
             "type": "object",
             "properties": {
+                "clickCount": {
+                    "description": "Number of clicks, updated periodically",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isCustom": {
+                    "type": "boolean"
+                },
+                "lastClickedAt": {
+                    "type": "string"
+                },
                 "longUrl": {
                     "type": "string"
+                },
+                "maxClicks": {
+                    "type": "integer"
+                },
+                "passwordProtected": {
+                    "type": "boolean"
+                },
+                "remainingClicks": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
         },
-        "server.HTTPError": {
+        "server.UnlockLongUrlDTO": {
             "type": "object",
+            "required": [
+                "password"
+            ],
             "properties": {
-                "message": {
+                "password": {
                     "type": "string",
-                    "example": "error message"
+                    "maxLength": 72
                 }
             }
         },
-        "server.HTTPValidationError": {
+        "server.UpdateShortUrlDTO": {
             "type": "object",
+            "required": [
+                "url"
+            ],
             "properties": {
-                "errors": {
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/appvalidator.ValidationError"
-                        }
-                    ],
-                    "example": {
-                        "{\"field\"": "\"field error message\"}"
-                    }
+                "stripTrackingParams": {
+                    "description": "Strips the tracking params, e.g. utm_source, from the destination, they are kept by default so that campaigns can be measured",
+                    "type": "boolean"
                 },
-                "message": {
+                "url": {
+                    "description": "Validated and canonicalized the same way as the URL of CreateShortUrlDTO",
                     "type": "string",
-                    "example": "error message"
+                    "maxLength": 2048
                 }
             }
         },
-        "server.HealthResponse": {
+        "server.UrlStatsResponse": {
             "type": "object",
             "properties": {
-                "environment": {
-                    "type": "string",
-                    "example": "production"
+                "byBrowser": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ClicksByKey"
+                    }
                 },
-                "status": {
-                    "type": "string",
-                    "example": "ok"
-                }
-            }
-        },
-        "server.PaginatedURLs": {
-            "type": "object",
-            "properties": {
-                "items": {
+                "byDay": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/repository.Url"
+                        "$ref": "#/definitions/server.ClicksByDay"
                     }
                 },
-                "pagination": {
-                    "$ref": "#/definitions/server.Pagination"
+                "byDevice": {
+                    "description": "Clicks by device class: desktop, mobile, tablet, bot or unknown",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ClicksByKey"
+                    }
+                },
+                "byReferrerHost": {
+                    "description": "Top referrer hosts, direct visits have an empty key",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ClicksByKey"
+                    }
+                },
+                "code": {
+                    "type": "string"
+                },
+                "since": {
+                    "description": "Start of the period the stats are calculated for",
+                    "type": "string"
+                },
+                "totalClicks": {
+                    "type": "integer"
+                },
+                "uniqueVisitors": {
+                    "description": "Approximate number of distinct visitors in the period. Visitors are told apart per day,\nso a visitor coming back on another day is counted again",
+                    "type": "integer"
                 }
             }
         },
-        "server.PaginatedUserBlocks": {
+        "server.UserBlockHistoryResponse": {
             "type": "object",
             "properties": {
                 "items": {
@@ -882,70 +4552,115 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/repository.UserBlock"
                     }
-                },
-                "pagination": {
-                    "$ref": "#/definitions/server.Pagination"
                 }
             }
         },
-        "server.PaginatedUserURLs": {
+        "server.UserSettingsDTO": {
+            "type": "object",
+            "properties": {
+                "dedupeUrls": {
+                    "description": "Return the existing link for the same destination instead of creating a new one, can be overridden per request",
+                    "type": "boolean"
+                }
+            }
+        },
+        "server.UserTrendingURLs": {
             "type": "object",
             "properties": {
                 "items": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/server.URLResponse"
+                        "$ref": "#/definitions/server.TrendingURLResponse"
                     }
                 },
-                "pagination": {
-                    "$ref": "#/definitions/server.Pagination"
+                "period": {
+                    "type": "string",
+                    "enum": [
+                        "hour",
+                        "day",
+                        "week"
+                    ]
                 }
             }
         },
-        "server.Pagination": {
+        "server.WebhookDeadLetter": {
             "type": "object",
             "properties": {
-                "hasNext": {
-                    "type": "boolean"
+                "attempts": {
+                    "type": "integer"
                 },
-                "hasPrevious": {
-                    "type": "boolean"
+                "eventCreatedAt": {
+                    "type": "string"
                 },
-                "page": {
+                "eventId": {
                     "type": "integer"
                 },
-                "pageSize": {
-                    "type": "integer"
+                "eventType": {
+                    "type": "string"
                 },
-                "totalItems": {
+                "failedAt": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "totalPages": {
+                "lastError": {
+                    "type": "string"
+                },
+                "lastStatusCode": {
                     "type": "integer"
+                },
+                "payload": {
+                    "type": "object"
                 }
             }
         },
-        "server.URLResponse": {
+        "server.WebhookWithSecret": {
             "type": "object",
             "properties": {
                 "createdAt": {
                     "type": "string"
                 },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "description": "Secret the deliveries are signed with, only returned when the webhook is created or the secret is rotated",
+                    "type": "string",
+                    "example": "whsec_UKGCSOLNWSJX7DMDQJ5XLJKWAA"
+                },
+                "updatedAt": {
                     "type": "string"
                 },
-                "isCustom": {
-                    "type": "boolean"
+                "url": {
+                    "type": "string"
                 },
-                "longUrl": {
+                "userId": {
                     "type": "string"
                 }
             }
+        },
+        "server.WebhooksResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.Webhook"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
         "BearerAuth": {
-            "description": "Type \"Bearer\" followed by a space and JWT token",
+            "description": "Type \"Bearer\" followed by a space and a JWT or an API key (shk_...)",
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
@@ -1,7 +1,9 @@
 package appvalidator
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"reflect"
 	"strings"
 
@@ -9,12 +11,43 @@ import (
 )
 
 type AppValidator struct {
-	validate *validator.Validate
+	validate   *validator.Validate
+	hostPolicy HostPolicy
+	resolver   Resolver
+	shortLinks ShortLinkResolver
 }
 
 type ValidationError map[string]string
 
-func New() *AppValidator {
+type Option func(*AppValidator)
+
+// WithHostPolicy makes the destination tag reject the URLs with a host the policy does not allow
+func WithHostPolicy(policy HostPolicy) Option {
+	return func(av *AppValidator) {
+		av.hostPolicy = policy
+	}
+}
+
+// WithResolver replaces the resolver the public_host tag looks up host names with, net.DefaultResolver by default
+func WithResolver(resolver Resolver) Option {
+	return func(av *AppValidator) {
+		av.resolver = resolver
+	}
+}
+
+// WithShortLinkResolver makes the unshorten tag follow destinations that are short links, they are kept as they are without one
+func WithShortLinkResolver(resolver ShortLinkResolver) Option {
+	return func(av *AppValidator) {
+		av.shortLinks = resolver
+	}
+}
+
+func New(opts ...Option) *AppValidator {
+	av := &AppValidator{resolver: net.DefaultResolver}
+	for _, opt := range opts {
+		opt(av)
+	}
+
 	validate := validator.New(validator.WithRequiredStructEnabled())
 	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
 		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
@@ -28,14 +61,39 @@ func New() *AppValidator {
 	if err != nil {
 		panic(fmt.Errorf("register shortcode validator: %w", err))
 	}
-
-	return &AppValidator{
-		validate: validate,
+	err = validate.RegisterValidation("unreserved", ValidateUnreservedShortCode)
+	if err != nil {
+		panic(fmt.Errorf("register unreserved validator: %w", err))
+	}
+	err = validate.RegisterValidation("idn", validateIDN)
+	if err != nil {
+		panic(fmt.Errorf("register idn validator: %w", err))
+	}
+	err = validate.RegisterValidationCtx("unshorten", av.validateUnshorten)
+	if err != nil {
+		panic(fmt.Errorf("register unshorten validator: %w", err))
+	}
+	err = validate.RegisterValidationCtx("public_host", av.validatePublicHost)
+	if err != nil {
+		panic(fmt.Errorf("register public_host validator: %w", err))
+	}
+	err = validate.RegisterValidation("destination", av.validateDestination)
+	if err != nil {
+		panic(fmt.Errorf("register destination validator: %w", err))
 	}
+	av.validate = validate
+
+	return av
 }
 
 func (av *AppValidator) Validate(i any) error {
-	if err := av.validate.Struct(i); err != nil {
+	return av.ValidateCtx(context.Background(), i)
+}
+
+// ValidateCtx validates the struct with the context of the request, the unshorten and public_host tags
+// stop looking up hosts and following links once it is done
+func (av *AppValidator) ValidateCtx(ctx context.Context, i any) error {
+	if err := av.validate.StructCtx(ctx, i); err != nil {
 		return err
 	}
 
@@ -69,12 +127,28 @@ func (av *AppValidator) getErrorMessage(fe validator.FieldError) string {
 	case "email":
 		return "Invalid email format"
 	case "min":
+		if isNumber(fe.Kind()) {
+			return fmt.Sprintf("%s must be at least %s", fe.Field(), fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("%s must contain at least %s items", fe.Field(), fe.Param())
+		}
 		return fmt.Sprintf("%s must be at least %s characters long", fe.Field(), fe.Param())
 	case "max":
+		if isNumber(fe.Kind()) {
+			return fmt.Sprintf("%s must be at most %s", fe.Field(), fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("%s must contain at most %s items", fe.Field(), fe.Param())
+		}
 		return fmt.Sprintf("%s must be at most %s characters long", fe.Field(), fe.Param())
 	case "http_url":
 		return "Invalid URL format"
 	case "gt":
+		// Without a param, time values are compared to the current time
+		if fe.Param() == "" {
+			return fmt.Sprintf("%s must be in the future", fe.Field())
+		}
 		return fmt.Sprintf("%s must be greater than %s", fe.Field(), fe.Param())
 	case "gte":
 		return fmt.Sprintf("%s must be greater than or equal to %s", fe.Field(), fe.Param())
@@ -86,9 +160,35 @@ func (av *AppValidator) getErrorMessage(fe validator.FieldError) string {
 		return fmt.Sprintf("%s must be exactly %s characters long", fe.Field(), fe.Param())
 	case "oneof":
 		return fmt.Sprintf("%s must be one of: %s", fe.Field(), fe.Param())
+	case "unique":
+		return fmt.Sprintf("%s must not contain duplicates", fe.Field())
+	case "excluded_with":
+		// Param is the Go field name, make it match the JSON field name
+		return fmt.Sprintf("%s cannot be used together with %s", fe.Field(), strings.ToLower(fe.Param()[:1])+fe.Param()[1:])
 	case "shortcode":
 		return "Short code cannot contain special characters"
+	case "unreserved":
+		return "Short code is reserved"
+	case "idn":
+		return fmt.Sprintf("%s host is not a valid domain name", fe.Field())
+	case "unshorten":
+		return fmt.Sprintf("%s must not be a short link that loops, cannot be followed or goes through more than %s short links", fe.Field(), fe.Param())
+	case "public_host":
+		return fmt.Sprintf("%s must not point to a private or reserved address", fe.Field())
+	case "destination":
+		return "Destination is not allowed"
 	default:
 		return fmt.Sprintf("%s is invalid", fe.Field())
 	}
 }
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
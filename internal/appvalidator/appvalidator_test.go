@@ -1,7 +1,12 @@
 package appvalidator
 
 import (
+	"context"
+	"errors"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -39,3 +44,247 @@ func TestValidateShortCode(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateUnreservedShortCode(t *testing.T) {
+	type shortCode struct {
+		Code string `validate:"unreserved"`
+	}
+
+	validate := New()
+	assert.NoError(t, validate.Validate(shortCode{Code: "trends"}))
+
+	for _, code := range []string{"trending", "Trending"} {
+		err := validate.Validate(shortCode{Code: code})
+		assert.Error(t, err, code)
+		assert.Equal(t, "Short code is reserved", validate.FormatErrors(err)["code"], "wrong error message")
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	type dto struct {
+		Name      string     `json:"name" validate:"omitempty,min=3"`
+		Count     int        `json:"count" validate:"omitempty,min=60"`
+		ExpiresAt *time.Time `json:"expiresAt" validate:"omitzero,gt,excluded_with=ExpiresIn"`
+		ExpiresIn *int       `json:"expiresIn" validate:"omitzero"`
+		Events    []string   `json:"events" validate:"omitempty,min=2,unique"`
+	}
+
+	var (
+		past = time.Now().Add(-time.Hour)
+		next = time.Now().Add(time.Hour)
+		in   = 60
+	)
+
+	tests := []struct {
+		name     string
+		value    dto
+		field    string
+		expected string
+	}{
+		{name: "string min", value: dto{Name: "ab"}, field: "name", expected: "name must be at least 3 characters long"},
+		{name: "number min", value: dto{Count: 10}, field: "count", expected: "count must be at least 60"},
+		{name: "time in the past", value: dto{ExpiresAt: &past}, field: "expiresat", expected: "expiresAt must be in the future"},
+		{name: "slice min", value: dto{Events: []string{"a"}}, field: "events", expected: "events must contain at least 2 items"},
+		{name: "slice unique", value: dto{Events: []string{"a", "a"}}, field: "events", expected: "events must not contain duplicates"},
+		{name: "mutually exclusive fields", value: dto{ExpiresAt: &next, ExpiresIn: &in}, field: "expiresat", expected: "expiresAt cannot be used together with expiresIn"},
+	}
+
+	validate := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Validate(tt.value)
+			assert.Error(t, err)
+
+			errors := validate.FormatErrors(err)
+			assert.Equal(t, tt.expected, errors[tt.field], "wrong error message")
+		})
+	}
+}
+
+type hostPolicy map[string]bool
+
+func (p hostPolicy) Allowed(host string) bool {
+	return !p[host]
+}
+
+func TestValidateDestination(t *testing.T) {
+	type destination struct {
+		URL string `json:"url" validate:"required,http_url,destination"`
+	}
+
+	tests := []struct {
+		name     string
+		value    destination
+		expected bool
+	}{
+		{name: "allowed host", value: destination{URL: "https://example.com/path"}, expected: true},
+		{name: "denied host", value: destination{URL: "https://blocked.com/path"}, expected: false},
+		{name: "denied host with port", value: destination{URL: "https://blocked.com:8443"}, expected: false},
+	}
+
+	validate := New(WithHostPolicy(hostPolicy{"blocked.com": true}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Validate(tt.value)
+
+			errors := validate.FormatErrors(err)
+			if tt.expected {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, "Destination is not allowed", errors["url"], "wrong error message")
+			}
+		})
+	}
+
+	t.Run("without a policy", func(t *testing.T) {
+		assert.NoError(t, New().Validate(destination{URL: "https://blocked.com"}))
+	})
+}
+
+type staticResolver map[string][]string
+
+func (r staticResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	addrs := make([]net.IPAddr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+	return addrs, nil
+}
+
+func TestValidatePublicHost(t *testing.T) {
+	type destination struct {
+		URL string `json:"url" validate:"required,http_url,idn,max=2048,public_host"`
+	}
+
+	resolver := staticResolver{
+		"example.com":          {"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"},
+		"internal.example.com": {"93.184.216.34", "10.0.0.1"},
+		"xn--bcher-kva.de":     {"93.184.216.35"},
+		"rebind.example.com":   {"169.254.169.254"},
+	}
+
+	tests := []struct {
+		name        string
+		url         string
+		expected    string
+		expectedErr string
+	}{
+		{name: "public host", url: "https://example.com/path", expected: "https://example.com/path"},
+		{name: "public IP", url: "http://93.184.216.34", expected: "http://93.184.216.34"},
+		{name: "unresolved host", url: "https://unknown.example.org", expected: "https://unknown.example.org"},
+		{name: "IDN host", url: "https://Bücher.de/straße?q=ü", expected: "https://xn--bcher-kva.de/straße?q=ü"},
+		{name: "IDN host with port", url: "https://bücher.de:8443", expected: "https://xn--bcher-kva.de:8443"},
+		{name: "invalid IDN host", url: "https://b\u00fc\u200dcher.de", expectedErr: "url host is not a valid domain name"},
+		{name: "too long", url: "https://example.com/" + strings.Repeat("a", 2048), expectedErr: "url must be at most 2048 characters long"},
+		{name: "localhost", url: "http://localhost:8080", expectedErr: "url must not point to a private or reserved address"},
+		{name: "localhost subdomain", url: "http://app.localhost", expectedErr: "url must not point to a private or reserved address"},
+		{name: "loopback", url: "http://127.0.0.1/admin", expectedErr: "url must not point to a private or reserved address"},
+		{name: "loopback IPv6", url: "http://[::1]/", expectedErr: "url must not point to a private or reserved address"},
+		{name: "IPv4-mapped loopback", url: "http://[::ffff:127.0.0.1]/", expectedErr: "url must not point to a private or reserved address"},
+		{name: "decimal loopback", url: "http://2130706433/", expectedErr: "url must not point to a private or reserved address"},
+		{name: "hex loopback", url: "http://0x7f.1/", expectedErr: "url must not point to a private or reserved address"},
+		{name: "metadata service", url: "http://169.254.169.254/latest/meta-data", expectedErr: "url must not point to a private or reserved address"},
+		{name: "private", url: "http://192.168.1.1", expectedErr: "url must not point to a private or reserved address"},
+		{name: "unspecified", url: "http://0.0.0.0", expectedErr: "url must not point to a private or reserved address"},
+		{name: "shared address space", url: "http://100.64.0.1", expectedErr: "url must not point to a private or reserved address"},
+		{name: "unique local IPv6", url: "http://[fd00::1]/", expectedErr: "url must not point to a private or reserved address"},
+		{name: "resolves to private", url: "https://internal.example.com", expectedErr: "url must not point to a private or reserved address"},
+		{name: "resolves to link-local", url: "https://rebind.example.com", expectedErr: "url must not point to a private or reserved address"},
+	}
+
+	validate := New(WithResolver(resolver))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := &destination{URL: tt.url}
+			err := validate.Validate(value)
+
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, value.URL, "URL should be normalized")
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedErr, validate.FormatErrors(err)["url"], "wrong error message")
+			}
+		})
+	}
+}
+
+// shortLinks follows the links of a map, at most maxHops of them
+type shortLinks map[string]string
+
+func (l shortLinks) Resolve(ctx context.Context, rawURL string, maxHops int) (string, error) {
+	for range maxHops + 1 {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		next, ok := l[rawURL]
+		if !ok {
+			return rawURL, nil
+		}
+		rawURL = next
+	}
+	return "", errors.New("too many hops")
+}
+
+func TestValidateUnshorten(t *testing.T) {
+	type destination struct {
+		URL string `json:"url" validate:"required,http_url,unshorten=2"`
+	}
+
+	resolver := shortLinks{
+		"https://sho.rt/a": "https://example.com/a",
+		"https://sho.rt/b": "https://sho.rt/a",
+		"https://sho.rt/c": "https://sho.rt/b",
+		"https://sho.rt/x": "https://sho.rt/y",
+		"https://sho.rt/y": "https://sho.rt/x",
+	}
+
+	tests := []struct {
+		name        string
+		url         string
+		expected    string
+		expectedErr string
+	}{
+		{name: "not a short link", url: "https://example.com/path", expected: "https://example.com/path"},
+		{name: "short link", url: "https://sho.rt/a", expected: "https://example.com/a"},
+		{name: "chain", url: "https://sho.rt/b", expected: "https://example.com/a"},
+		{name: "too long chain", url: "https://sho.rt/c", expectedErr: "url must not be a short link that loops, cannot be followed or goes through more than 2 short links"},
+		{name: "loop", url: "https://sho.rt/x", expectedErr: "url must not be a short link that loops, cannot be followed or goes through more than 2 short links"},
+	}
+
+	validate := New(WithShortLinkResolver(resolver))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := &destination{URL: tt.url}
+			err := validate.Validate(value)
+
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, value.URL, "URL should be the final destination")
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedErr, validate.FormatErrors(err)["url"], "wrong error message")
+			}
+		})
+	}
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		value := &destination{URL: "https://sho.rt/a"}
+		assert.Error(t, validate.ValidateCtx(ctx, value), "links should not be followed once the request is done")
+	})
+
+	t.Run("without a resolver", func(t *testing.T) {
+		value := &destination{URL: "https://sho.rt/x"}
+		assert.NoError(t, New().Validate(value))
+		assert.Equal(t, "https://sho.rt/x", value.URL)
+	})
+}
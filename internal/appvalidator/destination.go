@@ -0,0 +1,219 @@
+package appvalidator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rousage/shortener/internal/netguard"
+	"golang.org/x/net/idna"
+)
+
+// lookupTimeout bounds the resolution of the host of a destination
+const lookupTimeout = 2 * time.Second
+
+// HostPolicy decides whether links can point to a host
+type HostPolicy interface {
+	Allowed(host string) bool
+}
+
+// Resolver looks up the addresses of a host, net.Resolver implements it
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// ShortLinkResolver follows short links to where they end within one deadline for all the links, unshorten.Resolver implements it
+type ShortLinkResolver interface {
+	Resolve(ctx context.Context, rawURL string, maxHops int) (string, error)
+}
+
+// parseHostAddr returns the address of a host that is an IP literal. Like browsers, hosts ending with
+// a number are taken as IPv4 addresses with decimal, octal or hex parts, e.g. 2130706433 and 0x7f.1 are 127.0.0.1.
+// The returned address is invalid if the host looks like an IPv4 address but is not a valid one
+func parseHostAddr(host string) (addr netip.Addr, ok bool) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr, true
+	}
+
+	parts := strings.Split(strings.TrimSuffix(host, "."), ".")
+	if _, isNumber := parseIPv4Part(parts[len(parts)-1]); !isNumber {
+		return netip.Addr{}, false
+	}
+	if len(parts) > 4 {
+		return netip.Addr{}, true
+	}
+
+	var ip uint64
+	for i, part := range parts {
+		n, isNumber := parseIPv4Part(part)
+		if !isNumber {
+			return netip.Addr{}, true
+		}
+		// The last part fills the remaining bytes of the address
+		if i == len(parts)-1 {
+			if n >= 1<<(8*(5-len(parts))) {
+				return netip.Addr{}, true
+			}
+			ip = ip<<(8*(5-len(parts))) | n
+			break
+		}
+		if n > 255 {
+			return netip.Addr{}, true
+		}
+		ip = ip<<8 | n
+	}
+
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+func parseIPv4Part(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case strings.HasPrefix(part, "0x") || strings.HasPrefix(part, "0X"):
+		base, part = 16, part[2:]
+		if part == "" {
+			return 0, true
+		}
+	case len(part) > 1 && part[0] == '0':
+		base, part = 8, part[1:]
+	}
+
+	n, err := strconv.ParseUint(part, base, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// validateIDN converts an internationalized host to its ASCII (punycode) form, so the same destination is always
+// stored and checked the same way. The field is rewritten when it is settable, i.e. a pointer to the struct is validated
+func validateIDN(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	u, err := url.Parse(field.String())
+	if err != nil {
+		return true
+	}
+
+	host := u.Hostname()
+	if isASCII(host) {
+		return true
+	}
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return false
+	}
+
+	if field.CanSet() {
+		field.SetString(replaceHost(field.String(), u, ascii))
+	}
+
+	return true
+}
+
+// replaceHost replaces the host of the raw URL, keeping the rest of it as it was written when possible
+func replaceHost(raw string, u *url.URL, host string) string {
+	if scheme, rest, ok := strings.Cut(raw, "//"); ok {
+		replaced := scheme + "//" + strings.Replace(rest, u.Hostname(), host, 1)
+		if parsed, err := url.Parse(replaced); err == nil && parsed.Hostname() == host {
+			return replaced
+		}
+	}
+
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else {
+		u.Host = host
+	}
+	return u.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// validateUnshorten replaces a destination that is a short link, ours or of a known shortener, with where it ends,
+// following at most the number of links given by the param. Loops, longer chains and links that cannot be followed are rejected.
+// Like the idn tag, the field is only rewritten when it is settable
+func (av *AppValidator) validateUnshorten(ctx context.Context, fl validator.FieldLevel) bool {
+	if av.shortLinks == nil {
+		return true
+	}
+
+	maxHops, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic(fmt.Errorf("invalid unshorten param %q: %w", fl.Param(), err))
+	}
+
+	field := fl.Field()
+	destination, err := av.shortLinks.Resolve(ctx, field.String(), maxHops)
+	if err != nil {
+		return false
+	}
+	if destination != field.String() && field.CanSet() {
+		field.SetString(destination)
+	}
+
+	return true
+}
+
+// validatePublicHost rejects the URLs that point to loopback, link-local, private or reserved addresses,
+// either as IP literals or as host names that resolve to them. Host names that cannot be resolved are allowed,
+// a failing DNS server should not stop links from being created
+func (av *AppValidator) validatePublicHost(ctx context.Context, fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil || u.Hostname() == "" {
+		return true
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if addr, ok := parseHostAddr(host); ok {
+		return netguard.IsPublicAddr(addr)
+	}
+	// Reserved for loopback by RFC 6761, whatever the resolver returns
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	addrs, err := av.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return true
+	}
+	for _, ipAddr := range addrs {
+		addr, ok := netip.AddrFromSlice(ipAddr.IP)
+		if !ok || !netguard.IsPublicAddr(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateDestination checks the host of the URL against the host policy, every host is allowed without one.
+// Malformed URLs are left to the http_url tag
+func (av *AppValidator) validateDestination(fl validator.FieldLevel) bool {
+	if av.hostPolicy == nil {
+		return true
+	}
+
+	u, err := url.Parse(fl.Field().String())
+	if err != nil || u.Hostname() == "" {
+		return true
+	}
+
+	return av.hostPolicy.Allowed(u.Hostname())
+}
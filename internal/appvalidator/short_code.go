@@ -2,6 +2,8 @@ package appvalidator
 
 import (
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -10,6 +12,15 @@ import (
 // See: https://github.com/matoous/go-nanoid?tab=readme-ov-file#go-nanoid
 var shortCodeRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 
+// reservedShortCodes are the static path segments of the routes next to the ones with a short code,
+// e.g. GET /v1/urls/trending would shadow GET /v1/urls/:code of a link with the trending code
+var reservedShortCodes = []string{"trending"}
+
 func ValidateShortCode(fl validator.FieldLevel) bool {
 	return shortCodeRe.MatchString(fl.Field().String())
 }
+
+// ValidateUnreservedShortCode rejects the short codes that clash with a route, regardless of their case
+func ValidateUnreservedShortCode(fl validator.FieldLevel) bool {
+	return !slices.Contains(reservedShortCodes, strings.ToLower(fl.Field().String()))
+}
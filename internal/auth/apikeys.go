@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/rousage/shortener/internal/repository"
+)
+
+// APIKeyPrefix tells API keys apart from JWTs in the Authorization header
+// and makes leaked keys recognizable, e.g. by secret scanners
+const APIKeyPrefix = "shk_"
+
+// Number of characters of a key kept in clear, so users can recognize their keys
+const apiKeyDisplayLength = len(APIKeyPrefix) + 6
+
+// APIKeyStore looks up an active API key by its hash and records its use
+type APIKeyStore interface {
+	UseApiKey(ctx context.Context, keyHash string) (repository.ApiKey, error)
+}
+
+// NewAPIKey generates a random API key and returns the key, the part of it that is kept for display and its hash
+func NewAPIKey() (key, displayPrefix, hash string) {
+	key = APIKeyPrefix + rand.Text()
+
+	return key, key[:apiKeyDisplayLength], HashAPIKey(key)
+}
+
+// HashAPIKey returns the hex SHA-256 hash of the key.
+// Keys are random and long enough that a fast hash is sufficient
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
@@ -17,21 +17,54 @@ const (
 	ClaimsContextKey contextKey = "claims"
 
 	// Permissions
-	CreateURLs    permission = "create:urls"
-	DeleteURLs    permission = "delete:urls"
-	DeleteOwnURLs permission = "delete:own-urls"
-	GetOwnURLs    permission = "get:own-urls"
-	GetURL        permission = "get:url"
-	GetURLs       permission = "get:urls"
-	UserBlock     permission = "user:block"
-	UserUnblock   permission = "user:unblock"
-	GetUserBlocks permission = "get:user-blocks"
+	CreateURLs        permission = "create:urls"
+	DeleteURLs        permission = "delete:urls"
+	DeleteOwnURLs     permission = "delete:own-urls"
+	GetOwnURLs        permission = "get:own-urls"
+	GetURL            permission = "get:url"
+	GetURLs           permission = "get:urls"
+	GetURLStats       permission = "get:url-stats"
+	UpdateURLs        permission = "update:urls"
+	UpdateOwnURLs     permission = "update:own-urls"
+	UserBlock         permission = "user:block"
+	UserUnblock       permission = "user:unblock"
+	GetUserBlocks     permission = "get:user-blocks"
+	ManageOwnWebhooks permission = "manage:own-webhooks"
+	ManageOwnAPIKeys  permission = "manage:own-api-keys"
+	GetAuditLog       permission = "get:audit-log"
+	GetAbuseReports   permission = "get:abuse-reports"
+	ResolveReports    permission = "resolve:abuse-reports"
+	ManageDomainRules permission = "manage:domain-rules"
 )
 
+// permissions are all the permissions known to the API
+var permissions = []permission{
+	CreateURLs,
+	DeleteURLs,
+	DeleteOwnURLs,
+	GetOwnURLs,
+	GetURL,
+	GetURLs,
+	GetURLStats,
+	UpdateURLs,
+	UpdateOwnURLs,
+	UserBlock,
+	UserUnblock,
+	GetUserBlocks,
+	ManageOwnWebhooks,
+	ManageOwnAPIKeys,
+	GetAuditLog,
+	GetAbuseReports,
+	ResolveReports,
+	ManageDomainRules,
+}
+
 // CustomClaims contains custom data we want from the token
 type CustomClaims struct {
 	Scope       string   `json:"scope"`
 	Permissions []string `json:"permissions"`
+	// APIKeyID is the ID of the API key the request is authenticated with, 0 for JWTs
+	APIKeyID int64 `json:"-"`
 }
 
 // Validate does nothing, but we need it to satisfy validator.CustomClaims interface
@@ -50,6 +83,46 @@ func (c CustomClaims) HasPermission(expectedPermission permission) bool {
 	return slices.Contains(c.Permissions, string(expectedPermission))
 }
 
+// HasPermission checks whether the claims of the current request have a specific permission
+func HasPermission(c *echo.Context, expectedPermission permission) bool {
+	claims := getCustomClaimsFromContext(c)
+	if claims == nil {
+		return false
+	}
+
+	return claims.HasPermission(expectedPermission)
+}
+
+// IsPermission checks whether name is one of the permissions known to the API
+func IsPermission(name string) bool {
+	return slices.Contains(permissions, permission(name))
+}
+
+// GetPermissions returns the permissions of the current request
+func GetPermissions(c *echo.Context) []string {
+	claims := getCustomClaimsFromContext(c)
+	if claims == nil {
+		return nil
+	}
+
+	return claims.Permissions
+}
+
+// IsAPIKey checks whether the current request is authenticated with an API key
+func IsAPIKey(c *echo.Context) bool {
+	return GetAPIKeyID(c) != 0
+}
+
+// GetAPIKeyID returns the ID of the API key the current request is authenticated with, 0 otherwise
+func GetAPIKeyID(c *echo.Context) int64 {
+	claims := getCustomClaimsFromContext(c)
+	if claims == nil {
+		return 0
+	}
+
+	return claims.APIKeyID
+}
+
 func GetUserID(c *echo.Context) *string {
 	_, span := tracer.Start(c.Request().Context(), "auth.GetUserID")
 	defer span.End()
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -10,6 +12,7 @@ import (
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/jwks"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v5"
 	"github.com/rousage/shortener/internal/config"
 	"go.opentelemetry.io/otel"
@@ -23,14 +26,15 @@ const name = "github.com/rousage/shortener/internal/auth"
 var tracer = otel.Tracer(name)
 
 type Middleware struct {
-	cfg config.Auth
+	cfg     config.Auth
+	apiKeys APIKeyStore
 }
 
-func NewMiddleware(cfg config.Auth) *Middleware {
-	return &Middleware{cfg: cfg}
+func NewMiddleware(cfg config.Auth, apiKeys APIKeyStore) *Middleware {
+	return &Middleware{cfg: cfg, apiKeys: apiKeys}
 }
 
-// Authenticate is a middleware that will check the validity of the JWT if it is present
+// Authenticate is a middleware that will check the validity of the JWT or the API key if it is present
 func (m *Middleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c *echo.Context) error {
 		ctx, span := tracer.Start(c.Request().Context(), "auth.Authenticate")
@@ -73,6 +77,10 @@ func (m *Middleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 			return next(c)
 		}
 
+		if isAPIKey(token) {
+			return m.authenticateAPIKey(ctx, c, token, next)
+		}
+
 		// Otherwise, validate the token.
 		tokenInfo, err := jwtValidator.ValidateToken(ctx, token)
 		if err != nil {
@@ -87,6 +95,38 @@ func (m *Middleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// authenticateAPIKey sets the same claims as a JWT of the owner of the key would, limited to the permissions of the key
+func (m *Middleware) authenticateAPIKey(ctx context.Context, c *echo.Context, key string, next echo.HandlerFunc) error {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Bool("apiKey", true))
+
+	if m.apiKeys == nil {
+		span.AddEvent("api keys are not supported")
+		return echo.ErrUnauthorized
+	}
+
+	apiKey, err := m.apiKeys.UseApiKey(ctx, HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			span.AddEvent("api key is unknown, expired or revoked")
+			return echo.ErrUnauthorized
+		}
+
+		span.SetStatus(codes.Error, "failed to get api key")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get api key", "error", err)
+		return echo.ErrInternalServerError
+	}
+	span.SetAttributes(attribute.Int64("apiKeyId", apiKey.ID))
+
+	setClaimsToContext(c, &validator.ValidatedClaims{
+		RegisteredClaims: validator.RegisteredClaims{Subject: apiKey.UserID},
+		CustomClaims:     &CustomClaims{Permissions: apiKey.Permissions, APIKeyID: apiKey.ID},
+	})
+
+	return next(c)
+}
+
 func (m *Middleware) RequireAuthentication(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c *echo.Context) error {
 		_, span := tracer.Start(c.Request().Context(), "auth.RequireAuthentication")
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/valkey-io/valkey-glide/go/v2/options"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// userBlockedExpire bounds how long a stale block status can be served
+// if the invalidation on block or unblock fails
+var userBlockedExpire = 5 * time.Minute
+
+// GetUserBlocked returns the cached block status of the user, found is false if it is not cached
+func (c *Cache) GetUserBlocked(ctx context.Context, userID string) (blocked, found bool, err error) {
+	ctx, span := tracer.Start(ctx, "cache.GetUserBlocked")
+	defer span.End()
+
+	key := c.getUserBlockedKey(userID)
+	span.SetAttributes(attribute.String("key", key))
+
+	resp, err := c.client.Get(ctx, key)
+	if err != nil {
+		span.RecordError(err)
+		return false, false, err
+	}
+	if resp.IsNil() {
+		span.AddEvent("user block status not found in cache")
+		return false, false, nil
+	}
+
+	return resp.Value() == "1", true, nil
+}
+
+// SetUserBlocked caches the block status of the user
+func (c *Cache) SetUserBlocked(ctx context.Context, userID string, blocked bool) error {
+	ctx, span := tracer.Start(ctx, "cache.SetUserBlocked")
+	defer span.End()
+
+	key := c.getUserBlockedKey(userID)
+	span.SetAttributes(attribute.String("key", key), attribute.Bool("blocked", blocked))
+
+	value := "0"
+	if blocked {
+		value = "1"
+	}
+
+	opts := options.NewSetOptions().SetExpiry(options.NewExpiryIn(userBlockedExpire))
+	if _, err := c.client.SetWithOptions(ctx, key, value, *opts); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUserBlocked invalidates the cached block status of the user
+func (c *Cache) DeleteUserBlocked(ctx context.Context, userID string) error {
+	ctx, span := tracer.Start(ctx, "cache.DeleteUserBlocked")
+	defer span.End()
+
+	key := c.getUserBlockedKey(userID)
+	span.SetAttributes(attribute.String("key", key))
+
+	if _, err := c.client.Del(ctx, []string{key}); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Cache) getUserBlockedKey(userID string) string {
+	return fmt.Sprintf("user_blocked:%s", userID)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"sync"
 
 	"github.com/rousage/shortener/internal/config"
 	glide "github.com/valkey-io/valkey-glide/go/v2"
@@ -44,6 +45,10 @@ func Connect(logger *slog.Logger, cfg config.Cache) *glide.Client {
 type Cache struct {
 	client            *glide.Client
 	resolutionCounter metric.Int64Counter
+
+	// visitorSalt memoizes the salt of the current day, see GetVisitorSalt
+	visitorSaltMu sync.Mutex
+	visitorSalt   visitorSalt
 }
 
 func New(logger *slog.Logger, client *glide.Client) *Cache {
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/valkey-io/valkey-glide/go/v2/options"
+	"github.com/valkey-io/valkey-glide/go/v2/pipeline"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// dirtyClickCountsKey is the set of codes with click counts that are not flushed to the database yet
+const dirtyClickCountsKey = "click_counts:dirty"
+
+// ClickCount is the number of clicks of a code accumulated since the last flush
+type ClickCount struct {
+	Code          string
+	Clicks        int64
+	LastClickedAt time.Time
+}
+
+// RecordClick counts a click of the code by the visitor hash in one atomic round trip: the click counter of the next flush,
+// the trending buckets and the unique visitors of the day of clickedAt
+func (c *Cache) RecordClick(ctx context.Context, code string, clickedAt time.Time, visitor string) error {
+	ctx, span := tracer.Start(ctx, "cache.RecordClick")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("code", code))
+
+	batch := pipeline.NewStandaloneBatch(true)
+	c.incrClickCount(batch, code, clickedAt)
+	c.incrTrending(batch, code, clickedAt)
+	c.addUniqueVisitor(batch, code, clickedAt, visitor)
+	if _, err := c.client.Exec(ctx, *batch, true); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// incrClickCount adds the commands counting a click of the code and marking the code for the next flush to the batch
+func (c *Cache) incrClickCount(batch *pipeline.StandaloneBatch, code string, clickedAt time.Time) {
+	batch.Incr(c.getClickCountKey(code)).
+		Set(c.getLastClickedAtKey(code), strconv.FormatInt(clickedAt.UnixMilli(), 10)).
+		SAdd(dirtyClickCountsKey, []string{code})
+}
+
+// TakeClickCounts removes up to limit codes from the pending flush and returns their accumulated click counts.
+// The counters of the returned codes are reset, so the caller must put them back with AddClickCounts if it fails to persist them
+func (c *Cache) TakeClickCounts(ctx context.Context, limit int64) ([]ClickCount, error) {
+	ctx, span := tracer.Start(ctx, "cache.TakeClickCounts")
+	defer span.End()
+
+	members, err := c.client.SPopCount(ctx, dirtyClickCountsKey, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("codes", len(members)))
+	if len(members) == 0 {
+		return []ClickCount{}, nil
+	}
+
+	codes := make([]string, 0, len(members))
+	batch := pipeline.NewStandaloneBatch(false)
+	for code := range members {
+		codes = append(codes, code)
+		batch.GetDel(c.getClickCountKey(code)).GetDel(c.getLastClickedAtKey(code))
+	}
+
+	results, err := c.client.Exec(ctx, *batch, true)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	counts := make([]ClickCount, 0, len(codes))
+	for i, code := range codes {
+		clicks, lastClickedAt := results[2*i], results[2*i+1]
+		// The code can be marked again after its counter was taken by a previous flush
+		if clicks == nil {
+			continue
+		}
+
+		count := ClickCount{Code: code}
+		if count.Clicks, err = strconv.ParseInt(fmt.Sprint(clicks), 10, 64); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if lastClickedAt != nil {
+			millis, err := strconv.ParseInt(fmt.Sprint(lastClickedAt), 10, 64)
+			if err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+			count.LastClickedAt = time.UnixMilli(millis)
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+// AddClickCounts puts click counts back to the pending flush, e.g. after they failed to be persisted
+func (c *Cache) AddClickCounts(ctx context.Context, counts []ClickCount) error {
+	ctx, span := tracer.Start(ctx, "cache.AddClickCounts")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("codes", len(counts)))
+	if len(counts) == 0 {
+		return nil
+	}
+
+	// Clicks counted in the meantime are newer, so their timestamp is kept
+	setOpts := options.NewSetOptions().SetOnlyIfDoesNotExist()
+
+	batch := pipeline.NewStandaloneBatch(true)
+	codes := make([]string, len(counts))
+	for i, count := range counts {
+		codes[i] = count.Code
+		batch.IncrBy(c.getClickCountKey(count.Code), count.Clicks).
+			SetWithOptions(c.getLastClickedAtKey(count.Code), strconv.FormatInt(count.LastClickedAt.UnixMilli(), 10), *setOpts)
+	}
+	batch.SAdd(dirtyClickCountsKey, codes)
+
+	if _, err := c.client.Exec(ctx, *batch, true); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Cache) getClickCountKey(code string) string {
+	return fmt.Sprintf("click_count:%s", code)
+}
+
+func (c *Cache) getLastClickedAtKey(code string) string {
+	return fmt.Sprintf("last_clicked_at:%s", code)
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// domainRulesVersionKey is bumped on every change of the domain rules, so all replicas know to reload them
+const domainRulesVersionKey = "domain_rules:version"
+
+// GetDomainRulesVersion returns the current version of the domain rules, 0 if they have not changed since the cache was emptied
+func (c *Cache) GetDomainRulesVersion(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "cache.GetDomainRulesVersion")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("key", domainRulesVersionKey))
+
+	resp, err := c.client.Get(ctx, domainRulesVersionKey)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	if resp.IsNil() {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseInt(resp.Value(), 10, 64)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// IncrDomainRulesVersion bumps the version of the domain rules after a change and returns the new version
+func (c *Cache) IncrDomainRulesVersion(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "cache.IncrDomainRulesVersion")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("key", domainRulesVersionKey))
+
+	version, err := c.client.Incr(ctx, domainRulesVersionKey)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	return version, nil
+}
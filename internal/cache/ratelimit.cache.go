@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/valkey-io/valkey-glide/go/v2/options"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// gcraScript implements the generic cell rate algorithm: the key stores the theoretical arrival time (TAT)
+// of the next request in milliseconds. A request is allowed if it does not arrive earlier than
+// the TAT minus the burst tolerance, and then pushes the TAT one emission interval further.
+// The server time is used, so all instances share the same clock.
+//
+// KEYS[1] rate limit key
+// ARGV[1] emission interval in milliseconds (1000 / requests per second)
+// ARGV[2] burst
+//
+// Returns {allowed (0 or 1), remaining requests, retry after ms, reset after ms}
+var gcraScript = options.NewScript(`
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if tat == nil or tat < now then
+  tat = now
+end
+
+local tolerance = interval * burst
+local newTat = tat + interval
+local allowAt = newTat - tolerance
+if allowAt > now then
+  return {0, 0, allowAt - now, tat - now}
+end
+
+redis.call('SET', KEYS[1], newTat, 'PX', newTat - now)
+return {1, math.floor((now - allowAt) / interval), 0, newTat - now}
+`)
+
+// RateLimitResult is the outcome of a rate limited request
+type RateLimitResult struct {
+	Allowed bool
+	// Remaining is the number of requests that are allowed right away after this one
+	Remaining int64
+	// RetryAfter is how long a rejected request has to wait before it is allowed
+	RetryAfter time.Duration
+	// ResetAfter is how long it takes until the full burst is available again
+	ResetAfter time.Duration
+}
+
+// AllowRate counts a request against the rate limit of the key: rps requests per second on average
+// with bursts of up to burst requests. The state is kept in the cache, so the limit is shared by all instances
+func (c *Cache) AllowRate(ctx context.Context, key string, rps, burst int) (RateLimitResult, error) {
+	ctx, span := tracer.Start(ctx, "cache.AllowRate")
+	defer span.End()
+
+	key = c.getRateLimitKey(key)
+	span.SetAttributes(attribute.String("key", key))
+
+	interval := max(int64(time.Second/time.Millisecond)/int64(max(rps, 1)), 1)
+	result, err := c.client.InvokeScriptWithOptions(ctx, *gcraScript, options.ScriptOptions{
+		Keys: []string{key},
+		Args: []string{strconv.FormatInt(interval, 10), strconv.Itoa(max(burst, 1))},
+	})
+	if err != nil {
+		span.RecordError(err)
+		return RateLimitResult{}, err
+	}
+
+	values, ok := result.([]any)
+	if !ok || len(values) != 4 {
+		err := fmt.Errorf("unexpected rate limit result %v", result)
+		span.RecordError(err)
+		return RateLimitResult{}, err
+	}
+
+	ints := make([]int64, len(values))
+	for i, value := range values {
+		if ints[i], ok = value.(int64); !ok {
+			err := fmt.Errorf("unexpected rate limit result %v", result)
+			span.RecordError(err)
+			return RateLimitResult{}, err
+		}
+	}
+
+	span.SetAttributes(attribute.Bool("allowed", ints[0] == 1), attribute.Int64("remaining", ints[1]))
+
+	return RateLimitResult{
+		Allowed:    ints[0] == 1,
+		Remaining:  ints[1],
+		RetryAfter: time.Duration(ints[2]) * time.Millisecond,
+		ResetAfter: time.Duration(ints[3]) * time.Millisecond,
+	}, nil
+}
+
+func (c *Cache) getRateLimitKey(key string) string {
+	return fmt.Sprintf("rate_limit:%s", key)
+}
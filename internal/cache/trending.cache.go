@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/valkey-io/valkey-glide/go/v2/options"
+	"github.com/valkey-io/valkey-glide/go/v2/pipeline"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type TrendingPeriod string
+
+const (
+	TrendingHour TrendingPeriod = "hour"
+	TrendingDay  TrendingPeriod = "day"
+	TrendingWeek TrendingPeriod = "week"
+)
+
+// Clicks are counted in two series of time buckets: short buckets give the last hour a finer resolution,
+// long buckets cover the last day and week with fewer keys to merge
+const (
+	trendingShortBucket = 5 * time.Minute
+	trendingLongBucket  = time.Hour
+	// trendingLeaderboardExpire is how long a merged leaderboard is reused before it is merged again
+	trendingLeaderboardExpire = time.Minute
+)
+
+// TrendingScore is the number of clicks of a code in a trending period
+type TrendingScore struct {
+	Code   string
+	Clicks int64
+}
+
+// trendingWindow returns the bucket size and the number of buckets covering the period
+func trendingWindow(period TrendingPeriod) (time.Duration, int64) {
+	switch period {
+	case TrendingHour:
+		return trendingShortBucket, int64(time.Hour / trendingShortBucket)
+	case TrendingWeek:
+		return trendingLongBucket, int64(7 * 24 * time.Hour / trendingLongBucket)
+	default:
+		return trendingLongBucket, int64(24 * time.Hour / trendingLongBucket)
+	}
+}
+
+// incrTrending adds the commands counting a click of the code in the trending buckets of clickedAt to the batch.
+// Buckets expire once they fall out of the longest period that uses them
+func (c *Cache) incrTrending(batch *pipeline.StandaloneBatch, code string, clickedAt time.Time) {
+	shortKey := c.getTrendingBucketKey(trendingShortBucket, clickedAt.Unix()/int64(trendingShortBucket.Seconds()))
+	longKey := c.getTrendingBucketKey(trendingLongBucket, clickedAt.Unix()/int64(trendingLongBucket.Seconds()))
+
+	batch.ZIncrBy(shortKey, 1, code).
+		Expire(shortKey, time.Hour+trendingShortBucket).
+		ZIncrBy(longKey, 1, code).
+		Expire(longKey, 7*24*time.Hour+trendingLongBucket)
+}
+
+// GetTrending returns up to limit codes with the most clicks in the period ending at now
+func (c *Cache) GetTrending(ctx context.Context, period TrendingPeriod, now time.Time, limit int64) ([]TrendingScore, error) {
+	ctx, span := tracer.Start(ctx, "cache.GetTrending")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("period", string(period)), attribute.Int64("limit", limit))
+
+	key, err := c.mergeTrending(ctx, period, now)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	members, err := c.client.ZRangeWithScores(ctx, key, options.NewRangeByIndexQuery(0, limit-1).SetReverse())
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	scores := make([]TrendingScore, len(members))
+	for i, member := range members {
+		scores[i] = TrendingScore{Code: member.Member, Clicks: int64(member.Score)}
+	}
+
+	return scores, nil
+}
+
+// GetTrendingScores returns the clicks in the period ending at now of the given codes, in the same order.
+// Codes without clicks have a score of 0
+func (c *Cache) GetTrendingScores(ctx context.Context, period TrendingPeriod, now time.Time, codes []string) ([]TrendingScore, error) {
+	ctx, span := tracer.Start(ctx, "cache.GetTrendingScores")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("period", string(period)), attribute.Int("codes", len(codes)))
+	if len(codes) == 0 {
+		return []TrendingScore{}, nil
+	}
+
+	key, err := c.mergeTrending(ctx, period, now)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	results, err := c.client.ZMScore(ctx, key, codes)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	scores := make([]TrendingScore, len(codes))
+	for i, code := range codes {
+		scores[i] = TrendingScore{Code: code}
+		if !results[i].IsNil() {
+			scores[i].Clicks = int64(results[i].Value())
+		}
+	}
+
+	return scores, nil
+}
+
+// mergeTrending sums up the buckets of the period into a leaderboard and returns its key.
+// The leaderboard is reused by all requests for a short time, so the buckets are not merged on every request
+func (c *Cache) mergeTrending(ctx context.Context, period TrendingPeriod, now time.Time) (string, error) {
+	bucket, buckets := trendingWindow(period)
+	current := now.Unix() / int64(bucket.Seconds())
+
+	key := fmt.Sprintf("trending:%s:%d", period, current)
+	exists, err := c.client.Exists(ctx, []string{key})
+	if err != nil {
+		return key, err
+	}
+	if exists > 0 {
+		return key, nil
+	}
+
+	keys := make([]string, buckets)
+	for i := range buckets {
+		keys[i] = c.getTrendingBucketKey(bucket, current-i)
+	}
+
+	batch := pipeline.NewStandaloneBatch(true).
+		ZUnionStore(key, options.KeyArray{Keys: keys}).
+		Expire(key, trendingLeaderboardExpire)
+	if _, err := c.client.Exec(ctx, *batch, true); err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+func (c *Cache) getTrendingBucketKey(bucket time.Duration, index int64) string {
+	return fmt.Sprintf("trending:%ds:%d", int64(bucket.Seconds()), index)
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/valkey-io/valkey-glide/go/v2/options"
@@ -14,14 +15,41 @@ var (
 	defaultExpire = 24 * time.Hour
 )
 
-func (c *Cache) SetLongUrl(ctx context.Context, code, longUrl string) (key string, err error) {
+// incrWindowScript increments the counter and starts its window with the first increment, in one step,
+// so the counter cannot be left without an expiration
+//
+// KEYS[1] counter key
+// ARGV[1] window in milliseconds
+//
+// Returns the incremented value
+var incrWindowScript = options.NewScript(`
+local value = redis.call('INCR', KEYS[1])
+if value == 1 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return value
+`)
+
+// SetLongUrl caches the long URL for the code.
+// The TTL is capped by the link's remaining lifetime if expiresAt is set
+func (c *Cache) SetLongUrl(ctx context.Context, code, longUrl string, expiresAt *time.Time) (key string, err error) {
 	ctx, span := tracer.Start(ctx, "cache.SetLongUrl")
 	defer span.End()
 
 	key = c.getUrlKey(code)
 	span.SetAttributes(attribute.String("key", key))
 
-	opts := options.NewSetOptions().SetExpiry(options.NewExpiryIn(defaultExpire))
+	expire := defaultExpire
+	if expiresAt != nil {
+		expire = min(expire, time.Until(*expiresAt))
+	}
+	span.SetAttributes(attribute.String("expire", expire.String()))
+	if expire.Milliseconds() <= 0 {
+		span.AddEvent("long url is already expired, skipping")
+		return key, nil
+	}
+
+	opts := options.NewSetOptions().SetExpiry(options.NewExpiryIn(expire))
 	if _, err := c.client.SetWithOptions(ctx, key, longUrl, *opts); err != nil {
 		span.RecordError(err)
 		return key, err
@@ -92,6 +120,54 @@ func (c *Cache) DeleteLongURLs(ctx context.Context, codes []string) (int64, erro
 	return resp, nil
 }
 
+// IncrPasswordAttempts records a password attempt for the code and returns the number of attempts in the current window,
+// this one included. The window starts with the first attempt and lasts for the given duration
+func (c *Cache) IncrPasswordAttempts(ctx context.Context, code string, window time.Duration) (int64, error) {
+	ctx, span := tracer.Start(ctx, "cache.IncrPasswordAttempts")
+	defer span.End()
+
+	key := c.getPasswordAttemptsKey(code)
+	span.SetAttributes(attribute.String("key", key))
+
+	result, err := c.client.InvokeScriptWithOptions(ctx, *incrWindowScript, options.ScriptOptions{
+		Keys: []string{key},
+		Args: []string{strconv.FormatInt(window.Milliseconds(), 10)},
+	})
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	attempts, ok := result.(int64)
+	if !ok {
+		err := fmt.Errorf("unexpected password attempts result %v", result)
+		span.RecordError(err)
+		return 0, err
+	}
+	span.SetAttributes(attribute.Int64("attempts", attempts))
+
+	return attempts, nil
+}
+
+// ResetPasswordAttempts forgets the password attempts for the code
+func (c *Cache) ResetPasswordAttempts(ctx context.Context, code string) error {
+	ctx, span := tracer.Start(ctx, "cache.ResetPasswordAttempts")
+	defer span.End()
+
+	key := c.getPasswordAttemptsKey(code)
+	span.SetAttributes(attribute.String("key", key))
+
+	if _, err := c.client.Del(ctx, []string{key}); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
 func (c *Cache) getUrlKey(code string) string {
 	return fmt.Sprintf("long_url:%s", code)
 }
+
+func (c *Cache) getPasswordAttemptsKey(code string) string {
+	return fmt.Sprintf("password_attempts:%s", code)
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/rousage/shortener/internal/testhelpers"
 	"github.com/stretchr/testify/assert"
@@ -55,7 +56,7 @@ func (suite *UrlTestSuite) TestSetLongUrl() {
 	expectedTTL := int64(defaultExpire.Seconds())
 
 	// Write a long URL to the cache and get back a key
-	key, err := suite.cache.SetLongUrl(suite.ctx, "short-url", "https://long.url")
+	key, err := suite.cache.SetLongUrl(suite.ctx, "short-url", "https://long.url", nil)
 	suite.NoError(err)
 	suite.Equal("long_url:short-url", key)
 	// Check that TTL is set to default
@@ -65,7 +66,7 @@ func (suite *UrlTestSuite) TestSetLongUrl() {
 	suite.LessOrEqual(ttl, expectedTTL, "incorrect TTL (too high)")
 
 	// Write another URL to the same key
-	key, err = suite.cache.SetLongUrl(suite.ctx, "short-url", "https://new-long.url")
+	key, err = suite.cache.SetLongUrl(suite.ctx, "short-url", "https://new-long.url", nil)
 	suite.NoError(err)
 	suite.Equal("long_url:short-url", key)
 	// Make sure the TTL is still the default
@@ -74,7 +75,7 @@ func (suite *UrlTestSuite) TestSetLongUrl() {
 	suite.GreaterOrEqual(ttl, expectedTTL-1, "incorrect TTL (too low)")
 	suite.LessOrEqual(ttl, expectedTTL, "incorrect TTL (too high)")
 
-	key2, err := suite.cache.SetLongUrl(suite.ctx, "short-url2", "https://another-long.url")
+	key2, err := suite.cache.SetLongUrl(suite.ctx, "short-url2", "https://another-long.url", nil)
 	suite.NoError(err)
 	suite.Equal("long_url:short-url2", key2)
 
@@ -88,6 +89,48 @@ func (suite *UrlTestSuite) TestSetLongUrl() {
 	suite.Equal(int64(2), resp, "incorrect number of keys in cache")
 }
 
+func (suite *UrlTestSuite) TestSetLongUrl_ExpiresAt() {
+	t := suite.T()
+
+	var (
+		soon    = time.Now().Add(time.Hour)
+		later   = time.Now().Add(48 * time.Hour)
+		expired = time.Now().Add(-time.Minute)
+	)
+
+	tests := []struct {
+		name        string
+		code        string
+		expiresAt   *time.Time
+		expectedTTL int64
+		expectCache bool
+	}{
+		{name: "ttl is capped by expiration", code: "short-url-1", expiresAt: &soon, expectedTTL: int64(time.Hour.Seconds()), expectCache: true},
+		{name: "ttl is default for distant expiration", code: "short-url-2", expiresAt: &later, expectedTTL: int64(defaultExpire.Seconds()), expectCache: true},
+		{name: "expired url is not cached", code: "short-url-3", expiresAt: &expired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := suite.cache.SetLongUrl(suite.ctx, tt.code, "https://long.url", tt.expiresAt)
+			assert.NoError(t, err)
+
+			exists, err := suite.cache.client.Exists(suite.ctx, []string{key})
+			assert.NoError(t, err)
+			if !tt.expectCache {
+				assert.Equal(t, int64(0), exists, "expired url should not be cached")
+				return
+			}
+			assert.Equal(t, int64(1), exists, "url should be cached")
+
+			ttl, err := suite.cache.client.TTL(suite.ctx, key)
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, ttl, tt.expectedTTL-1, "incorrect TTL (too low)")
+			assert.LessOrEqual(t, ttl, tt.expectedTTL, "incorrect TTL (too high)")
+		})
+	}
+}
+
 func (suite *UrlTestSuite) TestGetLongUrl() {
 	code := "short-url"
 
@@ -95,7 +138,7 @@ func (suite *UrlTestSuite) TestGetLongUrl() {
 	suite.NoError(err)
 	suite.Empty(longUrl, "long URL is not empty for non-existing cache entry")
 
-	_, err = suite.cache.SetLongUrl(suite.ctx, code, "https://long.url")
+	_, err = suite.cache.SetLongUrl(suite.ctx, code, "https://long.url", nil)
 	suite.NoError(err)
 
 	longUrl, err = suite.cache.GetLongUrl(suite.ctx, code)
@@ -103,7 +146,7 @@ func (suite *UrlTestSuite) TestGetLongUrl() {
 	suite.Equal("https://long.url", longUrl, "long URL is not correct for existing cache entry")
 
 	// Make sure the cache entry is overridden to a new value
-	_, err = suite.cache.SetLongUrl(suite.ctx, code, "https://another-long.url")
+	_, err = suite.cache.SetLongUrl(suite.ctx, code, "https://another-long.url", nil)
 	suite.NoError(err)
 
 	longUrl, err = suite.cache.GetLongUrl(suite.ctx, code)
@@ -118,7 +161,7 @@ func (suite *UrlTestSuite) TestDeleteLongURL() {
 	suite.NoError(err)
 	suite.Empty(removedKeys, "expected to delete nothing, but deleted actual keys")
 
-	_, err = suite.cache.SetLongUrl(suite.ctx, code, "https://long.url")
+	_, err = suite.cache.SetLongUrl(suite.ctx, code, "https://long.url", nil)
 	suite.NoError(err)
 
 	removedKeys, err = suite.cache.DeleteLongURL(suite.ctx, code)
@@ -138,7 +181,7 @@ func (suite *UrlTestSuite) TestDeleteLongURLs() {
 	for i := range len(codes) {
 		code := fmt.Sprintf("short-url-%d", i)
 
-		_, err := suite.cache.SetLongUrl(suite.ctx, code, "https://long.url")
+		_, err := suite.cache.SetLongUrl(suite.ctx, code, "https://long.url", nil)
 		suite.Require().NoError(err, "error setting long URL")
 
 		codes[i] = code
@@ -165,6 +208,237 @@ func (suite *UrlTestSuite) TestDeleteLongURLs() {
 	}
 }
 
+func (suite *UrlTestSuite) TestPasswordAttempts() {
+	for i := range 3 {
+		attempts, err := suite.cache.IncrPasswordAttempts(suite.ctx, "short-url", time.Minute)
+		suite.NoError(err)
+		suite.Equal(int64(i+1), attempts)
+	}
+
+	// The window is set by the first attempt and is not extended by the following ones
+	ttl, err := suite.cache.client.TTL(suite.ctx, "password_attempts:short-url")
+	suite.NoError(err)
+	suite.GreaterOrEqual(ttl, int64(59), "incorrect TTL (too low)")
+	suite.LessOrEqual(ttl, int64(60), "incorrect TTL (too high)")
+
+	// Attempts are counted per code
+	attempts, err := suite.cache.IncrPasswordAttempts(suite.ctx, "short-url2", time.Minute)
+	suite.NoError(err)
+	suite.Equal(int64(1), attempts)
+
+	// A reset starts a new window
+	suite.NoError(suite.cache.ResetPasswordAttempts(suite.ctx, "short-url"))
+	attempts, err = suite.cache.IncrPasswordAttempts(suite.ctx, "short-url", time.Minute)
+	suite.NoError(err)
+	suite.Equal(int64(1), attempts)
+}
+
+func (suite *UrlTestSuite) TestClickCounts() {
+	counts, err := suite.cache.TakeClickCounts(suite.ctx, 10)
+	suite.NoError(err)
+	suite.Empty(counts)
+
+	clickedAt := time.Now().Truncate(time.Millisecond)
+	for range 3 {
+		suite.NoError(suite.cache.RecordClick(suite.ctx, "short-url", clickedAt, "visitor-1"))
+	}
+	suite.NoError(suite.cache.RecordClick(suite.ctx, "short-url2", clickedAt, "visitor-1"))
+
+	counts, err = suite.cache.TakeClickCounts(suite.ctx, 10)
+	suite.NoError(err)
+	suite.ElementsMatch([]ClickCount{
+		{Code: "short-url", Clicks: 3, LastClickedAt: clickedAt},
+		{Code: "short-url2", Clicks: 1, LastClickedAt: clickedAt},
+	}, counts)
+
+	// Taken counters are reset
+	counts, err = suite.cache.TakeClickCounts(suite.ctx, 10)
+	suite.NoError(err)
+	suite.Empty(counts)
+
+	// Counters put back are merged with the clicks counted in the meantime
+	newerClickedAt := clickedAt.Add(time.Second)
+	suite.NoError(suite.cache.RecordClick(suite.ctx, "short-url", newerClickedAt, "visitor-1"))
+	suite.NoError(suite.cache.AddClickCounts(suite.ctx, []ClickCount{{Code: "short-url", Clicks: 3, LastClickedAt: clickedAt}}))
+
+	counts, err = suite.cache.TakeClickCounts(suite.ctx, 10)
+	suite.NoError(err)
+	suite.Equal([]ClickCount{{Code: "short-url", Clicks: 4, LastClickedAt: newerClickedAt}}, counts)
+}
+
+func (suite *UrlTestSuite) TestRecordClick() {
+	clickedAt := time.Now().Truncate(time.Millisecond)
+	suite.NoError(suite.cache.RecordClick(suite.ctx, "short-url", clickedAt, "visitor-1"))
+	suite.NoError(suite.cache.RecordClick(suite.ctx, "short-url", clickedAt, "visitor-1"))
+
+	// The click is counted for the flush, the trending links and the unique visitors at once
+	counts, err := suite.cache.TakeClickCounts(suite.ctx, 10)
+	suite.NoError(err)
+	suite.Equal([]ClickCount{{Code: "short-url", Clicks: 2, LastClickedAt: clickedAt}}, counts)
+
+	trending, err := suite.cache.GetTrending(suite.ctx, TrendingHour, clickedAt, 10)
+	suite.NoError(err)
+	suite.Equal([]TrendingScore{{Code: "short-url", Clicks: 2}}, trending)
+
+	visitors, err := suite.cache.CountUniqueVisitors(suite.ctx, "short-url", []time.Time{clickedAt})
+	suite.NoError(err)
+	suite.Equal(int64(1), visitors)
+}
+
+func (suite *UrlTestSuite) TestVisitorSalt() {
+	today := time.Now()
+
+	salt, err := suite.cache.GetVisitorSalt(suite.ctx, today)
+	suite.NoError(err)
+	suite.NotEmpty(salt)
+
+	// The salt is shared by all instances of the day
+	otherCache := New(slog.New(slog.NewTextHandler(io.Discard, nil)), suite.cache.client)
+	otherSalt, err := otherCache.GetVisitorSalt(suite.ctx, today)
+	suite.NoError(err)
+	suite.Equal(salt, otherSalt)
+
+	// and rotates daily
+	tomorrowSalt, err := suite.cache.GetVisitorSalt(suite.ctx, today.AddDate(0, 0, 1))
+	suite.NoError(err)
+	suite.NotEqual(salt, tomorrowSalt)
+
+	ttl, err := suite.cache.client.TTL(suite.ctx, fmt.Sprintf("visitor_salt:%s", today.UTC().Format(dayFormat)))
+	suite.NoError(err)
+	suite.LessOrEqual(ttl, int64(visitorSaltExpire.Seconds()), "incorrect TTL (too high)")
+	suite.Greater(ttl, int64(0), "salt should expire")
+}
+
+func (suite *UrlTestSuite) TestUniqueVisitors() {
+	var (
+		today     = time.Now().UTC()
+		yesterday = today.AddDate(0, 0, -1)
+	)
+
+	visits := []struct {
+		day     time.Time
+		visitor string
+	}{
+		{day: yesterday, visitor: "visitor-1"},
+		{day: yesterday, visitor: "visitor-2"},
+		{day: today, visitor: "visitor-1"},
+		{day: today, visitor: "visitor-1"},
+		{day: today, visitor: "visitor-3"},
+	}
+	for _, visit := range visits {
+		suite.NoError(suite.cache.RecordClick(suite.ctx, "short-url", visit.day, visit.visitor))
+	}
+	suite.NoError(suite.cache.RecordClick(suite.ctx, "short-url2", today, "visitor-4"))
+
+	byDay, err := suite.cache.CountUniqueVisitorsByDay(suite.ctx, "short-url", []time.Time{yesterday, today, today.AddDate(0, 0, 1)})
+	suite.NoError(err)
+	suite.Equal([]int64{2, 2, 0}, byDay)
+
+	total, err := suite.cache.CountUniqueVisitors(suite.ctx, "short-url", []time.Time{yesterday, today})
+	suite.NoError(err)
+	suite.Equal(int64(3), total)
+
+	total, err = suite.cache.CountUniqueVisitors(suite.ctx, "short-url", nil)
+	suite.NoError(err)
+	suite.Equal(int64(0), total)
+}
+
+func (suite *UrlTestSuite) TestTrending() {
+	now := time.Now()
+
+	clicks := []struct {
+		code      string
+		clickedAt time.Time
+	}{
+		{code: "short-url", clickedAt: now},
+		{code: "short-url", clickedAt: now},
+		{code: "short-url2", clickedAt: now},
+		// Out of the last hour, but within the last day
+		{code: "short-url2", clickedAt: now.Add(-2 * time.Hour)},
+		{code: "short-url2", clickedAt: now.Add(-3 * time.Hour)},
+		// Out of the last day, but within the last week
+		{code: "short-url3", clickedAt: now.Add(-48 * time.Hour)},
+	}
+	for _, click := range clicks {
+		suite.NoError(suite.cache.RecordClick(suite.ctx, click.code, click.clickedAt, "visitor-1"))
+	}
+
+	trending, err := suite.cache.GetTrending(suite.ctx, TrendingHour, now, 10)
+	suite.NoError(err)
+	suite.Equal([]TrendingScore{{Code: "short-url", Clicks: 2}, {Code: "short-url2", Clicks: 1}}, trending)
+
+	trending, err = suite.cache.GetTrending(suite.ctx, TrendingDay, now, 10)
+	suite.NoError(err)
+	suite.Equal([]TrendingScore{{Code: "short-url2", Clicks: 3}, {Code: "short-url", Clicks: 2}}, trending)
+
+	trending, err = suite.cache.GetTrending(suite.ctx, TrendingWeek, now, 1)
+	suite.NoError(err)
+	suite.Equal([]TrendingScore{{Code: "short-url2", Clicks: 3}}, trending)
+
+	scores, err := suite.cache.GetTrendingScores(suite.ctx, TrendingWeek, now, []string{"short-url3", "non-existent"})
+	suite.NoError(err)
+	suite.Equal([]TrendingScore{{Code: "short-url3", Clicks: 1}, {Code: "non-existent", Clicks: 0}}, scores)
+}
+
+func (suite *UrlTestSuite) TestAllowRate() {
+	// A burst of 3 is allowed right away
+	for i := range 3 {
+		result, err := suite.cache.AllowRate(suite.ctx, "create:user:1", 1, 3)
+		suite.NoError(err)
+		suite.True(result.Allowed, "request %d should be allowed", i+1)
+		suite.Equal(int64(2-i), result.Remaining)
+		suite.Zero(result.RetryAfter)
+		suite.Positive(result.ResetAfter)
+	}
+
+	// and then the requests are limited to the rate
+	result, err := suite.cache.AllowRate(suite.ctx, "create:user:1", 1, 3)
+	suite.NoError(err)
+	suite.False(result.Allowed)
+	suite.Zero(result.Remaining)
+	suite.Positive(result.RetryAfter)
+	suite.LessOrEqual(result.RetryAfter, time.Second)
+
+	// Other keys have their own limit
+	result, err = suite.cache.AllowRate(suite.ctx, "create:user:2", 1, 3)
+	suite.NoError(err)
+	suite.True(result.Allowed)
+
+	// The state expires once the burst is available again
+	ttl, err := suite.cache.client.PTTL(suite.ctx, "rate_limit:create:user:1")
+	suite.NoError(err)
+	suite.Positive(ttl)
+	suite.LessOrEqual(ttl, int64(3*time.Second/time.Millisecond))
+}
+
+func (suite *UrlTestSuite) TestUserBlocked() {
+	_, found, err := suite.cache.GetUserBlocked(suite.ctx, "user-id")
+	suite.NoError(err)
+	suite.False(found)
+
+	suite.NoError(suite.cache.SetUserBlocked(suite.ctx, "user-id", true))
+	blocked, found, err := suite.cache.GetUserBlocked(suite.ctx, "user-id")
+	suite.NoError(err)
+	suite.True(found)
+	suite.True(blocked)
+
+	suite.NoError(suite.cache.SetUserBlocked(suite.ctx, "user-id", false))
+	blocked, found, err = suite.cache.GetUserBlocked(suite.ctx, "user-id")
+	suite.NoError(err)
+	suite.True(found)
+	suite.False(blocked)
+
+	ttl, err := suite.cache.client.TTL(suite.ctx, "user_blocked:user-id")
+	suite.NoError(err)
+	suite.LessOrEqual(ttl, int64(userBlockedExpire.Seconds()), "incorrect TTL (too high)")
+	suite.Greater(ttl, int64(0), "block status should expire")
+
+	suite.NoError(suite.cache.DeleteUserBlocked(suite.ctx, "user-id"))
+	_, found, err = suite.cache.GetUserBlocked(suite.ctx, "user-id")
+	suite.NoError(err)
+	suite.False(found)
+}
+
 func TestUrlTestSuite(t *testing.T) {
 	suite.Run(t, new(UrlTestSuite))
 }
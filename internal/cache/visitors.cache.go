@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/valkey-io/valkey-glide/go/v2/options"
+	"github.com/valkey-io/valkey-glide/go/v2/pipeline"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// UniqueVisitorsRetention is how long the daily unique visitor estimations are kept
+	UniqueVisitorsRetention = 400 * 24 * time.Hour
+	// The salt of a day is kept a bit longer than the day, so all instances agree on it around midnight
+	visitorSaltExpire = 48 * time.Hour
+	dayFormat         = "2006-01-02"
+)
+
+type visitorSalt struct {
+	day   string
+	value string
+}
+
+// GetVisitorSalt returns the random salt of the UTC day of t.
+// The salt is created on first use, shared by all instances and expires shortly after the day,
+// so visitor hashes of different days cannot be linked
+func (c *Cache) GetVisitorSalt(ctx context.Context, t time.Time) (string, error) {
+	ctx, span := tracer.Start(ctx, "cache.GetVisitorSalt")
+	defer span.End()
+
+	day := t.UTC().Format(dayFormat)
+	key := c.getVisitorSaltKey(day)
+	span.SetAttributes(attribute.String("key", key))
+
+	c.visitorSaltMu.Lock()
+	defer c.visitorSaltMu.Unlock()
+
+	if c.visitorSalt.day == day {
+		return c.visitorSalt.value, nil
+	}
+
+	// Another instance may have created the salt first, in which case the existing one is kept
+	opts := options.NewSetOptions().
+		SetOnlyIfDoesNotExist().
+		SetExpiry(options.NewExpiryIn(visitorSaltExpire))
+	if _, err := c.client.SetWithOptions(ctx, key, rand.Text(), *opts); err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	resp, err := c.client.Get(ctx, key)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	if resp.IsNil() {
+		err := fmt.Errorf("visitor salt %s is missing", key)
+		span.RecordError(err)
+		return "", err
+	}
+
+	c.visitorSalt = visitorSalt{day: day, value: resp.Value()}
+
+	return resp.Value(), nil
+}
+
+// addUniqueVisitor adds the commands adding the visitor hash to the unique visitors of the code
+// on the UTC day of visitedAt to the batch
+func (c *Cache) addUniqueVisitor(batch *pipeline.StandaloneBatch, code string, visitedAt time.Time, visitor string) {
+	key := c.getUniqueVisitorsKey(code, visitedAt)
+	batch.PfAdd(key, []string{visitor}).
+		Expire(key, UniqueVisitorsRetention)
+}
+
+// CountUniqueVisitors returns the approximate number of distinct visitors of the code over all the given days
+func (c *Cache) CountUniqueVisitors(ctx context.Context, code string, days []time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "cache.CountUniqueVisitors")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("code", code), attribute.Int("days", len(days)))
+	if len(days) == 0 {
+		return 0, nil
+	}
+
+	keys := make([]string, len(days))
+	for i, day := range days {
+		keys[i] = c.getUniqueVisitorsKey(code, day)
+	}
+
+	count, err := c.client.PfCount(ctx, keys)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CountUniqueVisitorsByDay returns the approximate number of distinct visitors of the code on each of the given days
+func (c *Cache) CountUniqueVisitorsByDay(ctx context.Context, code string, days []time.Time) ([]int64, error) {
+	ctx, span := tracer.Start(ctx, "cache.CountUniqueVisitorsByDay")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("code", code), attribute.Int("days", len(days)))
+	if len(days) == 0 {
+		return []int64{}, nil
+	}
+
+	batch := pipeline.NewStandaloneBatch(false)
+	for _, day := range days {
+		batch.PfCount([]string{c.getUniqueVisitorsKey(code, day)})
+	}
+
+	results, err := c.client.Exec(ctx, *batch, true)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	counts := make([]int64, len(results))
+	for i, result := range results {
+		count, ok := result.(int64)
+		if !ok {
+			err := fmt.Errorf("unexpected PFCOUNT result %T", result)
+			span.RecordError(err)
+			return nil, err
+		}
+		counts[i] = count
+	}
+
+	return counts, nil
+}
+
+func (c *Cache) getVisitorSaltKey(day string) string {
+	return fmt.Sprintf("visitor_salt:%s", day)
+}
+
+func (c *Cache) getUniqueVisitorsKey(code string, day time.Time) string {
+	return fmt.Sprintf("unique_visitors:%s:%s", code, day.UTC().Format(dayFormat))
+}
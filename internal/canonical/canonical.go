@@ -0,0 +1,179 @@
+// Package canonical rewrites destination URLs to a canonical form, so the URLs that lead to the same page are stored,
+// deduplicated and checked against the domain rules the same way
+package canonical
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+type Options struct {
+	// StripParams are the query params removed from the URL, compared case-insensitively.
+	// A trailing * matches any suffix, e.g. utm_* matches utm_source and utm_medium
+	StripParams []string
+}
+
+// URL returns the canonical form of the URL: the scheme and the host are lowercased, the host is converted to punycode,
+// default ports are dropped, percent-encoded unreserved characters are decoded, the remaining escapes are uppercased,
+// the query params are sorted by name and the ones matching the options are removed.
+// The relative order of repeated params is kept. URLs that cannot be parsed are returned as they are
+func URL(rawURL string, opts Options) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Opaque != "" || u.Host == "" {
+		return rawURL
+	}
+
+	host, err := canonicalHost(u)
+	if err != nil {
+		return rawURL
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.ToLower(u.Scheme))
+	b.WriteString("://")
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+	b.WriteString(host)
+	b.WriteString(normalizeEscapes(u.EscapedPath()))
+	if query := canonicalQuery(u.RawQuery, opts.StripParams); query != "" {
+		b.WriteByte('?')
+		b.WriteString(query)
+	}
+	if u.Fragment != "" {
+		b.WriteByte('#')
+		b.WriteString(normalizeEscapes(u.EscapedFragment()))
+	}
+
+	return b.String()
+}
+
+func canonicalHost(u *url.URL) (string, error) {
+	host := strings.ToLower(u.Hostname())
+	isIPv6 := strings.Contains(host, ":")
+	if !isIPv6 {
+		ascii, err := idna.Lookup.ToASCII(host)
+		if err != nil {
+			return "", err
+		}
+		host = ascii
+	}
+
+	if port := u.Port(); port != "" && defaultPorts[strings.ToLower(u.Scheme)] != port {
+		return net.JoinHostPort(host, port), nil
+	}
+	if isIPv6 {
+		return "[" + host + "]", nil
+	}
+	return host, nil
+}
+
+// canonicalQuery sorts the params of the raw query by name and removes the stripped ones.
+// Params are kept as they were written apart from the normalized escapes, so their encoding does not change
+func canonicalQuery(rawQuery string, stripParams []string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	type param struct {
+		name string
+		raw  string
+	}
+	var params []param
+	for raw := range strings.SplitSeq(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		raw = normalizeEscapes(raw)
+		rawName, _, _ := strings.Cut(raw, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		if isStripped(name, stripParams) {
+			continue
+		}
+		params = append(params, param{name: name, raw: raw})
+	}
+
+	slices.SortStableFunc(params, func(a, b param) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.raw
+	}
+	return strings.Join(parts, "&")
+}
+
+func isStripped(name string, stripParams []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range stripParams {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEscapes decodes the percent-encoded unreserved characters (RFC 3986, section 2.3)
+// and uppercases the hex digits of the other escapes
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
@@ -0,0 +1,103 @@
+package clicks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Device classes
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// Longer referrers and user agents are truncated before they are stored
+const maxHeaderLength = 512
+
+// Event is a single resolution of a short link
+type Event struct {
+	Code         string
+	ClickedAt    time.Time
+	Referrer     *string
+	ReferrerHost *string
+	UserAgent    *string
+	Browser      string
+	Device       string
+	AnonymizedIP *string
+}
+
+// NewEvent builds a click event from the request data of a resolution.
+// The client IP is anonymized, so the full address is never stored
+func NewEvent(code, referrer, userAgent, clientIP string, clickedAt time.Time) Event {
+	browser, device := ParseUserAgent(userAgent)
+
+	return Event{
+		Code:         code,
+		ClickedAt:    clickedAt,
+		Referrer:     optional(truncate(referrer)),
+		ReferrerHost: optional(referrerHost(referrer)),
+		UserAgent:    optional(truncate(userAgent)),
+		Browser:      browser,
+		Device:       device,
+		AnonymizedIP: optional(AnonymizeIP(clientIP)),
+	}
+}
+
+// AnonymizeIP zeroes the host part of the address: the last octet of IPv4
+// and the last 80 bits of IPv6 addresses. Invalid addresses return an empty string
+func AnonymizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// VisitorHash identifies a visitor by the client IP and user agent for unique visitor counting.
+// The salt must rotate regularly, so the hash cannot be linked to the same visitor across salts
+func VisitorHash(salt, clientIP, userAgent string) string {
+	sum := sha256.Sum256([]byte(salt + "\x00" + clientIP + "\x00" + userAgent))
+	return hex.EncodeToString(sum[:])
+}
+
+// referrerHost returns the lowercase host of the referrer without the port
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(parsed.Hostname())
+}
+
+func truncate(value string) string {
+	if len(value) > maxHeaderLength {
+		return value[:maxHeaderLength]
+	}
+
+	return value
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
@@ -0,0 +1,155 @@
+package clicks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		ip       string
+		expected string
+	}{
+		{name: "ipv4", ip: "203.0.113.42", expected: "203.0.113.0"},
+		{name: "ipv4-mapped ipv6", ip: "::ffff:203.0.113.42", expected: "203.0.113.0"},
+		{name: "ipv6", ip: "2001:db8:85a3:8d3:1319:8a2e:370:7348", expected: "2001:db8:85a3::"},
+		{name: "invalid ip", ip: "not-an-ip", expected: ""},
+		{name: "empty ip", ip: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AnonymizeIP(tt.ip))
+		})
+	}
+}
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name            string
+		userAgent       string
+		expectedBrowser string
+		expectedDevice  string
+	}{
+		{
+			name:            "chrome on windows",
+			userAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			expectedBrowser: BrowserChrome,
+			expectedDevice:  DeviceDesktop,
+		},
+		{
+			name:            "edge on windows",
+			userAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51",
+			expectedBrowser: BrowserEdge,
+			expectedDevice:  DeviceDesktop,
+		},
+		{
+			name:            "firefox on linux",
+			userAgent:       "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			expectedBrowser: BrowserFirefox,
+			expectedDevice:  DeviceDesktop,
+		},
+		{
+			name:            "safari on iphone",
+			userAgent:       "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			expectedBrowser: BrowserSafari,
+			expectedDevice:  DeviceMobile,
+		},
+		{
+			name:            "samsung internet on android phone",
+			userAgent:       "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Mobile Safari/537.36",
+			expectedBrowser: BrowserSamsung,
+			expectedDevice:  DeviceMobile,
+		},
+		{
+			name:            "chrome on android tablet",
+			userAgent:       "Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			expectedBrowser: BrowserChrome,
+			expectedDevice:  DeviceTablet,
+		},
+		{
+			name:            "safari on ipad",
+			userAgent:       "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			expectedBrowser: BrowserSafari,
+			expectedDevice:  DeviceTablet,
+		},
+		{
+			name:            "crawler",
+			userAgent:       "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expectedBrowser: BrowserOther,
+			expectedDevice:  DeviceBot,
+		},
+		{
+			name:            "http client",
+			userAgent:       "curl/8.7.1",
+			expectedBrowser: BrowserOther,
+			expectedDevice:  DeviceBot,
+		},
+		{
+			name:            "empty user agent",
+			userAgent:       "",
+			expectedBrowser: BrowserOther,
+			expectedDevice:  DeviceUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			browser, device := ParseUserAgent(tt.userAgent)
+			assert.Equal(t, tt.expectedBrowser, browser, "browser does not match")
+			assert.Equal(t, tt.expectedDevice, device, "device does not match")
+		})
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	clickedAt := time.Now()
+
+	t.Run("full request data", func(t *testing.T) {
+		event := NewEvent("abc123", "https://News.Example.com:8443/article?id=1", "curl/8.7.1", "203.0.113.42", clickedAt)
+
+		assert.Equal(t, "abc123", event.Code)
+		assert.Equal(t, clickedAt, event.ClickedAt)
+		if assert.NotNil(t, event.ReferrerHost) {
+			assert.Equal(t, "news.example.com", *event.ReferrerHost)
+		}
+		if assert.NotNil(t, event.AnonymizedIP) {
+			assert.Equal(t, "203.0.113.0", *event.AnonymizedIP)
+		}
+		assert.Equal(t, DeviceBot, event.Device)
+	})
+
+	t.Run("missing request data", func(t *testing.T) {
+		event := NewEvent("abc123", "", "", "", clickedAt)
+
+		assert.Nil(t, event.Referrer)
+		assert.Nil(t, event.ReferrerHost)
+		assert.Nil(t, event.UserAgent)
+		assert.Nil(t, event.AnonymizedIP)
+		assert.Equal(t, BrowserOther, event.Browser)
+		assert.Equal(t, DeviceUnknown, event.Device)
+	})
+
+	t.Run("long user agent is truncated", func(t *testing.T) {
+		event := NewEvent("abc123", "", strings.Repeat("a", maxHeaderLength+10), "", clickedAt)
+
+		if assert.NotNil(t, event.UserAgent) {
+			assert.Len(t, *event.UserAgent, maxHeaderLength)
+		}
+	})
+}
+
+func TestVisitorHash(t *testing.T) {
+	hash := VisitorHash("salt", "203.0.113.42", "curl/8.7.1")
+
+	assert.Len(t, hash, 64)
+	assert.NotContains(t, hash, "203.0.113.42")
+	assert.Equal(t, hash, VisitorHash("salt", "203.0.113.42", "curl/8.7.1"), "hash should be stable for the same salt")
+	assert.NotEqual(t, hash, VisitorHash("other-salt", "203.0.113.42", "curl/8.7.1"), "hash should change with the salt")
+	assert.NotEqual(t, hash, VisitorHash("salt", "203.0.113.43", "curl/8.7.1"))
+	assert.NotEqual(t, hash, VisitorHash("salt", "203.0.113.42", "curl/8.8.0"))
+}
@@ -0,0 +1,229 @@
+package clicks
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const name = "github.com/rousage/shortener/internal/clicks"
+
+var (
+	tracer = otel.Tracer(name)
+	meter  = otel.Meter(name)
+)
+
+const (
+	defaultQueueSize     = 10_000
+	defaultBatchSize     = 500
+	defaultFlushInterval = time.Second
+	// flushTimeout bounds a single batch write, so a slow database cannot stall the pipeline forever
+	flushTimeout = 10 * time.Second
+)
+
+// Store persists batches of click events
+type Store interface {
+	CreateClicks(ctx context.Context, arg []repository.CreateClicksParams) (int64, error)
+}
+
+type PipelineConfig struct {
+	// QueueSize is the number of events buffered in memory, events are dropped when the queue is full
+	QueueSize int
+	// BatchSize is the maximum number of events written at once
+	BatchSize int
+	// FlushInterval is the maximum time an event waits in a partial batch
+	FlushInterval time.Duration
+}
+
+// Pipeline ingests click events asynchronously: events are queued in memory without blocking
+// the caller and written to the store in batches. Under pressure (a full queue or a failed write)
+// events are dropped rather than slowing down link resolution
+type Pipeline struct {
+	logger *slog.Logger
+	store  Store
+	cfg    PipelineConfig
+
+	events chan Event
+	done   chan struct{}
+	// mu guards closed, so events are never sent on the closed channel
+	mu     sync.RWMutex
+	closed bool
+
+	// OTel metrics
+	droppedCounter metric.Int64Counter
+	flushedCounter metric.Int64Counter
+	flushDuration  metric.Float64Histogram
+}
+
+func NewPipeline(logger *slog.Logger, store Store, cfg PipelineConfig) *Pipeline {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+
+	p := &Pipeline{
+		logger: logger,
+		store:  store,
+		cfg:    cfg,
+		events: make(chan Event, cfg.QueueSize),
+		done:   make(chan struct{}),
+	}
+
+	var err error
+	p.droppedCounter, err = meter.Int64Counter(
+		"clicks.dropped",
+		metric.WithDescription("Number of click events dropped because the queue was full or the batch write failed"),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		logger.Warn("failed to create dropped clicks counter", "error", err)
+	}
+	p.flushedCounter, err = meter.Int64Counter(
+		"clicks.flushed",
+		metric.WithDescription("Number of click events written to the database"),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		logger.Warn("failed to create flushed clicks counter", "error", err)
+	}
+	p.flushDuration, err = meter.Float64Histogram(
+		"clicks.flush.duration",
+		metric.WithDescription("Duration of click event batch writes"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		logger.Warn("failed to create clicks flush duration histogram", "error", err)
+	}
+	_, err = meter.Int64ObservableGauge(
+		"clicks.queue.depth",
+		metric.WithDescription("Number of click events waiting in the queue"),
+		metric.WithUnit("{event}"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(len(p.events)))
+			return nil
+		}),
+	)
+	if err != nil {
+		logger.Warn("failed to create clicks queue depth gauge", "error", err)
+	}
+
+	return p
+}
+
+// Start runs the batching loop in the background until Shutdown is called
+func (p *Pipeline) Start() {
+	go p.run()
+}
+
+// Enqueue adds the event to the queue without blocking.
+// It returns false if the event was dropped
+func (p *Pipeline) Enqueue(ctx context.Context, event Event) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.droppedCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", "closed")))
+		return false
+	}
+
+	select {
+	case p.events <- event:
+		return true
+	default:
+		p.droppedCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", "queue_full")))
+		return false
+	}
+}
+
+// Shutdown stops accepting events and waits until the queued events are written
+// or the context is done
+func (p *Pipeline) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pipeline) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]repository.CreateClicksParams, 0, p.cfg.BatchSize)
+	for {
+		select {
+		case event, ok := <-p.events:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+
+			batch = append(batch, repository.CreateClicksParams{
+				UrlID:        event.Code,
+				ClickedAt:    event.ClickedAt,
+				Referrer:     event.Referrer,
+				ReferrerHost: event.ReferrerHost,
+				UserAgent:    event.UserAgent,
+				Browser:      event.Browser,
+				Device:       event.Device,
+				AnonymizedIp: event.AnonymizedIP,
+			})
+			if len(batch) >= p.cfg.BatchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			p.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush writes the batch to the store, a failed batch is dropped
+func (p *Pipeline) flush(batch []repository.CreateClicksParams) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	ctx, span := tracer.Start(ctx, "clicks.Flush")
+	defer span.End()
+	span.SetAttributes(attribute.Int("batchSize", len(batch)))
+
+	start := time.Now()
+	written, err := p.store.CreateClicks(ctx, batch)
+	p.flushDuration.Record(ctx, time.Since(start).Seconds())
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to write clicks")
+		span.RecordError(err)
+		p.logger.ErrorContext(ctx, "failed to write clicks", "error", err, slog.Int("batchSize", len(batch)))
+		p.droppedCounter.Add(ctx, int64(len(batch)), metric.WithAttributes(attribute.String("reason", "write_failed")))
+		return
+	}
+
+	p.flushedCounter.Add(ctx, written)
+}
@@ -0,0 +1,148 @@
+package clicks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStore struct {
+	mu      sync.Mutex
+	batches [][]repository.CreateClicksParams
+	err     error
+	// block makes CreateClicks wait until it is closed
+	block chan struct{}
+}
+
+func (m *mockStore) CreateClicks(ctx context.Context, arg []repository.CreateClicksParams) (int64, error) {
+	if m.block != nil {
+		<-m.block
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return 0, m.err
+	}
+	m.batches = append(m.batches, append([]repository.CreateClicksParams(nil), arg...))
+
+	return int64(len(arg)), nil
+}
+
+func (m *mockStore) written() (batches, events int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, batch := range m.batches {
+		events += len(batch)
+	}
+
+	return len(m.batches), events
+}
+
+func newTestPipeline(store Store, cfg PipelineConfig) *Pipeline {
+	p := NewPipeline(slog.New(slog.NewTextHandler(io.Discard, nil)), store, cfg)
+	p.Start()
+
+	return p
+}
+
+func TestPipeline_BatchSize(t *testing.T) {
+	store := &mockStore{}
+	p := newTestPipeline(store, PipelineConfig{BatchSize: 3, FlushInterval: time.Hour})
+
+	for range 7 {
+		assert.True(t, p.Enqueue(context.Background(), NewEvent("abc123", "", "", "", time.Now())))
+	}
+
+	// Full batches are written without waiting for the flush interval
+	assert.Eventually(t, func() bool {
+		batches, _ := store.written()
+		return batches == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// The partial batch is written on shutdown
+	require.NoError(t, p.Shutdown(context.Background()))
+	batches, events := store.written()
+	assert.Equal(t, 3, batches, "batches do not match")
+	assert.Equal(t, 7, events, "events do not match")
+}
+
+func TestPipeline_FlushInterval(t *testing.T) {
+	store := &mockStore{}
+	p := newTestPipeline(store, PipelineConfig{BatchSize: 100, FlushInterval: 20 * time.Millisecond})
+
+	assert.True(t, p.Enqueue(context.Background(), NewEvent("abc123", "", "", "", time.Now())))
+
+	assert.Eventually(t, func() bool {
+		_, events := store.written()
+		return events == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, p.Shutdown(context.Background()))
+}
+
+func TestPipeline_DropsWhenFull(t *testing.T) {
+	store := &mockStore{block: make(chan struct{})}
+	p := newTestPipeline(store, PipelineConfig{QueueSize: 2, BatchSize: 1, FlushInterval: time.Hour})
+
+	// The first event is taken from the queue and blocks in the store,
+	// the next two fill the queue and the rest are dropped
+	assert.True(t, p.Enqueue(context.Background(), NewEvent("abc123", "", "", "", time.Now())))
+	assert.Eventually(t, func() bool { return len(p.events) == 0 }, time.Second, time.Millisecond)
+
+	accepted := 0
+	for range 5 {
+		if p.Enqueue(context.Background(), NewEvent("abc123", "", "", "", time.Now())) {
+			accepted++
+		}
+	}
+	assert.Equal(t, 2, accepted, "only events that fit into the queue should be accepted")
+
+	close(store.block)
+	require.NoError(t, p.Shutdown(context.Background()))
+	_, events := store.written()
+	assert.Equal(t, 3, events, "events do not match")
+}
+
+func TestPipeline_Shutdown(t *testing.T) {
+	t.Run("drops events after shutdown", func(t *testing.T) {
+		store := &mockStore{}
+		p := newTestPipeline(store, PipelineConfig{})
+
+		require.NoError(t, p.Shutdown(context.Background()))
+		require.NoError(t, p.Shutdown(context.Background()), "shutdown should be idempotent")
+		assert.False(t, p.Enqueue(context.Background(), NewEvent("abc123", "", "", "", time.Now())))
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		store := &mockStore{block: make(chan struct{})}
+		defer close(store.block)
+		p := newTestPipeline(store, PipelineConfig{BatchSize: 1})
+
+		assert.True(t, p.Enqueue(context.Background(), NewEvent("abc123", "", "", "", time.Now())))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+	})
+
+	t.Run("failed batches are dropped", func(t *testing.T) {
+		store := &mockStore{err: errors.New("database is down")}
+		p := newTestPipeline(store, PipelineConfig{})
+
+		assert.True(t, p.Enqueue(context.Background(), NewEvent("abc123", "", "", "", time.Now())))
+		require.NoError(t, p.Shutdown(context.Background()))
+		batches, _ := store.written()
+		assert.Equal(t, 0, batches)
+	})
+}
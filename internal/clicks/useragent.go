@@ -0,0 +1,80 @@
+package clicks
+
+import "strings"
+
+// Browser names
+const (
+	BrowserChrome  = "Chrome"
+	BrowserEdge    = "Edge"
+	BrowserFirefox = "Firefox"
+	BrowserOpera   = "Opera"
+	BrowserSafari  = "Safari"
+	BrowserSamsung = "Samsung Internet"
+	BrowserIE      = "Internet Explorer"
+	BrowserOther   = "Other"
+)
+
+// botMarkers are lowercase user agent fragments of crawlers, link previews and HTTP clients
+var botMarkers = []string{
+	"bot", "crawler", "spider", "slurp", "preview", "facebookexternalhit", "whatsapp",
+	"curl/", "wget/", "python-requests", "go-http-client", "okhttp", "httpclient", "headless",
+}
+
+// browserMarkers are checked in order, as most browsers also include the tokens of the ones they are based on
+var browserMarkers = []struct {
+	marker  string
+	browser string
+}{
+	{marker: "edg/", browser: BrowserEdge},
+	{marker: "edge/", browser: BrowserEdge},
+	{marker: "opr/", browser: BrowserOpera},
+	{marker: "opera", browser: BrowserOpera},
+	{marker: "samsungbrowser/", browser: BrowserSamsung},
+	{marker: "firefox/", browser: BrowserFirefox},
+	{marker: "fxios/", browser: BrowserFirefox},
+	{marker: "chrome/", browser: BrowserChrome},
+	{marker: "crios/", browser: BrowserChrome},
+	{marker: "safari/", browser: BrowserSafari},
+	{marker: "msie ", browser: BrowserIE},
+	{marker: "trident/", browser: BrowserIE},
+}
+
+// ParseUserAgent returns the browser name and the coarse device class of the user agent.
+// It only looks for well-known tokens, anything else is reported as other/unknown
+func ParseUserAgent(userAgent string) (browser, device string) {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return BrowserOther, DeviceUnknown
+	}
+
+	browser = BrowserOther
+	for _, m := range browserMarkers {
+		if strings.Contains(ua, m.marker) {
+			browser = m.browser
+			break
+		}
+	}
+
+	return browser, parseDevice(ua)
+}
+
+func parseDevice(ua string) string {
+	for _, marker := range botMarkers {
+		if strings.Contains(ua, marker) {
+			return DeviceBot
+		}
+	}
+
+	switch {
+	case strings.Contains(ua, "ipad"), strings.Contains(ua, "tablet"),
+		strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return DeviceTablet
+	case strings.Contains(ua, "mobi"), strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"):
+		return DeviceMobile
+	case strings.Contains(ua, "windows"), strings.Contains(ua, "macintosh"), strings.Contains(ua, "x11"),
+		strings.Contains(ua, "linux"), strings.Contains(ua, "cros"):
+		return DeviceDesktop
+	}
+
+	return DeviceUnknown
+}
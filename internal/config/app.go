@@ -2,14 +2,49 @@ package config
 
 import (
 	"errors"
+	"net/http"
+	"net/url"
 	"slices"
+	"strings"
+	"time"
 
 	"log/slog"
+
+	"github.com/rousage/shortener/internal/generator"
 )
 
+const (
+	defaultRedirectStatus     = http.StatusFound
+	defaultTrashRetentionDays = 30
+	defaultReportThreshold    = 5
+)
+
+// defaultShortenerHosts are the well-known third-party shorteners whose links are followed to their destination
+var defaultShortenerHosts = []string{"bit.ly", "tinyurl.com", "t.co", "goo.gl", "ow.ly", "is.gd", "buff.ly", "rebrand.ly", "cutt.ly"}
+
+// defaultTrackingParams are the query params that can be stripped from destinations, they only track where visitors come from
+var defaultTrackingParams = []string{"utm_*", "fbclid", "gclid"}
+
 type App struct {
 	Env            Environment
 	ShortUrlLength int
+	// ShortUrlGenerator is the strategy short codes are generated with, one of generator.Strategies
+	ShortUrlGenerator string
+	// ShortUrlAlphabet are the characters of generated short codes, empty for the default of the strategy
+	ShortUrlAlphabet string
+	// ShortUrlSalt makes the codes of the sequence and hashids strategies unique to the deployment
+	ShortUrlSalt string
+	// BaseURL is the public origin short links are served from, e.g. https://sho.rt
+	BaseURL        string
+	RedirectStatus int
+	// TrashRetention is how long deleted URLs stay in the trash before they are purged
+	TrashRetention time.Duration
+	// ReportThreshold is the number of distinct signed-in reporters after which a link is quarantined until it is reviewed
+	ReportThreshold int
+	// ShortenerHosts are the hosts of third-party shorteners, links to them are followed and their destination is stored instead
+	ShortenerHosts []string
+	// TrackingParams are the query params stripped from destinations if the user asks for it, a trailing * matches any suffix
+	TrackingParams []string
 }
 
 type Environment = string
@@ -20,7 +55,10 @@ const (
 	EnvProduction  Environment = "production"
 )
 
-var allowedEnvs = []Environment{EnvLocal, EnvDevelopment, EnvProduction}
+var (
+	allowedEnvs             = []Environment{EnvLocal, EnvDevelopment, EnvProduction}
+	allowedRedirectStatuses = []int{http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect}
+)
 
 func loadAppConfig(logger *slog.Logger) (App, error) {
 	env, err := getEnv("APP_ENV")
@@ -39,8 +77,79 @@ func loadAppConfig(logger *slog.Logger) (App, error) {
 		shortUrlLength = 0
 	}
 
+	shortUrlGenerator, err := getEnv("SHORT_URL_GENERATOR")
+	if err != nil {
+		logger.Warn("SHORT_URL_GENERATOR environment variable is not set, setting to default", slog.String("defaultShortUrlGenerator", generator.StrategyNanoid))
+		shortUrlGenerator = generator.StrategyNanoid
+	}
+	if !slices.Contains(generator.Strategies, shortUrlGenerator) {
+		return App{}, errors.New("invalid SHORT_URL_GENERATOR, expected one of nanoid, sequence, hashids, pronounceable")
+	}
+
+	shortUrlSalt := getOptionalEnv("SHORT_URL_SALT")
+	if shortUrlSalt == "" && (shortUrlGenerator == generator.StrategySequence || shortUrlGenerator == generator.StrategyHashids) {
+		logger.Warn("SHORT_URL_SALT environment variable is not set, short codes are the same as in any other deployment without a salt")
+	}
+
+	baseURL, err := getEnv("BASE_URL")
+	if err != nil {
+		return App{}, err
+	}
+	parsedBaseURL, err := url.Parse(baseURL)
+	if err != nil || parsedBaseURL.Host == "" || (parsedBaseURL.Scheme != "http" && parsedBaseURL.Scheme != "https") {
+		return App{}, errors.New("invalid BASE_URL, expected an absolute http(s) URL")
+	}
+
+	redirectStatus, err := getIntEnv("REDIRECT_STATUS")
+	if err != nil {
+		logger.Warn("REDIRECT_STATUS environment variable is not set, setting to default", slog.Int("defaultRedirectStatus", defaultRedirectStatus))
+		redirectStatus = defaultRedirectStatus
+	}
+	if !slices.Contains(allowedRedirectStatuses, redirectStatus) {
+		return App{}, errors.New("invalid REDIRECT_STATUS, expected one of 301, 302, 307, 308")
+	}
+
+	trashRetentionDays, err := getIntEnv("TRASH_RETENTION_DAYS")
+	if err != nil {
+		logger.Warn("TRASH_RETENTION_DAYS environment variable is not set, setting to default", slog.Int("defaultTrashRetentionDays", defaultTrashRetentionDays))
+		trashRetentionDays = defaultTrashRetentionDays
+	}
+	if trashRetentionDays < 1 {
+		return App{}, errors.New("invalid TRASH_RETENTION_DAYS, expected a positive number of days")
+	}
+
+	reportThreshold, err := getIntEnv("REPORT_THRESHOLD")
+	if err != nil {
+		logger.Warn("REPORT_THRESHOLD environment variable is not set, setting to default", slog.Int("defaultReportThreshold", defaultReportThreshold))
+		reportThreshold = defaultReportThreshold
+	}
+	if reportThreshold < 1 {
+		return App{}, errors.New("invalid REPORT_THRESHOLD, expected a positive number of reports")
+	}
+
+	shortenerHosts, err := getListEnv("SHORTENER_HOSTS")
+	if err != nil {
+		logger.Warn("SHORTENER_HOSTS environment variable is not set, setting to default", slog.Any("defaultShortenerHosts", defaultShortenerHosts))
+		shortenerHosts = defaultShortenerHosts
+	}
+
+	trackingParams, err := getListEnv("TRACKING_PARAMS")
+	if err != nil {
+		logger.Warn("TRACKING_PARAMS environment variable is not set, setting to default", slog.Any("defaultTrackingParams", defaultTrackingParams))
+		trackingParams = defaultTrackingParams
+	}
+
 	return App{
-		Env:            Environment(env),
-		ShortUrlLength: shortUrlLength,
+		Env:               Environment(env),
+		ShortUrlLength:    shortUrlLength,
+		ShortUrlGenerator: shortUrlGenerator,
+		ShortUrlAlphabet:  getOptionalEnv("SHORT_URL_ALPHABET"),
+		ShortUrlSalt:      shortUrlSalt,
+		BaseURL:           strings.TrimSuffix(baseURL, "/"),
+		RedirectStatus:    redirectStatus,
+		TrashRetention:    time.Duration(trashRetentionDays) * 24 * time.Hour,
+		ReportThreshold:   reportThreshold,
+		ShortenerHosts:    shortenerHosts,
+		TrackingParams:    trackingParams,
 	}, nil
 }
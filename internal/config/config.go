@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -99,3 +100,20 @@ func getBoolEnv(key string) (bool, error) {
 
 	return boolVal, nil
 }
+
+// getListEnv returns the comma-separated values of the env var, without the blank ones
+func getListEnv(key string) ([]string, error) {
+	value, err := getEnv(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for v := range strings.SplitSeq(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values, nil
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net"
 	"strings"
 
 	"log/slog"
@@ -10,14 +12,32 @@ import (
 const (
 	defaultRPS   = 10
 	defaultBurst = 20
+	// clientRateLimitFactor scales the default rate limit for the per IP limit, which is shared by everyone behind the IP
+	clientRateLimitFactor = 10
 )
 
+// RateLimit allows RPS requests per second on average with bursts of up to Burst requests
+type RateLimit struct {
+	RPS   int
+	Burst int
+}
+
 type Server struct {
 	Port         int
 	AllowOrigins []string
+	// TrustedProxies are the networks of the reverse proxies whose X-Forwarded-For header is trusted,
+	// the client IP is the address of the connection if there are none
+	TrustedProxies []*net.IPNet
 
-	LimiterRPS   int
-	LimiterBurst int
+	// Rate limits per route group, every user, API key or anonymous client IP has its own limit in each group.
+	// The groups without a specific configuration use the default rate limit
+	RateLimit        RateLimit
+	CreateRateLimit  RateLimit
+	ResolveRateLimit RateLimit
+	AdminRateLimit   RateLimit
+	ReportRateLimit  RateLimit
+	// ClientRateLimit limits every client IP before the authentication, whoever is authenticated
+	ClientRateLimit RateLimit
 }
 
 func loadServerConfig(logger *slog.Logger) (Server, error) {
@@ -35,6 +55,16 @@ func loadServerConfig(logger *slog.Logger) (Server, error) {
 		return Server{}, errors.New("empty CORS origins configuration")
 	}
 
+	var trustedProxies []*net.IPNet
+	proxies, _ := getListEnv("TRUSTED_PROXIES")
+	for _, proxy := range proxies {
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return Server{}, fmt.Errorf("invalid trusted proxy network %q: %w", proxy, err)
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+
 	rps, err := getIntEnv("LIMITER_RPS")
 	if err != nil {
 		logger.Warn("LIMITER_RPS environment variable is not set, setting to default", slog.Int("defaultRPS", defaultRPS))
@@ -45,11 +75,31 @@ func loadServerConfig(logger *slog.Logger) (Server, error) {
 		logger.Warn("LIMITER_BURST environment variable is not set, setting to default", slog.Int("defaultBurst", defaultBurst))
 		burst = defaultBurst
 	}
+	rateLimit := RateLimit{RPS: rps, Burst: burst}
 
 	return Server{
-		Port:         port,
-		AllowOrigins: origins,
-		LimiterRPS:   rps,
-		LimiterBurst: burst,
+		Port:             port,
+		AllowOrigins:     origins,
+		TrustedProxies:   trustedProxies,
+		RateLimit:        rateLimit,
+		CreateRateLimit:  loadRateLimit("LIMITER_CREATE", rateLimit),
+		ResolveRateLimit: loadRateLimit("LIMITER_RESOLVE", rateLimit),
+		AdminRateLimit:   loadRateLimit("LIMITER_ADMIN", rateLimit),
+		ReportRateLimit:  loadRateLimit("LIMITER_REPORT", rateLimit),
+		ClientRateLimit:  loadRateLimit("LIMITER_CLIENT", RateLimit{RPS: rps * clientRateLimitFactor, Burst: burst * clientRateLimitFactor}),
 	}, nil
 }
+
+// loadRateLimit reads the <prefix>_RPS and <prefix>_BURST environment variables,
+// each falls back to the default rate limit if it is not set
+func loadRateLimit(prefix string, defaultLimit RateLimit) RateLimit {
+	limit := defaultLimit
+	if rps, err := getIntEnv(prefix + "_RPS"); err == nil {
+		limit.RPS = rps
+	}
+	if burst, err := getIntEnv(prefix + "_BURST"); err == nil {
+		limit.Burst = burst
+	}
+
+	return limit
+}
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+COMMIT;
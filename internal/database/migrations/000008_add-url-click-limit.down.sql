@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE urls
+DROP CONSTRAINT IF EXISTS remaining_clicks_not_negative;
+
+ALTER TABLE urls
+DROP COLUMN IF EXISTS max_clicks,
+DROP COLUMN IF EXISTS remaining_clicks;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS max_clicks INTEGER,
+ADD COLUMN IF NOT EXISTS remaining_clicks INTEGER;
+
+ALTER TABLE urls
+ADD CONSTRAINT remaining_clicks_not_negative CHECK (remaining_clicks >= 0);
+
+COMMIT;
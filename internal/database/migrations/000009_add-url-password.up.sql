@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS password_hash TEXT;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE urls
+DROP COLUMN IF EXISTS updated_at;
+
+COMMIT;
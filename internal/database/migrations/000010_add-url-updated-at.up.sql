@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+
+UPDATE urls
+SET
+  updated_at = created_at
+WHERE
+  updated_at IS NULL;
+
+ALTER TABLE urls
+ALTER COLUMN updated_at SET NOT NULL,
+ALTER COLUMN updated_at SET DEFAULT NOW();
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_urls_deleted_at;
+
+ALTER TABLE urls
+DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_urls_deleted_at ON urls (deleted_at)
+WHERE
+  deleted_at IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS clicks (
+  id BIGSERIAL PRIMARY KEY,
+  url_id VARCHAR(16) NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+  clicked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  referrer TEXT,
+  referrer_host TEXT,
+  user_agent TEXT,
+  browser TEXT NOT NULL,
+  device TEXT NOT NULL,
+  anonymized_ip TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_url_id_clicked_at ON clicks (url_id, clicked_at);
+
+COMMIT;
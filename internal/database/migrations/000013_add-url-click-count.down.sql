@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE urls
+DROP COLUMN IF EXISTS last_clicked_at,
+DROP COLUMN IF EXISTS click_count;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS click_count BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS last_clicked_at TIMESTAMPTZ;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TABLE IF EXISTS webhook_dead_letters;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS webhook_outbox;
+
+DROP TABLE IF EXISTS webhooks;
+
+COMMIT;
@@ -0,0 +1,55 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS webhooks (
+  id BIGSERIAL PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  url TEXT NOT NULL,
+  events TEXT[] NOT NULL,
+  secret TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks (user_id);
+
+-- Events are written to the outbox in the same transaction as the change of the URL
+-- and fanned out to the deliveries of the subscribed webhooks afterwards
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+  id BIGSERIAL PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id BIGSERIAL PRIMARY KEY,
+  webhook_id BIGINT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+  event_id BIGINT NOT NULL,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  event_created_at TIMESTAMPTZ NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_error TEXT,
+  last_status_code INT
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+  id BIGSERIAL PRIMARY KEY,
+  webhook_id BIGINT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+  event_id BIGINT NOT NULL,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  event_created_at TIMESTAMPTZ NOT NULL,
+  attempts INT NOT NULL,
+  last_error TEXT,
+  last_status_code INT,
+  failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_webhook_id_failed_at ON webhook_dead_letters (webhook_id, failed_at);
+
+COMMIT;
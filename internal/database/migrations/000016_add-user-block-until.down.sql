@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_user_blocks_blocked_until;
+
+ALTER TABLE user_blocks
+DROP COLUMN IF EXISTS blocked_until;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE user_blocks
+ADD COLUMN IF NOT EXISTS blocked_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked_until ON user_blocks (blocked_until)
+WHERE
+  blocked_until IS NOT NULL
+  AND unblocked_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_user_blocks_user_id_blocked_at;
+
+DROP INDEX IF EXISTS user_blocks_active_user_id_idx;
+
+-- Keep only the latest block of every user
+DELETE FROM user_blocks
+WHERE
+  EXISTS (
+    SELECT
+      1
+    FROM
+      user_blocks AS newer
+    WHERE
+      newer.user_id = user_blocks.user_id
+      AND newer.id > user_blocks.id
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_blocks_user_id_idx ON user_blocks (user_id);
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Every block is kept as its own row, only one block per user can be active
+DROP INDEX IF EXISTS user_blocks_user_id_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_blocks_active_user_id_idx ON user_blocks (user_id)
+WHERE
+  unblocked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_user_blocks_user_id_blocked_at ON user_blocks (user_id, blocked_at DESC);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS admin_audit_log;
+
+DROP FUNCTION IF EXISTS admin_audit_log_append_only;
+
+COMMIT;
@@ -0,0 +1,36 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  actor_id TEXT NOT NULL,
+  action TEXT NOT NULL,
+  target_type TEXT NOT NULL,
+  target_id TEXT NOT NULL,
+  request_id TEXT,
+  ip TEXT,
+  before JSONB,
+  after JSONB,
+  outcome TEXT NOT NULL,
+  error TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_created_at ON admin_audit_log (created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_actor_id ON admin_audit_log (actor_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_target ON admin_audit_log (target_type, target_id, created_at DESC);
+
+-- The audit log is append-only, entries can neither be changed nor removed
+CREATE OR REPLACE FUNCTION admin_audit_log_append_only () RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'admin_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER admin_audit_log_append_only BEFORE
+UPDATE
+OR DELETE ON admin_audit_log FOR EACH ROW
+EXECUTE FUNCTION admin_audit_log_append_only ();
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS disabled_urls;
+
+DROP TABLE IF EXISTS abuse_reports;
+
+COMMIT;
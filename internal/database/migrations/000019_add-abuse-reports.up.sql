@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS abuse_reports (
+  id BIGSERIAL PRIMARY KEY,
+  url_id VARCHAR(16) NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+  category TEXT NOT NULL CHECK (category IN ('phishing', 'malware', 'spam', 'illegal')),
+  note TEXT,
+  reporter_id TEXT,
+  reporter_ip TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  resolved_by TEXT,
+  resolved_at TIMESTAMPTZ,
+  resolution TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_abuse_reports_open_url_id ON abuse_reports (url_id)
+WHERE
+  resolved_at IS NULL;
+
+-- Links taken down by moderation: quarantined automatically after enough reports
+-- until an admin reviews them, or disabled by an admin
+CREATE TABLE IF NOT EXISTS disabled_urls (
+  url_id VARCHAR(16) PRIMARY KEY REFERENCES urls (id) ON DELETE CASCADE,
+  reason TEXT NOT NULL CHECK (reason IN ('quarantined', 'abuse')),
+  disabled_by TEXT NOT NULL,
+  disabled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+-- Host patterns that destinations of short links are checked against.
+-- Allow rules take precedence over block rules
+CREATE TABLE IF NOT EXISTS domain_rules (
+  id BIGSERIAL PRIMARY KEY,
+  list TEXT NOT NULL CHECK (list IN ('block', 'allow')),
+  kind TEXT NOT NULL CHECK (kind IN ('exact', 'suffix', 'regex')),
+  pattern TEXT NOT NULL,
+  note TEXT,
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (list, kind, pattern)
+);
+
+COMMIT;
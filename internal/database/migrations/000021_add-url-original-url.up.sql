@@ -0,0 +1,16 @@
+BEGIN;
+
+-- Destination as it was submitted, long_url holds its canonical form that links redirect to
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS original_url TEXT;
+
+UPDATE urls
+SET
+  original_url = long_url
+WHERE
+  original_url IS NULL;
+
+ALTER TABLE urls
+ALTER COLUMN original_url SET NOT NULL;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS idempotency_keys;
+
+DROP TABLE IF EXISTS user_settings;
+
+DROP INDEX IF EXISTS idx_urls_user_id_long_url_hash;
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+-- Finds the existing links of a user for a destination when deduplicating,
+-- the hash keeps the index small however long the URLs are
+CREATE INDEX IF NOT EXISTS idx_urls_user_id_long_url_hash ON urls (user_id, md5(long_url))
+WHERE
+  NOT is_custom
+  AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS user_settings (
+  user_id TEXT PRIMARY KEY,
+  dedupe_urls BOOLEAN NOT NULL DEFAULT FALSE,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Idempotency-Key headers of the link creation requests, scoped to the user or the client IP of anonymous requests.
+-- Keys are removed with their link or after a day
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  scope TEXT NOT NULL,
+  key TEXT NOT NULL,
+  request_hash TEXT NOT NULL,
+  url_id VARCHAR(16) NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys (created_at);
+
+COMMIT;
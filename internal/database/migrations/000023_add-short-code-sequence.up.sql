@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Counter behind the sequence and hashids short code generators, each value is turned into a distinct code
+CREATE SEQUENCE IF NOT EXISTS short_code_seq AS BIGINT;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE idempotency_keys
+DROP COLUMN IF EXISTS status_code;
+
+COMMIT;
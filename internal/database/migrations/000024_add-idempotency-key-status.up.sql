@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Status of the response to the first request, replays respond with it,
+-- e.g. 200 when an existing link has been reused instead of created
+ALTER TABLE idempotency_keys
+ADD COLUMN IF NOT EXISTS status_code INT NOT NULL DEFAULT 201;
+
+COMMIT;
@@ -0,0 +1,197 @@
+// Package domainrules matches the hosts of link destinations against the block and allow rules managed by admins
+package domainrules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	ListBlock = "block"
+	ListAllow = "allow"
+)
+
+const (
+	// KindExact matches the host only
+	KindExact = "exact"
+	// KindSuffix matches the domain and all its subdomains
+	KindSuffix = "suffix"
+	// KindRegex matches the hosts the whole of which match the expression
+	KindRegex = "regex"
+)
+
+var errInvalidHost = errors.New("must be a host name, without a scheme, port or path")
+
+type Rule struct {
+	ID      int64
+	List    string
+	Kind    string
+	Pattern string
+}
+
+// NormalizeHost lowercases the host and drops the trailing dot of fully qualified names.
+// Internationalized hosts are converted to punycode, like the destinations of links
+func NormalizeHost(host string) string {
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		host = ascii
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// NormalizePattern validates the pattern of a rule of the given kind and returns it in the form it is matched in.
+// Suffix patterns can be written as "*.example.com" or ".example.com" too.
+// The errors describe what the pattern must be, e.g. "must not be empty"
+func NormalizePattern(kind, pattern string) (string, error) {
+	pattern = strings.TrimSpace(pattern)
+
+	switch kind {
+	case KindExact, KindSuffix:
+		if kind == KindSuffix {
+			pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "*"), ".")
+		}
+		pattern = NormalizeHost(pattern)
+		if pattern == "" || strings.ContainsAny(pattern, "/:@?#*[] \t") {
+			return "", errInvalidHost
+		}
+		return pattern, nil
+	case KindRegex:
+		if _, err := compileRegex(pattern); err != nil {
+			return "", err
+		}
+		return pattern, nil
+	default:
+		return "", fmt.Errorf("unknown rule kind %q", kind)
+	}
+}
+
+// compileRegex anchors the expression, so it has to match the whole host
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, errors.New("must not be empty")
+	}
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("must be a valid regular expression: %w", err)
+	}
+	return re, nil
+}
+
+// Matcher matches hosts against a list of rules
+type Matcher struct {
+	exact    map[string]struct{}
+	suffixes []string
+	regexps  []*regexp.Regexp
+}
+
+// NewMatcher compiles the rules, their list is not taken into account
+func NewMatcher(rules []Rule) (*Matcher, error) {
+	m := &Matcher{exact: make(map[string]struct{})}
+	for _, rule := range rules {
+		pattern, err := NormalizePattern(rule.Kind, rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", rule.ID, err)
+		}
+
+		switch rule.Kind {
+		case KindExact:
+			m.exact[pattern] = struct{}{}
+		case KindSuffix:
+			m.suffixes = append(m.suffixes, pattern)
+		case KindRegex:
+			re, err := compileRegex(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", rule.ID, err)
+			}
+			m.regexps = append(m.regexps, re)
+		}
+	}
+
+	return m, nil
+}
+
+// Match reports whether the host matches any of the rules
+func (m *Matcher) Match(host string) bool {
+	host = NormalizeHost(host)
+
+	if _, ok := m.exact[host]; ok {
+		return true
+	}
+	for _, suffix := range m.suffixes {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	for _, re := range m.regexps {
+		if re.MatchString(host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Set holds the rules in memory, so destinations are checked without a database query.
+// It is safe for concurrent use
+type Set struct {
+	mu    sync.RWMutex
+	block *Matcher
+	allow *Matcher
+}
+
+func NewSet() *Set {
+	empty := &Matcher{exact: make(map[string]struct{})}
+	return &Set{block: empty, allow: empty}
+}
+
+// Replace swaps all the rules of the set. The rules are kept as they were if any of them is invalid
+func (s *Set) Replace(rules []Rule) error {
+	var block, allow []Rule
+	for _, rule := range rules {
+		switch rule.List {
+		case ListBlock:
+			block = append(block, rule)
+		case ListAllow:
+			allow = append(allow, rule)
+		default:
+			return fmt.Errorf("rule %d: unknown list %q", rule.ID, rule.List)
+		}
+	}
+
+	blockMatcher, err := NewMatcher(block)
+	if err != nil {
+		return err
+	}
+	allowMatcher, err := NewMatcher(allow)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.block = blockMatcher
+	s.allow = allowMatcher
+
+	return nil
+}
+
+// Allowed reports whether links can point to the host. Hosts matching an allow rule are allowed
+// even if they match a block rule, e.g. to exempt a subdomain of a blocked domain
+func (s *Set) Allowed(host string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.allow.Match(host) || !s.block.Match(host)
+}
+
+// Allowlisted reports whether the host matches an allow rule
+func (s *Set) Allowlisted(host string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.allow.Match(host)
+}
@@ -0,0 +1,86 @@
+package domainrules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		pattern  string
+		expected string
+		wantErr  bool
+	}{
+		{name: "exact", kind: KindExact, pattern: "Example.COM.", expected: "example.com"},
+		{name: "exact IDN", kind: KindExact, pattern: "Bücher.de", expected: "xn--bcher-kva.de"},
+		{name: "exact with scheme", kind: KindExact, pattern: "https://example.com", wantErr: true},
+		{name: "exact with path", kind: KindExact, pattern: "example.com/path", wantErr: true},
+		{name: "exact with wildcard", kind: KindExact, pattern: "*.example.com", wantErr: true},
+		{name: "empty", kind: KindExact, pattern: " ", wantErr: true},
+		{name: "suffix", kind: KindSuffix, pattern: "example.com", expected: "example.com"},
+		{name: "suffix with wildcard", kind: KindSuffix, pattern: "*.Example.com", expected: "example.com"},
+		{name: "suffix with leading dot", kind: KindSuffix, pattern: ".example.com", expected: "example.com"},
+		{name: "regex", kind: KindRegex, pattern: `bit\.ly|.*\.tk`, expected: `bit\.ly|.*\.tk`},
+		{name: "invalid regex", kind: KindRegex, pattern: `(example`, wantErr: true},
+		{name: "unknown kind", kind: "glob", pattern: "example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := NormalizePattern(tt.kind, tt.pattern)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	s := NewSet()
+	assert.True(t, s.Allowed("example.com"), "empty set should allow every host")
+
+	err := s.Replace([]Rule{
+		{ID: 1, List: ListBlock, Kind: KindExact, Pattern: "blocked.com"},
+		{ID: 2, List: ListBlock, Kind: KindSuffix, Pattern: "*.evil.net"},
+		{ID: 3, List: ListBlock, Kind: KindRegex, Pattern: `.*\.tk`},
+		{ID: 4, List: ListAllow, Kind: KindExact, Pattern: "safe.evil.net"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{host: "example.com", expected: true},
+		{host: "blocked.com", expected: false},
+		{host: "BLOCKED.com.", expected: false},
+		{host: "sub.blocked.com", expected: true},
+		{host: "evil.net", expected: false},
+		{host: "www.evil.net", expected: false},
+		{host: "notevil.net", expected: true},
+		{host: "safe.evil.net", expected: true},
+		{host: "free.tk", expected: false},
+		{host: "free.tk.example.com", expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.expected, s.Allowed(tt.host))
+		})
+	}
+
+	assert.True(t, s.Allowlisted("safe.evil.net"))
+	assert.False(t, s.Allowlisted("www.evil.net"))
+
+	t.Run("invalid rule keeps the rules", func(t *testing.T) {
+		err := s.Replace([]Rule{{ID: 5, List: ListBlock, Kind: KindRegex, Pattern: "("}})
+		assert.Error(t, err)
+		assert.False(t, s.Allowed("blocked.com"))
+	})
+}
@@ -2,33 +2,86 @@ package generator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
-	gonanoid "github.com/matoous/go-nanoid/v2"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/trace"
 )
 
 const defaultLength = 8
 
+// base62Alphabet is the default alphabet of the counter based generators
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Strategies of short code generation
+const (
+	StrategyNanoid        = "nanoid"
+	StrategySequence      = "sequence"
+	StrategyHashids       = "hashids"
+	StrategyPronounceable = "pronounceable"
+)
+
+var Strategies = []string{StrategyNanoid, StrategySequence, StrategyHashids, StrategyPronounceable}
+
 var tracer = otel.Tracer("github.com/rousage/shortener/internal/generator")
 
-func ShortUrl(ctx context.Context, length int) (string, error) {
-	_, span := tracer.Start(ctx, "generator.ShortUrl")
-	defer span.End()
+// Generator generates the codes of short links that are not custom.
+// Any generator can return a code that is taken, if not by a code it has returned before then by a custom one,
+// so callers retry with the next code on a duplicate
+type Generator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// NextFunc returns the next value of a counter that never repeats, e.g. a database sequence
+type NextFunc func(ctx context.Context) (int64, error)
+
+type Config struct {
+	// Strategy is one of Strategies, nanoid is used when it is empty
+	Strategy string
+	// Length of the generated codes, the minimum length for hashids
+	Length int
+	// Alphabet of the nanoid, sequence and hashids codes, each of them has its own default
+	Alphabet string
+	// Salt makes the sequence and hashids codes of a deployment different from the ones of any other
+	Salt string
+}
+
+// New creates the generator of the configured strategy, next is only used by the counter based ones
+func New(cfg Config, next NextFunc) (Generator, error) {
+	if cfg.Length <= 0 {
+		cfg.Length = defaultLength
+	}
+
+	switch cfg.Strategy {
+	case StrategyNanoid, "":
+		return NewNanoid(cfg.Alphabet, cfg.Length)
+	case StrategySequence:
+		return NewSequence(next, cfg.Alphabet, cfg.Length, cfg.Salt)
+	case StrategyHashids:
+		return NewHashids(next, cfg.Alphabet, cfg.Length, cfg.Salt)
+	case StrategyPronounceable:
+		return NewPronounceable(cfg.Length), nil
+	default:
+		return nil, fmt.Errorf("unknown short code generation strategy %q", cfg.Strategy)
+	}
+}
 
-	if length <= 0 {
-		span.AddEvent("invalid length, using default", trace.WithAttributes(attribute.Int("length", length)), trace.WithAttributes(attribute.Int("default", defaultLength)))
-		length = defaultLength
+// validateAlphabet checks that the alphabet has enough distinct characters and that all of them are allowed in short codes
+func validateAlphabet(alphabet string, minLength int) error {
+	if len(alphabet) < minLength {
+		return fmt.Errorf("alphabet must have at least %d characters", minLength)
 	}
 
-	id, err := gonanoid.New(length)
-	if err != nil {
-		span.SetStatus(codes.Error, "nanoid generation failed")
-		span.RecordError(err)
-		return "", err
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return errors.New("alphabet must only contain letters, digits, - and _")
+		}
+		if strings.IndexByte(alphabet[i+1:], c) >= 0 {
+			return fmt.Errorf("alphabet must not repeat characters, %q is repeated", c)
+		}
 	}
 
-	return id, nil
+	return nil
 }
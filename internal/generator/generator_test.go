@@ -2,30 +2,215 @@ package generator
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestShortUrl(t *testing.T) {
+// counter returns a NextFunc counting up from start, like a database sequence
+func counter(start int64) NextFunc {
+	next := start
+	return func(context.Context) (int64, error) {
+		next++
+		return next - 1, nil
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		expected    Generator
+		expectedErr bool
+	}{
+		{name: "defaults to nanoid", cfg: Config{}, expected: &Nanoid{}},
+		{name: "nanoid", cfg: Config{Strategy: StrategyNanoid}, expected: &Nanoid{}},
+		{name: "sequence", cfg: Config{Strategy: StrategySequence}, expected: &Sequence{}},
+		{name: "hashids", cfg: Config{Strategy: StrategyHashids}, expected: &Hashids{}},
+		{name: "pronounceable", cfg: Config{Strategy: StrategyPronounceable}, expected: &Pronounceable{}},
+		{name: "unknown strategy", cfg: Config{Strategy: "uuid"}, expectedErr: true},
+		{name: "alphabet with one character", cfg: Config{Alphabet: "a"}, expectedErr: true},
+		{name: "alphabet with repeated characters", cfg: Config{Alphabet: "abca"}, expectedErr: true},
+		{name: "alphabet with characters not allowed in short codes", cfg: Config{Alphabet: "ab/c"}, expectedErr: true},
+		{name: "short hashids alphabet", cfg: Config{Strategy: StrategyHashids, Alphabet: "abcdef"}, expectedErr: true},
+		{name: "sequence longer than the alphabet allows", cfg: Config{Strategy: StrategySequence, Length: 11}, expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := New(tt.cfg, counter(1))
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.IsType(t, tt.expected, gen)
+		})
+	}
+}
+
+func TestNanoid(t *testing.T) {
 	ctx := context.Background()
 
 	tests := []struct {
 		name           string
+		alphabet       string
 		length         int
 		expectedLength int
 	}{
 		{name: "uses default length", length: 0, expectedLength: defaultLength},
 		{name: "uses default length for negative", length: -1, expectedLength: defaultLength},
 		{name: "uses custom length", length: 10, expectedLength: 10},
+		{name: "uses custom alphabet", alphabet: "23456789abcdefghijkmnopqrstuvwxyz", length: 12, expectedLength: 12},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			shortUrl, err := ShortUrl(ctx, tt.length)
+			gen, err := NewNanoid(tt.alphabet, tt.length)
+			require.NoError(t, err)
+
+			shortUrl, err := gen.Generate(ctx)
 
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedLength, len(shortUrl))
+			if tt.alphabet != "" {
+				for _, c := range shortUrl {
+					assert.Contains(t, tt.alphabet, string(c))
+				}
+			}
 		})
 	}
 }
+
+func TestSequence(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("maps every value to a distinct code", func(t *testing.T) {
+		gen, err := NewSequence(counter(0), "abcdefgh", 3, "salt")
+		require.NoError(t, err)
+
+		seen := make(map[string]struct{})
+		for range 8 * 8 * 8 {
+			code, err := gen.Generate(ctx)
+			require.NoError(t, err)
+			assert.Len(t, code, 3)
+			seen[code] = struct{}{}
+		}
+		assert.Len(t, seen, 8*8*8, "codes should not repeat")
+
+		_, err = gen.Generate(ctx)
+		assert.ErrorIs(t, err, ErrExhausted)
+	})
+
+	t.Run("consecutive values give unrelated codes", func(t *testing.T) {
+		gen, err := NewSequence(counter(1), "", 8, "")
+		require.NoError(t, err)
+
+		first, err := gen.Generate(ctx)
+		require.NoError(t, err)
+		second, err := gen.Generate(ctx)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first[:7], second[:7], "codes should differ in more than the last character")
+	})
+
+	t.Run("salt changes the codes", func(t *testing.T) {
+		gen, err := NewSequence(counter(1), "", 8, "one")
+		require.NoError(t, err)
+		other, err := NewSequence(counter(1), "", 8, "two")
+		require.NoError(t, err)
+
+		code, err := gen.Generate(ctx)
+		require.NoError(t, err)
+		otherCode, err := other.Generate(ctx)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, code, otherCode)
+	})
+
+	t.Run("counter error", func(t *testing.T) {
+		counterErr := errors.New("sequence unavailable")
+		gen, err := NewSequence(func(context.Context) (int64, error) { return 0, counterErr }, "", 8, "")
+		require.NoError(t, err)
+
+		_, err = gen.Generate(ctx)
+		assert.ErrorIs(t, err, counterErr)
+	})
+}
+
+func TestHashids(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("maps every value to a distinct code", func(t *testing.T) {
+		gen, err := NewHashids(counter(0), "", 6, "salt")
+		require.NoError(t, err)
+
+		seen := make(map[string]struct{})
+		for range 100_000 {
+			code, err := gen.Generate(ctx)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, len(code), 6)
+			seen[code] = struct{}{}
+		}
+		assert.Len(t, seen, 100_000, "codes should not repeat")
+	})
+
+	t.Run("grows past the minimum length", func(t *testing.T) {
+		gen, err := NewHashids(counter(1<<62), "", 4, "salt")
+		require.NoError(t, err)
+
+		code, err := gen.Generate(ctx)
+		require.NoError(t, err)
+		assert.Greater(t, len(code), 4)
+	})
+
+	t.Run("uses the alphabet", func(t *testing.T) {
+		alphabet := "23456789abcdefghijkmnopqrstuvwxyz"
+		gen, err := NewHashids(counter(1), alphabet, 8, "salt")
+		require.NoError(t, err)
+
+		code, err := gen.Generate(ctx)
+		require.NoError(t, err)
+		assert.Len(t, code, 8)
+		for _, c := range code {
+			assert.Contains(t, alphabet, string(c))
+		}
+	})
+
+	t.Run("salt changes the codes", func(t *testing.T) {
+		gen, err := NewHashids(counter(1), "", 8, "one")
+		require.NoError(t, err)
+		other, err := NewHashids(counter(1), "", 8, "two")
+		require.NoError(t, err)
+
+		code, err := gen.Generate(ctx)
+		require.NoError(t, err)
+		otherCode, err := other.Generate(ctx)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, code, otherCode)
+	})
+}
+
+func TestPronounceable(t *testing.T) {
+	ctx := context.Background()
+
+	for _, length := range []int{5, 8} {
+		gen := NewPronounceable(length)
+
+		word, err := gen.Generate(ctx)
+		require.NoError(t, err)
+		require.Len(t, word, length)
+		for i, c := range word {
+			if i%2 == 0 {
+				assert.True(t, strings.ContainsRune(consonants, c), "%q should alternate consonants and vowels", word)
+			} else {
+				assert.True(t, strings.ContainsRune(vowels, c), "%q should alternate consonants and vowels", word)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// minHashidsAlphabetLength is the minimum alphabet length of hashids
+const minHashidsAlphabetLength = 16
+
+// Hashids encodes the values of a counter the way hashids does: the first character is picked by the value
+// and reshuffles the salted alphabet the rest of the value is written in, which is padded to the minimum length.
+// Codes can be decoded back to their value by whoever knows the salt, and never collide with each other
+type Hashids struct {
+	next      NextFunc
+	alphabet  []byte
+	salt      string
+	minLength int
+}
+
+func NewHashids(next NextFunc, alphabet string, minLength int, salt string) (*Hashids, error) {
+	if next == nil {
+		return nil, errors.New("hashids generator requires a counter")
+	}
+	if minLength <= 0 {
+		minLength = defaultLength
+	}
+	if alphabet == "" {
+		alphabet = base62Alphabet
+	}
+	if err := validateAlphabet(alphabet, minHashidsAlphabetLength); err != nil {
+		return nil, err
+	}
+
+	return &Hashids{
+		next:      next,
+		alphabet:  consistentShuffle([]byte(alphabet), salt),
+		salt:      salt,
+		minLength: minLength,
+	}, nil
+}
+
+func (h *Hashids) Generate(ctx context.Context) (string, error) {
+	ctx, span := tracer.Start(ctx, "generator.Hashids")
+	defer span.End()
+
+	value, err := h.next(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get next counter value")
+		span.RecordError(err)
+		return "", err
+	}
+	span.SetAttributes(attribute.Int64("value", value))
+
+	code, err := h.encode(value)
+	if err != nil {
+		span.SetStatus(codes.Error, "hashids generation failed")
+		span.RecordError(err)
+		return "", err
+	}
+
+	return code, nil
+}
+
+// encode writes the value as the lottery character followed by its digits in the alphabet the lottery shuffles.
+// The digits are padded with the zero digit on the left, so the value of a code is always the one it was encoded from
+func (h *Hashids) encode(value int64) (string, error) {
+	if value < 0 {
+		return "", errors.New("counter value must not be negative")
+	}
+
+	n := uint64(value)
+	base := uint64(len(h.alphabet))
+	lottery := h.alphabet[n%base]
+	buffer := string(lottery) + h.salt + string(h.alphabet)
+	alphabet := consistentShuffle(slices.Clone(h.alphabet), buffer[:len(h.alphabet)])
+
+	var digits []byte
+	for {
+		digits = append(digits, alphabet[n%base])
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+	for len(digits) < h.minLength-1 {
+		digits = append(digits, alphabet[0])
+	}
+	slices.Reverse(digits)
+
+	return string(lottery) + string(digits), nil
+}
+
+// consistentShuffle shuffles the alphabet in place, always in the same way for the same salt
+func consistentShuffle(alphabet []byte, salt string) []byte {
+	if salt == "" {
+		return alphabet
+	}
+
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(salt)
+		c := int(salt[v])
+		p += c
+		j := (c + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+	}
+
+	return alphabet
+}
@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"context"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Nanoid generates random codes, with the default nanoid alphabet of 64 characters unless another one is given
+type Nanoid struct {
+	alphabet string
+	length   int
+}
+
+func NewNanoid(alphabet string, length int) (*Nanoid, error) {
+	if length <= 0 {
+		length = defaultLength
+	}
+	if alphabet != "" {
+		if err := validateAlphabet(alphabet, 2); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Nanoid{alphabet: alphabet, length: length}, nil
+}
+
+func (n *Nanoid) Generate(ctx context.Context) (string, error) {
+	_, span := tracer.Start(ctx, "generator.Nanoid")
+	defer span.End()
+
+	var id string
+	var err error
+	if n.alphabet == "" {
+		id, err = gonanoid.New(n.length)
+	} else {
+		id, err = gonanoid.Generate(n.alphabet, n.length)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, "nanoid generation failed")
+		span.RecordError(err)
+		return "", err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"context"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	// consonants leave out the letters that read ambiguously or form awkward syllables
+	consonants = "bdfghjkmnprstvz"
+	vowels     = "aeiou"
+)
+
+// Pronounceable generates random made-up words of alternating consonants and vowels, e.g. "bakomidu",
+// that are easy to read out and type. They have fewer combinations than nanoid codes of the same length
+type Pronounceable struct {
+	length int
+}
+
+func NewPronounceable(length int) *Pronounceable {
+	if length <= 0 {
+		length = defaultLength
+	}
+
+	return &Pronounceable{length: length}
+}
+
+func (p *Pronounceable) Generate(ctx context.Context) (string, error) {
+	_, span := tracer.Start(ctx, "generator.Pronounceable")
+	defer span.End()
+
+	consonantCount := (p.length + 1) / 2
+	randomConsonants, err := gonanoid.Generate(consonants, consonantCount)
+	if err != nil {
+		span.SetStatus(codes.Error, "pronounceable generation failed")
+		span.RecordError(err)
+		return "", err
+	}
+	randomVowels, err := gonanoid.Generate(vowels, p.length-consonantCount)
+	if err != nil {
+		span.SetStatus(codes.Error, "pronounceable generation failed")
+		span.RecordError(err)
+		return "", err
+	}
+
+	word := make([]byte, p.length)
+	for i := range word {
+		if i%2 == 0 {
+			word[i] = randomConsonants[i/2]
+		} else {
+			word[i] = randomVowels[i/2]
+		}
+	}
+
+	return string(word), nil
+}
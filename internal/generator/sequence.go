@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// ErrExhausted is returned when the counter has gone past the number of codes of the configured length
+var ErrExhausted = errors.New("all short codes of the configured length have been generated")
+
+// Sequence turns the values of a counter into codes of a fixed length. Each value is mapped to a distinct code
+// by a permutation of all the codes of that length derived from the salt, so codes never collide with each other
+// while consecutive values do not give away the number of links or the codes of others
+type Sequence struct {
+	next     NextFunc
+	alphabet string
+	length   int
+	// space is the number of codes of the configured length
+	space uint64
+	// The permutation maps a value to value * multiplier + offset modulo space,
+	// which is a bijection because the multiplier is coprime with the space
+	multiplier uint64
+	offset     uint64
+}
+
+func NewSequence(next NextFunc, alphabet string, length int, salt string) (*Sequence, error) {
+	if next == nil {
+		return nil, errors.New("sequence generator requires a counter")
+	}
+	if length <= 0 {
+		length = defaultLength
+	}
+	if alphabet == "" {
+		alphabet = base62Alphabet
+	}
+	if err := validateAlphabet(alphabet, 2); err != nil {
+		return nil, err
+	}
+
+	base := uint64(len(alphabet))
+	space := uint64(1)
+	for range length {
+		hi, lo := bits.Mul64(space, base)
+		if hi != 0 {
+			return nil, fmt.Errorf("length %d is too long for an alphabet of %d characters", length, base)
+		}
+		space = lo
+	}
+
+	multiplier := saltHash(salt, "multiplier") % space
+	for gcd(multiplier, base) != 1 {
+		multiplier = (multiplier + 1) % space
+	}
+
+	return &Sequence{
+		next:       next,
+		alphabet:   string(consistentShuffle([]byte(alphabet), salt)),
+		length:     length,
+		space:      space,
+		multiplier: multiplier,
+		offset:     saltHash(salt, "offset") % space,
+	}, nil
+}
+
+func (s *Sequence) Generate(ctx context.Context) (string, error) {
+	ctx, span := tracer.Start(ctx, "generator.Sequence")
+	defer span.End()
+
+	value, err := s.next(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get next counter value")
+		span.RecordError(err)
+		return "", err
+	}
+	span.SetAttributes(attribute.Int64("value", value))
+
+	code, err := s.encode(value)
+	if err != nil {
+		span.SetStatus(codes.Error, "sequence generation failed")
+		span.RecordError(err)
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (s *Sequence) encode(value int64) (string, error) {
+	if value < 0 || uint64(value) >= s.space {
+		return "", ErrExhausted
+	}
+
+	hi, lo := bits.Mul64(uint64(value), s.multiplier)
+	_, permuted := bits.Div64(hi, lo, s.space)
+	// Adds the offset modulo space without overflowing
+	if permuted >= s.space-s.offset {
+		permuted -= s.space - s.offset
+	} else {
+		permuted += s.offset
+	}
+
+	base := uint64(len(s.alphabet))
+	code := make([]byte, s.length)
+	for i := s.length - 1; i >= 0; i-- {
+		code[i] = s.alphabet[permuted%base]
+		permuted /= base
+	}
+
+	return string(code), nil
+}
+
+func saltHash(salt, purpose string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(purpose))
+	h.Write([]byte(salt))
+	return h.Sum64()
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,61 @@
+// Package netguard tells the addresses of the public internet apart from the private and reserved ones,
+// so the requests made on behalf of users cannot reach the internal network
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"syscall"
+)
+
+var ErrNonPublicAddr = errors.New("address is not on the public internet")
+
+// reservedPrefixes are the ranges that are not reachable on the public internet
+// and are not covered by the netip.Addr methods
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/23"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// IsPublicAddr reports whether the address is a unicast address of the public internet
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Control rejects the connections to addresses that are not public, it is meant to be the net.Dialer Control.
+// The address is checked after the host name is resolved, so a host that resolves to another address
+// than when it was validated (DNS rebinding) cannot reach the internal network either
+func Control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNonPublicAddr, err)
+	}
+	if !IsPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddr, addrPort.Addr())
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package netguard
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected bool
+	}{
+		{addr: "93.184.216.34", expected: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", expected: true},
+		{addr: "127.0.0.1", expected: false},
+		{addr: "10.0.0.1", expected: false},
+		{addr: "169.254.169.254", expected: false},
+		{addr: "100.64.0.1", expected: false},
+		{addr: "::1", expected: false},
+		{addr: "fd00::1", expected: false},
+		{addr: "::ffff:127.0.0.1", expected: false},
+		{addr: "2001:db8::1", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsPublicAddr(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func TestControl(t *testing.T) {
+	assert.NoError(t, Control("tcp4", "93.184.216.34:443", nil))
+	assert.ErrorIs(t, Control("tcp4", "127.0.0.1:8080", nil), ErrNonPublicAddr)
+	assert.ErrorIs(t, Control("tcp6", "[::1]:80", nil), ErrNonPublicAddr)
+	assert.ErrorIs(t, Control("tcp4", "not an address", nil), ErrNonPublicAddr)
+}
@@ -12,48 +12,54 @@ import (
 
 const blockUser = `-- name: BlockUser :one
 INSERT INTO
-  user_blocks (user_id, user_email, blocked_by, reason)
+  user_blocks (
+    user_id,
+    user_email,
+    blocked_by,
+    reason,
+    blocked_until
+  )
 VALUES
-  ($1, $2, $3, $4)
-ON CONFLICT (user_id) DO UPDATE
-SET
-  user_email = EXCLUDED.user_email,
-  blocked_by = EXCLUDED.blocked_by,
-  reason = EXCLUDED.reason,
-  unblocked_by = NULL,
-  unblocked_at = NULL
+  ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id)
+WHERE
+  unblocked_at IS NULL DO NOTHING
 RETURNING
-  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason
+  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason, blocked_until
 `
 
 type BlockUserParams struct {
-	UserID    string  `json:"userId"`
-	UserEmail *string `json:"userEmail"`
-	BlockedBy string  `json:"blockedBy"`
-	Reason    *string `json:"reason"`
+	UserID       string     `json:"userId"`
+	UserEmail    *string    `json:"userEmail"`
+	BlockedBy    string     `json:"blockedBy"`
+	Reason       *string    `json:"reason"`
+	BlockedUntil *time.Time `json:"blockedUntil"`
 }
 
 // BlockUser
 //
 //	INSERT INTO
-//	  user_blocks (user_id, user_email, blocked_by, reason)
+//	  user_blocks (
+//	    user_id,
+//	    user_email,
+//	    blocked_by,
+//	    reason,
+//	    blocked_until
+//	  )
 //	VALUES
-//	  ($1, $2, $3, $4)
-//	ON CONFLICT (user_id) DO UPDATE
-//	SET
-//	  user_email = EXCLUDED.user_email,
-//	  blocked_by = EXCLUDED.blocked_by,
-//	  reason = EXCLUDED.reason,
-//	  unblocked_by = NULL,
-//	  unblocked_at = NULL
+//	  ($1, $2, $3, $4, $5)
+//	ON CONFLICT (user_id)
+//	WHERE
+//	  unblocked_at IS NULL DO NOTHING
 //	RETURNING
-//	  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason
+//	  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason, blocked_until
 func (q *Queries) BlockUser(ctx context.Context, arg BlockUserParams) (UserBlock, error) {
 	row := q.db.QueryRow(ctx, blockUser,
 		arg.UserID,
 		arg.UserEmail,
 		arg.BlockedBy,
 		arg.Reason,
+		arg.BlockedUntil,
 	)
 	var i UserBlock
 	err := row.Scan(
@@ -65,23 +71,30 @@ func (q *Queries) BlockUser(ctx context.Context, arg BlockUserParams) (UserBlock
 		&i.UnblockedBy,
 		&i.UnblockedAt,
 		&i.Reason,
+		&i.BlockedUntil,
 	)
 	return i, err
 }
 
 const deleteAllUserURLs = `-- name: DeleteAllUserURLs :many
-DELETE FROM urls
+UPDATE urls
+SET
+  deleted_at = NOW()
 WHERE
   user_id = $1::text
+  AND deleted_at IS NULL
 RETURNING
   id
 `
 
 // DeleteAllUserURLs
 //
-//	DELETE FROM urls
+//	UPDATE urls
+//	SET
+//	  deleted_at = NOW()
 //	WHERE
 //	  user_id = $1::text
+//	  AND deleted_at IS NULL
 //	RETURNING
 //	  id
 func (q *Queries) DeleteAllUserURLs(ctx context.Context, userID string) ([]string, error) {
@@ -105,16 +118,22 @@ func (q *Queries) DeleteAllUserURLs(ctx context.Context, userID string) ([]strin
 }
 
 const deleteURL = `-- name: DeleteURL :execrows
-DELETE FROM urls
+UPDATE urls
+SET
+  deleted_at = NOW()
 WHERE
   id = $1
+  AND deleted_at IS NULL
 `
 
 // DeleteURL
 //
-//	DELETE FROM urls
+//	UPDATE urls
+//	SET
+//	  deleted_at = NOW()
 //	WHERE
 //	  id = $1
+//	  AND deleted_at IS NULL
 func (q *Queries) DeleteURL(ctx context.Context, id string) (int64, error) {
 	result, err := q.db.Exec(ctx, deleteURL, id)
 	if err != nil {
@@ -123,6 +142,170 @@ func (q *Queries) DeleteURL(ctx context.Context, id string) (int64, error) {
 	return result.RowsAffected(), nil
 }
 
+const getDeletedURLs = `-- name: GetDeletedURLs :many
+SELECT
+  id,
+  long_url,
+  created_at,
+  is_custom,
+  user_id,
+  expires_at,
+  max_clicks,
+  remaining_clicks,
+  updated_at,
+  deleted_at,
+  click_count,
+  last_clicked_at,
+  COUNT(*) OVER () as total_count
+FROM
+  urls
+WHERE
+  deleted_at IS NOT NULL
+  AND (
+    $1::text IS NULL
+    OR user_id = $1::text
+  )
+ORDER BY
+  deleted_at DESC
+LIMIT
+  $3
+OFFSET
+  $2
+`
+
+type GetDeletedURLsParams struct {
+	UserID *string `json:"userId"`
+	Offset int32   `json:"offset"`
+	Limit  int32   `json:"limit"`
+}
+
+type GetDeletedURLsRow struct {
+	ID              string     `json:"id"`
+	LongUrl         string     `json:"longUrl"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	IsCustom        bool       `json:"isCustom"`
+	UserID          *string    `json:"userId"`
+	ExpiresAt       *time.Time `json:"expiresAt"`
+	MaxClicks       *int32     `json:"maxClicks"`
+	RemainingClicks *int32     `json:"remainingClicks"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	DeletedAt       *time.Time `json:"deletedAt"`
+	ClickCount      int64      `json:"clickCount"`
+	LastClickedAt   *time.Time `json:"lastClickedAt"`
+	TotalCount      int64      `json:"totalCount"`
+}
+
+// GetDeletedURLs
+//
+//	SELECT
+//	  id,
+//	  long_url,
+//	  created_at,
+//	  is_custom,
+//	  user_id,
+//	  expires_at,
+//	  max_clicks,
+//	  remaining_clicks,
+//	  updated_at,
+//	  deleted_at,
+//	  click_count,
+//	  last_clicked_at,
+//	  COUNT(*) OVER () as total_count
+//	FROM
+//	  urls
+//	WHERE
+//	  deleted_at IS NOT NULL
+//	  AND (
+//	    $1::text IS NULL
+//	    OR user_id = $1::text
+//	  )
+//	ORDER BY
+//	  deleted_at DESC
+//	LIMIT
+//	  $3
+//	OFFSET
+//	  $2
+func (q *Queries) GetDeletedURLs(ctx context.Context, arg GetDeletedURLsParams) ([]GetDeletedURLsRow, error) {
+	rows, err := q.db.Query(ctx, getDeletedURLs, arg.UserID, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetDeletedURLsRow{}
+	for rows.Next() {
+		var i GetDeletedURLsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LongUrl,
+			&i.CreatedAt,
+			&i.IsCustom,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.RemainingClicks,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ClickCount,
+			&i.LastClickedAt,
+			&i.TotalCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExpiredUserBlocks = `-- name: GetExpiredUserBlocks :many
+SELECT
+  user_id
+FROM
+  user_blocks
+WHERE
+  unblocked_at IS NULL
+  AND blocked_until <= NOW()
+ORDER BY
+  blocked_until
+LIMIT
+  $1
+`
+
+// GetExpiredUserBlocks
+//
+//	SELECT
+//	  user_id
+//	FROM
+//	  user_blocks
+//	WHERE
+//	  unblocked_at IS NULL
+//	  AND blocked_until <= NOW()
+//	ORDER BY
+//	  blocked_until
+//	LIMIT
+//	  $1
+func (q *Queries) GetExpiredUserBlocks(ctx context.Context, limit int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, getExpiredUserBlocks, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var user_id string
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getURLs = `-- name: GetURLs :many
 SELECT
   id,
@@ -130,6 +313,12 @@ SELECT
   created_at,
   is_custom,
   user_id,
+  expires_at,
+  max_clicks,
+  remaining_clicks,
+  updated_at,
+  click_count,
+  last_clicked_at,
   COUNT(*) OVER () as total_count
 FROM
   urls
@@ -142,28 +331,42 @@ WHERE
     $2::text IS NULL
     OR user_id = $2::text
   )
+  AND deleted_at IS NULL
 ORDER BY
+  CASE
+    WHEN $3::text = 'clickCount' THEN click_count
+  END DESC,
+  CASE
+    WHEN $3::text = 'lastClickedAt' THEN last_clicked_at
+  END DESC NULLS LAST,
   created_at DESC
 LIMIT
-  $4
+  $5
 OFFSET
-  $3
+  $4
 `
 
 type GetURLsParams struct {
 	IsCustom *bool   `json:"isCustom"`
 	UserID   *string `json:"userId"`
+	SortBy   string  `json:"sortBy"`
 	Offset   int32   `json:"offset"`
 	Limit    int32   `json:"limit"`
 }
 
 type GetURLsRow struct {
-	ID         string    `json:"id"`
-	LongUrl    string    `json:"longUrl"`
-	CreatedAt  time.Time `json:"createdAt"`
-	IsCustom   bool      `json:"isCustom"`
-	UserID     *string   `json:"userId"`
-	TotalCount int64     `json:"totalCount"`
+	ID              string     `json:"id"`
+	LongUrl         string     `json:"longUrl"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	IsCustom        bool       `json:"isCustom"`
+	UserID          *string    `json:"userId"`
+	ExpiresAt       *time.Time `json:"expiresAt"`
+	MaxClicks       *int32     `json:"maxClicks"`
+	RemainingClicks *int32     `json:"remainingClicks"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	ClickCount      int64      `json:"clickCount"`
+	LastClickedAt   *time.Time `json:"lastClickedAt"`
+	TotalCount      int64      `json:"totalCount"`
 }
 
 // GetURLs
@@ -174,6 +377,12 @@ type GetURLsRow struct {
 //	  created_at,
 //	  is_custom,
 //	  user_id,
+//	  expires_at,
+//	  max_clicks,
+//	  remaining_clicks,
+//	  updated_at,
+//	  click_count,
+//	  last_clicked_at,
 //	  COUNT(*) OVER () as total_count
 //	FROM
 //	  urls
@@ -186,16 +395,24 @@ type GetURLsRow struct {
 //	    $2::text IS NULL
 //	    OR user_id = $2::text
 //	  )
+//	  AND deleted_at IS NULL
 //	ORDER BY
+//	  CASE
+//	    WHEN $3::text = 'clickCount' THEN click_count
+//	  END DESC,
+//	  CASE
+//	    WHEN $3::text = 'lastClickedAt' THEN last_clicked_at
+//	  END DESC NULLS LAST,
 //	  created_at DESC
 //	LIMIT
-//	  $4
+//	  $5
 //	OFFSET
-//	  $3
+//	  $4
 func (q *Queries) GetURLs(ctx context.Context, arg GetURLsParams) ([]GetURLsRow, error) {
 	rows, err := q.db.Query(ctx, getURLs,
 		arg.IsCustom,
 		arg.UserID,
+		arg.SortBy,
 		arg.Offset,
 		arg.Limit,
 	)
@@ -212,6 +429,12 @@ func (q *Queries) GetURLs(ctx context.Context, arg GetURLsParams) ([]GetURLsRow,
 			&i.CreatedAt,
 			&i.IsCustom,
 			&i.UserID,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.RemainingClicks,
+			&i.UpdatedAt,
+			&i.ClickCount,
+			&i.LastClickedAt,
 			&i.TotalCount,
 		); err != nil {
 			return nil, err
@@ -224,6 +447,57 @@ func (q *Queries) GetURLs(ctx context.Context, arg GetURLsParams) ([]GetURLsRow,
 	return items, nil
 }
 
+const getUserBlockHistory = `-- name: GetUserBlockHistory :many
+SELECT
+  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason, blocked_until
+FROM
+  user_blocks
+WHERE
+  user_id = $1
+ORDER BY
+  blocked_at DESC
+`
+
+// GetUserBlockHistory
+//
+//	SELECT
+//	  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason, blocked_until
+//	FROM
+//	  user_blocks
+//	WHERE
+//	  user_id = $1
+//	ORDER BY
+//	  blocked_at DESC
+func (q *Queries) GetUserBlockHistory(ctx context.Context, userID string) ([]UserBlock, error) {
+	rows, err := q.db.Query(ctx, getUserBlockHistory, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserBlock{}
+	for rows.Next() {
+		var i UserBlock
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.UserEmail,
+			&i.BlockedBy,
+			&i.BlockedAt,
+			&i.UnblockedBy,
+			&i.UnblockedAt,
+			&i.Reason,
+			&i.BlockedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserBlocks = `-- name: GetUserBlocks :many
 SELECT
   id,
@@ -234,32 +508,66 @@ SELECT
   unblocked_by,
   unblocked_at,
   reason,
+  blocked_until,
   COUNT(*) OVER () as total_count
 FROM
   user_blocks
+WHERE
+  (
+    $1::boolean IS NULL
+    OR (
+      unblocked_at IS NULL
+      AND (
+        blocked_until IS NULL
+        OR blocked_until > NOW()
+      )
+    ) = $1::boolean
+  )
+  AND (
+    $2::text IS NULL
+    OR blocked_by = $2::text
+  )
+  AND (
+    $3::timestamptz IS NULL
+    OR blocked_at >= $3::timestamptz
+  )
+  AND (
+    $4::timestamptz IS NULL
+    OR blocked_at < $4::timestamptz
+  )
+  AND (
+    $5::text IS NULL
+    OR user_email ILIKE '%' || $5::text || '%' ESCAPE '\'
+  )
 ORDER BY
   blocked_at DESC
 LIMIT
-  $2
+  $7
 OFFSET
-  $1
+  $6
 `
 
 type GetUserBlocksParams struct {
-	Offset int32 `json:"offset"`
-	Limit  int32 `json:"limit"`
+	Active      *bool      `json:"active"`
+	BlockedBy   *string    `json:"blockedBy"`
+	BlockedFrom *time.Time `json:"blockedFrom"`
+	BlockedTo   *time.Time `json:"blockedTo"`
+	Email       *string    `json:"email"`
+	Offset      int32      `json:"offset"`
+	Limit       int32      `json:"limit"`
 }
 
 type GetUserBlocksRow struct {
-	ID          int32      `json:"id"`
-	UserID      string     `json:"userId"`
-	UserEmail   *string    `json:"userEmail"`
-	BlockedBy   string     `json:"blockedBy"`
-	BlockedAt   time.Time  `json:"blockedAt"`
-	UnblockedBy *string    `json:"unblockedBy"`
-	UnblockedAt *time.Time `json:"unblockedAt"`
-	Reason      *string    `json:"reason"`
-	TotalCount  int64      `json:"totalCount"`
+	ID           int32      `json:"id"`
+	UserID       string     `json:"userId"`
+	UserEmail    *string    `json:"userEmail"`
+	BlockedBy    string     `json:"blockedBy"`
+	BlockedAt    time.Time  `json:"blockedAt"`
+	UnblockedBy  *string    `json:"unblockedBy"`
+	UnblockedAt  *time.Time `json:"unblockedAt"`
+	Reason       *string    `json:"reason"`
+	BlockedUntil *time.Time `json:"blockedUntil"`
+	TotalCount   int64      `json:"totalCount"`
 }
 
 // GetUserBlocks
@@ -273,17 +581,53 @@ type GetUserBlocksRow struct {
 //	  unblocked_by,
 //	  unblocked_at,
 //	  reason,
+//	  blocked_until,
 //	  COUNT(*) OVER () as total_count
 //	FROM
 //	  user_blocks
+//	WHERE
+//	  (
+//	    $1::boolean IS NULL
+//	    OR (
+//	      unblocked_at IS NULL
+//	      AND (
+//	        blocked_until IS NULL
+//	        OR blocked_until > NOW()
+//	      )
+//	    ) = $1::boolean
+//	  )
+//	  AND (
+//	    $2::text IS NULL
+//	    OR blocked_by = $2::text
+//	  )
+//	  AND (
+//	    $3::timestamptz IS NULL
+//	    OR blocked_at >= $3::timestamptz
+//	  )
+//	  AND (
+//	    $4::timestamptz IS NULL
+//	    OR blocked_at < $4::timestamptz
+//	  )
+//	  AND (
+//	    $5::text IS NULL
+//	    OR user_email ILIKE '%' || $5::text || '%' ESCAPE '\'
+//	  )
 //	ORDER BY
 //	  blocked_at DESC
 //	LIMIT
-//	  $2
+//	  $7
 //	OFFSET
-//	  $1
+//	  $6
 func (q *Queries) GetUserBlocks(ctx context.Context, arg GetUserBlocksParams) ([]GetUserBlocksRow, error) {
-	rows, err := q.db.Query(ctx, getUserBlocks, arg.Offset, arg.Limit)
+	rows, err := q.db.Query(ctx, getUserBlocks,
+		arg.Active,
+		arg.BlockedBy,
+		arg.BlockedFrom,
+		arg.BlockedTo,
+		arg.Email,
+		arg.Offset,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -300,6 +644,7 @@ func (q *Queries) GetUserBlocks(ctx context.Context, arg GetUserBlocksParams) ([
 			&i.UnblockedBy,
 			&i.UnblockedAt,
 			&i.Reason,
+			&i.BlockedUntil,
 			&i.TotalCount,
 		); err != nil {
 			return nil, err
@@ -312,6 +657,173 @@ func (q *Queries) GetUserBlocks(ctx context.Context, arg GetUserBlocksParams) ([
 	return items, nil
 }
 
+const isUserBlocked = `-- name: IsUserBlocked :one
+SELECT
+  EXISTS (
+    SELECT
+      1
+    FROM
+      user_blocks
+    WHERE
+      user_id = $1
+      AND unblocked_at IS NULL
+      AND (
+        blocked_until IS NULL
+        OR blocked_until > NOW()
+      )
+  )
+`
+
+// IsUserBlocked
+//
+//	SELECT
+//	  EXISTS (
+//	    SELECT
+//	      1
+//	    FROM
+//	      user_blocks
+//	    WHERE
+//	      user_id = $1
+//	      AND unblocked_at IS NULL
+//	      AND (
+//	        blocked_until IS NULL
+//	        OR blocked_until > NOW()
+//	      )
+//	  )
+func (q *Queries) IsUserBlocked(ctx context.Context, userID string) (bool, error) {
+	row := q.db.QueryRow(ctx, isUserBlocked, userID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const purgeDeletedURLs = `-- name: PurgeDeletedURLs :many
+DELETE FROM urls
+WHERE
+  deleted_at < $1
+RETURNING
+  id
+`
+
+// PurgeDeletedURLs
+//
+//	DELETE FROM urls
+//	WHERE
+//	  deleted_at < $1
+//	RETURNING
+//	  id
+func (q *Queries) PurgeDeletedURLs(ctx context.Context, deletedBefore *time.Time) ([]string, error) {
+	rows, err := q.db.Query(ctx, purgeDeletedURLs, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreURL = `-- name: RestoreURL :one
+UPDATE urls
+SET
+  deleted_at = NULL,
+  updated_at = NOW()
+WHERE
+  id = $1
+  AND deleted_at IS NOT NULL
+RETURNING
+  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+`
+
+// RestoreURL
+//
+//	UPDATE urls
+//	SET
+//	  deleted_at = NULL,
+//	  updated_at = NOW()
+//	WHERE
+//	  id = $1
+//	  AND deleted_at IS NOT NULL
+//	RETURNING
+//	  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+func (q *Queries) RestoreURL(ctx context.Context, id string) (Url, error) {
+	row := q.db.QueryRow(ctx, restoreURL, id)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.LongUrl,
+		&i.CreatedAt,
+		&i.IsCustom,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.RemainingClicks,
+		&i.PasswordHash,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ClickCount,
+		&i.LastClickedAt,
+		&i.OriginalUrl,
+	)
+	return i, err
+}
+
+const unblockExpiredUser = `-- name: UnblockExpiredUser :one
+UPDATE user_blocks
+SET
+  unblocked_by = $1::text,
+  unblocked_at = NOW()
+WHERE
+  user_id = $2
+  AND unblocked_at IS NULL
+  AND blocked_until <= NOW()
+RETURNING
+  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason, blocked_until
+`
+
+type UnblockExpiredUserParams struct {
+	UnblockedBy string `json:"unblockedBy"`
+	UserID      string `json:"userId"`
+}
+
+// UnblockExpiredUser
+//
+//	UPDATE user_blocks
+//	SET
+//	  unblocked_by = $1::text,
+//	  unblocked_at = NOW()
+//	WHERE
+//	  user_id = $2
+//	  AND unblocked_at IS NULL
+//	  AND blocked_until <= NOW()
+//	RETURNING
+//	  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason, blocked_until
+func (q *Queries) UnblockExpiredUser(ctx context.Context, arg UnblockExpiredUserParams) (UserBlock, error) {
+	row := q.db.QueryRow(ctx, unblockExpiredUser, arg.UnblockedBy, arg.UserID)
+	var i UserBlock
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.UserEmail,
+		&i.BlockedBy,
+		&i.BlockedAt,
+		&i.UnblockedBy,
+		&i.UnblockedAt,
+		&i.Reason,
+		&i.BlockedUntil,
+	)
+	return i, err
+}
+
 const unblockUser = `-- name: UnblockUser :one
 UPDATE user_blocks
 SET
@@ -319,8 +831,9 @@ SET
   unblocked_at = NOW()
 WHERE
   user_id = $2
+  AND unblocked_at IS NULL
 RETURNING
-  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason
+  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason, blocked_until
 `
 
 type UnblockUserParams struct {
@@ -336,8 +849,9 @@ type UnblockUserParams struct {
 //	  unblocked_at = NOW()
 //	WHERE
 //	  user_id = $2
+//	  AND unblocked_at IS NULL
 //	RETURNING
-//	  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason
+//	  id, user_id, user_email, blocked_by, blocked_at, unblocked_by, unblocked_at, reason, blocked_until
 func (q *Queries) UnblockUser(ctx context.Context, arg UnblockUserParams) (UserBlock, error) {
 	row := q.db.QueryRow(ctx, unblockUser, arg.UnblockedBy, arg.UserID)
 	var i UserBlock
@@ -350,6 +864,68 @@ func (q *Queries) UnblockUser(ctx context.Context, arg UnblockUserParams) (UserB
 		&i.UnblockedBy,
 		&i.UnblockedAt,
 		&i.Reason,
+		&i.BlockedUntil,
+	)
+	return i, err
+}
+
+const updateURL = `-- name: UpdateURL :one
+UPDATE urls
+SET
+  long_url = $1,
+  original_url = $2,
+  updated_at = NOW()
+WHERE
+  id = $3
+  AND updated_at = $4
+  AND deleted_at IS NULL
+RETURNING
+  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+`
+
+type UpdateURLParams struct {
+	LongUrl     string    `json:"longUrl"`
+	OriginalUrl string    `json:"originalUrl"`
+	ID          string    `json:"id"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// UpdateURL
+//
+//	UPDATE urls
+//	SET
+//	  long_url = $1,
+//	  original_url = $2,
+//	  updated_at = NOW()
+//	WHERE
+//	  id = $3
+//	  AND updated_at = $4
+//	  AND deleted_at IS NULL
+//	RETURNING
+//	  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+func (q *Queries) UpdateURL(ctx context.Context, arg UpdateURLParams) (Url, error) {
+	row := q.db.QueryRow(ctx, updateURL,
+		arg.LongUrl,
+		arg.OriginalUrl,
+		arg.ID,
+		arg.UpdatedAt,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.LongUrl,
+		&i.CreatedAt,
+		&i.IsCustom,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.RemainingClicks,
+		&i.PasswordHash,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ClickCount,
+		&i.LastClickedAt,
+		&i.OriginalUrl,
 	)
 	return i, err
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -136,6 +137,25 @@ func (suite *AdminTestSuite) TestGetURLs() {
 	}
 }
 
+func (suite *AdminTestSuite) TestUpdateURL() {
+	t := suite.T()
+
+	url, err := suite.queries.GetUrl(suite.ctx, "short-url1")
+	assert.NoError(t, err)
+
+	_, err = suite.queries.UpdateURL(suite.ctx, UpdateURLParams{LongUrl: "https://new-long.url", ID: "short-url", UpdatedAt: url.UpdatedAt})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	updatedUrl, err := suite.queries.UpdateURL(suite.ctx, UpdateURLParams{LongUrl: "https://new-long.url", ID: "short-url1", UpdatedAt: url.UpdatedAt})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://new-long.url", updatedUrl.LongUrl)
+	assert.Equal(t, url.UserID, updatedUrl.UserID)
+	assert.True(t, updatedUrl.UpdatedAt.After(url.UpdatedAt), "updatedAt should be bumped")
+
+	_, err = suite.queries.UpdateURL(suite.ctx, UpdateURLParams{LongUrl: "https://stale-long.url", ID: "short-url1", UpdatedAt: url.UpdatedAt})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "stale updatedAt must not update the url")
+}
+
 func (suite *AdminTestSuite) TestDeleteURL() {
 	t := suite.T()
 
@@ -182,6 +202,76 @@ func (suite *AdminTestSuite) TestDeleteAllUserURLs() {
 	}
 }
 
+func (suite *AdminTestSuite) TestGetDeletedURLs() {
+	t := suite.T()
+
+	_, err := suite.queries.DeleteAllUserURLs(suite.ctx, userID_2)
+	assert.NoError(t, err)
+	_, err = suite.queries.DeleteURL(suite.ctx, "short-url1")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		params       GetDeletedURLsParams
+		expectedUrls int
+	}{
+		{name: "get all deleted urls", params: GetDeletedURLsParams{Offset: 0, Limit: 25}, expectedUrls: 3},
+		{name: "get deleted urls of user 1", params: GetDeletedURLsParams{UserID: &userID_1, Offset: 0, Limit: 25}, expectedUrls: 1},
+		{name: "get deleted urls of user 2", params: GetDeletedURLsParams{UserID: &userID_2, Offset: 0, Limit: 25}, expectedUrls: 2},
+		{name: "return urls for offset=2 and limit=2", params: GetDeletedURLsParams{Offset: 2, Limit: 2}, expectedUrls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urls, err := suite.queries.GetDeletedURLs(suite.ctx, tt.params)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedUrls, len(urls))
+			for _, url := range urls {
+				assert.NotNil(t, url.DeletedAt, "deletedAt should be set")
+			}
+		})
+	}
+
+	urls, err := suite.queries.GetURLs(suite.ctx, GetURLsParams{Offset: 0, Limit: 25})
+	assert.NoError(t, err)
+	assert.Equal(t, len(urlParams)-3, len(urls), "deleted urls must not be listed")
+}
+
+func (suite *AdminTestSuite) TestRestoreURL() {
+	t := suite.T()
+
+	_, err := suite.queries.RestoreURL(suite.ctx, "short-url1")
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "url that is not deleted must not be restored")
+
+	_, err = suite.queries.DeleteURL(suite.ctx, "short-url1")
+	assert.NoError(t, err)
+
+	restoredUrl, err := suite.queries.RestoreURL(suite.ctx, "short-url1")
+	assert.NoError(t, err)
+	assert.Nil(t, restoredUrl.DeletedAt, "deletedAt should be cleared")
+	assert.Equal(t, &userID_1, restoredUrl.UserID)
+}
+
+func (suite *AdminTestSuite) TestPurgeDeletedURLs() {
+	t := suite.T()
+
+	_, err := suite.queries.DeleteAllUserURLs(suite.ctx, userID_2)
+	assert.NoError(t, err)
+
+	past := time.Now().Add(-time.Hour)
+	purgedIDs, err := suite.queries.PurgeDeletedURLs(suite.ctx, &past)
+	assert.NoError(t, err)
+	assert.Empty(t, purgedIDs, "urls deleted after the cutoff must not be purged")
+
+	future := time.Now().Add(time.Hour)
+	purgedIDs, err = suite.queries.PurgeDeletedURLs(suite.ctx, &future)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"short-url6", "short-url7"}, purgedIDs)
+
+	_, err = suite.queries.GetUrl(suite.ctx, "short-url6")
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "purged url must be removed")
+}
+
 func (suite *AdminTestSuite) TestBlockUser() {
 	t := suite.T()
 
@@ -191,18 +281,30 @@ func (suite *AdminTestSuite) TestBlockUser() {
 	)
 
 	tests := []struct {
-		name   string
-		params BlockUserParams
+		name           string
+		params         BlockUserParams
+		alreadyBlocked bool
 	}{
 		{name: "blocks a user", params: BlockUserParams{UserID: userID_1, BlockedBy: adminID, Reason: &reason}},
 		{name: "blocks another user", params: BlockUserParams{UserID: userID_2, BlockedBy: adminID, UserEmail: &email}},
-		{name: "blocks already blocked user", params: BlockUserParams{UserID: userID_2, BlockedBy: adminID, UserEmail: &email}},
-		{name: "email is overriden", params: BlockUserParams{UserID: userID_2, BlockedBy: adminID}},
+		{name: "does not replace the active block", params: BlockUserParams{UserID: userID_2, BlockedBy: "other-admin"}, alreadyBlocked: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			userBlock, err := suite.queries.BlockUser(suite.ctx, tt.params)
+			if tt.alreadyBlocked {
+				assert.True(t, suite.queries.IsNotFoundError(err), "expected no rows error")
+
+				history, err := suite.queries.GetUserBlockHistory(suite.ctx, tt.params.UserID)
+				assert.NoError(t, err)
+				if assert.Len(t, history, 1, "no block should be added") {
+					assert.Equal(t, adminID, history[0].BlockedBy, "blocked by should be kept")
+					assert.Equal(t, &email, history[0].UserEmail, "user email should be kept")
+				}
+				return
+			}
+
 			assert.NoError(t, err)
 			assert.Equal(t, tt.params.UserID, userBlock.UserID, "user id should be the same")
 			assert.Equal(t, tt.params.BlockedBy, userBlock.BlockedBy, "blocked by should be the same")
@@ -268,6 +370,259 @@ func (suite *AdminTestSuite) TestGetUserBlocks() {
 	}
 }
 
+func (suite *AdminTestSuite) TestIsUserBlocked() {
+	t := suite.T()
+
+	blocked, err := suite.queries.IsUserBlocked(suite.ctx, userID_1)
+	assert.NoError(t, err)
+	assert.True(t, blocked, "blocked user should be blocked")
+
+	url, err := suite.queries.GetLongUrl(suite.ctx, "short-url1")
+	assert.NoError(t, err)
+	assert.True(t, url.OwnerBlocked, "owner of the url should be blocked")
+
+	_, err = suite.queries.UnblockUser(suite.ctx, UnblockUserParams{UserID: userID_1, UnblockedBy: adminID})
+	assert.NoError(t, err)
+
+	blocked, err = suite.queries.IsUserBlocked(suite.ctx, userID_1)
+	assert.NoError(t, err)
+	assert.False(t, blocked, "unblocked user should not be blocked")
+
+	url, err = suite.queries.GetLongUrl(suite.ctx, "short-url1")
+	assert.NoError(t, err)
+	assert.False(t, url.OwnerBlocked, "owner of the url should not be blocked")
+
+	blocked, err = suite.queries.IsUserBlocked(suite.ctx, userID_2)
+	assert.NoError(t, err)
+	assert.False(t, blocked, "user without a block should not be blocked")
+}
+
+func (suite *AdminTestSuite) TestUnblockExpiredUser() {
+	t := suite.T()
+
+	var (
+		expired = time.Now().Add(-time.Minute)
+		active  = time.Now().Add(time.Hour)
+	)
+	_, err := suite.queries.BlockUser(suite.ctx, BlockUserParams{UserID: userID_1, BlockedBy: adminID, BlockedUntil: &expired})
+	assert.NoError(t, err)
+	_, err = suite.queries.BlockUser(suite.ctx, BlockUserParams{UserID: userID_2, BlockedBy: adminID, BlockedUntil: &active})
+	assert.NoError(t, err)
+
+	blocked, err := suite.queries.IsUserBlocked(suite.ctx, userID_1)
+	assert.NoError(t, err)
+	assert.False(t, blocked, "expired block should not be active")
+
+	expiredIDs, err := suite.queries.GetExpiredUserBlocks(suite.ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{userID_1}, expiredIDs, "only expired blocks should be returned")
+
+	_, err = suite.queries.UnblockExpiredUser(suite.ctx, UnblockExpiredUserParams{UserID: userID_2, UnblockedBy: adminID})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "active block should not be lifted")
+
+	userBlock, err := suite.queries.UnblockExpiredUser(suite.ctx, UnblockExpiredUserParams{UserID: userID_1, UnblockedBy: adminID})
+	assert.NoError(t, err)
+	assert.Equal(t, userID_1, userBlock.UserID)
+	assert.NotNil(t, userBlock.UnblockedAt, "expired block should be lifted")
+
+	expiredIDs, err = suite.queries.GetExpiredUserBlocks(suite.ctx, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, expiredIDs)
+}
+
+func (suite *AdminTestSuite) TestGetUserBlockHistory() {
+	t := suite.T()
+
+	_, err := suite.queries.UnblockUser(suite.ctx, UnblockUserParams{UserID: userID_1, UnblockedBy: adminID})
+	assert.NoError(t, err)
+
+	reason := "blocked again"
+	userBlock, err := suite.queries.BlockUser(suite.ctx, BlockUserParams{UserID: userID_1, BlockedBy: adminID, Reason: &reason})
+	assert.NoError(t, err)
+	assert.Nil(t, userBlock.UnblockedAt, "new block should be active")
+
+	history, err := suite.queries.GetUserBlockHistory(suite.ctx, userID_1)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 2, "previous block should be kept") {
+		assert.Equal(t, userBlock.ID, history[0].ID, "newest block should come first")
+		assert.Equal(t, &adminID, history[1].UnblockedBy, "previous block should keep its unblock")
+	}
+
+	active := true
+	userBlocks, err := suite.queries.GetUserBlocks(suite.ctx, GetUserBlocksParams{Active: &active, Limit: 25})
+	assert.NoError(t, err)
+	assert.Len(t, userBlocks, len(blockUserParams), "only one block per user should be active")
+}
+
+func (suite *AdminTestSuite) TestAdminAuditLog() {
+	t := suite.T()
+
+	errMsg := "auth0 is down"
+	for _, arg := range []CreateAdminAuditLogParams{
+		{ActorID: adminID, Action: "url.delete", TargetType: "url", TargetID: "short-url1", Before: []byte(`{"id":"short-url1"}`), Outcome: "success"},
+		{ActorID: adminID, Action: "user.block", TargetType: "user", TargetID: userID_2, Outcome: "failure", Error: &errMsg},
+		{ActorID: "system", Action: "user.unblock", TargetType: "user", TargetID: userID_1, After: []byte(`{"userId":"user-id"}`), Outcome: "success"},
+	} {
+		err := suite.queries.CreateAdminAuditLog(suite.ctx, arg)
+		assert.NoError(t, err)
+	}
+
+	entries, err := suite.queries.GetAdminAuditLog(suite.ctx, GetAdminAuditLogParams{Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, "user.unblock", entries[0].Action, "newest entry should come first")
+		assert.Equal(t, int64(3), entries[0].TotalCount)
+		assert.JSONEq(t, `{"id":"short-url1"}`, string(entries[2].Before))
+		assert.Nil(t, entries[2].After)
+	}
+
+	actorID, outcome := adminID, "failure"
+	entries, err = suite.queries.GetAdminAuditLog(suite.ctx, GetAdminAuditLogParams{ActorID: &actorID, Outcome: &outcome, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, userID_2, entries[0].TargetID)
+		assert.Equal(t, &errMsg, entries[0].Error)
+	}
+
+	_, err = suite.db.Exec(suite.ctx, "UPDATE admin_audit_log SET outcome = 'success'")
+	assert.Error(t, err, "audit log entries should not be changed")
+	_, err = suite.db.Exec(suite.ctx, "DELETE FROM admin_audit_log")
+	assert.Error(t, err, "audit log entries should not be removed")
+}
+
+func (suite *AdminTestSuite) TestAbuseReports() {
+	t := suite.T()
+
+	var (
+		code     = urlParams[0].ID
+		ip_1     = "10.0.0.1"
+		ip_2     = "10.0.0.2"
+		disabler = "system"
+	)
+	for _, arg := range []CreateAbuseReportParams{
+		{UrlID: code, Category: "phishing", ReporterIp: &ip_1},
+		{UrlID: code, Category: "spam", ReporterIp: &ip_1},
+		{UrlID: code, Category: "phishing", ReporterID: &userID_2, ReporterIp: &ip_1},
+		{UrlID: urlParams[1].ID, Category: "malware", ReporterIp: &ip_2},
+	} {
+		_, err := suite.queries.CreateAbuseReport(suite.ctx, arg)
+		assert.NoError(t, err)
+	}
+
+	counts, err := suite.queries.CountOpenAbuseReports(suite.ctx, code)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), counts.Reports)
+	assert.Equal(t, int64(1), counts.Reporters, "only signed-in reporters should be counted")
+
+	rowsAffected, err := suite.queries.QuarantineURL(suite.ctx, QuarantineURLParams{UrlID: code, DisabledBy: disabler})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rowsAffected)
+
+	quarantined := true
+	queue, err := suite.queries.GetAbuseReportQueue(suite.ctx, GetAbuseReportQueueParams{Quarantined: &quarantined, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, queue, 1) {
+		assert.Equal(t, code, queue[0].UrlID)
+		assert.Equal(t, int64(3), queue[0].ReportCount)
+		assert.Equal(t, int64(2), queue[0].PhishingCount)
+		assert.Equal(t, int64(1), queue[0].SpamCount)
+		assert.Equal(t, int64(1), queue[0].TotalCount)
+	}
+
+	url, err := suite.queries.GetLongUrl(suite.ctx, code)
+	assert.NoError(t, err)
+	if assert.NotNil(t, url.DisabledReason) {
+		assert.Equal(t, "quarantined", *url.DisabledReason)
+	}
+
+	resolved, err := suite.queries.ResolveAbuseReports(suite.ctx, ResolveAbuseReportsParams{ResolvedBy: adminID, Resolution: "disable", UrlID: code})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), resolved)
+
+	assert.NoError(t, suite.queries.LiftURLQuarantine(suite.ctx, code))
+	assert.NoError(t, suite.queries.DisableURL(suite.ctx, DisableURLParams{UrlID: code, DisabledBy: adminID}))
+
+	url, err = suite.queries.GetLongUrl(suite.ctx, code)
+	assert.NoError(t, err)
+	if assert.NotNil(t, url.DisabledReason) {
+		assert.Equal(t, "abuse", *url.DisabledReason)
+	}
+
+	rowsAffected, err = suite.queries.QuarantineURL(suite.ctx, QuarantineURLParams{UrlID: code, DisabledBy: disabler})
+	assert.NoError(t, err)
+	assert.Zero(t, rowsAffected, "disabled url should not be quarantined")
+
+	queue, err = suite.queries.GetAbuseReportQueue(suite.ctx, GetAbuseReportQueueParams{Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, queue, 1, "resolved reports should leave the queue") {
+		assert.Equal(t, urlParams[1].ID, queue[0].UrlID)
+		assert.Nil(t, queue[0].DisabledReason)
+	}
+}
+
+func (suite *AdminTestSuite) TestDomainRules() {
+	t := suite.T()
+
+	note := "phishing campaign"
+	rule, err := suite.queries.CreateDomainRule(suite.ctx, CreateDomainRuleParams{List: "block", Kind: "suffix", Pattern: "long.url", Note: &note, CreatedBy: adminID})
+	assert.NoError(t, err)
+	assert.Equal(t, adminID, rule.CreatedBy)
+
+	_, err = suite.queries.CreateDomainRule(suite.ctx, CreateDomainRuleParams{List: "block", Kind: "suffix", Pattern: "long.url", CreatedBy: adminID})
+	assert.True(t, suite.queries.IsDuplicateKeyError(err), "same rule should not be added twice")
+
+	_, err = suite.queries.CreateDomainRule(suite.ctx, CreateDomainRuleParams{List: "deny", Kind: "exact", Pattern: "long.url", CreatedBy: adminID})
+	assert.True(t, suite.queries.IsCheckConstraintError(err), "unknown list should be rejected")
+
+	allow, err := suite.queries.CreateDomainRule(suite.ctx, CreateDomainRuleParams{List: "allow", Kind: "exact", Pattern: "safe.long.url", CreatedBy: adminID})
+	assert.NoError(t, err)
+
+	list := "allow"
+	rules, err := suite.queries.GetDomainRules(suite.ctx, GetDomainRulesParams{List: &list, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, rules, 1) {
+		assert.Equal(t, allow.ID, rules[0].ID)
+		assert.Equal(t, int64(1), rules[0].TotalCount)
+	}
+
+	updated, err := suite.queries.UpdateDomainRule(suite.ctx, UpdateDomainRuleParams{ID: rule.ID, List: "block", Kind: "exact", Pattern: "long.url"})
+	assert.NoError(t, err)
+	assert.Equal(t, "exact", updated.Kind)
+	assert.Nil(t, updated.Note)
+	assert.True(t, updated.UpdatedAt.After(rule.UpdatedAt))
+
+	all, err := suite.queries.GetAllDomainRules(suite.ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	deleted, err := suite.queries.DeleteDomainRule(suite.ctx, allow.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, allow.ID, deleted.ID)
+
+	_, err = suite.queries.GetDomainRule(suite.ctx, allow.ID)
+	assert.True(t, suite.queries.IsNotFoundError(err))
+
+	// Read the destinations in batches, like a retroactive scan
+	var ids []string
+	afterID := ""
+	for {
+		batch, err := suite.queries.GetURLDestinations(suite.ctx, GetURLDestinationsParams{AfterID: afterID, Limit: 3})
+		assert.NoError(t, err)
+		for _, url := range batch {
+			ids = append(ids, url.ID)
+		}
+		if len(batch) < 3 {
+			break
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+	expected := make([]string, len(urlParams))
+	for i, arg := range urlParams {
+		expected[i] = arg.ID
+	}
+	assert.ElementsMatch(t, expected, ids)
+}
+
 func TestAdminTestSuite(t *testing.T) {
 	suite.Run(t, new(AdminTestSuite))
 }
@@ -5,24 +5,165 @@
 package repository
 
 import (
+	"encoding/json"
 	"time"
 )
 
-type Url struct {
-	ID        string    `json:"id"`
-	LongUrl   string    `json:"longUrl"`
+type AbuseReport struct {
+	ID         int64      `json:"id"`
+	UrlID      string     `json:"urlId"`
+	Category   string     `json:"category"`
+	Note       *string    `json:"note"`
+	ReporterID *string    `json:"reporterId"`
+	ReporterIp *string    `json:"reporterIp"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ResolvedBy *string    `json:"resolvedBy"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+	Resolution *string    `json:"resolution"`
+}
+
+type AdminAuditLog struct {
+	ID         int64           `json:"id"`
+	ActorID    string          `json:"actorId"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   string          `json:"targetId"`
+	RequestID  *string         `json:"requestId"`
+	Ip         *string         `json:"ip"`
+	Before     json.RawMessage `json:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" swaggertype:"object"`
+	Outcome    string          `json:"outcome"`
+	Error      *string         `json:"error"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+type ApiKey struct {
+	ID          int64      `json:"id"`
+	UserID      string     `json:"userId"`
+	Name        string     `json:"name"`
+	KeyPrefix   string     `json:"keyPrefix"`
+	KeyHash     string     `json:"-"`
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+}
+
+type Click struct {
+	ID           int64     `json:"id"`
+	UrlID        string    `json:"urlId"`
+	ClickedAt    time.Time `json:"clickedAt"`
+	Referrer     *string   `json:"referrer"`
+	ReferrerHost *string   `json:"referrerHost"`
+	UserAgent    *string   `json:"userAgent"`
+	Browser      string    `json:"browser"`
+	Device       string    `json:"device"`
+	AnonymizedIp *string   `json:"anonymizedIp"`
+}
+
+type DisabledUrl struct {
+	UrlID      string    `json:"urlId"`
+	Reason     string    `json:"reason"`
+	DisabledBy string    `json:"disabledBy"`
+	DisabledAt time.Time `json:"disabledAt"`
+}
+
+type DomainRule struct {
+	ID        int64     `json:"id"`
+	List      string    `json:"list"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	Note      *string   `json:"note"`
+	CreatedBy string    `json:"createdBy"`
 	CreatedAt time.Time `json:"createdAt"`
-	IsCustom  bool      `json:"isCustom"`
-	UserID    *string   `json:"userId"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type IdempotencyKey struct {
+	Scope       string    `json:"scope"`
+	Key         string    `json:"key"`
+	RequestHash string    `json:"requestHash"`
+	UrlID       string    `json:"urlId"`
+	CreatedAt   time.Time `json:"createdAt"`
+	StatusCode  int32     `json:"statusCode"`
+}
+
+type Url struct {
+	ID              string     `json:"id"`
+	LongUrl         string     `json:"longUrl"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	IsCustom        bool       `json:"isCustom"`
+	UserID          *string    `json:"userId"`
+	ExpiresAt       *time.Time `json:"expiresAt"`
+	MaxClicks       *int32     `json:"maxClicks"`
+	RemainingClicks *int32     `json:"remainingClicks"`
+	PasswordHash    *string    `json:"-"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	DeletedAt       *time.Time `json:"deletedAt"`
+	ClickCount      int64      `json:"clickCount"`
+	LastClickedAt   *time.Time `json:"lastClickedAt"`
+	OriginalUrl     string     `json:"originalUrl"`
 }
 
 type UserBlock struct {
-	ID          int32      `json:"id"`
-	UserID      string     `json:"userId"`
-	UserEmail   *string    `json:"userEmail"`
-	BlockedBy   string     `json:"blockedBy"`
-	BlockedAt   time.Time  `json:"blockedAt"`
-	UnblockedBy *string    `json:"unblockedBy"`
-	UnblockedAt *time.Time `json:"unblockedAt"`
-	Reason      *string    `json:"reason"`
+	ID           int32      `json:"id"`
+	UserID       string     `json:"userId"`
+	UserEmail    *string    `json:"userEmail"`
+	BlockedBy    string     `json:"blockedBy"`
+	BlockedAt    time.Time  `json:"blockedAt"`
+	UnblockedBy  *string    `json:"unblockedBy"`
+	UnblockedAt  *time.Time `json:"unblockedAt"`
+	Reason       *string    `json:"reason"`
+	BlockedUntil *time.Time `json:"blockedUntil"`
+}
+
+type UserSetting struct {
+	UserID     string    `json:"userId"`
+	DedupeUrls bool      `json:"dedupeUrls"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    string    `json:"userId"`
+	Url       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type WebhookDeadLetter struct {
+	ID             int64     `json:"id"`
+	WebhookID      int64     `json:"webhookId"`
+	EventID        int64     `json:"eventId"`
+	EventType      string    `json:"eventType"`
+	Payload        []byte    `json:"payload"`
+	EventCreatedAt time.Time `json:"eventCreatedAt"`
+	Attempts       int32     `json:"attempts"`
+	LastError      *string   `json:"lastError"`
+	LastStatusCode *int32    `json:"lastStatusCode"`
+	FailedAt       time.Time `json:"failedAt"`
+}
+
+type WebhookDelivery struct {
+	ID             int64     `json:"id"`
+	WebhookID      int64     `json:"webhookId"`
+	EventID        int64     `json:"eventId"`
+	EventType      string    `json:"eventType"`
+	Payload        []byte    `json:"payload"`
+	EventCreatedAt time.Time `json:"eventCreatedAt"`
+	Attempts       int32     `json:"attempts"`
+	NextAttemptAt  time.Time `json:"nextAttemptAt"`
+	LastError      *string   `json:"lastError"`
+	LastStatusCode *int32    `json:"lastStatusCode"`
+}
+
+type WebhookOutbox struct {
+	ID        int64     `json:"id"`
+	UserID    string    `json:"userId"`
+	EventType string    `json:"eventType"`
+	Payload   []byte    `json:"payload"`
+	CreatedAt time.Time `json:"createdAt"`
 }
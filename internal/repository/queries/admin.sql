@@ -5,6 +5,12 @@ SELECT
   created_at,
   is_custom,
   user_id,
+  expires_at,
+  max_clicks,
+  remaining_clicks,
+  updated_at,
+  click_count,
+  last_clicked_at,
   COUNT(*) OVER () as total_count
 FROM
   urls
@@ -17,37 +23,113 @@ WHERE
     sqlc.narg ('user_id')::text IS NULL
     OR user_id = sqlc.narg ('user_id')::text
   )
+  AND deleted_at IS NULL
 ORDER BY
+  CASE
+    WHEN sqlc.arg ('sort_by')::text = 'clickCount' THEN click_count
+  END DESC,
+  CASE
+    WHEN sqlc.arg ('sort_by')::text = 'lastClickedAt' THEN last_clicked_at
+  END DESC NULLS LAST,
   created_at DESC
 LIMIT
   sqlc.arg ('limit')
 OFFSET
   sqlc.arg ('offset');
 
+-- name: UpdateURL :one
+UPDATE urls
+SET
+  long_url = sqlc.arg ('long_url'),
+  original_url = sqlc.arg ('original_url'),
+  updated_at = NOW()
+WHERE
+  id = sqlc.arg ('id')
+  AND updated_at = sqlc.arg ('updated_at')
+  AND deleted_at IS NULL
+RETURNING
+  *;
+
 -- name: DeleteURL :execrows
-DELETE FROM urls
+UPDATE urls
+SET
+  deleted_at = NOW()
 WHERE
-  id = sqlc.arg ('id');
+  id = sqlc.arg ('id')
+  AND deleted_at IS NULL;
 
 -- name: DeleteAllUserURLs :many
-DELETE FROM urls
+UPDATE urls
+SET
+  deleted_at = NOW()
 WHERE
   user_id = sqlc.arg ('user_id')::text
+  AND deleted_at IS NULL
+RETURNING
+  id;
+
+-- name: GetDeletedURLs :many
+SELECT
+  id,
+  long_url,
+  created_at,
+  is_custom,
+  user_id,
+  expires_at,
+  max_clicks,
+  remaining_clicks,
+  updated_at,
+  deleted_at,
+  click_count,
+  last_clicked_at,
+  COUNT(*) OVER () as total_count
+FROM
+  urls
+WHERE
+  deleted_at IS NOT NULL
+  AND (
+    sqlc.narg ('user_id')::text IS NULL
+    OR user_id = sqlc.narg ('user_id')::text
+  )
+ORDER BY
+  deleted_at DESC
+LIMIT
+  sqlc.arg ('limit')
+OFFSET
+  sqlc.arg ('offset');
+
+-- name: RestoreURL :one
+UPDATE urls
+SET
+  deleted_at = NULL,
+  updated_at = NOW()
+WHERE
+  id = sqlc.arg ('id')
+  AND deleted_at IS NOT NULL
+RETURNING
+  *;
+
+-- name: PurgeDeletedURLs :many
+DELETE FROM urls
+WHERE
+  deleted_at < sqlc.arg ('deleted_before')
 RETURNING
   id;
 
 -- name: BlockUser :one
 INSERT INTO
-  user_blocks (user_id, user_email, blocked_by, reason)
+  user_blocks (
+    user_id,
+    user_email,
+    blocked_by,
+    reason,
+    blocked_until
+  )
 VALUES
-  ($1, $2, $3, $4)
-ON CONFLICT (user_id) DO UPDATE
-SET
-  user_email = EXCLUDED.user_email,
-  blocked_by = EXCLUDED.blocked_by,
-  reason = EXCLUDED.reason,
-  unblocked_by = NULL,
-  unblocked_at = NULL
+  ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id)
+WHERE
+  unblocked_at IS NULL DO NOTHING
 RETURNING
   *;
 
@@ -58,6 +140,7 @@ SET
   unblocked_at = NOW()
 WHERE
   user_id = sqlc.arg ('user_id')
+  AND unblocked_at IS NULL
 RETURNING
   *;
 
@@ -71,12 +154,91 @@ SELECT
   unblocked_by,
   unblocked_at,
   reason,
+  blocked_until,
   COUNT(*) OVER () as total_count
 FROM
   user_blocks
+WHERE
+  (
+    sqlc.narg ('active')::boolean IS NULL
+    OR (
+      unblocked_at IS NULL
+      AND (
+        blocked_until IS NULL
+        OR blocked_until > NOW()
+      )
+    ) = sqlc.narg ('active')::boolean
+  )
+  AND (
+    sqlc.narg ('blocked_by')::text IS NULL
+    OR blocked_by = sqlc.narg ('blocked_by')::text
+  )
+  AND (
+    sqlc.narg ('blocked_from')::timestamptz IS NULL
+    OR blocked_at >= sqlc.narg ('blocked_from')::timestamptz
+  )
+  AND (
+    sqlc.narg ('blocked_to')::timestamptz IS NULL
+    OR blocked_at < sqlc.narg ('blocked_to')::timestamptz
+  )
+  AND (
+    sqlc.narg ('email')::text IS NULL
+    OR user_email ILIKE '%' || sqlc.narg ('email')::text || '%' ESCAPE '\'
+  )
 ORDER BY
   blocked_at DESC
 LIMIT
   sqlc.arg ('limit')
 OFFSET
   sqlc.arg ('offset');
+
+-- name: GetUserBlockHistory :many
+SELECT
+  *
+FROM
+  user_blocks
+WHERE
+  user_id = $1
+ORDER BY
+  blocked_at DESC;
+
+-- name: IsUserBlocked :one
+SELECT
+  EXISTS (
+    SELECT
+      1
+    FROM
+      user_blocks
+    WHERE
+      user_id = $1
+      AND unblocked_at IS NULL
+      AND (
+        blocked_until IS NULL
+        OR blocked_until > NOW()
+      )
+  );
+
+-- name: GetExpiredUserBlocks :many
+SELECT
+  user_id
+FROM
+  user_blocks
+WHERE
+  unblocked_at IS NULL
+  AND blocked_until <= NOW()
+ORDER BY
+  blocked_until
+LIMIT
+  $1;
+
+-- name: UnblockExpiredUser :one
+UPDATE user_blocks
+SET
+  unblocked_by = sqlc.arg ('unblocked_by')::text,
+  unblocked_at = NOW()
+WHERE
+  user_id = sqlc.arg ('user_id')
+  AND unblocked_at IS NULL
+  AND blocked_until <= NOW()
+RETURNING
+  *;
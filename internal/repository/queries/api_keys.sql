@@ -0,0 +1,55 @@
+-- name: CreateApiKey :one
+INSERT INTO
+  api_keys (
+    user_id,
+    name,
+    key_prefix,
+    key_hash,
+    permissions,
+    expires_at
+  )
+VALUES
+  ($1, $2, $3, $4, $5, $6)
+RETURNING
+  *;
+
+-- name: CountUserApiKeys :one
+SELECT
+  COUNT(*)
+FROM
+  api_keys
+WHERE
+  user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > NOW();
+
+-- name: GetUserApiKeys :many
+SELECT
+  *
+FROM
+  api_keys
+WHERE
+  user_id = $1
+  AND revoked_at IS NULL
+ORDER BY
+  created_at DESC;
+
+-- name: RevokeUserApiKey :execrows
+UPDATE api_keys
+SET
+  revoked_at = NOW()
+WHERE
+  id = $1
+  AND user_id = $2
+  AND revoked_at IS NULL;
+
+-- name: UseApiKey :one
+UPDATE api_keys
+SET
+  last_used_at = NOW()
+WHERE
+  key_hash = $1
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING
+  *;
@@ -0,0 +1,70 @@
+-- name: CreateAdminAuditLog :exec
+INSERT INTO
+  admin_audit_log (
+    actor_id,
+    action,
+    target_type,
+    target_id,
+    request_id,
+    ip,
+    before,
+    after,
+    outcome,
+    error
+  )
+VALUES
+  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: GetAdminAuditLog :many
+SELECT
+  id,
+  actor_id,
+  action,
+  target_type,
+  target_id,
+  request_id,
+  ip,
+  before,
+  after,
+  outcome,
+  error,
+  created_at,
+  COUNT(*) OVER () as total_count
+FROM
+  admin_audit_log
+WHERE
+  (
+    sqlc.narg ('actor_id')::text IS NULL
+    OR actor_id = sqlc.narg ('actor_id')::text
+  )
+  AND (
+    sqlc.narg ('action')::text IS NULL
+    OR action = sqlc.narg ('action')::text
+  )
+  AND (
+    sqlc.narg ('target_type')::text IS NULL
+    OR target_type = sqlc.narg ('target_type')::text
+  )
+  AND (
+    sqlc.narg ('target_id')::text IS NULL
+    OR target_id = sqlc.narg ('target_id')::text
+  )
+  AND (
+    sqlc.narg ('outcome')::text IS NULL
+    OR outcome = sqlc.narg ('outcome')::text
+  )
+  AND (
+    sqlc.narg ('created_from')::timestamptz IS NULL
+    OR created_at >= sqlc.narg ('created_from')::timestamptz
+  )
+  AND (
+    sqlc.narg ('created_to')::timestamptz IS NULL
+    OR created_at < sqlc.narg ('created_to')::timestamptz
+  )
+ORDER BY
+  created_at DESC,
+  id DESC
+LIMIT
+  sqlc.arg ('limit')
+OFFSET
+  sqlc.arg ('offset');
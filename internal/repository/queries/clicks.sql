@@ -0,0 +1,85 @@
+-- name: CreateClicks :copyfrom
+INSERT INTO
+  clicks (
+    url_id,
+    clicked_at,
+    referrer,
+    referrer_host,
+    user_agent,
+    browser,
+    device,
+    anonymized_ip
+  )
+VALUES
+  ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: CountClicks :one
+SELECT
+  COUNT(*)
+FROM
+  clicks
+WHERE
+  url_id = sqlc.arg ('url_id')
+  AND clicked_at >= sqlc.arg ('since');
+
+-- name: GetClicksByDay :many
+SELECT
+  date_trunc('day', clicked_at, 'UTC')::timestamptz AS day,
+  COUNT(*) AS clicks
+FROM
+  clicks
+WHERE
+  url_id = sqlc.arg ('url_id')
+  AND clicked_at >= sqlc.arg ('since')
+GROUP BY
+  day
+ORDER BY
+  day;
+
+-- name: GetClicksByReferrerHost :many
+SELECT
+  COALESCE(referrer_host, '')::text AS referrer_host,
+  COUNT(*) AS clicks
+FROM
+  clicks
+WHERE
+  url_id = sqlc.arg ('url_id')
+  AND clicked_at >= sqlc.arg ('since')
+GROUP BY
+  referrer_host
+ORDER BY
+  clicks DESC
+LIMIT
+  sqlc.arg ('limit');
+
+-- name: GetClicksByBrowser :many
+SELECT
+  browser,
+  COUNT(*) AS clicks
+FROM
+  clicks
+WHERE
+  url_id = sqlc.arg ('url_id')
+  AND clicked_at >= sqlc.arg ('since')
+GROUP BY
+  browser
+ORDER BY
+  clicks DESC
+LIMIT
+  sqlc.arg ('limit');
+
+-- name: GetClicksByDevice :many
+SELECT
+  device,
+  COUNT(*) AS clicks
+FROM
+  clicks
+WHERE
+  url_id = sqlc.arg ('url_id')
+  AND clicked_at >= sqlc.arg ('since')
+GROUP BY
+  device
+ORDER BY
+  clicks DESC
+LIMIT
+  sqlc.arg ('limit');
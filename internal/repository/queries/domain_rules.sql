@@ -0,0 +1,81 @@
+-- name: CreateDomainRule :one
+INSERT INTO
+  domain_rules (list, kind, pattern, note, created_by)
+VALUES
+  ($1, $2, $3, $4, $5)
+RETURNING
+  *;
+
+-- name: GetDomainRule :one
+SELECT
+  *
+FROM
+  domain_rules
+WHERE
+  id = $1
+LIMIT
+  1;
+
+-- name: GetDomainRules :many
+SELECT
+  *,
+  COUNT(*) OVER () as total_count
+FROM
+  domain_rules
+WHERE
+  (
+    sqlc.narg ('list')::text IS NULL
+    OR list = sqlc.narg ('list')::text
+  )
+ORDER BY
+  created_at DESC,
+  id DESC
+LIMIT
+  sqlc.arg ('limit')
+OFFSET
+  sqlc.arg ('offset');
+
+-- name: GetAllDomainRules :many
+SELECT
+  *
+FROM
+  domain_rules
+ORDER BY
+  id;
+
+-- name: UpdateDomainRule :one
+UPDATE domain_rules
+SET
+  list = $2,
+  kind = $3,
+  pattern = $4,
+  note = $5,
+  updated_at = NOW()
+WHERE
+  id = $1
+RETURNING
+  *;
+
+-- name: DeleteDomainRule :one
+DELETE FROM domain_rules
+WHERE
+  id = $1
+RETURNING
+  *;
+
+-- name: GetURLDestinations :many
+SELECT
+  urls.id,
+  urls.long_url,
+  urls.user_id,
+  disabled_urls.reason AS disabled_reason
+FROM
+  urls
+  LEFT JOIN disabled_urls ON disabled_urls.url_id = urls.id
+WHERE
+  urls.deleted_at IS NULL
+  AND urls.id > sqlc.arg ('after_id')
+ORDER BY
+  urls.id
+LIMIT
+  sqlc.arg ('limit');
@@ -0,0 +1,44 @@
+-- name: CreateIdempotencyKey :execrows
+INSERT INTO
+  idempotency_keys (scope, key, request_hash, url_id, status_code)
+VALUES
+  (
+    sqlc.arg ('scope'),
+    sqlc.arg ('key'),
+    sqlc.arg ('request_hash'),
+    sqlc.arg ('url_id'),
+    sqlc.arg ('status_code')
+  )
+ON CONFLICT (scope, key) DO UPDATE
+SET
+  request_hash = EXCLUDED.request_hash,
+  url_id = EXCLUDED.url_id,
+  status_code = EXCLUDED.status_code,
+  created_at = NOW()
+WHERE
+  idempotency_keys.created_at <= sqlc.arg ('expired_before')
+  OR idempotency_keys.url_id IN (
+    SELECT
+      id
+    FROM
+      urls
+    WHERE
+      deleted_at IS NOT NULL
+  );
+
+-- name: GetIdempotencyKey :one
+SELECT
+  *
+FROM
+  idempotency_keys
+WHERE
+  scope = sqlc.arg ('scope')
+  AND key = sqlc.arg ('key')
+  AND created_at > sqlc.arg ('created_after')
+LIMIT
+  1;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE
+  created_at < sqlc.arg ('created_before');
@@ -0,0 +1,100 @@
+-- name: CreateAbuseReport :one
+INSERT INTO
+  abuse_reports (url_id, category, note, reporter_id, reporter_ip)
+VALUES
+  ($1, $2, $3, $4, $5)
+RETURNING
+  *;
+
+-- name: CountOpenAbuseReports :one
+SELECT
+  COUNT(*) AS reports,
+  COUNT(DISTINCT reporter_id) AS reporters
+FROM
+  abuse_reports
+WHERE
+  url_id = $1
+  AND resolved_at IS NULL;
+
+-- name: GetAbuseReportQueue :many
+SELECT
+  abuse_reports.url_id,
+  urls.long_url,
+  urls.user_id,
+  disabled_urls.reason AS disabled_reason,
+  COUNT(*) AS report_count,
+  COUNT(*) FILTER (
+    WHERE
+      abuse_reports.category = 'phishing'
+  ) AS phishing_count,
+  COUNT(*) FILTER (
+    WHERE
+      abuse_reports.category = 'malware'
+  ) AS malware_count,
+  COUNT(*) FILTER (
+    WHERE
+      abuse_reports.category = 'spam'
+  ) AS spam_count,
+  COUNT(*) FILTER (
+    WHERE
+      abuse_reports.category = 'illegal'
+  ) AS illegal_count,
+  MIN(abuse_reports.created_at)::timestamptz AS first_reported_at,
+  MAX(abuse_reports.created_at)::timestamptz AS last_reported_at,
+  COUNT(*) OVER () AS total_count
+FROM
+  abuse_reports
+  JOIN urls ON urls.id = abuse_reports.url_id
+  LEFT JOIN disabled_urls ON disabled_urls.url_id = abuse_reports.url_id
+WHERE
+  abuse_reports.resolved_at IS NULL
+  AND (
+    sqlc.narg ('quarantined')::boolean IS NULL
+    OR (disabled_urls.reason IS NOT DISTINCT FROM 'quarantined') = sqlc.narg ('quarantined')::boolean
+  )
+GROUP BY
+  abuse_reports.url_id,
+  urls.long_url,
+  urls.user_id,
+  disabled_urls.reason
+ORDER BY
+  report_count DESC,
+  last_reported_at DESC
+LIMIT
+  sqlc.arg ('limit')
+OFFSET
+  sqlc.arg ('offset');
+
+-- name: ResolveAbuseReports :execrows
+UPDATE abuse_reports
+SET
+  resolved_by = sqlc.arg ('resolved_by')::text,
+  resolved_at = NOW(),
+  resolution = sqlc.arg ('resolution')::text
+WHERE
+  url_id = sqlc.arg ('url_id')
+  AND resolved_at IS NULL;
+
+-- name: QuarantineURL :execrows
+INSERT INTO
+  disabled_urls (url_id, reason, disabled_by)
+VALUES
+  ($1, 'quarantined', $2)
+ON CONFLICT (url_id) DO NOTHING;
+
+-- name: DisableURL :exec
+INSERT INTO
+  disabled_urls (url_id, reason, disabled_by)
+VALUES
+  ($1, 'abuse', $2)
+ON CONFLICT (url_id) DO UPDATE
+SET
+  reason = EXCLUDED.reason,
+  disabled_by = EXCLUDED.disabled_by,
+  disabled_at = NOW();
+
+-- name: LiftURLQuarantine :exec
+DELETE FROM disabled_urls
+WHERE
+  url_id = $1
+  AND reason = 'quarantined';
@@ -1,8 +1,18 @@
 -- name: CreateUrl :one
 INSERT INTO
-  urls (id, long_url, is_custom, user_id)
+  urls (
+    id,
+    long_url,
+    original_url,
+    is_custom,
+    user_id,
+    expires_at,
+    max_clicks,
+    remaining_clicks,
+    password_hash
+  )
 VALUES
-  ($1, $2, $3, $4)
+  ($1, $2, $3, $4, $5, $6, $7, $7, $8)
 RETURNING
   *;
 
@@ -12,21 +22,91 @@ SELECT
   long_url,
   created_at,
   is_custom,
+  expires_at,
+  max_clicks,
+  remaining_clicks,
+  password_hash IS NOT NULL AS password_protected,
+  updated_at,
+  click_count,
+  last_clicked_at,
   COUNT(*) OVER () as total_count
 FROM
   urls
 WHERE
-  user_id = $1
+  user_id = sqlc.arg ('user_id')
+  AND deleted_at IS NULL
 ORDER BY
+  CASE
+    WHEN sqlc.arg ('sort_by')::text = 'clickCount' THEN click_count
+  END DESC,
+  CASE
+    WHEN sqlc.arg ('sort_by')::text = 'lastClickedAt' THEN last_clicked_at
+  END DESC NULLS LAST,
   created_at DESC
 LIMIT
-  $2
+  sqlc.arg ('limit')
 OFFSET
-  $3;
+  sqlc.arg ('offset');
 
 -- name: GetLongUrl :one
 SELECT
-  long_url
+  long_url,
+  expires_at,
+  remaining_clicks,
+  password_hash,
+  deleted_at,
+  EXISTS (
+    SELECT
+      1
+    FROM
+      user_blocks
+    WHERE
+      user_blocks.user_id = urls.user_id
+      AND user_blocks.unblocked_at IS NULL
+      AND (
+        user_blocks.blocked_until IS NULL
+        OR user_blocks.blocked_until > NOW()
+      )
+  ) AS owner_blocked,
+  disabled_urls.reason AS disabled_reason
+FROM
+  urls
+  LEFT JOIN disabled_urls ON disabled_urls.url_id = urls.id
+WHERE
+  urls.id = $1
+LIMIT
+  1;
+
+-- name: GetReusableUserURL :one
+SELECT
+  *
+FROM
+  urls
+WHERE
+  user_id = sqlc.arg ('user_id')
+  AND md5(long_url) = md5(sqlc.arg ('long_url'))
+  AND long_url = sqlc.arg ('long_url')
+  AND NOT is_custom
+  AND deleted_at IS NULL
+  AND expires_at IS NULL
+  AND max_clicks IS NULL
+  AND password_hash IS NULL
+  AND NOT EXISTS (
+    SELECT
+      1
+    FROM
+      disabled_urls
+    WHERE
+      disabled_urls.url_id = urls.id
+  )
+ORDER BY
+  created_at
+LIMIT
+  1;
+
+-- name: GetUrl :one
+SELECT
+  *
 FROM
   urls
 WHERE
@@ -34,8 +114,114 @@ WHERE
 LIMIT
   1;
 
+-- name: UpdateUserURL :one
+UPDATE urls
+SET
+  long_url = sqlc.arg ('long_url'),
+  original_url = sqlc.arg ('original_url'),
+  updated_at = NOW()
+WHERE
+  id = sqlc.arg ('id')
+  AND user_id = sqlc.arg ('user_id')
+  AND updated_at = sqlc.arg ('updated_at')
+  AND deleted_at IS NULL
+RETURNING
+  *;
+
 -- name: DeleteUserURL :execrows
-DELETE FROM urls
+UPDATE urls
+SET
+  deleted_at = NOW()
 WHERE
   id = $1
-  AND user_id = $2;
+  AND user_id = $2
+  AND deleted_at IS NULL;
+
+-- name: GetUserDeletedUrls :many
+SELECT
+  id,
+  long_url,
+  created_at,
+  is_custom,
+  expires_at,
+  max_clicks,
+  remaining_clicks,
+  password_hash IS NOT NULL AS password_protected,
+  updated_at,
+  deleted_at,
+  click_count,
+  last_clicked_at,
+  COUNT(*) OVER () as total_count
+FROM
+  urls
+WHERE
+  user_id = $1
+  AND deleted_at IS NOT NULL
+ORDER BY
+  deleted_at DESC
+LIMIT
+  $2
+OFFSET
+  $3;
+
+-- name: RestoreUserURL :one
+UPDATE urls
+SET
+  deleted_at = NULL,
+  updated_at = NOW()
+WHERE
+  id = $1
+  AND user_id = $2
+  AND deleted_at IS NOT NULL
+RETURNING
+  *;
+
+-- name: ConsumeClick :one
+UPDATE urls
+SET
+  remaining_clicks = remaining_clicks - 1
+WHERE
+  id = $1
+  AND remaining_clicks > 0
+RETURNING
+  remaining_clicks;
+
+-- name: AddClickCounts :execrows
+UPDATE urls
+SET
+  click_count = urls.click_count + counts.clicks,
+  last_clicked_at = GREATEST(urls.last_clicked_at, counts.last_clicked_at)
+FROM
+  (
+    SELECT
+      UNNEST(sqlc.arg ('ids')::text[]) AS id,
+      UNNEST(sqlc.arg ('clicks')::bigint[]) AS clicks,
+      UNNEST(sqlc.arg ('last_clicked_at')::timestamptz[]) AS last_clicked_at
+  ) AS counts
+WHERE
+  urls.id = counts.id;
+
+-- name: GetUrlsByIDs :many
+SELECT
+  id,
+  long_url,
+  user_id
+FROM
+  urls
+WHERE
+  id = ANY (sqlc.arg ('ids')::text[])
+  AND deleted_at IS NULL;
+
+-- name: GetAllUserUrls :many
+SELECT
+  id,
+  long_url
+FROM
+  urls
+WHERE
+  user_id = $1
+  AND deleted_at IS NULL;
+
+-- name: NextShortCode :one
+SELECT
+  nextval('short_code_seq');
@@ -0,0 +1,21 @@
+-- name: GetUserSettings :one
+SELECT
+  *
+FROM
+  user_settings
+WHERE
+  user_id = $1
+LIMIT
+  1;
+
+-- name: UpsertUserSettings :one
+INSERT INTO
+  user_settings (user_id, dedupe_urls)
+VALUES
+  ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET
+  dedupe_urls = EXCLUDED.dedupe_urls,
+  updated_at = NOW()
+RETURNING
+  *;
@@ -0,0 +1,252 @@
+-- name: CreateWebhook :one
+INSERT INTO
+  webhooks (user_id, url, events, secret)
+VALUES
+  ($1, $2, $3, $4)
+RETURNING
+  *;
+
+-- name: CountUserWebhooks :one
+SELECT
+  COUNT(*)
+FROM
+  webhooks
+WHERE
+  user_id = $1;
+
+-- name: GetUserWebhooks :many
+SELECT
+  *
+FROM
+  webhooks
+WHERE
+  user_id = $1
+ORDER BY
+  created_at;
+
+-- name: GetUserWebhook :one
+SELECT
+  *
+FROM
+  webhooks
+WHERE
+  id = $1
+  AND user_id = $2
+LIMIT
+  1;
+
+-- name: RotateWebhookSecret :one
+UPDATE webhooks
+SET
+  secret = $3,
+  updated_at = NOW()
+WHERE
+  id = $1
+  AND user_id = $2
+RETURNING
+  *;
+
+-- name: DeleteUserWebhook :execrows
+DELETE FROM webhooks
+WHERE
+  id = $1
+  AND user_id = $2;
+
+-- name: CreateWebhookEvents :exec
+INSERT INTO
+  webhook_outbox (user_id, event_type, payload)
+SELECT
+  u.user_id,
+  sqlc.arg ('event_type')::text,
+  e.payload::jsonb
+FROM
+  UNNEST(
+    sqlc.arg ('url_ids')::text[],
+    sqlc.arg ('payloads')::text[]
+  ) AS e (url_id, payload)
+  JOIN urls AS u ON u.id = e.url_id
+WHERE
+  EXISTS (
+    SELECT
+      1
+    FROM
+      webhooks AS w
+    WHERE
+      w.user_id = u.user_id
+      AND sqlc.arg ('event_type')::text = ANY (w.events)
+  );
+
+-- name: FanOutWebhookEvents :execrows
+WITH
+  events AS (
+    DELETE FROM webhook_outbox
+    WHERE
+      id IN (
+        SELECT
+          id
+        FROM
+          webhook_outbox
+        ORDER BY
+          id
+        LIMIT
+          $1
+        FOR UPDATE
+          SKIP LOCKED
+      )
+    RETURNING
+      id,
+      user_id,
+      event_type,
+      payload,
+      created_at
+  )
+INSERT INTO
+  webhook_deliveries (
+    webhook_id,
+    event_id,
+    event_type,
+    payload,
+    event_created_at
+  )
+SELECT
+  w.id,
+  e.id,
+  e.event_type,
+  e.payload,
+  e.created_at
+FROM
+  events AS e
+  JOIN webhooks AS w ON w.user_id = e.user_id
+  AND e.event_type = ANY (w.events);
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries AS d
+SET
+  next_attempt_at = sqlc.arg ('locked_until')::timestamptz
+FROM
+  webhooks AS w
+WHERE
+  w.id = d.webhook_id
+  AND d.id IN (
+    SELECT
+      id
+    FROM
+      webhook_deliveries
+    WHERE
+      next_attempt_at <= NOW()
+    ORDER BY
+      next_attempt_at
+    LIMIT
+      sqlc.arg ('limit')
+    FOR UPDATE
+      SKIP LOCKED
+  )
+RETURNING
+  d.id,
+  d.webhook_id,
+  d.event_id,
+  d.event_type,
+  d.payload,
+  d.event_created_at,
+  d.attempts,
+  w.url,
+  w.secret;
+
+-- name: DeleteWebhookDelivery :exec
+DELETE FROM webhook_deliveries
+WHERE
+  id = $1;
+
+-- name: RetryWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET
+  attempts = $2,
+  next_attempt_at = $3,
+  last_error = $4,
+  last_status_code = $5
+WHERE
+  id = $1;
+
+-- name: DeadLetterWebhookDelivery :exec
+WITH
+  delivery AS (
+    DELETE FROM webhook_deliveries
+    WHERE
+      id = sqlc.arg ('id')
+    RETURNING
+      webhook_id,
+      event_id,
+      event_type,
+      payload,
+      event_created_at
+  )
+INSERT INTO
+  webhook_dead_letters (
+    webhook_id,
+    event_id,
+    event_type,
+    payload,
+    event_created_at,
+    attempts,
+    last_error,
+    last_status_code
+  )
+SELECT
+  webhook_id,
+  event_id,
+  event_type,
+  payload,
+  event_created_at,
+  sqlc.arg ('attempts')::int,
+  sqlc.narg ('last_error')::text,
+  sqlc.narg ('last_status_code')::int
+FROM
+  delivery;
+
+-- name: GetWebhookDeadLetters :many
+SELECT
+  *,
+  COUNT(*) OVER () as total_count
+FROM
+  webhook_dead_letters
+WHERE
+  webhook_id = $1
+ORDER BY
+  failed_at DESC
+LIMIT
+  $2
+OFFSET
+  $3;
+
+-- name: ReplayWebhookDeadLetter :one
+WITH
+  dead_letter AS (
+    DELETE FROM webhook_dead_letters
+    WHERE
+      id = $1
+      AND webhook_id = $2
+    RETURNING
+      webhook_id,
+      event_id,
+      event_type,
+      payload,
+      event_created_at
+  )
+INSERT INTO
+  webhook_deliveries (
+    webhook_id,
+    event_id,
+    event_type,
+    payload,
+    event_created_at
+  )
+SELECT
+  webhook_id,
+  event_id,
+  event_type,
+  payload,
+  event_created_at
+FROM
+  dead_letter
+RETURNING
+  id;
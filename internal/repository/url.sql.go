@@ -10,36 +10,137 @@ import (
 	"time"
 )
 
+const addClickCounts = `-- name: AddClickCounts :execrows
+UPDATE urls
+SET
+  click_count = urls.click_count + counts.clicks,
+  last_clicked_at = GREATEST(urls.last_clicked_at, counts.last_clicked_at)
+FROM
+  (
+    SELECT
+      UNNEST($1::text[]) AS id,
+      UNNEST($2::bigint[]) AS clicks,
+      UNNEST($3::timestamptz[]) AS last_clicked_at
+  ) AS counts
+WHERE
+  urls.id = counts.id
+`
+
+type AddClickCountsParams struct {
+	Ids           []string    `json:"ids"`
+	Clicks        []int64     `json:"clicks"`
+	LastClickedAt []time.Time `json:"lastClickedAt"`
+}
+
+// AddClickCounts
+//
+//	UPDATE urls
+//	SET
+//	  click_count = urls.click_count + counts.clicks,
+//	  last_clicked_at = GREATEST(urls.last_clicked_at, counts.last_clicked_at)
+//	FROM
+//	  (
+//	    SELECT
+//	      UNNEST($1::text[]) AS id,
+//	      UNNEST($2::bigint[]) AS clicks,
+//	      UNNEST($3::timestamptz[]) AS last_clicked_at
+//	  ) AS counts
+//	WHERE
+//	  urls.id = counts.id
+func (q *Queries) AddClickCounts(ctx context.Context, arg AddClickCountsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addClickCounts, arg.Ids, arg.Clicks, arg.LastClickedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const consumeClick = `-- name: ConsumeClick :one
+UPDATE urls
+SET
+  remaining_clicks = remaining_clicks - 1
+WHERE
+  id = $1
+  AND remaining_clicks > 0
+RETURNING
+  remaining_clicks
+`
+
+// ConsumeClick
+//
+//	UPDATE urls
+//	SET
+//	  remaining_clicks = remaining_clicks - 1
+//	WHERE
+//	  id = $1
+//	  AND remaining_clicks > 0
+//	RETURNING
+//	  remaining_clicks
+func (q *Queries) ConsumeClick(ctx context.Context, id string) (*int32, error) {
+	row := q.db.QueryRow(ctx, consumeClick, id)
+	var remaining_clicks *int32
+	err := row.Scan(&remaining_clicks)
+	return remaining_clicks, err
+}
+
 const createUrl = `-- name: CreateUrl :one
 INSERT INTO
-  urls (id, long_url, is_custom, user_id)
+  urls (
+    id,
+    long_url,
+    original_url,
+    is_custom,
+    user_id,
+    expires_at,
+    max_clicks,
+    remaining_clicks,
+    password_hash
+  )
 VALUES
-  ($1, $2, $3, $4)
+  ($1, $2, $3, $4, $5, $6, $7, $7, $8)
 RETURNING
-  id, long_url, created_at, is_custom, user_id
+  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
 `
 
 type CreateUrlParams struct {
-	ID       string  `json:"id"`
-	LongUrl  string  `json:"longUrl"`
-	IsCustom bool    `json:"isCustom"`
-	UserID   *string `json:"userId"`
+	ID           string     `json:"id"`
+	LongUrl      string     `json:"longUrl"`
+	OriginalUrl  string     `json:"originalUrl"`
+	IsCustom     bool       `json:"isCustom"`
+	UserID       *string    `json:"userId"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	MaxClicks    *int32     `json:"maxClicks"`
+	PasswordHash *string    `json:"-"`
 }
 
 // CreateUrl
 //
 //	INSERT INTO
-//	  urls (id, long_url, is_custom, user_id)
+//	  urls (
+//	    id,
+//	    long_url,
+//	    original_url,
+//	    is_custom,
+//	    user_id,
+//	    expires_at,
+//	    max_clicks,
+//	    remaining_clicks,
+//	    password_hash
+//	  )
 //	VALUES
-//	  ($1, $2, $3, $4)
+//	  ($1, $2, $3, $4, $5, $6, $7, $7, $8)
 //	RETURNING
-//	  id, long_url, created_at, is_custom, user_id
+//	  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
 func (q *Queries) CreateUrl(ctx context.Context, arg CreateUrlParams) (Url, error) {
 	row := q.db.QueryRow(ctx, createUrl,
 		arg.ID,
 		arg.LongUrl,
+		arg.OriginalUrl,
 		arg.IsCustom,
 		arg.UserID,
+		arg.ExpiresAt,
+		arg.MaxClicks,
+		arg.PasswordHash,
 	)
 	var i Url
 	err := row.Scan(
@@ -48,15 +149,27 @@ func (q *Queries) CreateUrl(ctx context.Context, arg CreateUrlParams) (Url, erro
 		&i.CreatedAt,
 		&i.IsCustom,
 		&i.UserID,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.RemainingClicks,
+		&i.PasswordHash,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ClickCount,
+		&i.LastClickedAt,
+		&i.OriginalUrl,
 	)
 	return i, err
 }
 
 const deleteUserURL = `-- name: DeleteUserURL :execrows
-DELETE FROM urls
+UPDATE urls
+SET
+  deleted_at = NOW()
 WHERE
   id = $1
   AND user_id = $2
+  AND deleted_at IS NULL
 `
 
 type DeleteUserURLParams struct {
@@ -66,10 +179,13 @@ type DeleteUserURLParams struct {
 
 // DeleteUserURL
 //
-//	DELETE FROM urls
+//	UPDATE urls
+//	SET
+//	  deleted_at = NOW()
 //	WHERE
 //	  id = $1
 //	  AND user_id = $2
+//	  AND deleted_at IS NULL
 func (q *Queries) DeleteUserURL(ctx context.Context, arg DeleteUserURLParams) (int64, error) {
 	result, err := q.db.Exec(ctx, deleteUserURL, arg.ID, arg.UserID)
 	if err != nil {
@@ -78,32 +194,419 @@ func (q *Queries) DeleteUserURL(ctx context.Context, arg DeleteUserURLParams) (i
 	return result.RowsAffected(), nil
 }
 
-const getLongUrl = `-- name: GetLongUrl :one
+const getAllUserUrls = `-- name: GetAllUserUrls :many
 SELECT
+  id,
   long_url
 FROM
   urls
 WHERE
-  id = $1
+  user_id = $1
+  AND deleted_at IS NULL
+`
+
+type GetAllUserUrlsRow struct {
+	ID      string `json:"id"`
+	LongUrl string `json:"longUrl"`
+}
+
+// GetAllUserUrls
+//
+//	SELECT
+//	  id,
+//	  long_url
+//	FROM
+//	  urls
+//	WHERE
+//	  user_id = $1
+//	  AND deleted_at IS NULL
+func (q *Queries) GetAllUserUrls(ctx context.Context, userID *string) ([]GetAllUserUrlsRow, error) {
+	rows, err := q.db.Query(ctx, getAllUserUrls, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAllUserUrlsRow{}
+	for rows.Next() {
+		var i GetAllUserUrlsRow
+		if err := rows.Scan(&i.ID, &i.LongUrl); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLongUrl = `-- name: GetLongUrl :one
+SELECT
+  long_url,
+  expires_at,
+  remaining_clicks,
+  password_hash,
+  deleted_at,
+  EXISTS (
+    SELECT
+      1
+    FROM
+      user_blocks
+    WHERE
+      user_blocks.user_id = urls.user_id
+      AND user_blocks.unblocked_at IS NULL
+      AND (
+        user_blocks.blocked_until IS NULL
+        OR user_blocks.blocked_until > NOW()
+      )
+  ) AS owner_blocked,
+  disabled_urls.reason AS disabled_reason
+FROM
+  urls
+  LEFT JOIN disabled_urls ON disabled_urls.url_id = urls.id
+WHERE
+  urls.id = $1
 LIMIT
   1
 `
 
+type GetLongUrlRow struct {
+	LongUrl         string     `json:"longUrl"`
+	ExpiresAt       *time.Time `json:"expiresAt"`
+	RemainingClicks *int32     `json:"remainingClicks"`
+	PasswordHash    *string    `json:"-"`
+	DeletedAt       *time.Time `json:"deletedAt"`
+	OwnerBlocked    bool       `json:"ownerBlocked"`
+	DisabledReason  *string    `json:"disabledReason"`
+}
+
 // GetLongUrl
 //
 //	SELECT
-//	  long_url
+//	  long_url,
+//	  expires_at,
+//	  remaining_clicks,
+//	  password_hash,
+//	  deleted_at,
+//	  EXISTS (
+//	    SELECT
+//	      1
+//	    FROM
+//	      user_blocks
+//	    WHERE
+//	      user_blocks.user_id = urls.user_id
+//	      AND user_blocks.unblocked_at IS NULL
+//	      AND (
+//	        user_blocks.blocked_until IS NULL
+//	        OR user_blocks.blocked_until > NOW()
+//	      )
+//	  ) AS owner_blocked,
+//	  disabled_urls.reason AS disabled_reason
 //	FROM
 //	  urls
+//	  LEFT JOIN disabled_urls ON disabled_urls.url_id = urls.id
 //	WHERE
-//	  id = $1
+//	  urls.id = $1
 //	LIMIT
 //	  1
-func (q *Queries) GetLongUrl(ctx context.Context, id string) (string, error) {
+func (q *Queries) GetLongUrl(ctx context.Context, id string) (GetLongUrlRow, error) {
 	row := q.db.QueryRow(ctx, getLongUrl, id)
-	var long_url string
-	err := row.Scan(&long_url)
-	return long_url, err
+	var i GetLongUrlRow
+	err := row.Scan(
+		&i.LongUrl,
+		&i.ExpiresAt,
+		&i.RemainingClicks,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.OwnerBlocked,
+		&i.DisabledReason,
+	)
+	return i, err
+}
+
+const getReusableUserURL = `-- name: GetReusableUserURL :one
+SELECT
+  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+FROM
+  urls
+WHERE
+  user_id = $1
+  AND md5(long_url) = md5($2)
+  AND long_url = $2
+  AND NOT is_custom
+  AND deleted_at IS NULL
+  AND expires_at IS NULL
+  AND max_clicks IS NULL
+  AND password_hash IS NULL
+  AND NOT EXISTS (
+    SELECT
+      1
+    FROM
+      disabled_urls
+    WHERE
+      disabled_urls.url_id = urls.id
+  )
+ORDER BY
+  created_at
+LIMIT
+  1
+`
+
+type GetReusableUserURLParams struct {
+	UserID  *string `json:"userId"`
+	LongUrl string  `json:"longUrl"`
+}
+
+// GetReusableUserURL
+//
+//	SELECT
+//	  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+//	FROM
+//	  urls
+//	WHERE
+//	  user_id = $1
+//	  AND md5(long_url) = md5($2)
+//	  AND long_url = $2
+//	  AND NOT is_custom
+//	  AND deleted_at IS NULL
+//	  AND expires_at IS NULL
+//	  AND max_clicks IS NULL
+//	  AND password_hash IS NULL
+//	  AND NOT EXISTS (
+//	    SELECT
+//	      1
+//	    FROM
+//	      disabled_urls
+//	    WHERE
+//	      disabled_urls.url_id = urls.id
+//	  )
+//	ORDER BY
+//	  created_at
+//	LIMIT
+//	  1
+func (q *Queries) GetReusableUserURL(ctx context.Context, arg GetReusableUserURLParams) (Url, error) {
+	row := q.db.QueryRow(ctx, getReusableUserURL, arg.UserID, arg.LongUrl)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.LongUrl,
+		&i.CreatedAt,
+		&i.IsCustom,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.RemainingClicks,
+		&i.PasswordHash,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ClickCount,
+		&i.LastClickedAt,
+		&i.OriginalUrl,
+	)
+	return i, err
+}
+
+const getUrl = `-- name: GetUrl :one
+SELECT
+  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+FROM
+  urls
+WHERE
+  id = $1
+LIMIT
+  1
+`
+
+// GetUrl
+//
+//	SELECT
+//	  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+//	FROM
+//	  urls
+//	WHERE
+//	  id = $1
+//	LIMIT
+//	  1
+func (q *Queries) GetUrl(ctx context.Context, id string) (Url, error) {
+	row := q.db.QueryRow(ctx, getUrl, id)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.LongUrl,
+		&i.CreatedAt,
+		&i.IsCustom,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.RemainingClicks,
+		&i.PasswordHash,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ClickCount,
+		&i.LastClickedAt,
+		&i.OriginalUrl,
+	)
+	return i, err
+}
+
+const getUrlsByIDs = `-- name: GetUrlsByIDs :many
+SELECT
+  id,
+  long_url,
+  user_id
+FROM
+  urls
+WHERE
+  id = ANY ($1::text[])
+  AND deleted_at IS NULL
+`
+
+type GetUrlsByIDsRow struct {
+	ID      string  `json:"id"`
+	LongUrl string  `json:"longUrl"`
+	UserID  *string `json:"userId"`
+}
+
+// GetUrlsByIDs
+//
+//	SELECT
+//	  id,
+//	  long_url,
+//	  user_id
+//	FROM
+//	  urls
+//	WHERE
+//	  id = ANY ($1::text[])
+//	  AND deleted_at IS NULL
+func (q *Queries) GetUrlsByIDs(ctx context.Context, ids []string) ([]GetUrlsByIDsRow, error) {
+	rows, err := q.db.Query(ctx, getUrlsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetUrlsByIDsRow{}
+	for rows.Next() {
+		var i GetUrlsByIDsRow
+		if err := rows.Scan(&i.ID, &i.LongUrl, &i.UserID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserDeletedUrls = `-- name: GetUserDeletedUrls :many
+SELECT
+  id,
+  long_url,
+  created_at,
+  is_custom,
+  expires_at,
+  max_clicks,
+  remaining_clicks,
+  password_hash IS NOT NULL AS password_protected,
+  updated_at,
+  deleted_at,
+  click_count,
+  last_clicked_at,
+  COUNT(*) OVER () as total_count
+FROM
+  urls
+WHERE
+  user_id = $1
+  AND deleted_at IS NOT NULL
+ORDER BY
+  deleted_at DESC
+LIMIT
+  $2
+OFFSET
+  $3
+`
+
+type GetUserDeletedUrlsParams struct {
+	UserID *string `json:"userId"`
+	Limit  int32   `json:"limit"`
+	Offset int32   `json:"offset"`
+}
+
+type GetUserDeletedUrlsRow struct {
+	ID                string     `json:"id"`
+	LongUrl           string     `json:"longUrl"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	IsCustom          bool       `json:"isCustom"`
+	ExpiresAt         *time.Time `json:"expiresAt"`
+	MaxClicks         *int32     `json:"maxClicks"`
+	RemainingClicks   *int32     `json:"remainingClicks"`
+	PasswordProtected bool       `json:"passwordProtected"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	DeletedAt         *time.Time `json:"deletedAt"`
+	ClickCount        int64      `json:"clickCount"`
+	LastClickedAt     *time.Time `json:"lastClickedAt"`
+	TotalCount        int64      `json:"totalCount"`
+}
+
+// GetUserDeletedUrls
+//
+//	SELECT
+//	  id,
+//	  long_url,
+//	  created_at,
+//	  is_custom,
+//	  expires_at,
+//	  max_clicks,
+//	  remaining_clicks,
+//	  password_hash IS NOT NULL AS password_protected,
+//	  updated_at,
+//	  deleted_at,
+//	  click_count,
+//	  last_clicked_at,
+//	  COUNT(*) OVER () as total_count
+//	FROM
+//	  urls
+//	WHERE
+//	  user_id = $1
+//	  AND deleted_at IS NOT NULL
+//	ORDER BY
+//	  deleted_at DESC
+//	LIMIT
+//	  $2
+//	OFFSET
+//	  $3
+func (q *Queries) GetUserDeletedUrls(ctx context.Context, arg GetUserDeletedUrlsParams) ([]GetUserDeletedUrlsRow, error) {
+	rows, err := q.db.Query(ctx, getUserDeletedUrls, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetUserDeletedUrlsRow{}
+	for rows.Next() {
+		var i GetUserDeletedUrlsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LongUrl,
+			&i.CreatedAt,
+			&i.IsCustom,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.RemainingClicks,
+			&i.PasswordProtected,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ClickCount,
+			&i.LastClickedAt,
+			&i.TotalCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getUserUrls = `-- name: GetUserUrls :many
@@ -112,31 +615,53 @@ SELECT
   long_url,
   created_at,
   is_custom,
+  expires_at,
+  max_clicks,
+  remaining_clicks,
+  password_hash IS NOT NULL AS password_protected,
+  updated_at,
+  click_count,
+  last_clicked_at,
   COUNT(*) OVER () as total_count
 FROM
   urls
 WHERE
   user_id = $1
+  AND deleted_at IS NULL
 ORDER BY
+  CASE
+    WHEN $2::text = 'clickCount' THEN click_count
+  END DESC,
+  CASE
+    WHEN $2::text = 'lastClickedAt' THEN last_clicked_at
+  END DESC NULLS LAST,
   created_at DESC
 LIMIT
-  $2
+  $4
 OFFSET
   $3
 `
 
 type GetUserUrlsParams struct {
 	UserID *string `json:"userId"`
-	Limit  int32   `json:"limit"`
+	SortBy string  `json:"sortBy"`
 	Offset int32   `json:"offset"`
+	Limit  int32   `json:"limit"`
 }
 
 type GetUserUrlsRow struct {
-	ID         string    `json:"id"`
-	LongUrl    string    `json:"longUrl"`
-	CreatedAt  time.Time `json:"createdAt"`
-	IsCustom   bool      `json:"isCustom"`
-	TotalCount int64     `json:"totalCount"`
+	ID                string     `json:"id"`
+	LongUrl           string     `json:"longUrl"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	IsCustom          bool       `json:"isCustom"`
+	ExpiresAt         *time.Time `json:"expiresAt"`
+	MaxClicks         *int32     `json:"maxClicks"`
+	RemainingClicks   *int32     `json:"remainingClicks"`
+	PasswordProtected bool       `json:"passwordProtected"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	ClickCount        int64      `json:"clickCount"`
+	LastClickedAt     *time.Time `json:"lastClickedAt"`
+	TotalCount        int64      `json:"totalCount"`
 }
 
 // GetUserUrls
@@ -146,19 +671,38 @@ type GetUserUrlsRow struct {
 //	  long_url,
 //	  created_at,
 //	  is_custom,
+//	  expires_at,
+//	  max_clicks,
+//	  remaining_clicks,
+//	  password_hash IS NOT NULL AS password_protected,
+//	  updated_at,
+//	  click_count,
+//	  last_clicked_at,
 //	  COUNT(*) OVER () as total_count
 //	FROM
 //	  urls
 //	WHERE
 //	  user_id = $1
+//	  AND deleted_at IS NULL
 //	ORDER BY
+//	  CASE
+//	    WHEN $2::text = 'clickCount' THEN click_count
+//	  END DESC,
+//	  CASE
+//	    WHEN $2::text = 'lastClickedAt' THEN last_clicked_at
+//	  END DESC NULLS LAST,
 //	  created_at DESC
 //	LIMIT
-//	  $2
+//	  $4
 //	OFFSET
 //	  $3
 func (q *Queries) GetUserUrls(ctx context.Context, arg GetUserUrlsParams) ([]GetUserUrlsRow, error) {
-	rows, err := q.db.Query(ctx, getUserUrls, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getUserUrls,
+		arg.UserID,
+		arg.SortBy,
+		arg.Offset,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -171,6 +715,13 @@ func (q *Queries) GetUserUrls(ctx context.Context, arg GetUserUrlsParams) ([]Get
 			&i.LongUrl,
 			&i.CreatedAt,
 			&i.IsCustom,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.RemainingClicks,
+			&i.PasswordProtected,
+			&i.UpdatedAt,
+			&i.ClickCount,
+			&i.LastClickedAt,
 			&i.TotalCount,
 		); err != nil {
 			return nil, err
@@ -182,3 +733,136 @@ func (q *Queries) GetUserUrls(ctx context.Context, arg GetUserUrlsParams) ([]Get
 	}
 	return items, nil
 }
+
+const nextShortCode = `-- name: NextShortCode :one
+SELECT
+  nextval('short_code_seq')
+`
+
+// NextShortCode
+//
+//	SELECT
+//	  nextval('short_code_seq')
+func (q *Queries) NextShortCode(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, nextShortCode)
+	var nextval int64
+	err := row.Scan(&nextval)
+	return nextval, err
+}
+
+const restoreUserURL = `-- name: RestoreUserURL :one
+UPDATE urls
+SET
+  deleted_at = NULL,
+  updated_at = NOW()
+WHERE
+  id = $1
+  AND user_id = $2
+  AND deleted_at IS NOT NULL
+RETURNING
+  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+`
+
+type RestoreUserURLParams struct {
+	ID     string  `json:"id"`
+	UserID *string `json:"userId"`
+}
+
+// RestoreUserURL
+//
+//	UPDATE urls
+//	SET
+//	  deleted_at = NULL,
+//	  updated_at = NOW()
+//	WHERE
+//	  id = $1
+//	  AND user_id = $2
+//	  AND deleted_at IS NOT NULL
+//	RETURNING
+//	  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+func (q *Queries) RestoreUserURL(ctx context.Context, arg RestoreUserURLParams) (Url, error) {
+	row := q.db.QueryRow(ctx, restoreUserURL, arg.ID, arg.UserID)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.LongUrl,
+		&i.CreatedAt,
+		&i.IsCustom,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.RemainingClicks,
+		&i.PasswordHash,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ClickCount,
+		&i.LastClickedAt,
+		&i.OriginalUrl,
+	)
+	return i, err
+}
+
+const updateUserURL = `-- name: UpdateUserURL :one
+UPDATE urls
+SET
+  long_url = $1,
+  original_url = $2,
+  updated_at = NOW()
+WHERE
+  id = $3
+  AND user_id = $4
+  AND updated_at = $5
+  AND deleted_at IS NULL
+RETURNING
+  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+`
+
+type UpdateUserURLParams struct {
+	LongUrl     string    `json:"longUrl"`
+	OriginalUrl string    `json:"originalUrl"`
+	ID          string    `json:"id"`
+	UserID      *string   `json:"userId"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// UpdateUserURL
+//
+//	UPDATE urls
+//	SET
+//	  long_url = $1,
+//	  original_url = $2,
+//	  updated_at = NOW()
+//	WHERE
+//	  id = $3
+//	  AND user_id = $4
+//	  AND updated_at = $5
+//	  AND deleted_at IS NULL
+//	RETURNING
+//	  id, long_url, created_at, is_custom, user_id, expires_at, max_clicks, remaining_clicks, password_hash, updated_at, deleted_at, click_count, last_clicked_at, original_url
+func (q *Queries) UpdateUserURL(ctx context.Context, arg UpdateUserURLParams) (Url, error) {
+	row := q.db.QueryRow(ctx, updateUserURL,
+		arg.LongUrl,
+		arg.OriginalUrl,
+		arg.ID,
+		arg.UserID,
+		arg.UpdatedAt,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.LongUrl,
+		&i.CreatedAt,
+		&i.IsCustom,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.RemainingClicks,
+		&i.PasswordHash,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ClickCount,
+		&i.LastClickedAt,
+		&i.OriginalUrl,
+	)
+	return i, err
+}
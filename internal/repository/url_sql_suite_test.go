@@ -6,6 +6,7 @@ import (
 	"io"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -124,9 +125,55 @@ func (suite *UrlTestSuite) TestGetLongUrl() {
 	_, err = suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url", LongUrl: "https://long.url"})
 	assert.NoError(t, err)
 
-	longUrl, err := suite.queries.GetLongUrl(suite.ctx, "short-url")
+	url, err := suite.queries.GetLongUrl(suite.ctx, "short-url")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://long.url", url.LongUrl)
+	assert.Nil(t, url.ExpiresAt)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	_, err = suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url2", LongUrl: "https://long.url", ExpiresAt: &expiresAt})
+	assert.NoError(t, err)
+
+	url, err = suite.queries.GetLongUrl(suite.ctx, "short-url2")
+	assert.NoError(t, err)
+	if assert.NotNil(t, url.ExpiresAt) {
+		assert.True(t, expiresAt.Equal(*url.ExpiresAt), "expiresAt does not match")
+	}
+}
+
+func (suite *UrlTestSuite) TestConsumeClick() {
+	t := suite.T()
+
+	_, err := suite.queries.ConsumeClick(suite.ctx, "short-url")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// Links without a click limit are not affected
+	_, err = suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url", LongUrl: "https://long.url"})
+	assert.NoError(t, err)
+
+	_, err = suite.queries.ConsumeClick(suite.ctx, "short-url")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	maxClicks := int32(2)
+	url, err := suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url2", LongUrl: "https://long.url", MaxClicks: &maxClicks})
+	assert.NoError(t, err)
+	assert.Equal(t, &maxClicks, url.MaxClicks)
+	assert.Equal(t, &maxClicks, url.RemainingClicks)
+
+	remaining, err := suite.queries.ConsumeClick(suite.ctx, "short-url2")
 	assert.NoError(t, err)
-	assert.Equal(t, "https://long.url", longUrl)
+	assert.Equal(t, int32(1), *remaining)
+
+	remaining, err = suite.queries.ConsumeClick(suite.ctx, "short-url2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), *remaining)
+
+	_, err = suite.queries.ConsumeClick(suite.ctx, "short-url2")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	longUrl, err := suite.queries.GetLongUrl(suite.ctx, "short-url2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), *longUrl.RemainingClicks)
 }
 
 func (suite *UrlTestSuite) TestGetUserUrls() {
@@ -167,6 +214,70 @@ func (suite *UrlTestSuite) TestGetUserUrls() {
 	urls, err = suite.queries.GetUserUrls(suite.ctx, GetUserUrlsParams{UserID: &userID_2, Limit: 25, Offset: 0})
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(urls))
+
+	// Sort by popularity
+	now := time.Now()
+	_, err = suite.queries.AddClickCounts(suite.ctx, AddClickCountsParams{
+		Ids:           []string{"short-url2", "short-url4"},
+		Clicks:        []int64{5, 10},
+		LastClickedAt: []time.Time{now, now.Add(-time.Hour)},
+	})
+	assert.NoError(t, err)
+
+	urls, err = suite.queries.GetUserUrls(suite.ctx, GetUserUrlsParams{UserID: &userID_1, SortBy: "clickCount", Limit: 2, Offset: 0})
+	assert.NoError(t, err)
+	if assert.Len(t, urls, 2) {
+		assert.Equal(t, "short-url4", urls[0].ID)
+		assert.Equal(t, "short-url2", urls[1].ID)
+	}
+
+	urls, err = suite.queries.GetUserUrls(suite.ctx, GetUserUrlsParams{UserID: &userID_1, SortBy: "lastClickedAt", Limit: 25, Offset: 0})
+	assert.NoError(t, err)
+	if assert.Len(t, urls, 5) {
+		assert.Equal(t, "short-url2", urls[0].ID)
+		assert.Equal(t, "short-url4", urls[1].ID)
+		assert.Nil(t, urls[4].LastClickedAt, "never clicked urls should come last")
+	}
+}
+
+func (suite *UrlTestSuite) TestGetUrl() {
+	t := suite.T()
+
+	_, err := suite.queries.GetUrl(suite.ctx, "short-url")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	userID := "user-id"
+	createdUrl, err := suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url", LongUrl: "https://long.url", UserID: &userID})
+	assert.NoError(t, err)
+
+	url, err := suite.queries.GetUrl(suite.ctx, "short-url")
+	assert.NoError(t, err)
+	assert.Equal(t, createdUrl, url)
+	assert.Equal(t, url.CreatedAt, url.UpdatedAt)
+}
+
+func (suite *UrlTestSuite) TestUpdateUserURL() {
+	t := suite.T()
+
+	var (
+		userID      = "user-id"
+		otherUserID = "other-user-id"
+	)
+
+	createdUrl, err := suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url", LongUrl: "https://long.url", UserID: &userID})
+	assert.NoError(t, err)
+
+	_, err = suite.queries.UpdateUserURL(suite.ctx, UpdateUserURLParams{LongUrl: "https://new-long.url", ID: "short-url", UserID: &otherUserID, UpdatedAt: createdUrl.UpdatedAt})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "url of another user must not be updated")
+
+	updatedUrl, err := suite.queries.UpdateUserURL(suite.ctx, UpdateUserURLParams{LongUrl: "https://new-long.url", ID: "short-url", UserID: &userID, UpdatedAt: createdUrl.UpdatedAt})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://new-long.url", updatedUrl.LongUrl)
+	assert.Equal(t, createdUrl.ID, updatedUrl.ID)
+	assert.True(t, updatedUrl.UpdatedAt.After(createdUrl.UpdatedAt), "updatedAt should be bumped")
+
+	_, err = suite.queries.UpdateUserURL(suite.ctx, UpdateUserURLParams{LongUrl: "https://stale-long.url", ID: "short-url", UserID: &userID, UpdatedAt: createdUrl.UpdatedAt})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "stale updatedAt must not update the url")
 }
 
 func (suite *UrlTestSuite) TestDeleteShortUrl() {
@@ -186,6 +297,234 @@ func (suite *UrlTestSuite) TestDeleteShortUrl() {
 	assert.Equal(t, int64(1), rowsAffected)
 }
 
+func (suite *UrlTestSuite) TestDeletedUrl() {
+	t := suite.T()
+
+	var (
+		userID      = "user-id"
+		otherUserID = "other-user-id"
+	)
+
+	_, err := suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url", LongUrl: "https://long.url", UserID: &userID})
+	assert.NoError(t, err)
+
+	rowsAffected, err := suite.queries.DeleteUserURL(suite.ctx, DeleteUserURLParams{ID: "short-url", UserID: &userID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rowsAffected)
+
+	// The deleted url keeps its code reserved
+	_, err = suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url", LongUrl: "https://other-long.url", UserID: &otherUserID})
+	assert.True(t, suite.queries.IsDuplicateKeyError(err), "code of a deleted url must stay reserved")
+
+	longUrl, err := suite.queries.GetLongUrl(suite.ctx, "short-url")
+	assert.NoError(t, err)
+	assert.NotNil(t, longUrl.DeletedAt, "deletedAt should be set")
+
+	urls, err := suite.queries.GetUserUrls(suite.ctx, GetUserUrlsParams{UserID: &userID, Limit: 10, Offset: 0})
+	assert.NoError(t, err)
+	assert.Empty(t, urls, "deleted url must not be listed")
+
+	deletedUrls, err := suite.queries.GetUserDeletedUrls(suite.ctx, GetUserDeletedUrlsParams{UserID: &userID, Limit: 10, Offset: 0})
+	assert.NoError(t, err)
+	if assert.Len(t, deletedUrls, 1) {
+		assert.Equal(t, "short-url", deletedUrls[0].ID)
+		assert.NotNil(t, deletedUrls[0].DeletedAt, "deletedAt should be set")
+	}
+}
+
+func (suite *UrlTestSuite) TestRestoreUserURL() {
+	t := suite.T()
+
+	var (
+		userID      = "user-id"
+		otherUserID = "other-user-id"
+	)
+
+	createdUrl, err := suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url", LongUrl: "https://long.url", UserID: &userID})
+	assert.NoError(t, err)
+
+	_, err = suite.queries.RestoreUserURL(suite.ctx, RestoreUserURLParams{ID: "short-url", UserID: &userID})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "url that is not deleted must not be restored")
+
+	_, err = suite.queries.DeleteUserURL(suite.ctx, DeleteUserURLParams{ID: "short-url", UserID: &userID})
+	assert.NoError(t, err)
+
+	_, err = suite.queries.RestoreUserURL(suite.ctx, RestoreUserURLParams{ID: "short-url", UserID: &otherUserID})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "url of another user must not be restored")
+
+	restoredUrl, err := suite.queries.RestoreUserURL(suite.ctx, RestoreUserURLParams{ID: "short-url", UserID: &userID})
+	assert.NoError(t, err)
+	assert.Nil(t, restoredUrl.DeletedAt, "deletedAt should be cleared")
+	assert.Equal(t, createdUrl.LongUrl, restoredUrl.LongUrl)
+	assert.True(t, restoredUrl.UpdatedAt.After(createdUrl.UpdatedAt), "updatedAt should be bumped")
+}
+
+func (suite *UrlTestSuite) TestClicks() {
+	t := suite.T()
+
+	var (
+		now      = time.Now()
+		referrer = "news.example.org"
+	)
+
+	_, err := suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url", LongUrl: "https://long.url"})
+	assert.NoError(t, err)
+
+	clicks := []CreateClicksParams{
+		{UrlID: "short-url", ClickedAt: now.AddDate(0, 0, -10), Browser: "Firefox", Device: "desktop"},
+		{UrlID: "short-url", ClickedAt: now, ReferrerHost: &referrer, Browser: "Firefox", Device: "desktop"},
+		{UrlID: "short-url", ClickedAt: now, ReferrerHost: &referrer, Browser: "Safari", Device: "mobile"},
+	}
+	written, err := suite.queries.CreateClicks(suite.ctx, clicks)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(clicks)), written)
+
+	_, err = suite.queries.CreateClicks(suite.ctx, []CreateClicksParams{{UrlID: "non-existent", ClickedAt: now, Browser: "Other", Device: "unknown"}})
+	assert.Error(t, err, "click of a non-existent url must not be created")
+
+	total, err := suite.queries.CountClicks(suite.ctx, CountClicksParams{UrlID: "short-url", Since: now.AddDate(0, 0, -30)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	total, err = suite.queries.CountClicks(suite.ctx, CountClicksParams{UrlID: "short-url", Since: now.AddDate(0, 0, -1)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	byDay, err := suite.queries.GetClicksByDay(suite.ctx, GetClicksByDayParams{UrlID: "short-url", Since: now.AddDate(0, 0, -30)})
+	assert.NoError(t, err)
+	assert.Len(t, byDay, 2)
+
+	byReferrerHost, err := suite.queries.GetClicksByReferrerHost(suite.ctx, GetClicksByReferrerHostParams{UrlID: "short-url", Since: now.AddDate(0, 0, -30), Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []GetClicksByReferrerHostRow{{ReferrerHost: referrer, Clicks: 2}, {ReferrerHost: "", Clicks: 1}}, byReferrerHost)
+
+	byBrowser, err := suite.queries.GetClicksByBrowser(suite.ctx, GetClicksByBrowserParams{UrlID: "short-url", Since: now.AddDate(0, 0, -30), Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []GetClicksByBrowserRow{{Browser: "Firefox", Clicks: 2}}, byBrowser)
+
+	byDevice, err := suite.queries.GetClicksByDevice(suite.ctx, GetClicksByDeviceParams{UrlID: "short-url", Since: now.AddDate(0, 0, -1), Limit: 10})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []GetClicksByDeviceRow{{Device: "desktop", Clicks: 1}, {Device: "mobile", Clicks: 1}}, byDevice)
+}
+
+func (suite *UrlTestSuite) TestAddClickCounts() {
+	t := suite.T()
+
+	var (
+		now     = time.Now().Truncate(time.Microsecond)
+		earlier = now.Add(-time.Hour)
+	)
+
+	_, err := suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "short-url", LongUrl: "https://long.url"})
+	assert.NoError(t, err)
+
+	updated, err := suite.queries.AddClickCounts(suite.ctx, AddClickCountsParams{
+		Ids:           []string{"short-url", "non-existent"},
+		Clicks:        []int64{3, 1},
+		LastClickedAt: []time.Time{now, now},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), updated, "only existing urls should be updated")
+
+	// Counts are added up and the last click time never goes back
+	_, err = suite.queries.AddClickCounts(suite.ctx, AddClickCountsParams{
+		Ids:           []string{"short-url"},
+		Clicks:        []int64{2},
+		LastClickedAt: []time.Time{earlier},
+	})
+	assert.NoError(t, err)
+
+	url, err := suite.queries.GetUrl(suite.ctx, "short-url")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), url.ClickCount)
+	if assert.NotNil(t, url.LastClickedAt) {
+		assert.True(t, now.Equal(*url.LastClickedAt), "last clicked at does not match")
+	}
+}
+
+func (suite *UrlTestSuite) TestWebhookOutbox() {
+	t := suite.T()
+
+	var (
+		userID      = "user-id"
+		otherUserID = "other-user-id"
+	)
+	_, err := suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "user-url", LongUrl: "https://long.url", UserID: &userID})
+	assert.NoError(t, err)
+	_, err = suite.queries.CreateUrl(suite.ctx, CreateUrlParams{ID: "other-url", LongUrl: "https://long.url", UserID: &otherUserID})
+	assert.NoError(t, err)
+
+	webhook, err := suite.queries.CreateWebhook(suite.ctx, CreateWebhookParams{
+		UserID: userID,
+		Url:    "https://example.com/webhook",
+		Events: []string{"link.created", "link.deleted"},
+		Secret: "secret",
+	})
+	assert.NoError(t, err)
+
+	// Only events of subscribed types for links of users with webhooks are written
+	for _, eventType := range []string{"link.created", "link.clicked"} {
+		err = suite.queries.CreateWebhookEvents(suite.ctx, CreateWebhookEventsParams{
+			EventType: eventType,
+			UrlIds:    []string{"user-url", "other-url"},
+			Payloads:  []string{`{"code":"user-url"}`, `{"code":"other-url"}`},
+		})
+		assert.NoError(t, err)
+	}
+
+	fannedOut, err := suite.queries.FanOutWebhookEvents(suite.ctx, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), fannedOut)
+
+	deliveries, err := suite.queries.ClaimWebhookDeliveries(suite.ctx, ClaimWebhookDeliveriesParams{LockedUntil: time.Now().Add(time.Minute), Limit: 100})
+	assert.NoError(t, err)
+	if !assert.Len(t, deliveries, 1) {
+		return
+	}
+	assert.Equal(t, webhook.ID, deliveries[0].WebhookID)
+	assert.Equal(t, "link.created", deliveries[0].EventType)
+	assert.JSONEq(t, `{"code":"user-url"}`, string(deliveries[0].Payload))
+	assert.Equal(t, "secret", deliveries[0].Secret)
+
+	// Claimed deliveries are not due until the lease ends
+	claimed, err := suite.queries.ClaimWebhookDeliveries(suite.ctx, ClaimWebhookDeliveriesParams{LockedUntil: time.Now().Add(time.Minute), Limit: 100})
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	lastError := "unexpected status 500"
+	lastStatusCode := int32(500)
+	err = suite.queries.DeadLetterWebhookDelivery(suite.ctx, DeadLetterWebhookDeliveryParams{
+		ID:             deliveries[0].ID,
+		Attempts:       3,
+		LastError:      &lastError,
+		LastStatusCode: &lastStatusCode,
+	})
+	assert.NoError(t, err)
+
+	deadLetters, err := suite.queries.GetWebhookDeadLetters(suite.ctx, GetWebhookDeadLettersParams{WebhookID: webhook.ID, Limit: 10, Offset: 0})
+	assert.NoError(t, err)
+	if !assert.Len(t, deadLetters, 1) {
+		return
+	}
+	assert.Equal(t, deliveries[0].EventID, deadLetters[0].EventID)
+	assert.Equal(t, int32(3), deadLetters[0].Attempts)
+	assert.Equal(t, &lastError, deadLetters[0].LastError)
+	assert.Equal(t, &lastStatusCode, deadLetters[0].LastStatusCode)
+
+	// Replaying moves the dead letter back to the due deliveries with fresh attempts
+	_, err = suite.queries.ReplayWebhookDeadLetter(suite.ctx, ReplayWebhookDeadLetterParams{ID: deadLetters[0].ID, WebhookID: webhook.ID})
+	assert.NoError(t, err)
+	_, err = suite.queries.ReplayWebhookDeadLetter(suite.ctx, ReplayWebhookDeadLetterParams{ID: deadLetters[0].ID, WebhookID: webhook.ID})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "dead letter should only be replayed once")
+
+	replayed, err := suite.queries.ClaimWebhookDeliveries(suite.ctx, ClaimWebhookDeliveriesParams{LockedUntil: time.Now().Add(time.Minute), Limit: 100})
+	assert.NoError(t, err)
+	if assert.Len(t, replayed, 1) {
+		assert.Equal(t, deliveries[0].EventID, replayed[0].EventID)
+		assert.Equal(t, int32(0), replayed[0].Attempts)
+	}
+}
+
 func TestUrlTestSuite(t *testing.T) {
 	suite.Run(t, new(UrlTestSuite))
 }
@@ -1,14 +1,18 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/auth0/go-auth0/v2/management/core"
 	"github.com/labstack/echo/v5"
 	"github.com/rousage/shortener/internal/auth"
 	"github.com/rousage/shortener/internal/repository"
+	"github.com/rousage/shortener/internal/webhook"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
@@ -16,6 +20,7 @@ import (
 
 type URLsFilters struct {
 	PaginationFilters
+	URLSortFilters
 	IsCustom *bool   `query:"isCustom" validate:"omitzero,boolean"`
 	UserID   *string `query:"userId" validate:"omitzero,min=1,max=50"`
 }
@@ -27,13 +32,14 @@ type PaginatedURLs struct {
 // getURLs godoc
 //
 //	@Summary		Get all URLs
-//	@Description	Retrieves a paginated list of all URLs created by users
+//	@Description	Retrieves a paginated list of all URLs created by users. Click counts are updated periodically, so they can lag behind the latest clicks.
 //	@Tags			Admin
 //	@Produce		json
 //	@Param			isCustom	query		bool				false	"Get custom URLs only"
 //	@Param			userId		query		string				false	"Get URLs created by a specific user"	minlength(1)	maxlength(50)
-//	@Param			page		query		int					true	"Page number"							minimum(1)		maximum(10000)	default(1)
-//	@Param			pageSize	query		int					true	"Page size"								minimum(1)		maximum(100)	default(20)
+//	@Param			sortBy		query		string				false	"Sort order, newest first by default"	Enums(createdAt, clickCount, lastClickedAt)
+//	@Param			page		query		int					true	"Page number"							minimum(1)	maximum(10000)	default(1)
+//	@Param			pageSize	query		int					true	"Page size"								minimum(1)	maximum(100)	default(20)
 //	@Success		200			{object}	PaginatedURLs		"Paginated list of URLs"
 //	@Failure		400			{object}	HTTPValidationError	"Validation failed"
 //	@Failure		401			{object}	HTTPError			"Unauthorized"
@@ -62,8 +68,11 @@ func (s *Server) getURLs(c *echo.Context) error {
 	if params.UserID != nil {
 		span.SetAttributes(attribute.String("userId", *params.UserID))
 	}
+	if params.SortBy != "" {
+		span.SetAttributes(attribute.String("sortBy", params.SortBy))
+	}
 
-	urls, err := s.rep.GetURLs(ctx, repository.GetURLsParams{IsCustom: params.IsCustom, UserID: params.UserID, Limit: params.limit(), Offset: params.offset()})
+	urls, err := s.rep.GetURLs(ctx, repository.GetURLsParams{IsCustom: params.IsCustom, UserID: params.UserID, SortBy: params.SortBy, Limit: params.limit(), Offset: params.offset()})
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to get urls")
 		span.RecordError(err)
@@ -79,11 +88,17 @@ func (s *Server) getURLs(c *echo.Context) error {
 	items := make([]repository.Url, len(urls))
 	for i, url := range urls {
 		items[i] = repository.Url{
-			ID:        url.ID,
-			LongUrl:   url.LongUrl,
-			CreatedAt: url.CreatedAt,
-			IsCustom:  url.IsCustom,
-			UserID:    url.UserID,
+			ID:              url.ID,
+			LongUrl:         url.LongUrl,
+			CreatedAt:       url.CreatedAt,
+			IsCustom:        url.IsCustom,
+			UserID:          url.UserID,
+			ExpiresAt:       url.ExpiresAt,
+			MaxClicks:       url.MaxClicks,
+			RemainingClicks: url.RemainingClicks,
+			UpdatedAt:       url.UpdatedAt,
+			ClickCount:      url.ClickCount,
+			LastClickedAt:   url.LastClickedAt,
 		}
 	}
 
@@ -95,6 +110,51 @@ func (s *Server) getURLs(c *echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+type UpdateURLParams struct {
+	UpdateShortUrlParams
+}
+
+// updateURLHandler godoc
+//
+//	@Summary		Update URL
+//	@Description	Changes the long URL of any short URL, keeping the short code. Also removes it from cache. Supports optimistic concurrency with the If-Match header.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			code		path		string					true	"Short code of the URL"	maxlength(16)
+//	@Param			If-Match	header		string					false	"ETag of the URL the update is based on"
+//	@Param			request		body		UpdateShortUrlDTO		true	"New long URL"
+//	@Success		200			{object}	CreateShortUrlResponse	"Updated short URL"
+//	@Header			200			{string}	ETag					"ETag of the updated URL"
+//	@Failure		400			{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401			{object}	HTTPError				"Unauthorized"
+//	@Failure		403			{object}	HTTPError				"Forbidden"
+//	@Failure		404			{object}	HTTPError				"Short URL not found"
+//	@Failure		412			{object}	HTTPError				"Short URL has been modified"
+//	@Failure		500			{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/urls/{code} [patch]
+func (s *Server) updateURLHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "admin.UpdateURLHandler")
+	defer span.End()
+
+	params := new(UpdateURLParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	originalUrl := params.URL
+	if err := s.validateCtx(ctx, c, params); err != nil {
+		span.SetStatus(codes.Error, "invalid user (admin) input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code), attribute.String("url", params.URL))
+
+	return s.updateLongUrl(ctx, c, &params.UpdateShortUrlParams, originalUrl, nil)
+}
+
 type DeleteURLParams struct {
 	GetLongUrlParams
 }
@@ -102,7 +162,7 @@ type DeleteURLParams struct {
 // deleteURLHandler godoc
 //
 //	@Summary		Delete URL
-//	@Description	Deletes a URL. Also removes it from cache.
+//	@Description	Moves a URL to the trash. Also removes it from cache. The short code stays reserved until the URL is restored or purged after the trash retention period.
 //	@Tags			Admin
 //	@Produce		json
 //	@Param			code	path	string	true	"Short code of the URL"	maxlength(16)
@@ -131,12 +191,37 @@ func (s *Server) deleteURLHandler(c *echo.Context) error {
 	}
 	span.SetAttributes(attribute.String("code", params.Code))
 
-	rowsAffected, err := s.rep.DeleteURL(ctx, params.Code)
+	audit := newAuditEntry(c, auditActionURLDelete, auditTargetURL, params.Code)
+
+	var rowsAffected int64
+	err := s.inTx(ctx, func(qtx *repository.Queries) error {
+		// The URL is read in the transaction for the audit log snapshot
+		url, err := qtx.GetUrl(ctx, params.Code)
+		if err != nil {
+			if s.rep.IsNotFoundError(err) {
+				return nil
+			}
+			return err
+		}
+		audit = audit.withSnapshots(url, nil)
+
+		rowsAffected, err = qtx.DeleteURL(ctx, params.Code)
+		if err != nil || rowsAffected == 0 {
+			return err
+		}
+
+		if err := recordAudit(ctx, qtx, audit); err != nil {
+			return err
+		}
+
+		return webhook.Queue(ctx, qtx, webhook.LinkDeleted, webhook.NewDeletedLinkEvent(params.Code, time.Now()))
+	})
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to delete url")
 		span.RecordError(err)
 
 		c.Logger().ErrorContext(ctx, "failed to delete short url", "error", err, slog.String("code", params.Code))
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
 		return echo.ErrInternalServerError
 	}
 	if rowsAffected == 0 {
@@ -160,10 +245,15 @@ type DeleteUserURLsResponse struct {
 	Deleted int `json:"deleted"`
 }
 
+// DeleteUserURLsAudit is the audit log snapshot of the URLs deleted with deleteUserURLsHandler
+type DeleteUserURLsAudit struct {
+	DeletedIDs []string `json:"deletedIds"`
+}
+
 // deleteUserURLs godoc
 //
 //	@Summary		Delete URLs created by a user
-//	@Description	Delete all URLs created by a user. Also removes them from cache.
+//	@Description	Moves all URLs created by a user to the trash. Also removes them from cache. The URLs can be restored until they are purged after the trash retention period.
 //	@Tags			Admin
 //	@Produce		json
 //	@Param			userId	path		string					true	"ID of the user"	minlength(1)	maxlength(50)
@@ -191,12 +281,34 @@ func (s *Server) deleteUserURLsHandler(c *echo.Context) error {
 	}
 	span.SetAttributes(attribute.String("userId", params.UserID))
 
-	deletedIDs, err := s.rep.DeleteAllUserURLs(ctx, params.UserID)
+	audit := newAuditEntry(c, auditActionUserURLsDelete, auditTargetUser, params.UserID)
+
+	var deletedIDs []string
+	err := s.inTx(ctx, func(qtx *repository.Queries) error {
+		var err error
+		deletedIDs, err = qtx.DeleteAllUserURLs(ctx, params.UserID)
+		if err != nil {
+			return err
+		}
+
+		if err := recordAudit(ctx, qtx, audit.withSnapshots(nil, &DeleteUserURLsAudit{DeletedIDs: deletedIDs})); err != nil {
+			return err
+		}
+
+		deletedAt := time.Now()
+		events := make([]webhook.Event, len(deletedIDs))
+		for i, id := range deletedIDs {
+			events[i] = webhook.NewDeletedLinkEvent(id, deletedAt)
+		}
+
+		return webhook.Queue(ctx, qtx, webhook.LinkDeleted, events...)
+	})
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to delete user urls")
 		span.RecordError(err)
 
 		c.Logger().ErrorContext(ctx, "failed to delete user urls", "error", err, slog.String("userId", params.UserID))
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
 		return echo.ErrInternalServerError
 	}
 
@@ -210,18 +322,166 @@ func (s *Server) deleteUserURLsHandler(c *echo.Context) error {
 	})
 }
 
+type DeletedURLsFilters struct {
+	PaginationFilters
+	UserID *string `query:"userId" validate:"omitzero,min=1,max=50"`
+}
+type TrashedURL struct {
+	repository.Url
+	// Time after which the URL is permanently removed and can no longer be restored
+	PurgeAt time.Time `json:"purgeAt"`
+}
+type PaginatedDeletedURLs struct {
+	Items      []TrashedURL `json:"items"`
+	Pagination Pagination   `json:"pagination"`
+}
+
+// getDeletedURLs godoc
+//
+//	@Summary		Get all deleted URLs
+//	@Description	Retrieves a paginated list of all URLs in the trash that can still be restored
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			userId		query		string					false	"Get URLs created by a specific user"	minlength(1)	maxlength(50)
+//	@Param			page		query		int						true	"Page number"							minimum(1)		maximum(10000)	default(1)
+//	@Param			pageSize	query		int						true	"Page size"								minimum(1)		maximum(100)	default(20)
+//	@Success		200			{object}	PaginatedDeletedURLs	"Paginated list of deleted URLs"
+//	@Failure		400			{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401			{object}	HTTPError				"Unauthorized"
+//	@Failure		403			{object}	HTTPError				"Forbidden"
+//	@Failure		500			{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/trash/urls [get]
+func (s *Server) getDeletedURLs(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "admin.GetDeletedURLs")
+	defer span.End()
+
+	params := new(DeletedURLsFilters)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+
+	span.SetAttributes(attribute.Int("page", int(params.Page)), attribute.Int("pageSize", int(params.PageSize)))
+	if params.UserID != nil {
+		span.SetAttributes(attribute.String("userId", *params.UserID))
+	}
+
+	urls, err := s.rep.GetDeletedURLs(ctx, repository.GetDeletedURLsParams{UserID: params.UserID, Limit: params.limit(), Offset: params.offset()})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get deleted urls")
+		span.RecordError(err)
+
+		return echo.ErrInternalServerError
+	}
+
+	var totalCount int
+	if len(urls) > 0 {
+		totalCount = int(urls[0].TotalCount)
+	}
+
+	items := make([]TrashedURL, len(urls))
+	for i, url := range urls {
+		items[i] = TrashedURL{
+			Url: repository.Url{
+				ID:              url.ID,
+				LongUrl:         url.LongUrl,
+				CreatedAt:       url.CreatedAt,
+				IsCustom:        url.IsCustom,
+				UserID:          url.UserID,
+				ExpiresAt:       url.ExpiresAt,
+				MaxClicks:       url.MaxClicks,
+				RemainingClicks: url.RemainingClicks,
+				UpdatedAt:       url.UpdatedAt,
+				DeletedAt:       url.DeletedAt,
+				ClickCount:      url.ClickCount,
+				LastClickedAt:   url.LastClickedAt,
+			},
+			PurgeAt: s.purgeAt(*url.DeletedAt),
+		}
+	}
+
+	response := &PaginatedDeletedURLs{
+		Items:      items,
+		Pagination: calculatePagination(totalCount, int(params.Page), int(params.PageSize)),
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+type RestoreURLParams struct {
+	GetLongUrlParams
+}
+
+// restoreURLHandler godoc
+//
+//	@Summary		Restore URL
+//	@Description	Restores any deleted URL from the trash, so the short code resolves again.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			code	path		string					true	"Short code of the URL"	maxlength(16)
+//	@Success		200		{object}	CreateShortUrlResponse	"Restored short URL"
+//	@Header			200		{string}	ETag					"ETag of the restored URL"
+//	@Failure		400		{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401		{object}	HTTPError				"Unauthorized"
+//	@Failure		403		{object}	HTTPError				"Forbidden"
+//	@Failure		404		{object}	HTTPError				"Short URL not found in the trash"
+//	@Failure		409		{object}	HTTPError				"Short code is not available"
+//	@Failure		500		{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/trash/urls/{code}/restore [post]
+func (s *Server) restoreURLHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "admin.RestoreURLHandler")
+	defer span.End()
+
+	params := new(RestoreURLParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		span.SetStatus(codes.Error, "invalid user (admin) input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code))
+
+	return s.restoreUrl(ctx, c, params.Code, nil)
+}
+
 type BlockUserDTO struct {
 	Reason *string `json:"reason" validate:"omitzero,min=1,max=255"`
+	// Time at which the user is unblocked automatically, the block is permanent if omitted
+	Until *time.Time `json:"until" validate:"omitzero,gt" example:"2026-12-31T23:59:59Z"`
 }
 type BlockUserParams struct {
 	DeleteUserURLsParams
 	BlockUserDTO
 }
 
+// UserBlockAudit is the block state of a user in the audit log
+type UserBlockAudit struct {
+	Blocked bool                  `json:"blocked"`
+	Block   *repository.UserBlock `json:"block"`
+}
+
+// unblockSnapshots returns the block state of a user before and after userBlock has been lifted
+func unblockSnapshots(userBlock repository.UserBlock) (before, after UserBlockAudit) {
+	activeBlock := userBlock
+	activeBlock.UnblockedBy, activeBlock.UnblockedAt = nil, nil
+
+	return UserBlockAudit{Blocked: true, Block: &activeBlock}, UserBlockAudit{Blocked: false, Block: &userBlock}
+}
+
 // blockUser godoc
 //
 //	@Summary		Block a user
-//	@Description	Block a user in the system, preventing them from accessing their account.
+//	@Description	Block a user in the system, preventing them from accessing their account. Blocks with an until time are lifted automatically once it has passed.
 //	@Tags			Admin
 //	@Accept			json
 //	@Produce		json
@@ -232,6 +492,7 @@ type BlockUserParams struct {
 //	@Failure		401		{object}	HTTPError				"Unauthorized"
 //	@Failure		403		{object}	HTTPError				"Forbidden"
 //	@Failure		404		{object}	HTTPError				"Not Found"
+//	@Failure		409		{object}	HTTPError				"User is already blocked"
 //	@Failure		500		{object}	HTTPError				"Internal server error"
 //	@Security		BearerAuth
 //	@Router			/v1/admin/users/block/{userId} [post]
@@ -255,6 +516,25 @@ func (s *Server) blockUserHandler(c *echo.Context) error {
 	if params.Reason != nil {
 		span.SetAttributes(attribute.String("reason", *params.Reason))
 	}
+	if params.Until != nil {
+		span.SetAttributes(attribute.String("until", params.Until.Format(time.RFC3339)))
+	}
+
+	userBlock, err := s.blockUser(ctx, c, params, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, userBlock)
+}
+
+var errUserAlreadyBlocked = echo.NewHTTPError(http.StatusConflict, "User is already blocked")
+
+// blockUser blocks the user in Auth0 and then in the DB, the Auth0 block is reverted if the DB block fails.
+// withTx, if not nil, runs in the transaction of the DB block, e.g. to resolve the reports that led to the block.
+// The returned error is an HTTP error that can be returned from the handler as is
+func (s *Server) blockUser(ctx context.Context, c *echo.Context, params *BlockUserParams, withTx func(qtx *repository.Queries) error) (repository.UserBlock, error) {
+	span := trace.SpanFromContext(ctx)
 
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -262,7 +542,7 @@ func (s *Server) blockUserHandler(c *echo.Context) error {
 		span.RecordError(err)
 		c.Logger().ErrorContext(ctx, "failed to start transaction", "error", err)
 
-		return echo.ErrInternalServerError
+		return repository.UserBlock{}, echo.ErrInternalServerError
 	}
 	defer func() {
 		_ = tx.Rollback(ctx)
@@ -270,36 +550,77 @@ func (s *Server) blockUserHandler(c *echo.Context) error {
 
 	userId := auth.GetUserID(c)
 	qtx := s.rep.WithTx(tx)
+	audit := newAuditEntry(c, auditActionUserBlock, auditTargetUser, params.UserID)
+
+	// An active block is never replaced, it has to be lifted first so that its reason and author are kept.
+	// An expired block that the expirer has not lifted yet is lifted here to make room for the new one
+	blocked, err := qtx.IsUserBlocked(ctx, params.UserID)
+	if err == nil && !blocked {
+		_, err = s.liftExpiredBlock(ctx, qtx, params.UserID)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to check the user block")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to check the user block", "error", err, slog.String("userId", params.UserID))
+
+		return repository.UserBlock{}, echo.ErrInternalServerError
+	}
+	if blocked {
+		span.AddEvent("user is already blocked")
+		return repository.UserBlock{}, errUserAlreadyBlocked
+	}
 
 	// Block the user in Auth0 first
 	updatedUser, err := s.authManagement.BlockUser(ctx, params.UserID)
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to block the user in auth0")
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
 
 		var apiErr *core.APIError
 		if errors.As(err, &apiErr) {
 			span.RecordError(apiErr.Unwrap())
 
 			c.Logger().ErrorContext(ctx, "failed to block the user in auth0", "error", apiErr.Unwrap(), slog.String("userId", params.UserID))
-			return echo.NewHTTPError(apiErr.StatusCode, "")
+			return repository.UserBlock{}, echo.NewHTTPError(apiErr.StatusCode, "")
 		}
 
 		span.RecordError(err)
 		c.Logger().ErrorContext(ctx, "failed to block the user in auth0", "error", err, slog.String("userId", params.UserID))
 
-		return echo.ErrInternalServerError
+		return repository.UserBlock{}, echo.ErrInternalServerError
 	}
 
 	// Block the user in the DB
-	userBlock, err := qtx.BlockUser(ctx, repository.BlockUserParams{UserID: params.UserID, UserEmail: updatedUser.Email, BlockedBy: *userId, Reason: params.Reason})
+	userBlock, err := qtx.BlockUser(ctx, repository.BlockUserParams{
+		UserID:       params.UserID,
+		UserEmail:    updatedUser.Email,
+		BlockedBy:    *userId,
+		Reason:       params.Reason,
+		BlockedUntil: params.Until,
+	})
+	if err == nil {
+		err = recordAudit(ctx, qtx, audit.withSnapshots(UserBlockAudit{Blocked: false}, UserBlockAudit{Blocked: true, Block: &userBlock}))
+	}
+	if err == nil && withTx != nil {
+		err = withTx(qtx)
+	}
+	if err != nil && s.rep.IsNotFoundError(err) {
+		// The user has been blocked by a concurrent request, the Auth0 block belongs to it and is kept
+		span.AddEvent("user is already blocked")
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
+
+		return repository.UserBlock{}, errUserAlreadyBlocked
+	}
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to block the user in db")
 		span.RecordError(err)
 		c.Logger().ErrorContext(ctx, "failed to block the user in db", "error", err, slog.String("userId", params.UserID))
 
 		_ = s.authManagement.UnblockUser(ctx, params.UserID)
+		_ = tx.Rollback(ctx)
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
 
-		return echo.ErrInternalServerError
+		return repository.UserBlock{}, echo.ErrInternalServerError
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -307,10 +628,12 @@ func (s *Server) blockUserHandler(c *echo.Context) error {
 		span.RecordError(err)
 		c.Logger().ErrorContext(ctx, "failed to commit transaction", "error", err)
 
-		return echo.ErrInternalServerError
+		return repository.UserBlock{}, echo.ErrInternalServerError
 	}
 
-	return c.JSON(http.StatusCreated, userBlock)
+	s.invalidateUserBlock(ctx, c.Logger(), params.UserID, true)
+
+	return userBlock, nil
 }
 
 // unblockUser godoc
@@ -363,7 +686,11 @@ func (s *Server) unblockUserHandler(c *echo.Context) error {
 	// For unblock, we don't need Auth0 response,
 	// so first, update the DB record, then unblock the user in Auth0.
 	// And if DB update fails, do not make any Auth0 calls
+	audit := newAuditEntry(c, auditActionUserUnblock, auditTargetUser, params.UserID)
 	userBlock, err := qtx.UnblockUser(ctx, repository.UnblockUserParams{UserID: params.UserID, UnblockedBy: *userId})
+	if err == nil {
+		err = recordAudit(ctx, qtx, audit.withSnapshots(unblockSnapshots(userBlock)))
+	}
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to unblock the user in db")
 		span.RecordError(err)
@@ -374,12 +701,16 @@ func (s *Server) unblockUserHandler(c *echo.Context) error {
 		}
 
 		c.Logger().ErrorContext(ctx, "failed to unblock the user in db", "error", err, slog.String("userId", params.UserID))
+		_ = tx.Rollback(ctx)
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
 		return echo.ErrInternalServerError
 	}
 
 	err = s.authManagement.UnblockUser(ctx, params.UserID)
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to unblock the user in auth")
+		_ = tx.Rollback(ctx)
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
 
 		var apiErr *core.APIError
 		if errors.As(err, &apiErr) {
@@ -403,11 +734,20 @@ func (s *Server) unblockUserHandler(c *echo.Context) error {
 		return echo.ErrInternalServerError
 	}
 
+	s.invalidateUserBlock(ctx, c.Logger(), params.UserID, false)
+
 	return c.JSON(http.StatusOK, userBlock)
 }
 
 type UserBlocksFilters struct {
 	PaginationFilters
+	// Active filters blocks that are in effect (true) or have been lifted or have expired (false)
+	Active      *bool      `query:"active" validate:"omitzero,boolean"`
+	BlockedBy   *string    `query:"blockedBy" validate:"omitzero,min=1,max=50"`
+	BlockedFrom *time.Time `query:"blockedFrom" validate:"omitzero"`
+	BlockedTo   *time.Time `query:"blockedTo" validate:"omitzero"`
+	// Email is matched case-insensitively against a part of the user email
+	Email *string `query:"email" validate:"omitzero,min=1,max=255"`
 }
 type PaginatedUserBlocks struct {
 	Items      []repository.UserBlock `json:"items"`
@@ -417,11 +757,16 @@ type PaginatedUserBlocks struct {
 // getUserBlocks godoc
 //
 //	@Summary		Get all User Blocks
-//	@Description	Retrieves a paginated list of all User Blocks created by admins
+//	@Description	Retrieves a paginated list of all User Blocks created by admins, newest first. Every block of a user is kept, so a user can have several blocks but only one active block.
 //	@Tags			Admin
 //	@Produce		json
-//	@Param			page		query		int					true	"Page number"	minimum(1)	maximum(10000)	default(1)
-//	@Param			pageSize	query		int					true	"Page size"		minimum(1)	maximum(100)	default(20)
+//	@Param			active		query		bool				false	"Only active (true) or only lifted and expired (false) blocks"
+//	@Param			blockedBy	query		string				false	"Only blocks created by a specific admin"				minlength(1)	maxlength(50)
+//	@Param			blockedFrom	query		string				false	"Only blocks created at or after this time (RFC 3339)"	format(date-time)
+//	@Param			blockedTo	query		string				false	"Only blocks created before this time (RFC 3339)"		format(date-time)
+//	@Param			email		query		string				false	"Search in the email of the blocked user"				minlength(1)	maxlength(255)
+//	@Param			page		query		int					true	"Page number"											minimum(1)		maximum(10000)	default(1)
+//	@Param			pageSize	query		int					true	"Page size"												minimum(1)		maximum(100)	default(20)
 //	@Success		200			{object}	PaginatedUserBlocks	"Paginated list of User Blocks"
 //	@Failure		400			{object}	HTTPValidationError	"Validation failed"
 //	@Failure		401			{object}	HTTPError			"Unauthorized"
@@ -445,7 +790,15 @@ func (s *Server) getUserBlocks(c *echo.Context) error {
 
 	span.SetAttributes(attribute.Int("page", int(params.Page)), attribute.Int("pageSize", int(params.PageSize)))
 
-	userBlocks, err := s.rep.GetUserBlocks(ctx, repository.GetUserBlocksParams{Limit: params.limit(), Offset: params.offset()})
+	userBlocks, err := s.rep.GetUserBlocks(ctx, repository.GetUserBlocksParams{
+		Active:      params.Active,
+		BlockedBy:   params.BlockedBy,
+		BlockedFrom: params.BlockedFrom,
+		BlockedTo:   params.BlockedTo,
+		Email:       escapeLikePattern(params.Email),
+		Limit:       params.limit(),
+		Offset:      params.offset(),
+	})
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to get user blocks")
 		span.RecordError(err)
@@ -461,14 +814,15 @@ func (s *Server) getUserBlocks(c *echo.Context) error {
 	items := make([]repository.UserBlock, len(userBlocks))
 	for i, userBlock := range userBlocks {
 		items[i] = repository.UserBlock{
-			ID:          userBlock.ID,
-			UserID:      userBlock.UserID,
-			UserEmail:   userBlock.UserEmail,
-			BlockedBy:   userBlock.BlockedBy,
-			BlockedAt:   userBlock.BlockedAt,
-			UnblockedBy: userBlock.UnblockedBy,
-			UnblockedAt: userBlock.UnblockedAt,
-			Reason:      userBlock.Reason,
+			ID:           userBlock.ID,
+			UserID:       userBlock.UserID,
+			UserEmail:    userBlock.UserEmail,
+			BlockedBy:    userBlock.BlockedBy,
+			BlockedAt:    userBlock.BlockedAt,
+			UnblockedBy:  userBlock.UnblockedBy,
+			UnblockedAt:  userBlock.UnblockedAt,
+			Reason:       userBlock.Reason,
+			BlockedUntil: userBlock.BlockedUntil,
 		}
 	}
 
@@ -479,3 +833,59 @@ func (s *Server) getUserBlocks(c *echo.Context) error {
 
 	return c.JSON(http.StatusOK, response)
 }
+
+// likeEscaper escapes the wildcards of LIKE patterns with the backslash
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLikePattern makes a user input match itself in a LIKE pattern instead of being a pattern on its own
+func escapeLikePattern(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	escaped := likeEscaper.Replace(*s)
+	return &escaped
+}
+
+type UserBlockHistoryResponse struct {
+	Items []repository.UserBlock `json:"items"`
+}
+
+// getUserBlockHistory godoc
+//
+//	@Summary		Get User Block History
+//	@Description	Retrieves all blocks of a user, newest first, including lifted and expired ones
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			userId	path		string						true	"ID of the user"	minlength(1)	maxlength(50)
+//	@Success		200		{object}	UserBlockHistoryResponse	"Blocks of the user"
+//	@Failure		400		{object}	HTTPValidationError			"Validation failed"
+//	@Failure		401		{object}	HTTPError					"Unauthorized"
+//	@Failure		403		{object}	HTTPError					"Forbidden"
+//	@Failure		500		{object}	HTTPError					"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/users/{userId}/blocks [get]
+func (s *Server) getUserBlockHistory(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "admin.GetUserBlockHistory")
+	defer span.End()
+
+	params := new(DeleteUserURLsParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("userId", params.UserID))
+
+	userBlocks, err := s.rep.GetUserBlockHistory(ctx, params.UserID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get user block history")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get user block history", "error", err, slog.String("userId", params.UserID))
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, &UserBlockHistoryResponse{Items: userBlocks})
+}
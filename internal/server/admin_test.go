@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/auth0/go-auth0/v2/management"
 	"github.com/auth0/go-auth0/v2/management/core"
@@ -45,7 +47,7 @@ func (m *mockAuthManager) UnblockUser(ctx context.Context, userID string) error
 
 func TestGetURLsHandler(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
 
 	for i := range 5 {
 		createShortUrl(t, s, e, fmt.Sprintf("https://example-%d.com", i), "", "")
@@ -125,10 +127,10 @@ func TestGetURLsHandler(t *testing.T) {
 
 func TestDeleteURLHandler(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
 
 	createdUrl := createShortUrl(t, s, e, "https://example.com", "", "")
-	_, err := s.cache.SetLongUrl(context.Background(), createdUrl.ID, createdUrl.LongUrl)
+	_, err := s.cache.SetLongUrl(context.Background(), createdUrl.ID, createdUrl.LongUrl, nil)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -182,9 +184,83 @@ func TestDeleteURLHandler(t *testing.T) {
 	t.Cleanup(cleanup)
 }
 
+func TestUpdateURLHandler(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	createdUrl := createShortUrl(t, s, e, "https://example.com", "user-id", "")
+	_, err := s.cache.SetLongUrl(context.Background(), createdUrl.ID, createdUrl.LongUrl, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		code              string
+		url               string
+		ifMatch           string
+		withoutPermission bool
+		expectedStatus    int
+	}{
+		{name: "no required permission", code: createdUrl.ID, url: "https://new.example.com", withoutPermission: true, expectedStatus: http.StatusForbidden},
+		{name: "non-existent code", code: "non-existent", url: "https://new.example.com", expectedStatus: http.StatusNotFound},
+		{name: "invalid url", code: createdUrl.ID, url: "ftp://example.com", expectedStatus: http.StatusBadRequest},
+		{name: "successful update of another user's url", code: createdUrl.ID, url: "https://new.example.com", ifMatch: urlETag(createdUrl.UpdatedAt), expectedStatus: http.StatusOK},
+		{name: "stale etag", code: createdUrl.ID, url: "https://stale.example.com", ifMatch: urlETag(createdUrl.UpdatedAt), expectedStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(UpdateShortUrlDTO{URL: tt.url})
+			require.NoError(t, err, "could not marshal payload")
+
+			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/v1/admin/urls/%s", tt.code), bytes.NewBuffer(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.ifMatch != "" {
+				req.Header.Set(headerIfMatch, tt.ifMatch)
+			}
+			res := httptest.NewRecorder()
+
+			c := e.NewContext(req, res)
+			c.SetPath("/v1/admin/urls/:code")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: tt.code}})
+
+			claims := &validator.ValidatedClaims{
+				RegisteredClaims: validator.RegisteredClaims{Subject: adminID},
+				CustomClaims:     &auth.CustomClaims{},
+			}
+			if !tt.withoutPermission {
+				claims.CustomClaims.(*auth.CustomClaims).Permissions = []string{string(auth.UpdateURLs)}
+			}
+			c.Set(string(auth.ClaimsContextKey), claims)
+
+			handler := authMw.RequireAuthentication(authMw.RequirePermission(auth.UpdateURLs)(s.updateURLHandler))
+
+			// Assertions
+			err = handler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+
+				var actual CreateShortUrlResponse
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+				assert.Equal(t, tt.url, actual.LongUrl, "long URL does not match")
+				assert.Equal(t, createdUrl.UserID, actual.UserID, "owner must not change")
+
+				actualCache, err := s.cache.GetLongUrl(c.Request().Context(), tt.code)
+				require.NoError(t, err)
+				assert.Equal(t, "", actualCache, "cache should be invalidated")
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
 func TestDeleteUserURLsHandler(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
 
 	invalidUserID := "the-user-id-that-is-too-long-for-the-endpoint-that-validation-should-prevent"
 	// Populate DB and cache with some URLs
@@ -193,9 +269,9 @@ func TestDeleteUserURLsHandler(t *testing.T) {
 	for i := range 5 {
 		url_1 := createShortUrl(t, s, e, fmt.Sprintf("https://example-one-%d.com", i), userID_1, "")
 		url_2 := createShortUrl(t, s, e, fmt.Sprintf("https://example-two-%d.com", i), userID_2, fmt.Sprintf("custom-code-%d", i))
-		_, err := s.cache.SetLongUrl(context.Background(), url_1.ID, url_1.LongUrl)
+		_, err := s.cache.SetLongUrl(context.Background(), url_1.ID, url_1.LongUrl, nil)
 		require.NoError(t, err)
-		_, err = s.cache.SetLongUrl(context.Background(), url_2.ID, url_2.LongUrl)
+		_, err = s.cache.SetLongUrl(context.Background(), url_2.ID, url_2.LongUrl, nil)
 		require.NoError(t, err)
 
 		codes_1[i] = url_1.ID
@@ -263,15 +339,77 @@ func TestDeleteUserURLsHandler(t *testing.T) {
 	t.Cleanup(cleanup)
 }
 
+func TestRestoreURLHandler(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	createdUrl := createShortUrl(t, s, e, "https://example.com", "user-id", "")
+	_, err := s.rep.DeleteURL(context.Background(), createdUrl.ID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		code              string
+		withoutPermission bool
+		expectedStatus    int
+	}{
+		{name: "no required permission", code: createdUrl.ID, withoutPermission: true, expectedStatus: http.StatusForbidden},
+		{name: "non-existent code", code: "non-existent", expectedStatus: http.StatusNotFound},
+		{name: "successful restore", code: createdUrl.ID, expectedStatus: http.StatusOK},
+		{name: "code already in use", code: createdUrl.ID, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/admin/trash/urls/%s/restore", tt.code), nil)
+			res := httptest.NewRecorder()
+
+			c := e.NewContext(req, res)
+			c.SetPath("/v1/admin/trash/urls/:code/restore")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: tt.code}})
+
+			claims := &validator.ValidatedClaims{
+				RegisteredClaims: validator.RegisteredClaims{Subject: adminID},
+				CustomClaims:     &auth.CustomClaims{},
+			}
+			if !tt.withoutPermission {
+				claims.CustomClaims.(*auth.CustomClaims).Permissions = []string{string(auth.DeleteURLs)}
+			}
+			c.Set(string(auth.ClaimsContextKey), claims)
+
+			handler := authMw.RequireAuthentication(authMw.RequirePermission(auth.DeleteURLs)(s.restoreURLHandler))
+
+			// Assertions
+			err := handler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+
+				var actual CreateShortUrlResponse
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+				assert.Nil(t, actual.DeletedAt, "deletedAt should be cleared")
+				assert.Equal(t, createdUrl.UserID, actual.UserID, "owner should be kept")
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
 func TestBlockUserHandler(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
 
 	var (
 		validUserID   = "auth0|507f1f77bcf86cd799439011"
 		userEmail     = "user@example.com"
 		invalidUserID = "user-id-that-is-way-too-long-and-exceeds-the-maximum-length-of-fifty-characters"
 		reason        = "Test reason"
+		until         = time.Now().Add(24 * time.Hour)
+		pastTime      = time.Now().Add(-time.Hour)
 	)
 
 	tests := []struct {
@@ -280,6 +418,7 @@ func TestBlockUserHandler(t *testing.T) {
 		userEmail         string
 		payload           BlockUserDTO
 		withoutPermission bool
+		alreadyBlocked    bool
 		mockSetup         func() *mockAuthManager
 		expectedStatus    int
 	}{
@@ -322,6 +461,17 @@ func TestBlockUserHandler(t *testing.T) {
 			},
 			expectedStatus: http.StatusCreated,
 		},
+		{
+			name:    "successful block (with until)",
+			userID:  validUserID,
+			payload: BlockUserDTO{Until: &until},
+			mockSetup: func() *mockAuthManager {
+				m := &mockAuthManager{}
+				m.On("BlockUser", mock.Anything, validUserID).Return(&management.UpdateUserResponseContent{}, nil)
+				return m
+			},
+			expectedStatus: http.StatusCreated,
+		},
 		{
 			name:   "user not found in Auth0 (404)",
 			userID: validUserID,
@@ -368,6 +518,21 @@ func TestBlockUserHandler(t *testing.T) {
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:           "user is already blocked",
+			userID:         validUserID,
+			payload:        BlockUserDTO{Reason: &reason},
+			alreadyBlocked: true,
+			mockSetup:      func() *mockAuthManager { return &mockAuthManager{} },
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "validation error - until in the past",
+			userID:         validUserID,
+			payload:        BlockUserDTO{Until: &pastTime},
+			mockSetup:      func() *mockAuthManager { return &mockAuthManager{} },
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:           "validation error - user ID too long",
 			userID:         invalidUserID,
@@ -387,6 +552,15 @@ func TestBlockUserHandler(t *testing.T) {
 			// Setup mock auth manager
 			s.authManagement = tt.mockSetup()
 
+			if tt.alreadyBlocked {
+				_, err := s.rep.BlockUser(context.Background(), repository.BlockUserParams{UserID: tt.userID, BlockedBy: adminID})
+				require.NoError(t, err)
+			}
+			// Every case starts with the user not blocked
+			t.Cleanup(func() {
+				_, _ = s.rep.UnblockUser(context.Background(), repository.UnblockUserParams{UserID: tt.userID, UnblockedBy: adminID})
+			})
+
 			body, err := json.Marshal(tt.payload)
 			require.NoError(t, err, "could not marshal payload")
 
@@ -426,6 +600,13 @@ func TestBlockUserHandler(t *testing.T) {
 					assert.Equal(t, tt.userID, actual.UserID, "user id should be the same")
 					assert.Equal(t, adminID, actual.BlockedBy, "blocked by should be the admin")
 					assert.Equal(t, tt.payload.Reason, actual.Reason, "reason should be the same")
+					if tt.payload.Until != nil {
+						if assert.NotNil(t, actual.BlockedUntil, "blocked until should be set") {
+							assert.WithinDuration(t, *tt.payload.Until, *actual.BlockedUntil, time.Millisecond, "blocked until should be the same")
+						}
+					} else {
+						assert.Nil(t, actual.BlockedUntil, "blocked until should be nil")
+					}
 					assert.NotNil(t, actual.BlockedAt, "blocked at should not be nil")
 					assert.Nil(t, actual.UnblockedBy, "unblocked by should be nil")
 					assert.Nil(t, actual.UnblockedAt, "unblocked at should be nil")
@@ -442,7 +623,7 @@ func TestBlockUserHandler(t *testing.T) {
 
 func TestUnblockUserHandler(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
 
 	validUserID := "auth0|507f1f77bcf86cd799439011"
 	invalidUserID := "user-id-that-is-way-too-long-and-exceeds-the-maximum-length-of-fifty-characters"
@@ -464,16 +645,6 @@ func TestUnblockUserHandler(t *testing.T) {
 			mockSetup:         func() *mockAuthManager { return &mockAuthManager{} },
 			expectedStatus:    http.StatusForbidden,
 		},
-		{
-			name:   "successful unblock",
-			userID: validUserID,
-			mockSetup: func() *mockAuthManager {
-				m := &mockAuthManager{}
-				m.On("UnblockUser", mock.Anything, validUserID).Return(nil)
-				return m
-			},
-			expectedStatus: http.StatusOK,
-		},
 		{
 			name:   "user block not found",
 			userID: "non-existent-user-id",
@@ -530,6 +701,22 @@ func TestUnblockUserHandler(t *testing.T) {
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:   "successful unblock",
+			userID: validUserID,
+			mockSetup: func() *mockAuthManager {
+				m := &mockAuthManager{}
+				m.On("UnblockUser", mock.Anything, validUserID).Return(nil)
+				return m
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "already unblocked user",
+			userID:         validUserID,
+			mockSetup:      func() *mockAuthManager { return &mockAuthManager{} },
+			expectedStatus: http.StatusNotFound,
+		},
 		{
 			name:           "validation error - user ID too long",
 			userID:         invalidUserID,
@@ -597,21 +784,28 @@ func TestUnblockUserHandler(t *testing.T) {
 
 func TestGetUserBlocks(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
 
 	// Setup mock to expect BlockUser calls for test data setup
 	mockAuth := &mockAuthManager{}
-	mockAuth.On("BlockUser", mock.Anything, mock.Anything).Return(&management.UpdateUserResponseContent{}, nil)
+	email := "user@example.com"
+	mockAuth.On("BlockUser", mock.Anything, mock.Anything).Return(&management.UpdateUserResponseContent{Email: &email}, nil)
 	s.authManagement = mockAuth
 
 	for i := range 15 {
 		blockUser(t, s, e, fmt.Sprintf("user-%d", i), BlockUserDTO{Reason: nil})
 	}
+	for i := range 5 {
+		_, err := s.rep.UnblockUser(context.Background(), repository.UnblockUserParams{UserID: fmt.Sprintf("user-%d", i), UnblockedBy: adminID})
+		require.NoError(t, err)
+	}
+	future := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
 
 	tests := []struct {
 		name              string
 		withoutPermission bool
 		filters           UserBlocksFilters
+		query             string
 		expectedStatus    int
 		expectedBlocks    int
 	}{
@@ -620,6 +814,17 @@ func TestGetUserBlocks(t *testing.T) {
 		{name: "return user blocks for page=1 and pageSize=5", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 5}}, expectedStatus: http.StatusOK, expectedBlocks: 5},
 		{name: "return user blocks for page=3 and pageSize=5", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 3, PageSize: 5}}, expectedStatus: http.StatusOK, expectedBlocks: 5},
 		{name: "return user blocks for page=4 and pageSize=5", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 4, PageSize: 5}}, expectedStatus: http.StatusOK},
+		{name: "return active user blocks", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&active=true", expectedStatus: http.StatusOK, expectedBlocks: 10},
+		{name: "return lifted user blocks", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&active=false", expectedStatus: http.StatusOK, expectedBlocks: 5},
+		{name: "return user blocks by admin", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&blockedBy=" + adminID, expectedStatus: http.StatusOK, expectedBlocks: 15},
+		{name: "return user blocks by another admin", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&blockedBy=other-admin", expectedStatus: http.StatusOK},
+		{name: "return user blocks created before", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&blockedTo=" + future, expectedStatus: http.StatusOK, expectedBlocks: 15},
+		{name: "return user blocks created after", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&blockedFrom=" + future, expectedStatus: http.StatusOK},
+		{name: "search user blocks by email", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&email=EXAMPLE", expectedStatus: http.StatusOK, expectedBlocks: 15},
+		{name: "search user blocks by unknown email", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&email=nobody", expectedStatus: http.StatusOK},
+		{name: "search user blocks by email with %", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&email=%25", expectedStatus: http.StatusOK},
+		{name: "search user blocks by email with _", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&email=user_example", expectedStatus: http.StatusOK},
+		{name: "error on invalid blockedFrom", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 25}}, query: "&blockedFrom=yesterday", expectedStatus: http.StatusBadRequest},
 		{name: "error on 0 page", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 0, PageSize: 25}}, expectedStatus: http.StatusBadRequest},
 		{name: "error on page > max", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 20_000, PageSize: 25}}, expectedStatus: http.StatusBadRequest},
 		{name: "error on 0 pageSize", filters: UserBlocksFilters{PaginationFilters: PaginationFilters{Page: 1, PageSize: 0}}, expectedStatus: http.StatusBadRequest},
@@ -628,9 +833,9 @@ func TestGetUserBlocks(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			url := fmt.Sprintf("/v1/admin/users/blocks?page=%d&pageSize=%d", tt.filters.Page, tt.filters.PageSize)
+			target := fmt.Sprintf("/v1/admin/users/blocks?page=%d&pageSize=%d%s", tt.filters.Page, tt.filters.PageSize, tt.query)
 
-			req := httptest.NewRequest(http.MethodGet, url, nil)
+			req := httptest.NewRequest(http.MethodGet, target, nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -666,8 +871,43 @@ func TestGetUserBlocks(t *testing.T) {
 	t.Cleanup(cleanup)
 }
 
+func TestGetUserBlockHistory(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	mockAuth := &mockAuthManager{}
+	mockAuth.On("BlockUser", mock.Anything, mock.Anything).Return(&management.UpdateUserResponseContent{}, nil)
+	s.authManagement = mockAuth
+
+	firstReason, secondReason := "spam", "spam again"
+	blockUser(t, s, e, userID_1, BlockUserDTO{Reason: &firstReason})
+	_, err := s.rep.UnblockUser(context.Background(), repository.UnblockUserParams{UserID: userID_1, UnblockedBy: adminID})
+	require.NoError(t, err)
+	blockUser(t, s, e, userID_1, BlockUserDTO{Reason: &secondReason})
+	blockUser(t, s, e, userID_2, BlockUserDTO{})
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/admin/users/%s/blocks", userID_1), nil)
+	res := httptest.NewRecorder()
+	c := e.NewContext(req, res)
+	c.SetPath("/v1/admin/users/:userId/blocks")
+	c.SetPathValues(echo.PathValues{{Name: "userId", Value: userID_1}})
+
+	require.NoError(t, s.getUserBlockHistory(c))
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var actual UserBlockHistoryResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+	require.Len(t, actual.Items, 2, "every block should be kept")
+
+	assert.Equal(t, &secondReason, actual.Items[0].Reason, "newest block should come first")
+	assert.Nil(t, actual.Items[0].UnblockedAt, "newest block should be active")
+	assert.Equal(t, &firstReason, actual.Items[1].Reason, "previous block should keep its reason")
+	assert.Equal(t, &adminID, actual.Items[1].UnblockedBy, "previous block should keep its unblock")
+
+	t.Cleanup(cleanup)
+}
+
 func blockUser(t *testing.T, s *Server, e *echo.Echo, userID string, payload BlockUserDTO) repository.UserBlock {
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
 	claims := &validator.ValidatedClaims{
 		RegisteredClaims: validator.RegisteredClaims{Subject: adminID},
 		CustomClaims:     &auth.CustomClaims{Permissions: []string{string(auth.UserBlock)}},
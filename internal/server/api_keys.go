@@ -0,0 +1,203 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/appvalidator"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxAPIKeysPerUser = 25
+
+type CreateAPIKeyDTO struct {
+	Name string `json:"name" validate:"required,max=100" example:"CI pipeline"`
+	// Permissions of the key, must be a subset of the permissions of the user
+	Permissions []string `json:"permissions" validate:"required,min=1,unique" example:"create:urls"`
+	// Lifetime of the key in days
+	ExpiresInDays int32 `json:"expiresInDays" validate:"required,min=1,max=365" example:"90"`
+}
+type APIKeyParams struct {
+	ID int64 `param:"id" validate:"required,min=1"`
+}
+type APIKeyWithKey struct {
+	repository.ApiKey
+	// The key to send as a Bearer token, only returned when the key is created
+	Key string `json:"key" example:"shk_UKGCSOLNWSJX7DMDQJ5XLJKWAA"`
+}
+type APIKeysResponse struct {
+	Items []repository.ApiKey `json:"items"`
+}
+
+// getUserAPIKeys godoc
+//
+//	@Summary		Get User API Keys
+//	@Description	Retrieves the API keys of the authenticated user that have not been revoked, including expired ones
+//	@Tags			API Keys
+//	@Produce		json
+//	@Success		200	{object}	APIKeysResponse	"API keys of the user"
+//	@Failure		401	{object}	HTTPError		"Unauthorized"
+//	@Failure		403	{object}	HTTPError		"Forbidden"
+//	@Failure		500	{object}	HTTPError		"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/api-keys [get]
+func (s *Server) getUserAPIKeys(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "apiKeys.GetUserAPIKeys")
+	defer span.End()
+
+	userID := auth.GetUserID(c)
+
+	apiKeys, err := s.rep.GetUserApiKeys(ctx, *userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get user api keys")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get user api keys", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, &APIKeysResponse{Items: apiKeys})
+}
+
+// createAPIKeyHandler godoc
+//
+//	@Summary		Create API Key
+//	@Description	Issues a personal API key for machine use, e.g. in CI jobs. Send it as a Bearer token instead of a JWT. The key acts on behalf of the authenticated user with a subset of their permissions and expires after the given number of days. The key is only returned once and cannot be created with another API key.
+//	@Tags			API Keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateAPIKeyDTO		true	"Name, permissions and lifetime of the key"
+//	@Success		201		{object}	APIKeyWithKey		"Created API key"
+//	@Failure		400		{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401		{object}	HTTPError			"Unauthorized"
+//	@Failure		403		{object}	HTTPError			"Forbidden"
+//	@Failure		409		{object}	HTTPError			"API key limit reached"
+//	@Failure		500		{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/api-keys [post]
+func (s *Server) createAPIKeyHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "apiKeys.CreateAPIKeyHandler")
+	defer span.End()
+
+	if auth.IsAPIKey(c) {
+		span.AddEvent("api key attempted to create api key")
+		return echo.NewHTTPError(http.StatusForbidden, "API keys cannot create API keys")
+	}
+
+	dto := new(CreateAPIKeyDTO)
+	if err := c.Bind(dto); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(dto); err != nil {
+		span.SetStatus(codes.Error, "invalid user input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("name", dto.Name), attribute.StringSlice("permissions", dto.Permissions), attribute.Int("expiresInDays", int(dto.ExpiresInDays)))
+
+	userPermissions := auth.GetPermissions(c)
+	for _, permission := range dto.Permissions {
+		var message string
+		if !auth.IsPermission(permission) {
+			message = fmt.Sprintf("Unknown permission %s", permission)
+		} else if !slices.Contains(userPermissions, permission) {
+			message = fmt.Sprintf("Permission %s is not granted to the user", permission)
+		}
+
+		if message != "" {
+			span.AddEvent("invalid api key permission", trace.WithAttributes(attribute.String("permission", permission)))
+			return c.JSON(http.StatusBadRequest, &HTTPValidationError{
+				HTTPError: HTTPError{Message: "Validation failed"},
+				Errors:    appvalidator.ValidationError{"permissions": message},
+			})
+		}
+	}
+
+	userID := auth.GetUserID(c)
+
+	count, err := s.rep.CountUserApiKeys(ctx, *userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to count user api keys")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to count user api keys", "error", err)
+		return echo.ErrInternalServerError
+	}
+	if count >= maxAPIKeysPerUser {
+		span.AddEvent("api key limit reached", trace.WithAttributes(attribute.Int64("count", count)))
+		return echo.NewHTTPError(http.StatusConflict, "API key limit reached")
+	}
+
+	key, keyPrefix, keyHash := auth.NewAPIKey()
+	apiKey, err := s.rep.CreateApiKey(ctx, repository.CreateApiKeyParams{
+		UserID:      *userID,
+		Name:        dto.Name,
+		KeyPrefix:   keyPrefix,
+		KeyHash:     keyHash,
+		Permissions: dto.Permissions,
+		ExpiresAt:   time.Now().AddDate(0, 0, int(dto.ExpiresInDays)),
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to create api key")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to create api key", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusCreated, &APIKeyWithKey{ApiKey: apiKey, Key: key})
+}
+
+// revokeAPIKeyHandler godoc
+//
+//	@Summary		Revoke API Key
+//	@Description	Revokes an API key of the authenticated user, requests with the key are rejected right away
+//	@Tags			API Keys
+//	@Produce		json
+//	@Param			id	path	int	true	"ID of the API key"	minimum(1)
+//	@Success		204	"No Content - API key successfully revoked"
+//	@Failure		400	{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401	{object}	HTTPError			"Unauthorized"
+//	@Failure		403	{object}	HTTPError			"Forbidden"
+//	@Failure		404	{object}	HTTPError			"API key not found"
+//	@Failure		500	{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/api-keys/{id} [delete]
+func (s *Server) revokeAPIKeyHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "apiKeys.RevokeAPIKeyHandler")
+	defer span.End()
+
+	params := new(APIKeyParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Int64("apiKeyId", params.ID))
+
+	userID := auth.GetUserID(c)
+
+	rowsAffected, err := s.rep.RevokeUserApiKey(ctx, repository.RevokeUserApiKeyParams{ID: params.ID, UserID: *userID})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to revoke api key")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to revoke api key", "error", err, slog.Int64("apiKeyId", params.ID))
+		return echo.ErrInternalServerError
+	}
+	if rowsAffected == 0 {
+		span.AddEvent("api key not found")
+		return echo.ErrNotFound
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	userID := "user-id"
+	userPermissions := []string{string(auth.ManageOwnAPIKeys), string(auth.CreateURLs), string(auth.GetOwnURLs)}
+
+	createAPIKey := func(dto CreateAPIKeyDTO) *httptest.ResponseRecorder {
+		body, err := json.Marshal(dto)
+		require.NoError(t, err, "could not marshal payload")
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/api-keys", bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{
+			RegisteredClaims: validator.RegisteredClaims{Subject: userID},
+			CustomClaims:     &auth.CustomClaims{Permissions: userPermissions},
+		})
+
+		require.NoError(t, s.createAPIKeyHandler(c))
+		return res
+	}
+
+	// authenticate runs the request with the key through the middleware and returns the authenticated user and permissions
+	authenticate := func(key string) (*string, []string, error) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/urls", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+key)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		var (
+			userID      *string
+			permissions []string
+		)
+		err := authMw.Authenticate(func(c *echo.Context) error {
+			userID = auth.GetUserID(c)
+			permissions = auth.GetPermissions(c)
+			return nil
+		})(c)
+
+		return userID, permissions, err
+	}
+
+	t.Run("permissions must be granted to the user", func(t *testing.T) {
+		res := createAPIKey(CreateAPIKeyDTO{Name: "ci", Permissions: []string{string(auth.DeleteURLs)}, ExpiresInDays: 30})
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+
+		res = createAPIKey(CreateAPIKeyDTO{Name: "ci", Permissions: []string{"launch:rockets"}, ExpiresInDays: 30})
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	res := createAPIKey(CreateAPIKeyDTO{Name: "ci", Permissions: []string{string(auth.CreateURLs)}, ExpiresInDays: 30})
+	require.Equal(t, http.StatusCreated, res.Code)
+
+	var created APIKeyWithKey
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&created), "error decoding response body")
+	assert.Contains(t, created.Key, auth.APIKeyPrefix)
+	assert.Equal(t, created.Key[:len(created.KeyPrefix)], created.KeyPrefix)
+
+	t.Run("key is not listed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/api-keys", nil)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: userID}})
+
+		require.NoError(t, s.getUserAPIKeys(c))
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.NotContains(t, res.Body.String(), created.Key)
+		assert.NotContains(t, res.Body.String(), auth.HashAPIKey(created.Key))
+		assert.Contains(t, res.Body.String(), created.KeyPrefix)
+	})
+
+	t.Run("authenticate with key", func(t *testing.T) {
+		actualUserID, permissions, err := authenticate(created.Key)
+		require.NoError(t, err)
+		if assert.NotNil(t, actualUserID) {
+			assert.Equal(t, userID, *actualUserID)
+		}
+		assert.Equal(t, []string{string(auth.CreateURLs)}, permissions, "only the permissions of the key should be granted")
+
+		_, _, err = authenticate(auth.APIKeyPrefix + "unknown")
+		if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok) {
+			assert.Equal(t, http.StatusUnauthorized, sc.StatusCode())
+		}
+	})
+
+	t.Run("revoked key is rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/v1/api-keys/1", nil)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPathValues(echo.PathValues{{Name: "id", Value: strconv.FormatInt(created.ID, 10)}})
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: userID}})
+
+		require.NoError(t, s.revokeAPIKeyHandler(c))
+		assert.Equal(t, http.StatusNoContent, res.Code)
+
+		_, _, err := authenticate(created.Key)
+		if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok) {
+			assert.Equal(t, http.StatusUnauthorized, sc.StatusCode())
+		}
+	})
+
+	t.Cleanup(cleanup)
+}
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	auditActionURLUpdate      = "url.update"
+	auditActionURLDelete      = "url.delete"
+	auditActionURLRestore     = "url.restore"
+	auditActionUserURLsDelete = "user.urls.delete"
+	auditActionUserBlock      = "user.block"
+	auditActionUserUnblock    = "user.unblock"
+	auditActionURLQuarantine  = "url.quarantine"
+	auditActionReportsResolve = "reports.resolve"
+
+	auditActionDomainRuleCreate      = "domain_rule.create"
+	auditActionDomainRuleUpdate      = "domain_rule.update"
+	auditActionDomainRuleDelete      = "domain_rule.delete"
+	auditActionDomainRuleDisableURLs = "domain_rule.disable_urls"
+)
+
+const (
+	auditTargetURL        = "url"
+	auditTargetUser       = "user"
+	auditTargetDomainRule = "domain_rule"
+)
+
+const (
+	auditOutcomeSuccess = "success"
+	auditOutcomeFailure = "failure"
+)
+
+// auditEntry is a privileged action to be recorded in the audit log.
+// Before and After are snapshots of the target, they are stored as JSON and can be nil
+type auditEntry struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  *string
+	IP         *string
+	Before     any
+	After      any
+}
+
+// newAuditEntry returns the entry of an action made by the authenticated user of the request
+func newAuditEntry(c *echo.Context, action, targetType, targetID string) auditEntry {
+	entry := auditEntry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if userID := auth.GetUserID(c); userID != nil {
+		entry.ActorID = *userID
+	}
+	// The request ID middleware sets the header on the response, whether it came with the request or not
+	if requestID := c.Response().Header().Get(echo.HeaderXRequestID); requestID != "" {
+		entry.RequestID = &requestID
+	}
+	// RealIP uses the extractor of the server, which only trusts the forwarding headers set by the trusted proxies
+	if ip := c.RealIP(); ip != "" {
+		entry.IP = &ip
+	}
+
+	return entry
+}
+
+// withSnapshots returns a copy of the entry with the state of the target before and after the action
+func (e auditEntry) withSnapshots(before, after any) auditEntry {
+	e.Before = before
+	e.After = after
+	return e
+}
+
+func (e auditEntry) params(outcome string, actionErr error) (repository.CreateAdminAuditLogParams, error) {
+	before, err := auditSnapshot(e.Before)
+	if err != nil {
+		return repository.CreateAdminAuditLogParams{}, err
+	}
+	after, err := auditSnapshot(e.After)
+	if err != nil {
+		return repository.CreateAdminAuditLogParams{}, err
+	}
+
+	arg := repository.CreateAdminAuditLogParams{
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		RequestID:  e.RequestID,
+		Ip:         e.IP,
+		Before:     before,
+		After:      after,
+		Outcome:    outcome,
+	}
+	if actionErr != nil {
+		errMsg := actionErr.Error()
+		arg.Error = &errMsg
+	}
+
+	return arg, nil
+}
+
+// auditSnapshot returns the JSON of v, or nil for a nil value, including a typed nil pointer, map or slice,
+// so that a missing snapshot is stored as NULL rather than JSON null
+func auditSnapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// recordAudit writes the entry of a successful action with qtx, so it is committed or rolled back together with the action
+func recordAudit(ctx context.Context, qtx *repository.Queries, entry auditEntry) error {
+	arg, err := entry.params(auditOutcomeSuccess, nil)
+	if err != nil {
+		return err
+	}
+
+	return qtx.CreateAdminAuditLog(ctx, arg)
+}
+
+// recordAuditFailure writes the entry of a failed action. The transaction of the action has been rolled back,
+// so the entry is written on its own, and failing to write it does not change the response
+func (s *Server) recordAuditFailure(ctx context.Context, logger *slog.Logger, entry auditEntry, actionErr error) {
+	span := trace.SpanFromContext(ctx)
+
+	arg, err := entry.params(auditOutcomeFailure, actionErr)
+	if err == nil {
+		err = s.rep.CreateAdminAuditLog(ctx, arg)
+	}
+	if err != nil {
+		span.AddEvent("failed to record audit log entry")
+		logger.WarnContext(ctx, "failed to record audit log entry", "error", err, slog.String("action", entry.Action), slog.String("targetId", entry.TargetID))
+	}
+}
+
+type AuditLogFilters struct {
+	PaginationFilters
+	ActorID     *string    `query:"actorId" validate:"omitzero,min=1,max=50"`
+	Action      *string    `query:"action" validate:"omitzero,oneof=url.update url.delete url.restore user.urls.delete user.block user.unblock url.quarantine reports.resolve domain_rule.create domain_rule.update domain_rule.delete domain_rule.disable_urls"`
+	TargetType  *string    `query:"targetType" validate:"omitzero,oneof=url user domain_rule"`
+	TargetID    *string    `query:"targetId" validate:"omitzero,min=1,max=50"`
+	Outcome     *string    `query:"outcome" validate:"omitzero,oneof=success failure"`
+	CreatedFrom *time.Time `query:"createdFrom" validate:"omitzero"`
+	CreatedTo   *time.Time `query:"createdTo" validate:"omitzero"`
+}
+type PaginatedAuditLog struct {
+	Items      []repository.AdminAuditLog `json:"items"`
+	Pagination Pagination                 `json:"pagination"`
+}
+
+// getAuditLog godoc
+//
+//	@Summary		Get Audit Log
+//	@Description	Retrieves a paginated list of the privileged actions made by admins and by the server itself, newest first. Entries cannot be changed or removed.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			actorId		query		string				false	"Only actions made by a specific user"					minlength(1)	maxlength(50)
+//	@Param			action		query		string				false	"Only actions of a specific type"						Enums(url.update, url.delete, url.restore, user.urls.delete, user.block, user.unblock, url.quarantine, reports.resolve, domain_rule.create, domain_rule.update, domain_rule.delete, domain_rule.disable_urls)
+//	@Param			targetType	query		string				false	"Only actions on a specific type of target"				Enums(url, user, domain_rule)
+//	@Param			targetId	query		string				false	"Only actions on a specific target"						minlength(1)	maxlength(50)
+//	@Param			outcome		query		string				false	"Only successful or only failed actions"				Enums(success, failure)
+//	@Param			createdFrom	query		string				false	"Only actions made at or after this time (RFC 3339)"	format(date-time)
+//	@Param			createdTo	query		string				false	"Only actions made before this time (RFC 3339)"			format(date-time)
+//	@Param			page		query		int					true	"Page number"											minimum(1)	maximum(10000)	default(1)
+//	@Param			pageSize	query		int					true	"Page size"												minimum(1)	maximum(100)	default(20)
+//	@Success		200			{object}	PaginatedAuditLog	"Paginated list of audit log entries"
+//	@Failure		400			{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401			{object}	HTTPError			"Unauthorized"
+//	@Failure		403			{object}	HTTPError			"Forbidden"
+//	@Failure		500			{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/audit [get]
+func (s *Server) getAuditLog(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "audit.GetAuditLog")
+	defer span.End()
+
+	params := new(AuditLogFilters)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+
+	span.SetAttributes(attribute.Int("page", int(params.Page)), attribute.Int("pageSize", int(params.PageSize)))
+
+	entries, err := s.rep.GetAdminAuditLog(ctx, repository.GetAdminAuditLogParams{
+		ActorID:     params.ActorID,
+		Action:      params.Action,
+		TargetType:  params.TargetType,
+		TargetID:    params.TargetID,
+		Outcome:     params.Outcome,
+		CreatedFrom: params.CreatedFrom,
+		CreatedTo:   params.CreatedTo,
+		Limit:       params.limit(),
+		Offset:      params.offset(),
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get audit log")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get audit log", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	var totalCount int
+	if len(entries) > 0 {
+		totalCount = int(entries[0].TotalCount)
+	}
+
+	items := make([]repository.AdminAuditLog, len(entries))
+	for i, entry := range entries {
+		items[i] = repository.AdminAuditLog{
+			ID:         entry.ID,
+			ActorID:    entry.ActorID,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID,
+			RequestID:  entry.RequestID,
+			Ip:         entry.Ip,
+			Before:     entry.Before,
+			After:      entry.After,
+			Outcome:    entry.Outcome,
+			Error:      entry.Error,
+			CreatedAt:  entry.CreatedAt,
+		}
+	}
+
+	response := &PaginatedAuditLog{
+		Items:      items,
+		Pagination: calculatePagination(totalCount, int(params.Page), int(params.PageSize)),
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-auth0/v2/management"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	createdUrl := createShortUrl(t, s, e, "https://example.com", userID_1, "")
+
+	withAdmin := func(c *echo.Context, permissions ...string) {
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{
+			RegisteredClaims: validator.RegisteredClaims{Subject: adminID},
+			CustomClaims:     &auth.CustomClaims{Permissions: permissions},
+		})
+	}
+
+	// Delete the URL of the user, made in the same transaction as its audit log entry
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/admin/urls/%s", createdUrl.ID), nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+	res := httptest.NewRecorder()
+	res.Header().Set(echo.HeaderXRequestID, "request-id")
+	c := e.NewContext(req, res)
+	c.SetPathValues(echo.PathValues{{Name: "code", Value: createdUrl.ID}})
+	withAdmin(c, string(auth.DeleteURLs))
+	require.NoError(t, s.deleteURLHandler(c))
+	require.Equal(t, http.StatusNoContent, res.Code)
+
+	// Fail to block the user in Auth0
+	m := &mockAuthManager{}
+	m.On("BlockUser", mock.Anything, userID_2).Return((*management.UpdateUserResponseContent)(nil), assert.AnError)
+	s.authManagement = m
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/admin/users/block/%s", userID_2), nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	c.SetPathValues(echo.PathValues{{Name: "userId", Value: userID_2}})
+	withAdmin(c, string(auth.UserBlock))
+	require.Error(t, s.blockUserHandler(c))
+
+	tests := []struct {
+		name              string
+		query             string
+		withoutPermission bool
+		expectedStatus    int
+		expectedActions   []string
+	}{
+		{name: "no required permission", query: "page=1&pageSize=10", withoutPermission: true, expectedStatus: http.StatusForbidden},
+		{name: "invalid action", query: "page=1&pageSize=10&action=url.create", expectedStatus: http.StatusBadRequest},
+		{name: "all entries", query: "page=1&pageSize=10", expectedStatus: http.StatusOK, expectedActions: []string{auditActionUserBlock, auditActionURLDelete}},
+		{name: "failed actions", query: "page=1&pageSize=10&outcome=failure", expectedStatus: http.StatusOK, expectedActions: []string{auditActionUserBlock}},
+		{name: "actions on a target", query: fmt.Sprintf("page=1&pageSize=10&targetType=url&targetId=%s", createdUrl.ID), expectedStatus: http.StatusOK, expectedActions: []string{auditActionURLDelete}},
+		{name: "actions of another actor", query: "page=1&pageSize=10&actorId=other-admin", expectedStatus: http.StatusOK, expectedActions: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/admin/audit?"+tt.query, nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/v1/admin/audit")
+			if tt.withoutPermission {
+				withAdmin(c)
+			} else {
+				withAdmin(c, string(auth.GetAuditLog))
+			}
+
+			handler := authMw.RequireAuthentication(authMw.RequirePermission(auth.GetAuditLog)(s.getAuditLog))
+
+			err := handler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStatus, res.Code)
+			if tt.expectedActions == nil {
+				return
+			}
+
+			var actual PaginatedAuditLog
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+
+			actions := make([]string, len(actual.Items))
+			for i, item := range actual.Items {
+				actions[i] = item.Action
+				assert.Equal(t, adminID, item.ActorID)
+			}
+			assert.Equal(t, tt.expectedActions, actions)
+			assert.Equal(t, len(tt.expectedActions), actual.Pagination.TotalItems)
+
+			for _, item := range actual.Items {
+				switch item.Action {
+				case auditActionURLDelete:
+					assert.Equal(t, auditOutcomeSuccess, item.Outcome)
+					assert.Equal(t, "request-id", *item.RequestID)
+					assert.Equal(t, "10.0.0.1", *item.Ip, "forged forwarding header should not be recorded")
+					assert.Contains(t, string(item.Before), createdUrl.ID, "URL should be kept as it was before the delete")
+				case auditActionUserBlock:
+					assert.Equal(t, auditOutcomeFailure, item.Outcome)
+					assert.Equal(t, userID_2, item.TargetID)
+					if assert.NotNil(t, item.Error) {
+						assert.Equal(t, assert.AnError.Error(), *item.Error)
+					}
+				}
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestAuditSnapshot(t *testing.T) {
+	unblockedBy, unblockedAt := adminID, time.Now()
+	before, after := unblockSnapshots(repository.UserBlock{UserID: userID_1, UnblockedBy: &unblockedBy, UnblockedAt: &unblockedAt})
+
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{name: "nil", value: nil},
+		{name: "nil pointer", value: (*repository.UserBlock)(nil)},
+		{name: "nil map", value: map[string]string(nil)},
+		{name: "nil slice", value: []string(nil)},
+		{name: "empty slice", value: []string{}, expected: `[]`},
+		{name: "user is blocked", value: UserBlockAudit{Blocked: false}, expected: `{"blocked":false,"block":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := auditSnapshot(tt.value)
+			require.NoError(t, err)
+			if tt.expected == "" {
+				assert.Nil(t, actual)
+				return
+			}
+			assert.JSONEq(t, tt.expected, string(actual))
+		})
+	}
+
+	t.Run("user is unblocked", func(t *testing.T) {
+		assert.True(t, before.Blocked)
+		assert.Nil(t, before.Block.UnblockedBy)
+		assert.Nil(t, before.Block.UnblockedAt)
+
+		assert.False(t, after.Blocked)
+		assert.Equal(t, &unblockedBy, after.Block.UnblockedBy)
+		assert.Equal(t, &unblockedAt, after.Block.UnblockedAt)
+		assert.Equal(t, userID_1, before.Block.UserID)
+	})
+}
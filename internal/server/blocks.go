@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// reasonUserBlocked is returned to blocked users, so clients can tell a block apart from missing permissions
+const reasonUserBlocked = "user_blocked"
+
+const (
+	// blockExpiryInterval is how often expired temporary blocks are lifted
+	blockExpiryInterval = time.Minute
+	// blockExpiryBatchSize caps the number of users unblocked in one run
+	blockExpiryBatchSize = 100
+	// systemActor is recorded as the actor of changes made by the server itself, e.g. expired blocks
+	systemActor = "system"
+)
+
+// rejectBlockedUsers rejects the requests of users that are blocked, including those made with their API keys.
+// Blocking the user in Auth0 only stops new logins, their JWTs stay valid until they expire.
+// The block status is cached, block and unblock invalidate it
+func (s *Server) rejectBlockedUsers(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c *echo.Context) error {
+		userID := auth.GetUserID(c)
+		if userID == nil {
+			return next(c)
+		}
+
+		ctx, span := tracer.Start(c.Request().Context(), "blocks.RejectBlockedUsers")
+		defer span.End()
+		span.SetAttributes(attribute.String("userId", *userID))
+
+		blocked, err := s.isUserBlocked(ctx, c, *userID)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to check user block")
+			span.RecordError(err)
+			c.Logger().ErrorContext(ctx, "failed to check user block", "error", err, slog.String("userId", *userID))
+			return echo.ErrInternalServerError
+		}
+		if blocked {
+			span.AddEvent("user is blocked")
+			return c.JSON(http.StatusForbidden, &HTTPReasonError{
+				HTTPError: HTTPError{Message: "User is blocked"},
+				Reason:    reasonUserBlocked,
+			})
+		}
+
+		return next(c)
+	}
+}
+
+// isUserBlocked looks up the block status of the user in the cache first, then in the database
+func (s *Server) isUserBlocked(ctx context.Context, c *echo.Context, userID string) (bool, error) {
+	span := trace.SpanFromContext(ctx)
+
+	blocked, found, err := s.cache.GetUserBlocked(ctx, userID)
+	if err != nil {
+		span.AddEvent("failed to get user block from cache")
+		c.Logger().WarnContext(ctx, "failed to get user block from cache", "error", err, slog.String("userId", userID))
+	}
+	if found {
+		return blocked, nil
+	}
+
+	blocked, err = s.rep.IsUserBlocked(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.cache.SetUserBlocked(ctx, userID, blocked); err != nil {
+		span.AddEvent("failed to cache user block")
+		c.Logger().WarnContext(ctx, "failed to cache user block", "error", err, slog.String("userId", userID))
+	}
+
+	return blocked, nil
+}
+
+// invalidateUserBlock drops the cached block status of the user after a block or unblock.
+// When the user is blocked, their cached links are dropped too, so they stop resolving right away
+func (s *Server) invalidateUserBlock(ctx context.Context, logger *slog.Logger, userID string, blocked bool) {
+	span := trace.SpanFromContext(ctx)
+
+	if err := s.cache.DeleteUserBlocked(ctx, userID); err != nil {
+		span.AddEvent("failed to delete user block from cache")
+		logger.WarnContext(ctx, "failed to delete user block from cache", "error", err, slog.String("userId", userID))
+	}
+	if !blocked {
+		return
+	}
+
+	urls, err := s.rep.GetAllUserUrls(ctx, &userID)
+	if err != nil {
+		span.AddEvent("failed to get user urls")
+		logger.WarnContext(ctx, "failed to get user urls", "error", err, slog.String("userId", userID))
+		return
+	}
+
+	ids := make([]string, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+	}
+	if removedKeys, err := s.cache.DeleteLongURLs(ctx, ids); err != nil {
+		span.AddEvent("failed to delete user urls from cache", trace.WithAttributes(attribute.Int64("removedKeys", removedKeys)))
+		logger.WarnContext(ctx, "failed to delete user urls from cache", "error", err, slog.String("userId", userID), slog.Int64("removedKeys", removedKeys))
+	}
+}
+
+// unblockExpiredUsers lifts the temporary blocks that have expired.
+// Like unblockUserHandler, the block is lifted in the DB first and then in Auth0, and the DB change is
+// rolled back if Auth0 fails, so the user is retried on the next run
+func (s *Server) unblockExpiredUsers(ctx context.Context, logger *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "blocks.UnblockExpiredUsers")
+	defer span.End()
+
+	userIDs, err := s.rep.GetExpiredUserBlocks(ctx, blockExpiryBatchSize)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get expired user blocks")
+		span.RecordError(err)
+		logger.ErrorContext(ctx, "failed to get expired user blocks", "error", err)
+		return
+	}
+	span.SetAttributes(attribute.Int("expired", len(userIDs)))
+
+	var unblocked int
+	for _, userID := range userIDs {
+		if err := s.unblockExpiredUser(ctx, userID); err != nil {
+			span.RecordError(err)
+			logger.ErrorContext(ctx, "failed to unblock user with expired block", "error", err, slog.String("userId", userID))
+			continue
+		}
+
+		s.invalidateUserBlock(ctx, logger, userID, false)
+		unblocked++
+	}
+
+	if unblocked > 0 {
+		logger.InfoContext(ctx, "unblocked users with expired blocks", slog.Int("unblocked", unblocked))
+	}
+}
+
+func (s *Server) unblockExpiredUser(ctx context.Context, userID string) error {
+	return s.inTx(ctx, func(qtx *repository.Queries) error {
+		lifted, err := s.liftExpiredBlock(ctx, qtx, userID)
+		if err != nil || !lifted {
+			return err
+		}
+
+		return s.authManagement.UnblockUser(ctx, userID)
+	})
+}
+
+// liftExpiredBlock lifts the expired block of the user in the DB and records it in the audit log,
+// it reports false if the user has no expired block that is still in place
+func (s *Server) liftExpiredBlock(ctx context.Context, qtx *repository.Queries, userID string) (bool, error) {
+	userBlock, err := qtx.UnblockExpiredUser(ctx, repository.UnblockExpiredUserParams{UserID: userID, UnblockedBy: systemActor})
+	if err != nil {
+		// The block has been lifted or extended in the meantime
+		if s.rep.IsNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	entry := auditEntry{
+		ActorID:    systemActor,
+		Action:     auditActionUserUnblock,
+		TargetType: auditTargetUser,
+		TargetID:   userID,
+	}
+	err = recordAudit(ctx, qtx, entry.withSnapshots(unblockSnapshots(userBlock)))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// runBlockExpirer lifts expired blocks on start and then every blockExpiryInterval until ctx is cancelled
+func (s *Server) runBlockExpirer(ctx context.Context, logger *slog.Logger) {
+	ticker := time.NewTicker(blockExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		s.unblockExpiredUsers(ctx, logger)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-auth0/v2/management"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRejectBlockedUsers(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	url := createShortUrl(t, s, e, "https://example.com", userID_1, "")
+
+	withClaims := func(c *echo.Context, userID string) {
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{
+			RegisteredClaims: validator.RegisteredClaims{Subject: userID},
+			CustomClaims:     &auth.CustomClaims{},
+		})
+	}
+
+	// request runs a request of the user through the middleware
+	request := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/urls", nil)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		if userID != "" {
+			withClaims(c, userID)
+		}
+
+		require.NoError(t, s.rejectBlockedUsers(func(c *echo.Context) error {
+			return c.NoContent(http.StatusNoContent)
+		})(c))
+		return res
+	}
+
+	// resolve resolves the link of the user and returns the status code
+	resolve := func() int {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/urls/%s", url.ID), nil)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPath("/v1/urls/:code")
+		c.SetPathValues(echo.PathValues{{Name: "code", Value: url.ID}})
+
+		if err := s.getLongUrlHandler(c); err != nil {
+			sc, ok := err.(echo.HTTPStatusCoder)
+			require.True(t, ok, "expected an HTTP error")
+			return sc.StatusCode()
+		}
+		return res.Code
+	}
+
+	// setBlocked blocks or unblocks the user through the admin handlers
+	setBlocked := func(blocked bool) {
+		m := &mockAuthManager{}
+		m.On("BlockUser", mock.Anything, userID_1).Return(&management.UpdateUserResponseContent{}, nil)
+		m.On("UnblockUser", mock.Anything, userID_1).Return(nil)
+		s.authManagement = m
+
+		path, handler := "/v1/admin/users/unblock/%s", s.unblockUserHandler
+		if blocked {
+			path, handler = "/v1/admin/users/block/%s", s.blockUserHandler
+		}
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf(path, userID_1), nil)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPathValues(echo.PathValues{{Name: "userId", Value: userID_1}})
+		withClaims(c, adminID)
+
+		require.NoError(t, handler(c))
+		require.Less(t, res.Code, http.StatusBadRequest)
+	}
+
+	// Cache the block status and the link before the block
+	assert.Equal(t, http.StatusNoContent, request(userID_1).Code)
+	assert.Equal(t, http.StatusOK, resolve())
+
+	setBlocked(true)
+
+	t.Run("blocked user is rejected", func(t *testing.T) {
+		res := request(userID_1)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+
+		var actual HTTPReasonError
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+		assert.Equal(t, reasonUserBlocked, actual.Reason)
+	})
+
+	t.Run("other users and anonymous requests are not affected", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, request(userID_2).Code)
+		assert.Equal(t, http.StatusNoContent, request("").Code)
+	})
+
+	t.Run("links of blocked user do not resolve", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, resolve())
+	})
+
+	setBlocked(false)
+
+	t.Run("unblocked user is accepted", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, request(userID_1).Code)
+		assert.Equal(t, http.StatusOK, resolve())
+	})
+
+	t.Cleanup(cleanup)
+}
+
+func TestUnblockExpiredUsers(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	ctx := context.Background()
+
+	var (
+		expired   = time.Now().Add(-time.Minute)
+		active    = time.Now().Add(time.Hour)
+		failingID = "auth0-failure"
+	)
+	for _, arg := range []repository.BlockUserParams{
+		{UserID: userID_1, BlockedBy: adminID, BlockedUntil: &expired},
+		{UserID: userID_2, BlockedBy: adminID, BlockedUntil: &active},
+		{UserID: failingID, BlockedBy: adminID, BlockedUntil: &expired},
+		{UserID: "permanent", BlockedBy: adminID},
+	} {
+		_, err := s.rep.BlockUser(ctx, arg)
+		require.NoError(t, err)
+	}
+
+	m := &mockAuthManager{}
+	m.On("UnblockUser", mock.Anything, userID_1).Return(nil)
+	m.On("UnblockUser", mock.Anything, failingID).Return(assert.AnError)
+	s.authManagement = m
+
+	s.unblockExpiredUsers(ctx, e.Logger)
+
+	m.AssertExpectations(t)
+
+	userBlocks, err := s.rep.GetUserBlocks(ctx, repository.GetUserBlocksParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, userBlocks, 4)
+
+	for _, userBlock := range userBlocks {
+		switch userBlock.UserID {
+		case userID_1:
+			if assert.NotNil(t, userBlock.UnblockedBy, "expired block should be lifted") {
+				assert.Equal(t, systemActor, *userBlock.UnblockedBy)
+			}
+			assert.NotNil(t, userBlock.UnblockedAt)
+		case failingID:
+			assert.Nil(t, userBlock.UnblockedAt, "block should be kept if Auth0 fails")
+		default:
+			assert.Nil(t, userBlock.UnblockedAt, "active block should be kept")
+		}
+	}
+
+	t.Cleanup(cleanup)
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"net"
+
+	"github.com/labstack/echo/v5"
+)
+
+// clientIPExtractor returns how c.RealIP() gets the client IP. Without trusted proxies it is the address of the connection,
+// otherwise it is the nearest X-Forwarded-For address that is not a trusted proxy, so clients cannot forge it with the headers
+func clientIPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	// Only the configured networks are trusted, not every private network as by default
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, network := range trustedProxies {
+		options = append(options, echo.TrustIPRange(network))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIPExtractor(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.1.0.0/16")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		trustedProxies []*net.IPNet
+		remoteAddr     string
+		forwardedFor   string
+		expected       string
+	}{
+		{name: "connection address", remoteAddr: "203.0.113.1:1234", expected: "203.0.113.1"},
+		{name: "forged headers without trusted proxies", remoteAddr: "203.0.113.1:1234", forwardedFor: "198.51.100.1", expected: "203.0.113.1"},
+		{name: "forged headers from a private network", remoteAddr: "192.168.0.1:1234", forwardedFor: "198.51.100.1", expected: "192.168.0.1"},
+		{name: "forwarded by a trusted proxy", trustedProxies: []*net.IPNet{proxies}, remoteAddr: "10.1.0.1:1234", forwardedFor: "198.51.100.1", expected: "198.51.100.1"},
+		{name: "forwarded by an untrusted proxy", trustedProxies: []*net.IPNet{proxies}, remoteAddr: "10.2.0.1:1234", forwardedFor: "198.51.100.1", expected: "10.2.0.1"},
+		{name: "forged address before the trusted proxy", trustedProxies: []*net.IPNet{proxies}, remoteAddr: "10.1.0.1:1234", forwardedFor: "198.51.100.1, 203.0.113.2", expected: "203.0.113.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tt.forwardedFor)
+			}
+			req.Header.Set(echo.HeaderXRealIP, "198.51.100.99")
+
+			assert.Equal(t, tt.expected, clientIPExtractor(tt.trustedProxies)(req))
+		})
+	}
+}
@@ -0,0 +1,605 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/appvalidator"
+	"github.com/rousage/shortener/internal/domainrules"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// domainRulesRefreshInterval is how often the version of the domain rules is checked,
+	// so the changes made on other replicas are picked up
+	domainRulesRefreshInterval = 5 * time.Second
+	// domainRuleScanBatchSize is the number of links read per query by a retroactive scan
+	domainRuleScanBatchSize = 1000
+)
+
+type DomainRuleDTO struct {
+	// Block rules deny the matching destinations, allow rules exempt them from the block rules
+	List string `json:"list" validate:"required,oneof=block allow" enums:"block,allow"`
+	// exact matches the host only, suffix matches the domain and its subdomains, regex has to match the whole host
+	Kind    string  `json:"kind" validate:"required,oneof=exact suffix regex" enums:"exact,suffix,regex"`
+	Pattern string  `json:"pattern" validate:"required,max=500" example:"*.example.com"`
+	Note    *string `json:"note" validate:"omitzero,max=500"`
+}
+type DomainRuleParams struct {
+	ID int64 `param:"id" validate:"required,min=1"`
+}
+type UpdateDomainRuleParams struct {
+	DomainRuleParams
+	DomainRuleDTO
+}
+type DomainRuleFilters struct {
+	PaginationFilters
+	List *string `query:"list" validate:"omitzero,oneof=block allow"`
+}
+type PaginatedDomainRules struct {
+	Items      []repository.DomainRule `json:"items"`
+	Pagination Pagination              `json:"pagination"`
+}
+
+// loadDomainRules replaces the domain rules kept in memory with the ones in the database
+func (s *Server) loadDomainRules(ctx context.Context) error {
+	rows, err := s.rep.GetAllDomainRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]domainrules.Rule, len(rows))
+	for i, row := range rows {
+		rules[i] = domainrules.Rule{ID: row.ID, List: row.List, Kind: row.Kind, Pattern: row.Pattern}
+	}
+
+	return s.domainRules.Replace(rules)
+}
+
+// domainRulesChanged reloads the domain rules of this replica right away and bumps their version,
+// so the other replicas reload them on their next refresh
+func (s *Server) domainRulesChanged(ctx context.Context, logger *slog.Logger) {
+	span := trace.SpanFromContext(ctx)
+
+	if err := s.loadDomainRules(ctx); err != nil {
+		span.AddEvent("failed to reload domain rules")
+		logger.WarnContext(ctx, "failed to reload domain rules", "error", err)
+	}
+	if _, err := s.cache.IncrDomainRulesVersion(ctx); err != nil {
+		span.AddEvent("failed to bump domain rules version")
+		logger.WarnContext(ctx, "failed to bump domain rules version", "error", err)
+	}
+}
+
+// refreshDomainRules reloads the domain rules if their version has changed since loadedVersion.
+// While the version cannot be read from the cache, the rules are reloaded on every run so no change is missed
+func (s *Server) refreshDomainRules(ctx context.Context, logger *slog.Logger, loadedVersion *int64) {
+	ctx, span := tracer.Start(ctx, "domainRules.RefreshDomainRules")
+	defer span.End()
+
+	version, err := s.cache.GetDomainRulesVersion(ctx)
+	if err != nil {
+		span.AddEvent("failed to get domain rules version from cache")
+		logger.WarnContext(ctx, "failed to get domain rules version from cache", "error", err)
+		version = -1
+	}
+	if version >= 0 && version == *loadedVersion {
+		return
+	}
+	span.SetAttributes(attribute.Int64("version", version))
+
+	if err := s.loadDomainRules(ctx); err != nil {
+		span.SetStatus(codes.Error, "failed to load domain rules")
+		span.RecordError(err)
+		logger.ErrorContext(ctx, "failed to load domain rules", "error", err)
+		return
+	}
+	*loadedVersion = version
+}
+
+// runDomainRulesRefresher loads the domain rules on start and then checks for changes every domainRulesRefreshInterval until ctx is cancelled
+func (s *Server) runDomainRulesRefresher(ctx context.Context, logger *slog.Logger) {
+	ticker := time.NewTicker(domainRulesRefreshInterval)
+	defer ticker.Stop()
+
+	loadedVersion := int64(-1)
+	for {
+		s.refreshDomainRules(ctx, logger, &loadedVersion)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// invalidPatternError responds like a failed validation of the pattern field
+func invalidPatternError(c *echo.Context, err error) error {
+	return c.JSON(http.StatusBadRequest, &HTTPValidationError{
+		HTTPError: HTTPError{
+			Message: "Validation failed",
+		},
+		Errors: appvalidator.ValidationError{"pattern": "pattern " + err.Error()},
+	})
+}
+
+// getDomainRules godoc
+//
+//	@Summary		Get Domain Rules
+//	@Description	Retrieves a paginated list of the rules the destinations of new and edited links are checked against, newest first
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			list		query		string					false	"Only the rules of a specific list"	Enums(block, allow)
+//	@Param			page		query		int						true	"Page number"						minimum(1)	maximum(10000)	default(1)
+//	@Param			pageSize	query		int						true	"Page size"							minimum(1)	maximum(100)	default(20)
+//	@Success		200			{object}	PaginatedDomainRules	"Paginated list of domain rules"
+//	@Failure		400			{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401			{object}	HTTPError				"Unauthorized"
+//	@Failure		403			{object}	HTTPError				"Forbidden"
+//	@Failure		500			{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/domain-rules [get]
+func (s *Server) getDomainRules(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "domainRules.GetDomainRules")
+	defer span.End()
+
+	params := new(DomainRuleFilters)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+
+	span.SetAttributes(attribute.Int("page", int(params.Page)), attribute.Int("pageSize", int(params.PageSize)))
+
+	rules, err := s.rep.GetDomainRules(ctx, repository.GetDomainRulesParams{
+		List:   params.List,
+		Limit:  params.limit(),
+		Offset: params.offset(),
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get domain rules")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get domain rules", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	var totalCount int
+	if len(rules) > 0 {
+		totalCount = int(rules[0].TotalCount)
+	}
+
+	items := make([]repository.DomainRule, len(rules))
+	for i, rule := range rules {
+		items[i] = repository.DomainRule{
+			ID:        rule.ID,
+			List:      rule.List,
+			Kind:      rule.Kind,
+			Pattern:   rule.Pattern,
+			Note:      rule.Note,
+			CreatedBy: rule.CreatedBy,
+			CreatedAt: rule.CreatedAt,
+			UpdatedAt: rule.UpdatedAt,
+		}
+	}
+
+	response := &PaginatedDomainRules{
+		Items:      items,
+		Pagination: calculatePagination(totalCount, int(params.Page), int(params.PageSize)),
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// createDomainRuleHandler godoc
+//
+//	@Summary		Create Domain Rule
+//	@Description	Adds a rule the destinations of new and edited links are checked against. Allow rules take precedence over block rules. The rule applies to all replicas within a few seconds, existing links are not affected until they are scanned.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		DomainRuleDTO			true	"Rule to add"
+//	@Success		201		{object}	repository.DomainRule	"Created domain rule"
+//	@Failure		400		{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401		{object}	HTTPError				"Unauthorized"
+//	@Failure		403		{object}	HTTPError				"Forbidden"
+//	@Failure		409		{object}	HTTPError				"Domain rule already exists"
+//	@Failure		500		{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/domain-rules [post]
+func (s *Server) createDomainRuleHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "domainRules.CreateDomainRuleHandler")
+	defer span.End()
+
+	dto := new(DomainRuleDTO)
+	if err := c.Bind(dto); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(dto); err != nil {
+		span.SetStatus(codes.Error, "invalid user (admin) input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	pattern, err := domainrules.NormalizePattern(dto.Kind, dto.Pattern)
+	if err != nil {
+		span.SetStatus(codes.Error, "invalid pattern")
+		span.RecordError(err)
+		return invalidPatternError(c, err)
+	}
+	span.SetAttributes(attribute.String("list", dto.List), attribute.String("kind", dto.Kind), attribute.String("pattern", pattern))
+
+	// The ID of the rule is not known until it is created, failures are recorded with the requested rule
+	audit := newAuditEntry(c, auditActionDomainRuleCreate, auditTargetDomainRule, "")
+	arg := repository.CreateDomainRuleParams{
+		List:      dto.List,
+		Kind:      dto.Kind,
+		Pattern:   pattern,
+		Note:      dto.Note,
+		CreatedBy: audit.ActorID,
+	}
+	audit = audit.withSnapshots(nil, arg)
+
+	var rule repository.DomainRule
+	err = s.inTx(ctx, func(qtx *repository.Queries) error {
+		created, err := qtx.CreateDomainRule(ctx, arg)
+		if err != nil {
+			return err
+		}
+		rule = created
+
+		entry := audit.withSnapshots(nil, rule)
+		entry.TargetID = strconv.FormatInt(rule.ID, 10)
+		return recordAudit(ctx, qtx, entry)
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to create domain rule")
+		span.RecordError(err)
+
+		if s.rep.IsDuplicateKeyError(err) {
+			return echo.NewHTTPError(http.StatusConflict, "Domain rule already exists")
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to create domain rule", "error", err)
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
+		return echo.ErrInternalServerError
+	}
+
+	s.domainRulesChanged(ctx, c.Logger())
+
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// updateDomainRuleHandler godoc
+//
+//	@Summary		Update Domain Rule
+//	@Description	Replaces a domain rule. The change applies to all replicas within a few seconds.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"ID of the domain rule"	minimum(1)
+//	@Param			request	body		DomainRuleDTO			true	"New rule"
+//	@Success		200		{object}	repository.DomainRule	"Updated domain rule"
+//	@Failure		400		{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401		{object}	HTTPError				"Unauthorized"
+//	@Failure		403		{object}	HTTPError				"Forbidden"
+//	@Failure		404		{object}	HTTPError				"Domain rule not found"
+//	@Failure		409		{object}	HTTPError				"Domain rule already exists"
+//	@Failure		500		{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/domain-rules/{id} [put]
+func (s *Server) updateDomainRuleHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "domainRules.UpdateDomainRuleHandler")
+	defer span.End()
+
+	params := new(UpdateDomainRuleParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		span.SetStatus(codes.Error, "invalid user (admin) input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	pattern, err := domainrules.NormalizePattern(params.Kind, params.Pattern)
+	if err != nil {
+		span.SetStatus(codes.Error, "invalid pattern")
+		span.RecordError(err)
+		return invalidPatternError(c, err)
+	}
+	span.SetAttributes(attribute.Int64("ruleId", params.ID), attribute.String("list", params.List), attribute.String("kind", params.Kind), attribute.String("pattern", pattern))
+
+	audit := newAuditEntry(c, auditActionDomainRuleUpdate, auditTargetDomainRule, strconv.FormatInt(params.ID, 10))
+
+	var rule repository.DomainRule
+	err = s.inTx(ctx, func(qtx *repository.Queries) error {
+		// The rule is read in the transaction for the audit log snapshot
+		before, err := qtx.GetDomainRule(ctx, params.ID)
+		if err != nil {
+			return err
+		}
+
+		rule, err = qtx.UpdateDomainRule(ctx, repository.UpdateDomainRuleParams{
+			ID:      params.ID,
+			List:    params.List,
+			Kind:    params.Kind,
+			Pattern: pattern,
+			Note:    params.Note,
+		})
+		if err != nil {
+			return err
+		}
+
+		audit = audit.withSnapshots(before, rule)
+		return recordAudit(ctx, qtx, audit)
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to update domain rule")
+		span.RecordError(err)
+
+		if s.rep.IsNotFoundError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Domain rule not found")
+		}
+		if s.rep.IsDuplicateKeyError(err) {
+			return echo.NewHTTPError(http.StatusConflict, "Domain rule already exists")
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to update domain rule", "error", err, slog.Int64("ruleId", params.ID))
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
+		return echo.ErrInternalServerError
+	}
+
+	s.domainRulesChanged(ctx, c.Logger())
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// deleteDomainRuleHandler godoc
+//
+//	@Summary		Delete Domain Rule
+//	@Description	Removes a domain rule. The change applies to all replicas within a few seconds, links disabled by a scan of the rule stay disabled.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path	int	true	"ID of the domain rule"	minimum(1)
+//	@Success		204	"No Content - Domain rule successfully deleted"
+//	@Failure		400	{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401	{object}	HTTPError			"Unauthorized"
+//	@Failure		403	{object}	HTTPError			"Forbidden"
+//	@Failure		404	{object}	HTTPError			"Domain rule not found"
+//	@Failure		500	{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/domain-rules/{id} [delete]
+func (s *Server) deleteDomainRuleHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "domainRules.DeleteDomainRuleHandler")
+	defer span.End()
+
+	params := new(DomainRuleParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Int64("ruleId", params.ID))
+
+	audit := newAuditEntry(c, auditActionDomainRuleDelete, auditTargetDomainRule, strconv.FormatInt(params.ID, 10))
+
+	err := s.inTx(ctx, func(qtx *repository.Queries) error {
+		rule, err := qtx.DeleteDomainRule(ctx, params.ID)
+		if err != nil {
+			return err
+		}
+
+		audit = audit.withSnapshots(rule, nil)
+		return recordAudit(ctx, qtx, audit)
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to delete domain rule")
+		span.RecordError(err)
+
+		if s.rep.IsNotFoundError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Domain rule not found")
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to delete domain rule", "error", err, slog.Int64("ruleId", params.ID))
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
+		return echo.ErrInternalServerError
+	}
+
+	s.domainRulesChanged(ctx, c.Logger())
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type ScanDomainRuleDTO struct {
+	// Disable the matching links, like the disable action of the abuse report queue
+	Disable bool `json:"disable"`
+}
+type ScanDomainRuleParams struct {
+	DomainRuleParams
+	ScanDomainRuleDTO
+}
+type DomainRuleMatch struct {
+	Code    string  `json:"code"`
+	LongURL string  `json:"longUrl"`
+	UserID  *string `json:"userId"`
+	// Reason the link is disabled for, missing if it is not disabled
+	DisabledReason *string `json:"disabledReason"`
+}
+type ScanDomainRuleResponse struct {
+	// Links that are not deleted and point to a host matching the rule, unless the host is allowlisted
+	Matches []DomainRuleMatch `json:"matches"`
+	// Number of links disabled by the scan
+	Disabled int `json:"disabled"`
+}
+
+// DisableDomainRuleURLsAudit is the audit log snapshot of the URLs disabled with scanDomainRuleHandler
+type DisableDomainRuleURLsAudit struct {
+	DisabledIDs []string `json:"disabledIds"`
+}
+
+// scanDomainRuleHandler godoc
+//
+//	@Summary		Scan Domain Rule
+//	@Description	Lists the existing links that point to a host matching a block rule, e.g. after the rule was added. Hosts matching an allow rule are skipped. With disable, the matching links stop resolving, like when an admin disables a reported link.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"ID of the domain rule"	minimum(1)
+//	@Param			request	body		ScanDomainRuleDTO		false	"Whether to disable the matching links"
+//	@Success		200		{object}	ScanDomainRuleResponse	"Matching links"
+//	@Failure		400		{object}	HTTPValidationError		"Validation failed or not a block rule"
+//	@Failure		401		{object}	HTTPError				"Unauthorized"
+//	@Failure		403		{object}	HTTPError				"Forbidden"
+//	@Failure		404		{object}	HTTPError				"Domain rule not found"
+//	@Failure		500		{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/domain-rules/{id}/scan [post]
+func (s *Server) scanDomainRuleHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "domainRules.ScanDomainRuleHandler")
+	defer span.End()
+
+	params := new(ScanDomainRuleParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Int64("ruleId", params.ID), attribute.Bool("disable", params.Disable))
+
+	rule, err := s.rep.GetDomainRule(ctx, params.ID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get domain rule")
+		span.RecordError(err)
+
+		if s.rep.IsNotFoundError(err) {
+			return echo.NewHTTPError(http.StatusNotFound, "Domain rule not found")
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to get domain rule", "error", err, slog.Int64("ruleId", params.ID))
+		return echo.ErrInternalServerError
+	}
+	if rule.List != domainrules.ListBlock {
+		span.AddEvent("not a block rule")
+		return echo.NewHTTPError(http.StatusBadRequest, "Only block rules can be scanned")
+	}
+
+	matches, err := s.scanDomainRule(ctx, rule)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to scan domain rule")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to scan domain rule", "error", err, slog.Int64("ruleId", params.ID))
+		return echo.ErrInternalServerError
+	}
+	span.SetAttributes(attribute.Int("matches", len(matches)))
+
+	response := &ScanDomainRuleResponse{Matches: matches}
+	if !params.Disable {
+		return c.JSON(http.StatusOK, response)
+	}
+
+	var disabledIDs []string
+	for _, match := range matches {
+		if match.DisabledReason == nil || *match.DisabledReason != disabledReasonAbuse {
+			disabledIDs = append(disabledIDs, match.Code)
+		}
+	}
+	if len(disabledIDs) == 0 {
+		return c.JSON(http.StatusOK, response)
+	}
+
+	audit := newAuditEntry(c, auditActionDomainRuleDisableURLs, auditTargetDomainRule, strconv.FormatInt(rule.ID, 10)).
+		withSnapshots(nil, DisableDomainRuleURLsAudit{DisabledIDs: disabledIDs})
+	actorID := audit.ActorID
+
+	err = s.inTx(ctx, func(qtx *repository.Queries) error {
+		for _, code := range disabledIDs {
+			if err := qtx.DisableURL(ctx, repository.DisableURLParams{UrlID: code, DisabledBy: actorID}); err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(ctx, qtx, audit)
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to disable matching urls")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to disable matching urls", "error", err, slog.Int64("ruleId", params.ID))
+		s.recordAuditFailure(ctx, c.Logger(), audit, err)
+		return echo.ErrInternalServerError
+	}
+
+	reason := disabledReasonAbuse
+	for i := range response.Matches {
+		response.Matches[i].DisabledReason = &reason
+	}
+	response.Disabled = len(disabledIDs)
+
+	if removedKeys, err := s.cache.DeleteLongURLs(ctx, disabledIDs); err != nil {
+		span.AddEvent("failed to delete disabled urls from cache", trace.WithAttributes(attribute.Int64("removedKeys", removedKeys)))
+		c.Logger().WarnContext(ctx, "failed to delete disabled urls from cache", "error", err, slog.Int64("removedKeys", removedKeys))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// scanDomainRule reads all the links that are not deleted in batches and returns the ones pointing to a host matching the rule.
+// Hosts matching an allow rule are skipped, as they can still be used in new links
+func (s *Server) scanDomainRule(ctx context.Context, rule repository.DomainRule) ([]DomainRuleMatch, error) {
+	matcher, err := domainrules.NewMatcher([]domainrules.Rule{{ID: rule.ID, List: rule.List, Kind: rule.Kind, Pattern: rule.Pattern}})
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []DomainRuleMatch{}
+	afterID := ""
+	for {
+		urls, err := s.rep.GetURLDestinations(ctx, repository.GetURLDestinationsParams{AfterID: afterID, Limit: domainRuleScanBatchSize})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range urls {
+			parsed, err := url.Parse(u.LongUrl)
+			if err != nil {
+				continue
+			}
+			host := parsed.Hostname()
+			if !matcher.Match(host) || s.domainRules.Allowlisted(host) {
+				continue
+			}
+
+			matches = append(matches, DomainRuleMatch{
+				Code:           u.ID,
+				LongURL:        u.LongUrl,
+				UserID:         u.UserID,
+				DisabledReason: u.DisabledReason,
+			})
+		}
+
+		if len(urls) < domainRuleScanBatchSize {
+			return matches, nil
+		}
+		afterID = urls[len(urls)-1].ID
+	}
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainRules(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	// Links created before the rules are added
+	var (
+		blocked = createShortUrl(t, s, e, "https://www.blocked.com/path", userID_1, "")
+		allowed = createShortUrl(t, s, e, "https://safe.blocked.com/path", userID_1, "")
+		other   = createShortUrl(t, s, e, "https://example.com/path", userID_1, "")
+	)
+
+	// serve runs the handler as an admin with the required permission and returns the status code
+	serve := func(t *testing.T, method, path string, payload any, pathValues echo.PathValues, handler echo.HandlerFunc, out any) int {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+
+		req := httptest.NewRequest(method, path, &body)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPathValues(pathValues)
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{
+			RegisteredClaims: validator.RegisteredClaims{Subject: adminID},
+			CustomClaims:     &auth.CustomClaims{Permissions: []string{string(auth.ManageDomainRules)}},
+		})
+
+		err := authMw.RequireAuthentication(authMw.RequirePermission(auth.ManageDomainRules)(handler))(c)
+		if sc, ok := err.(echo.HTTPStatusCoder); ok {
+			return sc.StatusCode()
+		}
+		require.NoError(t, err)
+		if out != nil && res.Code < http.StatusBadRequest {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(out), "error decoding response body")
+		}
+		return res.Code
+	}
+	createRule := func(t *testing.T, dto DomainRuleDTO) (repository.DomainRule, int) {
+		var rule repository.DomainRule
+		status := serve(t, http.MethodPost, "/v1/admin/domain-rules", dto, nil, s.createDomainRuleHandler, &rule)
+		return rule, status
+	}
+	scanRule := func(t *testing.T, id int64, disable bool) (ScanDomainRuleResponse, int) {
+		var response ScanDomainRuleResponse
+		path := fmt.Sprintf("/v1/admin/domain-rules/%d/scan", id)
+		status := serve(t, http.MethodPost, path, ScanDomainRuleDTO{Disable: disable}, echo.PathValues{{Name: "id", Value: fmt.Sprint(id)}}, s.scanDomainRuleHandler, &response)
+		return response, status
+	}
+	createLinkStatus := func(t *testing.T, url string) int {
+		body, err := json.Marshal(CreateShortUrlDTO{URL: url})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/urls", bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		require.NoError(t, s.createShortURLHandler(e.NewContext(req, res)))
+		return res.Code
+	}
+
+	blockRule, status := createRule(t, DomainRuleDTO{List: "block", Kind: "suffix", Pattern: "*.Blocked.com"})
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "blocked.com", blockRule.Pattern, "pattern should be normalized")
+	assert.Equal(t, adminID, blockRule.CreatedBy)
+
+	allowRule, status := createRule(t, DomainRuleDTO{List: "allow", Kind: "exact", Pattern: "safe.blocked.com"})
+	require.Equal(t, http.StatusCreated, status)
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, status := createRule(t, DomainRuleDTO{List: "block", Kind: "regex", Pattern: "(blocked"})
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("duplicate rule", func(t *testing.T) {
+		_, status := createRule(t, DomainRuleDTO{List: "block", Kind: "suffix", Pattern: "blocked.com"})
+		assert.Equal(t, http.StatusConflict, status)
+	})
+
+	t.Run("new links", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, createLinkStatus(t, "https://blocked.com"))
+		assert.Equal(t, http.StatusBadRequest, createLinkStatus(t, "https://sub.BLOCKED.com/path"))
+		assert.Equal(t, http.StatusCreated, createLinkStatus(t, "https://safe.blocked.com"), "allowlisted host should be allowed")
+		assert.Equal(t, http.StatusCreated, createLinkStatus(t, "https://notblocked.com"))
+	})
+
+	t.Run("list rules", func(t *testing.T) {
+		var actual PaginatedDomainRules
+		status := serve(t, http.MethodGet, "/v1/admin/domain-rules?page=1&pageSize=10&list=block", nil, nil, s.getDomainRules, &actual)
+		require.Equal(t, http.StatusOK, status)
+		if assert.Len(t, actual.Items, 1) {
+			assert.Equal(t, blockRule.ID, actual.Items[0].ID)
+		}
+	})
+
+	t.Run("scan allow rule", func(t *testing.T) {
+		_, status := scanRule(t, allowRule.ID, false)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("scan", func(t *testing.T) {
+		response, status := scanRule(t, blockRule.ID, false)
+		require.Equal(t, http.StatusOK, status)
+		if assert.Len(t, response.Matches, 1, "allowlisted and other hosts should not match") {
+			assert.Equal(t, blocked.ID, response.Matches[0].Code)
+		}
+		assert.Zero(t, response.Disabled)
+		assert.Equal(t, http.StatusOK, resolveStatus(t, s, e, blocked.ID))
+	})
+
+	t.Run("scan and disable", func(t *testing.T) {
+		response, status := scanRule(t, blockRule.ID, true)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, 1, response.Disabled)
+
+		assert.Equal(t, http.StatusForbidden, resolveStatus(t, s, e, blocked.ID))
+		assert.Equal(t, http.StatusOK, resolveStatus(t, s, e, allowed.ID))
+		assert.Equal(t, http.StatusOK, resolveStatus(t, s, e, other.ID))
+
+		response, status = scanRule(t, blockRule.ID, true)
+		require.Equal(t, http.StatusOK, status)
+		assert.Zero(t, response.Disabled, "disabled links should not be disabled again")
+	})
+
+	t.Run("update missing rule", func(t *testing.T) {
+		status := serve(t, http.MethodPut, "/v1/admin/domain-rules/999", DomainRuleDTO{List: "block", Kind: "exact", Pattern: "blocked.com"},
+			echo.PathValues{{Name: "id", Value: "999"}}, s.updateDomainRuleHandler, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("update rule", func(t *testing.T) {
+		var rule repository.DomainRule
+		status := serve(t, http.MethodPut, fmt.Sprintf("/v1/admin/domain-rules/%d", blockRule.ID), DomainRuleDTO{List: "block", Kind: "exact", Pattern: "blocked.com"},
+			echo.PathValues{{Name: "id", Value: fmt.Sprint(blockRule.ID)}}, s.updateDomainRuleHandler, &rule)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "exact", rule.Kind)
+
+		assert.Equal(t, http.StatusBadRequest, createLinkStatus(t, "https://blocked.com"))
+		assert.Equal(t, http.StatusCreated, createLinkStatus(t, "https://sub.blocked.com"), "subdomains should be allowed by an exact rule")
+	})
+
+	t.Run("delete rule", func(t *testing.T) {
+		status := serve(t, http.MethodDelete, fmt.Sprintf("/v1/admin/domain-rules/%d", blockRule.ID), nil,
+			echo.PathValues{{Name: "id", Value: fmt.Sprint(blockRule.ID)}}, s.deleteDomainRuleHandler, nil)
+		require.Equal(t, http.StatusNoContent, status)
+
+		assert.Equal(t, http.StatusCreated, createLinkStatus(t, "https://blocked.com"))
+	})
+
+	t.Run("audit log", func(t *testing.T) {
+		var actual PaginatedAuditLog
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/audit?page=1&pageSize=10&targetType=domain_rule", nil)
+		res := httptest.NewRecorder()
+		require.NoError(t, s.getAuditLog(e.NewContext(req, res)))
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+
+		actions := make([]string, len(actual.Items))
+		for i, item := range actual.Items {
+			actions[i] = item.Action
+		}
+		assert.Equal(t, []string{auditActionDomainRuleDelete, auditActionDomainRuleUpdate, auditActionDomainRuleDisableURLs, auditActionDomainRuleCreate, auditActionDomainRuleCreate}, actions)
+	})
+
+	t.Cleanup(cleanup)
+}
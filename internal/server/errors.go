@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/labstack/echo/v5"
@@ -18,6 +19,22 @@ type HTTPValidationError struct {
 	Errors appvalidator.ValidationError `json:"errors" example:"{\"field\":\"field error message\"}"`
 }
 
+// HTTPReasonError represents an HTTP error response with a machine-readable reason
+type HTTPReasonError struct {
+	HTTPError
+	Reason string `json:"reason" example:"user_blocked"`
+}
+
+// validateCtx validates i with the context of the request, so the validations that look up hosts or follow links
+// stop once the client goes away
+func (s *Server) validateCtx(ctx context.Context, c *echo.Context, i any) error {
+	if appValidator, ok := c.Echo().Validator.(*appvalidator.AppValidator); ok {
+		return appValidator.ValidateCtx(ctx, i)
+	}
+
+	return c.Validate(i)
+}
+
 func (s *Server) failedValidationError(c *echo.Context, err error) error {
 	if appValidator, ok := c.Echo().Validator.(*appvalidator.AppValidator); ok {
 		validationErrors := appValidator.FormatErrors(err)
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// urlETag builds a strong ETag for a URL from its updated_at column
+func urlETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 36) + `"`
+}
+
+// etagMatches reports whether the If-Match header value matches the current ETag.
+// Weak ETags never match as If-Match requires the strong comparison
+func etagMatches(ifMatch, etag string) bool {
+	for candidate := range strings.SplitSeq(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
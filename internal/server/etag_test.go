@@ -0,0 +1,32 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEtagMatches(t *testing.T) {
+	updatedAt := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+	etag := urlETag(updatedAt)
+
+	tests := []struct {
+		name     string
+		ifMatch  string
+		expected bool
+	}{
+		{name: "same etag", ifMatch: etag, expected: true},
+		{name: "any etag", ifMatch: "*", expected: true},
+		{name: "etag in a list", ifMatch: `"abc", ` + etag, expected: true},
+		{name: "different etag", ifMatch: urlETag(updatedAt.Add(time.Microsecond)), expected: false},
+		{name: "weak etag", ifMatch: "W/" + etag, expected: false},
+		{name: "unquoted etag", ifMatch: etag[1 : len(etag)-1], expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, etagMatches(tt.ifMatch, etag))
+		})
+	}
+}
@@ -36,3 +36,12 @@ func calculatePagination(totalItems, page, pageSize int) Pagination {
 		HasPrevious: page > 1,
 	}
 }
+
+type URLSortFilters struct {
+	// Sort order of the URLs, the newest URLs come first by default
+	SortBy string `query:"sortBy" validate:"omitempty,oneof=createdAt clickCount lastClickedAt"`
+}
+type UserURLsFilters struct {
+	PaginationFilters
+	URLSortFilters
+}
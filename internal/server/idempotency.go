@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyKeyTTL is how long a key is remembered, retries after it create a new link
+	idempotencyKeyTTL           = 24 * time.Hour
+	idempotencyKeyPurgeInterval = time.Hour
+)
+
+// errIdempotencyKeyUsed is returned when a concurrent request with the same key created its link first
+var errIdempotencyKeyUsed = errors.New("idempotency key has already been used")
+
+type idempotencyKey struct {
+	scope       string
+	key         string
+	requestHash string
+}
+
+// newIdempotencyKey returns the Idempotency-Key of the request, or nil if it has none.
+// Keys are scoped to the user, or to the client IP of anonymous requests, so they cannot be used to read the links of others.
+// The request is fingerprinted without its password, which would otherwise be stored with a fast hash
+func newIdempotencyKey(c *echo.Context, dto CreateShortUrlDTO) (*idempotencyKey, error) {
+	key := c.Request().Header.Get(headerIdempotencyKey)
+	if key == "" {
+		return nil, nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Idempotency-Key must be at most 255 characters long")
+	}
+
+	scope := "ip:" + c.RealIP()
+	if userID := auth.GetUserID(c); userID != nil {
+		scope = "user:" + *userID
+	}
+
+	dto.Password = ""
+	payload, err := json.Marshal(dto)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(payload)
+
+	return &idempotencyKey{scope: scope, key: key, requestHash: hex.EncodeToString(hash[:])}, nil
+}
+
+// replayIdempotentRequest responds with the link of an earlier request with the same key and with the status of its response.
+// It reports whether the request was replayed, a key reused for a different request is rejected.
+// Keys older than idempotencyKeyTTL or whose link has been deleted are not replayed, the request stores the key again
+func (s *Server) replayIdempotentRequest(ctx context.Context, c *echo.Context, key *idempotencyKey) (bool, error) {
+	span := trace.SpanFromContext(ctx)
+
+	stored, err := s.rep.GetIdempotencyKey(ctx, repository.GetIdempotencyKeyParams{
+		Scope:        key.scope,
+		Key:          key.key,
+		CreatedAfter: time.Now().Add(-idempotencyKeyTTL),
+	})
+	if err != nil {
+		if s.rep.IsNotFoundError(err) {
+			return false, nil
+		}
+
+		span.SetStatus(codes.Error, "failed to get idempotency key")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get idempotency key", "error", err)
+		return false, echo.ErrInternalServerError
+	}
+
+	url, err := s.rep.GetUrl(ctx, stored.UrlID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get short url")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get short url", "error", err, slog.String("code", stored.UrlID))
+		return false, echo.ErrInternalServerError
+	}
+	if url.DeletedAt != nil {
+		span.AddEvent("link of the idempotency key has been deleted", trace.WithAttributes(attribute.String("code", url.ID)))
+		return false, nil
+	}
+
+	if stored.RequestHash != key.requestHash {
+		span.AddEvent("idempotency key reused for a different request")
+		return false, echo.NewHTTPError(http.StatusUnprocessableEntity, "Idempotency-Key has already been used for a different request")
+	}
+	span.AddEvent("idempotent request replayed", trace.WithAttributes(attribute.String("code", url.ID)))
+
+	c.Response().Header().Set(headerIdempotentReplayed, "true")
+	c.Response().Header().Set(headerETag, urlETag(url.UpdatedAt))
+	return true, c.JSON(int(stored.StatusCode), s.newCreateShortUrlResponse(url))
+}
+
+// storeIdempotencyKey remembers the link and the response status of the request with the key.
+// It replaces an expired key or the key of a deleted link, errIdempotencyKeyUsed is returned
+// if a concurrent request has stored the key first
+func storeIdempotencyKey(ctx context.Context, rep *repository.Queries, key *idempotencyKey, urlID string, statusCode int) error {
+	// Waits for a concurrent request with the same key to finish
+	stored, err := rep.CreateIdempotencyKey(ctx, repository.CreateIdempotencyKeyParams{
+		Scope:         key.scope,
+		Key:           key.key,
+		RequestHash:   key.requestHash,
+		UrlID:         urlID,
+		StatusCode:    int32(statusCode),
+		ExpiredBefore: time.Now().Add(-idempotencyKeyTTL),
+	})
+	if err != nil {
+		return err
+	}
+	if stored == 0 {
+		return errIdempotencyKeyUsed
+	}
+
+	return nil
+}
+
+// replayConcurrentRequest responds with the link created by the concurrent request that stored the key first
+func (s *Server) replayConcurrentRequest(ctx context.Context, c *echo.Context, key *idempotencyKey) error {
+	replayed, err := s.replayIdempotentRequest(ctx, c, key)
+	if err == nil && !replayed {
+		// The link of the concurrent request has been removed in the meantime
+		c.Logger().ErrorContext(ctx, "idempotency key not found after a conflict", slog.String("key", key.key))
+		return echo.ErrInternalServerError
+	}
+
+	return err
+}
+
+// purgeIdempotencyKeys removes the keys older than idempotencyKeyTTL
+func (s *Server) purgeIdempotencyKeys(ctx context.Context, logger *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "idempotency.PurgeIdempotencyKeys")
+	defer span.End()
+
+	purged, err := s.rep.DeleteExpiredIdempotencyKeys(ctx, time.Now().Add(-idempotencyKeyTTL))
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to purge idempotency keys")
+		span.RecordError(err)
+		logger.ErrorContext(ctx, "failed to purge idempotency keys", "error", err)
+		return
+	}
+
+	span.SetAttributes(attribute.Int64("purged", purged))
+	if purged > 0 {
+		logger.InfoContext(ctx, "purged idempotency keys", slog.Int64("purged", purged))
+	}
+}
+
+// runIdempotencyKeyPurger purges the expired keys on start and then every idempotencyKeyPurgeInterval until ctx is cancelled
+func (s *Server) runIdempotencyKeyPurger(ctx context.Context, logger *slog.Logger) {
+	ticker := time.NewTicker(idempotencyKeyPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.purgeIdempotencyKeys(ctx, logger)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// headerLinkPassword is used by API clients to submit the password of a password-protected link
+	headerLinkPassword = "X-Link-Password"
+
+	// Wrong password attempts are limited per short code
+	maxPasswordAttempts    = 5
+	passwordAttemptsWindow = 15 * time.Minute
+)
+
+var (
+	errPasswordRequired         = echo.NewHTTPError(http.StatusUnauthorized, "Short URL is password protected")
+	errInvalidPassword          = echo.NewHTTPError(http.StatusUnauthorized, "Invalid password")
+	errTooManyPasswordAttempts  = echo.NewHTTPError(http.StatusTooManyRequests, "Too many wrong password attempts, try again later")
+	errPasswordCheckUnavailable = echo.NewHTTPError(http.StatusServiceUnavailable, "Password cannot be checked right now, try again later")
+)
+
+//go:embed templates/password.html
+var templatesFS embed.FS
+
+var passwordTemplate = template.Must(template.ParseFS(templatesFS, "templates/password.html"))
+
+// hashPassword returns a bcrypt hash of the link password
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// checkLinkPassword verifies the submitted password against the hash of a password-protected link.
+// Attempts are counted per code before the password is compared, so concurrent guesses cannot get past the limit,
+// and the count is reset by the correct password. Once the limit is reached the password is not checked at all until
+// the window expires. Passwords are not checked while the attempts cannot be counted
+func (s *Server) checkLinkPassword(ctx context.Context, c *echo.Context, code, passwordHash, password string) error {
+	span := trace.SpanFromContext(ctx)
+
+	if password == "" {
+		span.AddEvent("short url is password protected")
+		return errPasswordRequired
+	}
+
+	attempts, err := s.cache.IncrPasswordAttempts(ctx, code, passwordAttemptsWindow)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to record password attempt")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to record password attempt", "error", err, slog.String("code", code))
+		return errPasswordCheckUnavailable
+	}
+	if attempts > maxPasswordAttempts {
+		span.AddEvent("too many wrong password attempts", trace.WithAttributes(attribute.Int64("attempts", attempts)))
+		return errTooManyPasswordAttempts
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		span.AddEvent("invalid password")
+		return errInvalidPassword
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to check password")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to check password", "error", err, slog.String("code", code))
+		return echo.ErrInternalServerError
+	}
+
+	if err := s.cache.ResetPasswordAttempts(ctx, code); err != nil {
+		span.AddEvent("failed to reset password attempts")
+		c.Logger().WarnContext(ctx, "failed to reset password attempts", "error", err, slog.String("code", code))
+	}
+
+	return nil
+}
+
+// renderPasswordPrompt renders the HTML password prompt for browsers if err is a password error,
+// any other error is returned as is
+func renderPasswordPrompt(c *echo.Context, code string, err error) error {
+	var message string
+	switch {
+	case errors.Is(err, errPasswordRequired):
+	case errors.Is(err, errInvalidPassword), errors.Is(err, errTooManyPasswordAttempts):
+		message = err.(*echo.HTTPError).Message
+	default:
+		return err
+	}
+
+	var buf bytes.Buffer
+	if tmplErr := passwordTemplate.Execute(&buf, map[string]string{"Code": code, "Error": message}); tmplErr != nil {
+		return tmplErr
+	}
+
+	return c.HTMLBlob(err.(*echo.HTTPError).Code, buf.Bytes())
+}
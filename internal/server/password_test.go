@@ -0,0 +1,244 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateShortURLHandler_Password(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	longUrl := "https://www.example.com"
+	tests := []struct {
+		name           string
+		payload        CreateShortUrlDTO
+		userId         string
+		expectedStatus int
+	}{
+		{name: "password-protected url", payload: CreateShortUrlDTO{URL: longUrl, Password: "secret"}, userId: "user-id", expectedStatus: http.StatusCreated},
+		{name: "too short password", payload: CreateShortUrlDTO{URL: longUrl, Password: "abc"}, userId: "user-id", expectedStatus: http.StatusBadRequest},
+		{name: "too long password", payload: CreateShortUrlDTO{URL: longUrl, Password: strings.Repeat("a", 73)}, userId: "user-id", expectedStatus: http.StatusBadRequest},
+		// only authenticated users can create password-protected urls
+		{name: "unauthenticated user", payload: CreateShortUrlDTO{URL: longUrl, Password: "secret"}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.payload)
+			require.NoError(t, err, "could not marshal payload")
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/urls", bytes.NewBuffer(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+
+			if tt.userId != "" {
+				c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{
+					Subject: tt.userId,
+				}})
+			}
+
+			// Assertions
+			err = s.createShortURLHandler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+			}
+
+			if tt.expectedStatus == http.StatusCreated {
+				assert.NotContains(t, res.Body.String(), "passwordHash", "password hash must not be exposed")
+
+				var actual CreateShortUrlResponse
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+				assert.True(t, actual.PasswordProtected, "passwordProtected does not match")
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestGetLongUrlHandler_Password(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	protectedUrl := createPasswordProtectedUrl(t, s, "protected", "secret")
+
+	tests := []struct {
+		name           string
+		password       string
+		expectedStatus int
+	}{
+		{name: "missing password", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong password", password: "wrong", expectedStatus: http.StatusUnauthorized},
+		{name: "correct password", password: "secret", expectedStatus: http.StatusOK},
+		{name: "correct password again", password: "secret", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/urls/%s", protectedUrl.ID), nil)
+			if tt.password != "" {
+				req.Header.Set(headerLinkPassword, tt.password)
+			}
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/v1/urls/:code")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: protectedUrl.ID}})
+
+			// Assertions
+			err := s.getLongUrlHandler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+
+				var actual GetLongUrlResponse
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+				assert.Equal(t, protectedUrl.LongUrl, actual.LongUrl, "long URL does not match")
+			}
+
+			actualCache, err := s.cache.GetLongUrl(context.Background(), protectedUrl.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "", actualCache, "password-protected url should not be cached")
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestUnlockLongUrlHandler_Attempts(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	protectedUrl := createPasswordProtectedUrl(t, s, "protected", "secret")
+
+	unlock := func(password string) error {
+		body, err := json.Marshal(UnlockLongUrlDTO{Password: password})
+		require.NoError(t, err, "could not marshal payload")
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/urls/%s/unlock", protectedUrl.ID), bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPath("/v1/urls/:code/unlock")
+		c.SetPathValues(echo.PathValues{{Name: "code", Value: protectedUrl.ID}})
+
+		return s.unlockLongUrlHandler(c)
+	}
+
+	require.NoError(t, unlock("secret"))
+
+	for range maxPasswordAttempts {
+		err := unlock("wrong")
+		if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok, "expected an HTTP error") {
+			assert.Equal(t, http.StatusUnauthorized, sc.StatusCode())
+		}
+	}
+
+	// Even the correct password is rejected once the limit is reached
+	err := unlock("secret")
+	if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok, "expected an HTTP error") {
+		assert.Equal(t, http.StatusTooManyRequests, sc.StatusCode())
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestRedirectHandler_Password(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	protectedUrl := createPasswordProtectedUrl(t, s, "protected", "secret")
+
+	t.Run("renders the password prompt", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s", protectedUrl.ID), nil)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPath("/:code")
+		c.SetPathValues(echo.PathValues{{Name: "code", Value: protectedUrl.ID}})
+
+		err := s.redirectHandler(c)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Contains(t, res.Header().Get(echo.HeaderContentType), echo.MIMETextHTML)
+		assert.Contains(t, res.Body.String(), `action="/protected"`)
+	})
+
+	t.Run("redirects with the password header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s", protectedUrl.ID), nil)
+		req.Header.Set(headerLinkPassword, "secret")
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPath("/:code")
+		c.SetPathValues(echo.PathValues{{Name: "code", Value: protectedUrl.ID}})
+
+		err := s.redirectHandler(c)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusFound, res.Code)
+		assert.Equal(t, protectedUrl.LongUrl, res.Header().Get(echo.HeaderLocation), "location does not match")
+	})
+
+	tests := []struct {
+		name             string
+		password         string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "wrong password renders the prompt again", password: "wrong", expectedStatus: http.StatusUnauthorized},
+		{name: "correct password redirects", password: "secret", expectedStatus: http.StatusSeeOther, expectedLocation: protectedUrl.LongUrl},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"password": {tt.password}}
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/%s", protectedUrl.ID), strings.NewReader(form.Encode()))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:code")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: protectedUrl.ID}})
+
+			err := s.redirectUnlockHandler(c)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.Code)
+			if tt.expectedLocation != "" {
+				assert.Equal(t, tt.expectedLocation, res.Header().Get(echo.HeaderLocation), "location does not match")
+			} else {
+				assert.Contains(t, res.Body.String(), "Invalid password")
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func createPasswordProtectedUrl(t *testing.T, s *Server, code, password string) repository.Url {
+	userID := "user-id"
+	passwordHash, err := hashPassword(password)
+	require.NoError(t, err)
+
+	protectedUrl, err := s.rep.CreateUrl(context.Background(), repository.CreateUrlParams{
+		ID:           code,
+		LongUrl:      "https://example.com",
+		IsCustom:     true,
+		UserID:       &userID,
+		PasswordHash: &passwordHash,
+	})
+	require.NoError(t, err)
+
+	return protectedUrl
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+)
+
+// Route groups with separate rate limits
+const (
+	rateLimitDefault = "default"
+	rateLimitCreate  = "create"
+	rateLimitResolve = "resolve"
+	rateLimitAdmin   = "admin"
+	rateLimitReport  = "report"
+	// rateLimitClient is the limit of every client IP that runs before the authentication
+	rateLimitClient = "client"
+)
+
+// rateLimitGroup returns the rate limit group of the matched route
+func rateLimitGroup(c *echo.Context) string {
+	path := c.Path()
+	method := c.Request().Method
+
+	switch {
+	case strings.HasPrefix(path, "/v1/admin"):
+		return rateLimitAdmin
+	case method == http.MethodPost && path == "/v1/urls":
+		return rateLimitCreate
+	case path == "/v1/urls/:code/report":
+		return rateLimitReport
+	case path == "/:code", path == "/v1/urls/:code" && method == http.MethodGet, path == "/v1/urls/:code/unlock":
+		return rateLimitResolve
+	default:
+		return rateLimitDefault
+	}
+}
+
+func (s *Server) rateLimitOf(group string) config.RateLimit {
+	switch group {
+	case rateLimitCreate:
+		return s.cfg.Server.CreateRateLimit
+	case rateLimitResolve:
+		return s.cfg.Server.ResolveRateLimit
+	case rateLimitAdmin:
+		return s.cfg.Server.AdminRateLimit
+	case rateLimitReport:
+		return s.cfg.Server.ReportRateLimit
+	default:
+		return s.cfg.Server.RateLimit
+	}
+}
+
+// rateLimitIdentity returns who the request is counted for: the API key, the user or the client IP for anonymous requests
+func rateLimitIdentity(c *echo.Context) string {
+	if apiKeyID := auth.GetAPIKeyID(c); apiKeyID != 0 {
+		return fmt.Sprintf("key:%d", apiKeyID)
+	}
+	if userID := auth.GetUserID(c); userID != nil {
+		return "user:" + *userID
+	}
+
+	return "ip:" + c.RealIP()
+}
+
+// rateLimit limits the requests of every identity per route group with a limit shared by all instances.
+// It must run after the authentication, so authenticated users are not limited by their IP.
+// If the cache is unavailable, requests are let through
+func (s *Server) rateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c *echo.Context) error {
+		ctx, span := tracer.Start(c.Request().Context(), "ratelimit.RateLimit")
+		defer span.End()
+
+		group := rateLimitGroup(c)
+		return s.allowRate(ctx, c, group, rateLimitIdentity(c), s.rateLimitOf(group), next)
+	}
+}
+
+// rateLimitClient limits the requests of every client IP before they are authenticated, so the requests
+// with invalid credentials, which never reach the limits per user and API key, are counted as well.
+// The limit is higher than the others as it is shared by everyone behind the IP
+func (s *Server) rateLimitClient(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c *echo.Context) error {
+		ctx, span := tracer.Start(c.Request().Context(), "ratelimit.RateLimitClient")
+		defer span.End()
+
+		return s.allowRate(ctx, c, rateLimitClient, "ip:"+c.RealIP(), s.cfg.Server.ClientRateLimit, next)
+	}
+}
+
+// allowRate counts the request against the limit of the identity in the group and rejects it once the limit is exceeded
+func (s *Server) allowRate(ctx context.Context, c *echo.Context, group, identity string, limit config.RateLimit, next echo.HandlerFunc) error {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("group", group), attribute.String("identity", identity))
+
+	result, err := s.cache.AllowRate(ctx, group+":"+identity, limit.RPS, limit.Burst)
+	if err != nil {
+		span.AddEvent("rate limiter unavailable, failing open")
+		c.Logger().WarnContext(ctx, "rate limiter unavailable, failing open", "error", err, slog.String("group", group))
+		s.rateLimitFailOpenCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("group", group)))
+		return next(c)
+	}
+
+	header := c.Response().Header()
+	header.Set(headerRateLimitLimit, strconv.Itoa(limit.Burst))
+	header.Set(headerRateLimitRemaining, strconv.FormatInt(result.Remaining, 10))
+	header.Set(headerRateLimitReset, strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+	if !result.Allowed {
+		span.AddEvent("rate limit exceeded", trace.WithAttributes(attribute.Int64("retryAfterMs", result.RetryAfter.Milliseconds())))
+		header.Set(headerRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		return echo.ErrTooManyRequests
+	}
+
+	return next(c)
+}
+
+// ceilSeconds rounds the duration up to whole seconds, as the rate limit headers use seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
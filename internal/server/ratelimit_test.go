@@ -0,0 +1,157 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitGroup(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		group  string
+	}{
+		{method: http.MethodPost, path: "/v1/urls", group: rateLimitCreate},
+		{method: http.MethodGet, path: "/v1/urls", group: rateLimitDefault},
+		{method: http.MethodGet, path: "/v1/urls/:code", group: rateLimitResolve},
+		{method: http.MethodPatch, path: "/v1/urls/:code", group: rateLimitDefault},
+		{method: http.MethodPost, path: "/v1/urls/:code/unlock", group: rateLimitResolve},
+		{method: http.MethodPost, path: "/v1/urls/:code/report", group: rateLimitReport},
+		{method: http.MethodGet, path: "/:code", group: rateLimitResolve},
+		{method: http.MethodPost, path: "/:code", group: rateLimitResolve},
+		{method: http.MethodGet, path: "/v1/admin/urls", group: rateLimitAdmin},
+		{method: http.MethodPost, path: "/v1/admin/users/block/:userId", group: rateLimitAdmin},
+		{method: http.MethodGet, path: "/v1/webhooks", group: rateLimitDefault},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			c := e.NewContext(httptest.NewRequest(tt.method, "/", nil), httptest.NewRecorder())
+			c.SetPath(tt.path)
+
+			assert.Equal(t, tt.group, rateLimitGroup(c))
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	s.cfg.Server.CreateRateLimit = config.RateLimit{RPS: 1, Burst: 2}
+	s.cfg.Server.RateLimit = config.RateLimit{RPS: 100, Burst: 100}
+
+	handler := s.rateLimit(func(c *echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	request := func(method, path, userID, ip string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPath(path)
+		if userID != "" {
+			c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: userID}})
+		}
+
+		return res, handler(c)
+	}
+
+	for range 2 {
+		res, err := request(http.MethodPost, "/v1/urls", "user-1", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Equal(t, "2", res.Header().Get(headerRateLimitLimit))
+		assert.NotEmpty(t, res.Header().Get(headerRateLimitRemaining))
+		assert.NotEmpty(t, res.Header().Get(headerRateLimitReset))
+	}
+
+	t.Run("limit exceeded", func(t *testing.T) {
+		res, err := request(http.MethodPost, "/v1/urls", "user-1", "10.0.0.2")
+		if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok) {
+			assert.Equal(t, http.StatusTooManyRequests, sc.StatusCode())
+		}
+		assert.Equal(t, "0", res.Header().Get(headerRateLimitRemaining))
+		assert.Equal(t, "1", res.Header().Get(headerRetryAfter))
+	})
+
+	t.Run("users behind the same IP have their own limit", func(t *testing.T) {
+		res, err := request(http.MethodPost, "/v1/urls", "user-2", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.Code)
+	})
+
+	t.Run("route groups have their own limit", func(t *testing.T) {
+		res, err := request(http.MethodGet, "/v1/urls", "user-1", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Equal(t, "100", res.Header().Get(headerRateLimitLimit))
+	})
+
+	t.Run("anonymous requests are limited by IP", func(t *testing.T) {
+		for range 2 {
+			res, err := request(http.MethodPost, "/v1/urls", "", "10.0.0.3")
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusNoContent, res.Code)
+		}
+
+		_, err := request(http.MethodPost, "/v1/urls", "", "10.0.0.3")
+		if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok) {
+			assert.Equal(t, http.StatusTooManyRequests, sc.StatusCode())
+		}
+	})
+
+	t.Run("forged forwarding headers do not reset the limit", func(t *testing.T) {
+		forged := func(i int) error {
+			req := httptest.NewRequest(http.MethodPost, "/v1/urls", nil)
+			req.RemoteAddr = "10.0.0.5:1234"
+			req.Header.Set(echo.HeaderXForwardedFor, fmt.Sprintf("203.0.113.%d", i))
+			req.Header.Set(echo.HeaderXRealIP, fmt.Sprintf("198.51.100.%d", i))
+			c := e.NewContext(req, httptest.NewRecorder())
+			c.SetPath("/v1/urls")
+
+			return handler(c)
+		}
+
+		for i := range 2 {
+			require.NoError(t, forged(i))
+		}
+
+		err := forged(2)
+		if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok) {
+			assert.Equal(t, http.StatusTooManyRequests, sc.StatusCode())
+		}
+	})
+
+	t.Run("client IP is limited before the authentication", func(t *testing.T) {
+		s.cfg.Server.ClientRateLimit = config.RateLimit{RPS: 1, Burst: 2}
+		// Requests with invalid credentials are rejected by the authentication after the client limit
+		unauthorized := s.rateLimitClient(func(c *echo.Context) error {
+			return echo.ErrUnauthorized
+		})
+
+		for range 2 {
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/v1/urls", nil), httptest.NewRecorder())
+			c.Request().RemoteAddr = "10.0.0.4:1234"
+			assert.ErrorIs(t, unauthorized(c), echo.ErrUnauthorized)
+		}
+
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/v1/urls", nil), httptest.NewRecorder())
+		c.Request().RemoteAddr = "10.0.0.4:1234"
+		err := unauthorized(c)
+		if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok) {
+			assert.Equal(t, http.StatusTooManyRequests, sc.StatusCode())
+		}
+	})
+
+	t.Cleanup(cleanup)
+}
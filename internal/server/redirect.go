@@ -0,0 +1,113 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+type RedirectParams struct {
+	GetLongUrlParams
+}
+
+// redirectHandler godoc
+//
+//	@Summary		Redirect to Long URL
+//	@Description	Redirects to the original long URL for a given short code. Checks cache first, then database. The redirect status code is configurable (301, 302, 307 or 308). HEAD requests do not consume clicks of click-limited links. Password-protected links render an HTML password prompt unless the password is sent in the X-Link-Password header.
+//	@Tags			Redirect
+//	@Produce		html
+//	@Param			code			path	string	true	"Short code"	maxlength(16)
+//	@Param			X-Link-Password	header	string	false	"Password of a password-protected link"
+//	@Success		302				"Redirect to the long URL"
+//	@Failure		400				{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401				"Password prompt"
+//	@Failure		403				{object}	HTTPError	"Short URL has been disabled, is under review or its owner is blocked"
+//	@Failure		404				{object}	HTTPError	"Short URL not found"
+//	@Failure		410				{object}	HTTPError	"Short URL has expired, reached its click limit or has been deleted"
+//	@Failure		429				"Password prompt with too many wrong attempts error"
+//	@Failure		500				{object}	HTTPError	"Internal server error"
+//	@Failure		503				{object}	HTTPError	"Password cannot be checked right now"
+//	@Router			/{code} [get]
+//	@Router			/{code} [head]
+func (s *Server) redirectHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "redirect.RedirectHandler")
+	defer span.End()
+
+	params := new(RedirectParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code), attribute.String("method", c.Request().Method))
+
+	longUrl, err := s.resolveLongUrl(ctx, c, params.Code, resolveOptions{
+		// HEAD requests (link previews, uptime checks) do not consume clicks of click-limited links
+		consumeClick: c.Request().Method != http.MethodHead,
+		password:     c.Request().Header.Get(headerLinkPassword),
+	})
+	if err != nil {
+		return renderPasswordPrompt(c, params.Code, err)
+	}
+
+	// Do not let browsers cache the redirect permanently (even for 301/308),
+	// otherwise deleted or changed links would keep resolving on the client
+	c.Response().Header().Set(echo.HeaderCacheControl, "private, no-cache")
+
+	return c.Redirect(s.cfg.App.RedirectStatus, longUrl)
+}
+
+type RedirectUnlockParams struct {
+	GetLongUrlParams
+	Password string `form:"password" json:"password" validate:"max=72"`
+}
+
+// redirectUnlockHandler godoc
+//
+//	@Summary		Unlock and Redirect to Long URL
+//	@Description	Submits the password of a password-protected short code from the HTML prompt and redirects to the long URL with 303 See Other. A wrong password renders the prompt again. Wrong password attempts are rate limited per short code.
+//	@Tags			Redirect
+//	@Accept			x-www-form-urlencoded
+//	@Produce		html
+//	@Param			code		path		string	true	"Short code"			maxlength(16)
+//	@Param			password	formData	string	true	"Password of the link"	maxlength(72)
+//	@Success		303			"Redirect to the long URL"
+//	@Failure		400			{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401			"Password prompt with invalid password error"
+//	@Failure		403			{object}	HTTPError	"Short URL has been disabled, is under review or its owner is blocked"
+//	@Failure		404			{object}	HTTPError	"Short URL not found"
+//	@Failure		410			{object}	HTTPError	"Short URL has expired, reached its click limit or has been deleted"
+//	@Failure		429			"Password prompt with too many wrong attempts error"
+//	@Failure		500			{object}	HTTPError	"Internal server error"
+//	@Failure		503			{object}	HTTPError	"Password cannot be checked right now"
+//	@Router			/{code} [post]
+func (s *Server) redirectUnlockHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "redirect.RedirectUnlockHandler")
+	defer span.End()
+
+	params := new(RedirectUnlockParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code))
+
+	longUrl, err := s.resolveLongUrl(ctx, c, params.Code, resolveOptions{consumeClick: true, password: params.Password})
+	if err != nil {
+		return renderPasswordPrompt(c, params.Code, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "private, no-cache")
+
+	// 303 makes the browser follow the redirect with GET instead of re-submitting the form
+	return c.Redirect(http.StatusSeeOther, longUrl)
+}
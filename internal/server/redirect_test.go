@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	createdUrl := createShortUrl(t, s, e, "https://example.com", "", "")
+
+	tests := []struct {
+		name             string
+		method           string
+		code             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "redirect on GET (cache miss)", method: http.MethodGet, code: createdUrl.ID, expectedStatus: http.StatusFound, expectedLocation: createdUrl.LongUrl},
+		{name: "redirect on GET (cache hit)", method: http.MethodGet, code: createdUrl.ID, expectedStatus: http.StatusFound, expectedLocation: createdUrl.LongUrl},
+		{name: "redirect on HEAD", method: http.MethodHead, code: createdUrl.ID, expectedStatus: http.StatusFound, expectedLocation: createdUrl.LongUrl},
+		{name: "non-existent code", method: http.MethodGet, code: "invalid", expectedStatus: http.StatusNotFound},
+		{name: "too long code", method: http.MethodGet, code: "the-code-that-is-way-too-long", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, fmt.Sprintf("/%s", tt.code), nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:code")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: tt.code}})
+
+			// Assertions
+			err := s.redirectHandler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+				if tt.expectedLocation != "" {
+					assert.Equal(t, tt.expectedLocation, res.Header().Get(echo.HeaderLocation), "location does not match")
+				}
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestRedirectHandler_RedirectStatus(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	createdUrl := createShortUrl(t, s, e, "https://example.com", "", "")
+
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			s.cfg.App.RedirectStatus = status
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s", createdUrl.ID), nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:code")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: createdUrl.ID}})
+
+			// Assertions
+			err := s.redirectHandler(c)
+			require.NoError(t, err)
+			assert.Equal(t, status, res.Code)
+			assert.Equal(t, createdUrl.LongUrl, res.Header().Get(echo.HeaderLocation), "location does not match")
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestRedirectHandler_OneTimeLink(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	maxClicks := int32(1)
+	oneTimeUrl, err := s.rep.CreateUrl(context.Background(), repository.CreateUrlParams{ID: "one-time", LongUrl: "https://example.com", MaxClicks: &maxClicks})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		method         string
+		expectedStatus int
+	}{
+		{name: "HEAD does not consume the click", method: http.MethodHead, expectedStatus: http.StatusFound},
+		{name: "first GET redirects", method: http.MethodGet, expectedStatus: http.StatusFound},
+		{name: "second GET is gone", method: http.MethodGet, expectedStatus: http.StatusGone},
+		{name: "HEAD is gone after the click is consumed", method: http.MethodHead, expectedStatus: http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, fmt.Sprintf("/%s", oneTimeUrl.ID), nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:code")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: oneTimeUrl.ID}})
+
+			// Assertions
+			err := s.redirectHandler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
@@ -0,0 +1,419 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/rousage/shortener/internal/webhook"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// disabledReasonQuarantined is the reason of links that have been reported by enough users and wait for a review
+	disabledReasonQuarantined = "quarantined"
+	// disabledReasonAbuse is the reason of links disabled by an admin
+	disabledReasonAbuse = "abuse"
+)
+
+// Actions admins resolve the open reports of a link with
+const (
+	reportActionDismiss    = "dismiss"
+	reportActionDisable    = "disable"
+	reportActionDelete     = "delete"
+	reportActionBlockOwner = "block_owner"
+)
+
+// errNoOpenReports is returned when a link without open reports is resolved
+var errNoOpenReports = errors.New("no open reports")
+
+type ReportURLDTO struct {
+	Category string  `json:"category" validate:"required,oneof=phishing malware spam illegal" enums:"phishing,malware,spam,illegal"`
+	Note     *string `json:"note" validate:"omitzero,min=1,max=1000"`
+}
+type ReportURLParams struct {
+	GetLongUrlParams
+	ReportURLDTO
+}
+
+// reportURLHandler godoc
+//
+//	@Summary		Report Short URL
+//	@Description	Reports a short URL for abuse. Anyone can report a link, the reports are reviewed by admins. A link reported by enough distinct signed-in users is quarantined and does not resolve until it is reviewed, anonymous reports are only reviewed.
+//	@Tags			URLs
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	path	string			true	"Short code"	maxlength(16)
+//	@Param			request	body	ReportURLDTO	true	"Report request body"
+//	@Success		202		"Accepted - report received"
+//	@Failure		400		{object}	HTTPValidationError	"Validation failed"
+//	@Failure		404		{object}	HTTPError			"Short URL not found"
+//	@Failure		429		{object}	HTTPError			"Too many requests"
+//	@Failure		500		{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/urls/{code}/report [post]
+func (s *Server) reportURLHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "reports.ReportURLHandler")
+	defer span.End()
+
+	params := new(ReportURLParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code), attribute.String("category", params.Category))
+
+	url, err := s.rep.GetUrl(ctx, params.Code)
+	if err != nil {
+		if s.rep.IsNotFoundError(err) {
+			return echo.ErrNotFound
+		}
+
+		span.SetStatus(codes.Error, "failed to get short url")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get short url", "error", err, slog.String("code", params.Code))
+		return echo.ErrInternalServerError
+	}
+	if url.DeletedAt != nil {
+		span.AddEvent("short url has been deleted", trace.WithAttributes(attribute.String("code", params.Code)))
+		return echo.ErrNotFound
+	}
+
+	reporterID := auth.GetUserID(c)
+	reporterIP := c.RealIP()
+	audit := newAuditEntry(c, auditActionURLQuarantine, auditTargetURL, params.Code)
+	audit.ActorID = systemActor
+
+	var quarantined bool
+	err = s.inTx(ctx, func(qtx *repository.Queries) error {
+		_, err := qtx.CreateAbuseReport(ctx, repository.CreateAbuseReportParams{
+			UrlID:      params.Code,
+			Category:   params.Category,
+			Note:       params.Note,
+			ReporterID: reporterID,
+			ReporterIp: &reporterIP,
+		})
+		// Anonymous reports are reviewed by admins, but only authenticated reporters count towards the quarantine,
+		// one anonymous client could report from enough IPs to take any link down
+		if err != nil || reporterID == nil {
+			return err
+		}
+
+		counts, err := qtx.CountOpenAbuseReports(ctx, params.Code)
+		if err != nil || counts.Reporters < int64(s.cfg.App.ReportThreshold) {
+			return err
+		}
+
+		rowsAffected, err := qtx.QuarantineURL(ctx, repository.QuarantineURLParams{UrlID: params.Code, DisabledBy: systemActor})
+		if err != nil || rowsAffected == 0 {
+			return err
+		}
+		quarantined = true
+
+		return recordAudit(ctx, qtx, audit.withSnapshots(nil, &QuarantineAudit{Reporters: counts.Reporters}))
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to report short url")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to report short url", "error", err, slog.String("code", params.Code))
+		return echo.ErrInternalServerError
+	}
+
+	if quarantined {
+		span.AddEvent("short url has been quarantined")
+		c.Logger().InfoContext(ctx, "short url has been quarantined", slog.String("code", params.Code))
+		s.deleteCachedLongURL(ctx, c.Logger(), params.Code)
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+// QuarantineAudit is the audit log snapshot of a link quarantined after it has been reported
+type QuarantineAudit struct {
+	Reporters int64 `json:"reporters"`
+}
+
+type AbuseReportQueueFilters struct {
+	PaginationFilters
+	Quarantined *bool `query:"quarantined" validate:"omitzero,boolean"`
+}
+type AbuseReportCategoryCounts struct {
+	Phishing int64 `json:"phishing"`
+	Malware  int64 `json:"malware"`
+	Spam     int64 `json:"spam"`
+	Illegal  int64 `json:"illegal"`
+}
+type AbuseReportQueueItem struct {
+	Code    string  `json:"code"`
+	LongUrl string  `json:"longUrl"`
+	UserID  *string `json:"userId"`
+	// DisabledReason is quarantined or abuse if the link is disabled, null otherwise
+	DisabledReason  *string                   `json:"disabledReason"`
+	ReportCount     int64                     `json:"reportCount"`
+	Categories      AbuseReportCategoryCounts `json:"categories"`
+	FirstReportedAt time.Time                 `json:"firstReportedAt"`
+	LastReportedAt  time.Time                 `json:"lastReportedAt"`
+}
+type PaginatedAbuseReportQueue struct {
+	Items      []AbuseReportQueueItem `json:"items"`
+	Pagination Pagination             `json:"pagination"`
+}
+
+// getAbuseReportQueue godoc
+//
+//	@Summary		Get Abuse Report Queue
+//	@Description	Retrieves a paginated list of the links with open abuse reports, the most reported first. Reports are counted per link and per category.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			quarantined	query		bool						false	"Only quarantined (true) or only not quarantined (false) links"
+//	@Param			page		query		int							true	"Page number"	minimum(1)	maximum(10000)	default(1)
+//	@Param			pageSize	query		int							true	"Page size"		minimum(1)	maximum(100)	default(20)
+//	@Success		200			{object}	PaginatedAbuseReportQueue	"Paginated list of reported links"
+//	@Failure		400			{object}	HTTPValidationError			"Validation failed"
+//	@Failure		401			{object}	HTTPError					"Unauthorized"
+//	@Failure		403			{object}	HTTPError					"Forbidden"
+//	@Failure		500			{object}	HTTPError					"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/reports [get]
+func (s *Server) getAbuseReportQueue(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "reports.GetAbuseReportQueue")
+	defer span.End()
+
+	params := new(AbuseReportQueueFilters)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+
+	span.SetAttributes(attribute.Int("page", int(params.Page)), attribute.Int("pageSize", int(params.PageSize)))
+	if params.Quarantined != nil {
+		span.SetAttributes(attribute.Bool("quarantined", *params.Quarantined))
+	}
+
+	reports, err := s.rep.GetAbuseReportQueue(ctx, repository.GetAbuseReportQueueParams{Quarantined: params.Quarantined, Limit: params.limit(), Offset: params.offset()})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get abuse report queue")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get abuse report queue", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	var totalCount int
+	if len(reports) > 0 {
+		totalCount = int(reports[0].TotalCount)
+	}
+
+	items := make([]AbuseReportQueueItem, len(reports))
+	for i, report := range reports {
+		items[i] = AbuseReportQueueItem{
+			Code:           report.UrlID,
+			LongUrl:        report.LongUrl,
+			UserID:         report.UserID,
+			DisabledReason: report.DisabledReason,
+			ReportCount:    report.ReportCount,
+			Categories: AbuseReportCategoryCounts{
+				Phishing: report.PhishingCount,
+				Malware:  report.MalwareCount,
+				Spam:     report.SpamCount,
+				Illegal:  report.IllegalCount,
+			},
+			FirstReportedAt: report.FirstReportedAt,
+			LastReportedAt:  report.LastReportedAt,
+		}
+	}
+
+	response := &PaginatedAbuseReportQueue{
+		Items:      items,
+		Pagination: calculatePagination(totalCount, int(params.Page), int(params.PageSize)),
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+type ResolveReportsDTO struct {
+	Action string `json:"action" validate:"required,oneof=dismiss disable delete block_owner" enums:"dismiss,disable,delete,block_owner"`
+	// Reason of the block, only used with the block_owner action
+	Reason *string `json:"reason" validate:"omitzero,min=1,max=255"`
+}
+type ResolveReportsParams struct {
+	GetLongUrlParams
+	ResolveReportsDTO
+}
+type ResolveReportsResponse struct {
+	Action string `json:"action"`
+	// Number of reports resolved
+	Resolved int64 `json:"resolved"`
+}
+
+// resolveReportsHandler godoc
+//
+//	@Summary		Resolve Abuse Reports
+//	@Description	Resolves all open reports of a short URL and lifts its quarantine. dismiss keeps the link, disable stops it from resolving for good, delete moves it to the trash (requires delete:urls) and block_owner blocks the owner of the link (requires user:block).
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	path		string					true	"Short code"	maxlength(16)
+//	@Param			request	body		ResolveReportsDTO		true	"Resolve reports request body"
+//	@Success		200		{object}	ResolveReportsResponse	"Resolved reports"
+//	@Failure		400		{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401		{object}	HTTPError				"Unauthorized"
+//	@Failure		403		{object}	HTTPError				"Forbidden"
+//	@Failure		404		{object}	HTTPError				"Short URL not found or has no open reports"
+//	@Failure		409		{object}	HTTPError				"Short URL has no owner to block"
+//	@Failure		500		{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/reports/{code}/resolve [post]
+func (s *Server) resolveReportsHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "reports.ResolveReportsHandler")
+	defer span.End()
+
+	params := new(ResolveReportsParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		span.SetStatus(codes.Error, "invalid user (admin) input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code), attribute.String("action", params.Action))
+
+	if (params.Action == reportActionDelete && !auth.HasPermission(c, auth.DeleteURLs)) ||
+		(params.Action == reportActionBlockOwner && !auth.HasPermission(c, auth.UserBlock)) {
+		span.AddEvent("user does not have sufficient permission for the action")
+		return echo.ErrForbidden
+	}
+
+	url, err := s.rep.GetUrl(ctx, params.Code)
+	if err != nil {
+		if s.rep.IsNotFoundError(err) {
+			return echo.ErrNotFound
+		}
+
+		span.SetStatus(codes.Error, "failed to get short url")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get short url", "error", err, slog.String("code", params.Code))
+		return echo.ErrInternalServerError
+	}
+
+	counts, err := s.rep.CountOpenAbuseReports(ctx, params.Code)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to count open reports")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to count open reports", "error", err, slog.String("code", params.Code))
+		return echo.ErrInternalServerError
+	}
+	if counts.Reports == 0 {
+		span.AddEvent("short url has no open reports")
+		return echo.ErrNotFound
+	}
+
+	userID := auth.GetUserID(c)
+	audit := newAuditEntry(c, auditActionReportsResolve, auditTargetURL, params.Code)
+	response := &ResolveReportsResponse{Action: params.Action}
+
+	// resolveReports resolves the open reports and lifts the quarantine, every action does it in its transaction
+	resolveReports := func(qtx *repository.Queries) error {
+		resolved, err := qtx.ResolveAbuseReports(ctx, repository.ResolveAbuseReportsParams{ResolvedBy: *userID, Resolution: params.Action, UrlID: params.Code})
+		if err != nil {
+			return err
+		}
+		if resolved == 0 {
+			return errNoOpenReports
+		}
+		response.Resolved = resolved
+
+		if err := qtx.LiftURLQuarantine(ctx, params.Code); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, qtx, audit.withSnapshots(nil, response))
+	}
+
+	if params.Action == reportActionBlockOwner {
+		if url.UserID == nil {
+			span.AddEvent("short url has no owner")
+			return echo.NewHTTPError(http.StatusConflict, "Short URL has no owner")
+		}
+
+		// The owner is blocked like with blockUserHandler, their links stop resolving with the block
+		blockParams := &BlockUserParams{
+			DeleteUserURLsParams: DeleteUserURLsParams{UserID: *url.UserID},
+			BlockUserDTO:         BlockUserDTO{Reason: params.Reason},
+		}
+		if _, err := s.blockUser(ctx, c, blockParams, resolveReports); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, response)
+	}
+
+	err = s.inTx(ctx, func(qtx *repository.Queries) error {
+		if err := resolveReports(qtx); err != nil {
+			return err
+		}
+
+		switch params.Action {
+		case reportActionDisable:
+			return qtx.DisableURL(ctx, repository.DisableURLParams{UrlID: params.Code, DisabledBy: *userID})
+		case reportActionDelete:
+			rowsAffected, err := qtx.DeleteURL(ctx, params.Code)
+			if err != nil || rowsAffected == 0 {
+				return err
+			}
+
+			if err := recordAudit(ctx, qtx, newAuditEntry(c, auditActionURLDelete, auditTargetURL, params.Code).withSnapshots(url, nil)); err != nil {
+				return err
+			}
+
+			return webhook.Queue(ctx, qtx, webhook.LinkDeleted, webhook.NewDeletedLinkEvent(params.Code, time.Now()))
+		case reportActionDismiss:
+			// The link is kept, resolving the reports is all there is to do
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errNoOpenReports) {
+			span.AddEvent("short url has no open reports")
+			return echo.ErrNotFound
+		}
+
+		span.SetStatus(codes.Error, "failed to resolve reports")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to resolve reports", "error", err, slog.String("code", params.Code), slog.String("action", params.Action))
+		s.recordAuditFailure(ctx, c.Logger(), audit.withSnapshots(nil, response), err)
+		return echo.ErrInternalServerError
+	}
+
+	if params.Action == reportActionDisable || params.Action == reportActionDelete {
+		s.deleteCachedLongURL(ctx, c.Logger(), params.Code)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// deleteCachedLongURL drops the long URL of the code from the cache, so a link that has been taken down stops resolving right away
+func (s *Server) deleteCachedLongURL(ctx context.Context, logger *slog.Logger, code string) {
+	if removedKeys, err := s.cache.DeleteLongURL(ctx, code); err != nil {
+		trace.SpanFromContext(ctx).AddEvent("failed to delete long url from cache", trace.WithAttributes(attribute.String("code", code), attribute.Int64("removedKeys", removedKeys)))
+		logger.WarnContext(ctx, "failed to delete long url from cache", "error", err, slog.String("code", code), slog.Int64("removedKeys", removedKeys))
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-auth0/v2/management"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportURLHandler(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	s.cfg.App.ReportThreshold = 2
+
+	url := createShortUrl(t, s, e, "https://example.com", userID_1, "")
+
+	tests := []struct {
+		name           string
+		code           string
+		payload        ReportURLDTO
+		ip             string
+		forwardedFor   string
+		reporter       string
+		expectedStatus int
+		expectedLink   int
+	}{
+		{name: "invalid category", code: url.ID, payload: ReportURLDTO{Category: "boring"}, ip: "10.0.0.1", expectedStatus: http.StatusBadRequest, expectedLink: http.StatusOK},
+		{name: "non-existent code", code: "non-existent", payload: ReportURLDTO{Category: "spam"}, ip: "10.0.0.1", expectedStatus: http.StatusNotFound, expectedLink: http.StatusOK},
+		{name: "anonymous report", code: url.ID, payload: ReportURLDTO{Category: "phishing"}, ip: "10.0.0.1", forwardedFor: "203.0.113.1", expectedStatus: http.StatusAccepted, expectedLink: http.StatusOK},
+		{name: "anonymous reports do not reach the threshold", code: url.ID, payload: ReportURLDTO{Category: "phishing"}, ip: "10.0.0.2", forwardedFor: "203.0.113.2", expectedStatus: http.StatusAccepted, expectedLink: http.StatusOK},
+		{name: "first report", code: url.ID, payload: ReportURLDTO{Category: "phishing"}, ip: "10.0.0.1", reporter: "reporter-1", expectedStatus: http.StatusAccepted, expectedLink: http.StatusOK},
+		{name: "same reporter again", code: url.ID, payload: ReportURLDTO{Category: "malware"}, ip: "10.0.0.3", reporter: "reporter-1", expectedStatus: http.StatusAccepted, expectedLink: http.StatusOK},
+		{name: "threshold reached", code: url.ID, payload: ReportURLDTO{Category: "phishing"}, ip: "10.0.0.1", reporter: "reporter-2", expectedStatus: http.StatusAccepted, expectedLink: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.payload)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/urls/%s/report", tt.code), bytes.NewBuffer(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.RemoteAddr = tt.ip + ":1234"
+			if tt.forwardedFor != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tt.forwardedFor)
+			}
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: tt.code}})
+			if tt.reporter != "" {
+				c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: tt.reporter}})
+			}
+
+			err = s.reportURLHandler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+			}
+
+			assert.Equal(t, tt.expectedLink, resolveStatus(t, s, e, url.ID))
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestResolveReportsHandler(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	s.cfg.App.ReportThreshold = 1
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	m := &mockAuthManager{}
+	m.On("BlockUser", mock.Anything, userID_2).Return(&management.UpdateUserResponseContent{}, nil)
+	s.authManagement = m
+
+	var (
+		dismissed  = createShortUrl(t, s, e, "https://example.com/dismissed", userID_1, "")
+		disabled   = createShortUrl(t, s, e, "https://example.com/disabled", userID_1, "")
+		deleted    = createShortUrl(t, s, e, "https://example.com/deleted", userID_1, "")
+		blocked    = createShortUrl(t, s, e, "https://example.com/blocked", userID_2, "")
+		anonymous  = createShortUrl(t, s, e, "https://example.com/anonymous", "", "")
+		unreported = createShortUrl(t, s, e, "https://example.com/unreported", userID_1, "")
+	)
+	for _, code := range []string{dismissed.ID, disabled.ID, deleted.ID, blocked.ID, anonymous.ID} {
+		reportURL(t, s, e, code)
+	}
+
+	t.Run("queue", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/reports?page=1&pageSize=10&quarantined=true", nil)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		require.NoError(t, s.getAbuseReportQueue(c))
+		require.Equal(t, http.StatusOK, res.Code)
+
+		var actual PaginatedAbuseReportQueue
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+		assert.Equal(t, 5, actual.Pagination.TotalItems)
+		for _, item := range actual.Items {
+			assert.Equal(t, int64(1), item.ReportCount)
+			assert.Equal(t, int64(1), item.Categories.Spam)
+			if assert.NotNil(t, item.DisabledReason) {
+				assert.Equal(t, disabledReasonQuarantined, *item.DisabledReason)
+			}
+		}
+	})
+
+	tests := []struct {
+		name               string
+		code               string
+		action             string
+		permissions        []string
+		expectedStatus     int
+		expectedLinkStatus int
+	}{
+		{name: "no required permission", code: dismissed.ID, action: reportActionDismiss, expectedStatus: http.StatusForbidden, expectedLinkStatus: http.StatusForbidden},
+		{name: "no open reports", code: unreported.ID, action: reportActionDismiss, permissions: []string{string(auth.ResolveReports)}, expectedStatus: http.StatusNotFound, expectedLinkStatus: http.StatusOK},
+		{name: "dismiss", code: dismissed.ID, action: reportActionDismiss, permissions: []string{string(auth.ResolveReports)}, expectedStatus: http.StatusOK, expectedLinkStatus: http.StatusOK},
+		{name: "already resolved", code: dismissed.ID, action: reportActionDisable, permissions: []string{string(auth.ResolveReports)}, expectedStatus: http.StatusNotFound, expectedLinkStatus: http.StatusOK},
+		{name: "disable", code: disabled.ID, action: reportActionDisable, permissions: []string{string(auth.ResolveReports)}, expectedStatus: http.StatusOK, expectedLinkStatus: http.StatusForbidden},
+		{name: "delete without delete permission", code: deleted.ID, action: reportActionDelete, permissions: []string{string(auth.ResolveReports)}, expectedStatus: http.StatusForbidden, expectedLinkStatus: http.StatusForbidden},
+		{name: "delete", code: deleted.ID, action: reportActionDelete, permissions: []string{string(auth.ResolveReports), string(auth.DeleteURLs)}, expectedStatus: http.StatusOK, expectedLinkStatus: http.StatusGone},
+		{name: "block owner of anonymous link", code: anonymous.ID, action: reportActionBlockOwner, permissions: []string{string(auth.ResolveReports), string(auth.UserBlock)}, expectedStatus: http.StatusConflict, expectedLinkStatus: http.StatusForbidden},
+		{name: "block owner", code: blocked.ID, action: reportActionBlockOwner, permissions: []string{string(auth.ResolveReports), string(auth.UserBlock)}, expectedStatus: http.StatusOK, expectedLinkStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(ResolveReportsDTO{Action: tt.action})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/admin/reports/%s/resolve", tt.code), bytes.NewBuffer(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: tt.code}})
+			c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{
+				RegisteredClaims: validator.RegisteredClaims{Subject: adminID},
+				CustomClaims:     &auth.CustomClaims{Permissions: tt.permissions},
+			})
+
+			handler := authMw.RequireAuthentication(authMw.RequirePermission(auth.ResolveReports)(s.resolveReportsHandler))
+
+			err = handler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+			}
+
+			assert.Equal(t, tt.expectedLinkStatus, resolveStatus(t, s, e, tt.code))
+		})
+	}
+
+	m.AssertExpectations(t)
+
+	t.Cleanup(cleanup)
+}
+
+// reportURL reports the code as spam
+func reportURL(t *testing.T, s *Server, e *echo.Echo, code string) {
+	body, err := json.Marshal(ReportURLDTO{Category: "spam"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/urls/%s/report", code), bytes.NewBuffer(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+	c := e.NewContext(req, res)
+	c.SetPathValues(echo.PathValues{{Name: "code", Value: code}})
+	// Only signed-in reporters count towards the quarantine
+	c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: "reporter"}})
+
+	require.NoError(t, s.reportURLHandler(c))
+	require.Equal(t, http.StatusAccepted, res.Code)
+}
+
+// resolveStatus resolves the code and returns the status code
+func resolveStatus(t *testing.T, s *Server, e *echo.Echo, code string) int {
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/urls/%s", code), nil)
+	res := httptest.NewRecorder()
+	c := e.NewContext(req, res)
+	c.SetPathValues(echo.PathValues{{Name: "code", Value: code}})
+
+	if err := s.getLongUrlHandler(c); err != nil {
+		sc, ok := err.(echo.HTTPStatusCoder)
+		require.True(t, ok, "expected an HTTP error")
+		return sc.StatusCode()
+	}
+	return res.Code
+}
@@ -4,7 +4,6 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
-	"time"
 
 	echootel "github.com/labstack/echo-opentelemetry"
 	"github.com/labstack/echo/v5"
@@ -13,7 +12,6 @@ import (
 	"github.com/rousage/shortener/internal/auth"
 	"github.com/rousage/shortener/internal/otel"
 	echoSwagger "github.com/swaggo/echo-swagger/v2"
-	"golang.org/x/time/rate"
 
 	_ "github.com/rousage/shortener/docs"
 )
@@ -34,11 +32,15 @@ import (
 // @securityDefinitions.apikey	BearerAuth
 // @in							header
 // @name						Authorization
-// @description				Type "Bearer" followed by a space and JWT token
+// @description				Type "Bearer" followed by a space and a JWT or an API key (shk_...)
 func (s *Server) RegisterRoutes(logger *slog.Logger) http.Handler {
 	e := echo.New()
 	e.Logger = logger
-	e.Validator = appvalidator.New()
+	e.IPExtractor = clientIPExtractor(s.cfg.Server.TrustedProxies)
+	e.Validator = appvalidator.New(
+		appvalidator.WithHostPolicy(s.domainRules),
+		appvalidator.WithShortLinkResolver(s.shortLinks),
+	)
 
 	e.Use(echootel.NewMiddleware(otel.ServiceName.Value.AsString()))
 	e.Use(middleware.RequestID())
@@ -90,43 +92,89 @@ func (s *Server) RegisterRoutes(logger *slog.Logger) http.Handler {
 		},
 	}))
 
-	e.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
-		Rate:      float64(rate.Limit(s.cfg.Server.LimiterRPS)),
-		Burst:     s.cfg.Server.LimiterBurst,
-		ExpiresIn: 3 * time.Minute,
-	})))
-
 	e.Use(middleware.Recover())
 
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     s.cfg.Server.AllowOrigins,
 		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions, http.MethodPatch},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", headerLinkPassword, headerIfMatch, headerIdempotencyKey},
+		ExposeHeaders:    []string{headerETag, headerIdempotentReplayed, headerRateLimitLimit, headerRateLimitRemaining, headerRateLimitReset, headerRetryAfter},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	// Every route, the docs included, is limited per client IP before the authentication,
+	// so requests with invalid credentials cannot hit the authentication and the database without a limit
+	e.Use(s.rateLimitClient)
+
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	e.GET("/docs/*", echoSwagger.EchoWrapHandlerV3(echoSwagger.PersistAuthorization(true), echoSwagger.SyntaxHighlight(true)))
 
-	e.GET("/*", echoSwagger.EchoWrapHandlerV3(echoSwagger.PersistAuthorization(true), echoSwagger.SyntaxHighlight(true)))
+	// Public short link redirects, registered outside of /v1 so they skip the JWT authentication
+	e.GET("/:code", s.redirectHandler, s.rateLimit)
+	e.HEAD("/:code", s.redirectHandler, s.rateLimit)
+	e.POST("/:code", s.redirectUnlockHandler, s.rateLimit)
 
-	v1 := e.Group("/v1", authMw.Authenticate)
+	// Rate limits per route group run after the authentication, so they are counted per user or API key instead of per IP
+	v1 := e.Group("/v1", authMw.Authenticate, s.rateLimit, s.rejectBlockedUsers)
 	v1.GET("/health", s.healthHandler)
 	v1.GET("/health/metrics", s.healthMetricsHandler)
 
 	v1.POST("/urls", s.createShortURLHandler)
 	v1.GET("/urls/:code", s.getLongUrlHandler)
+	v1.POST("/urls/:code/unlock", s.unlockLongUrlHandler)
+	v1.POST("/urls/:code/report", s.reportURLHandler)
 	v1.GET("/urls", s.getUserUrls, authMw.RequireAuthentication, authMw.RequirePermission(auth.GetOwnURLs))
+	// Static segments next to :code must be reserved short codes, see appvalidator.ValidateUnreservedShortCode
+	v1.GET("/urls/trending", s.getUserTrendingUrls, authMw.RequireAuthentication, authMw.RequirePermission(auth.GetOwnURLs))
+	v1.GET("/urls/:code/stats", s.getUrlStatsHandler, authMw.RequireAuthentication)
+	v1.PATCH("/urls/:code", s.updateShortUrlHandler, authMw.RequireAuthentication, authMw.RequirePermission(auth.UpdateOwnURLs))
 	v1.DELETE("/urls/:code", s.deletShortUrlHandler, authMw.RequireAuthentication, authMw.RequirePermission(auth.DeleteOwnURLs))
+	v1.GET("/trash/urls", s.getUserDeletedUrls, authMw.RequireAuthentication, authMw.RequirePermission(auth.GetOwnURLs))
+	v1.POST("/trash/urls/:code/restore", s.restoreShortUrlHandler, authMw.RequireAuthentication, authMw.RequirePermission(auth.DeleteOwnURLs))
+
+	v1.GET("/settings", s.getUserSettingsHandler, authMw.RequireAuthentication)
+	v1.PUT("/settings", s.updateUserSettingsHandler, authMw.RequireAuthentication)
+
+	apiKeys := v1.Group("/api-keys", authMw.RequireAuthentication, authMw.RequirePermission(auth.ManageOwnAPIKeys))
+	apiKeys.GET("", s.getUserAPIKeys)
+	apiKeys.POST("", s.createAPIKeyHandler)
+	apiKeys.DELETE("/:id", s.revokeAPIKeyHandler)
+
+	webhooks := v1.Group("/webhooks", authMw.RequireAuthentication, authMw.RequirePermission(auth.ManageOwnWebhooks))
+	webhooks.GET("", s.getUserWebhooks)
+	webhooks.POST("", s.createWebhookHandler)
+	webhooks.DELETE("/:id", s.deleteWebhookHandler)
+	webhooks.POST("/:id/test", s.testWebhookHandler)
+	webhooks.POST("/:id/rotate-secret", s.rotateWebhookSecretHandler)
+	webhooks.GET("/:id/dead-letters", s.getWebhookDeadLetters)
+	webhooks.POST("/:id/dead-letters/:deadLetterId/replay", s.replayWebhookDeadLetterHandler)
 
 	// Admin routes
 	admin := v1.Group("/admin", authMw.RequireAuthentication)
 	admin.GET("/urls", s.getURLs, authMw.RequirePermission(auth.GetURLs))
+	admin.GET("/urls/trending", s.getTrendingURLs, authMw.RequirePermission(auth.GetURLs))
+	admin.PATCH("/urls/:code", s.updateURLHandler, authMw.RequirePermission(auth.UpdateURLs))
 	admin.DELETE("/urls/:code", s.deleteURLHandler, authMw.RequirePermission(auth.DeleteURLs))
 	admin.DELETE("/urls/user/:userId", s.deleteUserURLsHandler, authMw.RequirePermission(auth.DeleteURLs))
+	admin.GET("/trash/urls", s.getDeletedURLs, authMw.RequirePermission(auth.GetURLs))
+	admin.POST("/trash/urls/:code/restore", s.restoreURLHandler, authMw.RequirePermission(auth.DeleteURLs))
+
+	admin.GET("/audit", s.getAuditLog, authMw.RequirePermission(auth.GetAuditLog))
+	admin.GET("/reports", s.getAbuseReportQueue, authMw.RequirePermission(auth.GetAbuseReports))
+	admin.POST("/reports/:code/resolve", s.resolveReportsHandler, authMw.RequirePermission(auth.ResolveReports))
+
+	domainRules := admin.Group("/domain-rules", authMw.RequirePermission(auth.ManageDomainRules))
+	domainRules.GET("", s.getDomainRules)
+	domainRules.POST("", s.createDomainRuleHandler)
+	domainRules.PUT("/:id", s.updateDomainRuleHandler)
+	domainRules.DELETE("/:id", s.deleteDomainRuleHandler)
+	domainRules.POST("/:id/scan", s.scanDomainRuleHandler)
 
 	adminUsers := admin.Group("/users")
 	adminUsers.GET("/blocks", s.getUserBlocks, authMw.RequirePermission(auth.GetUserBlocks))
+	adminUsers.GET("/:userId/blocks", s.getUserBlockHistory, authMw.RequirePermission(auth.GetUserBlocks))
 	adminUsers.POST("/block/:userId", s.blockUserHandler, authMw.RequirePermission(auth.UserBlock))
 	adminUsers.POST("/unblock/:userId", s.unblockUserHandler, authMw.RequirePermission(auth.UserUnblock))
 
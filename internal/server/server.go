@@ -5,15 +5,22 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/auth0/go-auth0/v2/management"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rousage/shortener/internal/auth"
 	"github.com/rousage/shortener/internal/cache"
+	"github.com/rousage/shortener/internal/clicks"
 	"github.com/rousage/shortener/internal/config"
 	"github.com/rousage/shortener/internal/database"
+	"github.com/rousage/shortener/internal/domainrules"
+	"github.com/rousage/shortener/internal/generator"
 	"github.com/rousage/shortener/internal/repository"
+	"github.com/rousage/shortener/internal/unshorten"
+	"github.com/rousage/shortener/internal/webhook"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/metric"
 )
@@ -37,33 +44,70 @@ type Server struct {
 	rep            *repository.Queries
 	cache          *cache.Cache
 	authManagement AuthManager
+	clicks         *clicks.Pipeline
+	webhooks       *webhook.Dispatcher
+	domainRules    *domainrules.Set
+	shortLinks     *unshorten.Resolver
+	shortCodes     generator.Generator
 
 	// OTel metrics
-	collisionCounter metric.Int64Counter
+	collisionCounter         metric.Int64Counter
+	rateLimitFailOpenCounter metric.Int64Counter
 }
 
-func New(cfg *config.Config) *http.Server {
+// New creates the HTTP server and starts the background workers.
+// The returned shutdown function stops the workers and drains the queued click events
+func New(cfg *config.Config) (*http.Server, func(context.Context) error) {
 	logger := newLogger(cfg.App.Env)
 	db := database.Connect(logger, cfg.Database)
 	cacheClient := cache.Connect(logger, cfg.Cache)
 
 	collisionCounter, err := meter.Int64Counter(
 		"url.code.collisions",
-		metric.WithDescription("Number of short code collisions during auto-generation with nanoid"),
+		metric.WithDescription("Number of short code collisions during auto-generation"),
 		metric.WithUnit("{collision}"),
 	)
 	if err != nil {
 		logger.Warn("failed to create url code collision counter", "error", err)
 	}
+	rateLimitFailOpenCounter, err := meter.Int64Counter(
+		"ratelimit.failopen",
+		metric.WithDescription("Number of requests let through without rate limiting because the cache was unavailable"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		logger.Warn("failed to create rate limit fail open counter", "error", err)
+	}
 
+	rep := repository.New(db)
 	srv := &Server{
-		cfg:              cfg,
-		db:               db,
-		rep:              repository.New(db),
-		cache:            cache.New(logger, cacheClient),
-		authManagement:   auth.NewManagement(logger, cfg.Auth),
-		collisionCounter: collisionCounter,
+		cfg:                      cfg,
+		db:                       db,
+		rep:                      rep,
+		cache:                    cache.New(logger, cacheClient),
+		authManagement:           auth.NewManagement(logger, cfg.Auth),
+		clicks:                   clicks.NewPipeline(logger, webhook.NewClickStore(db, rep), clicks.PipelineConfig{}),
+		webhooks:                 webhook.NewDispatcher(logger, rep, webhook.DispatcherConfig{}),
+		domainRules:              domainrules.NewSet(),
+		collisionCounter:         collisionCounter,
+		rateLimitFailOpenCounter: rateLimitFailOpenCounter,
+	}
+	srv.shortLinks, err = unshorten.New(unshorten.Config{BaseURL: cfg.App.BaseURL, ShortenerHosts: cfg.App.ShortenerHosts}, srv.lookupShortLink)
+	if err != nil {
+		logger.Error("failed to create short link resolver", "error", err)
+		os.Exit(1)
 	}
+	srv.shortCodes, err = generator.New(generator.Config{
+		Strategy: cfg.App.ShortUrlGenerator,
+		Length:   cfg.App.ShortUrlLength,
+		Alphabet: cfg.App.ShortUrlAlphabet,
+		Salt:     cfg.App.ShortUrlSalt,
+	}, rep.NextShortCode)
+	if err != nil {
+		logger.Error("failed to create short code generator", "error", err)
+		os.Exit(1)
+	}
+	srv.clicks.Start()
 
 	// Declare Server config
 	server := &http.Server{
@@ -74,7 +118,50 @@ func New(cfg *config.Config) *http.Server {
 		WriteTimeout: 20 * time.Second,
 	}
 
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Go(func() { srv.runTrashPurger(workersCtx, logger) })
+	workers.Go(func() { srv.runClickCountFlusher(workersCtx, logger) })
+	workers.Go(func() { srv.runBlockExpirer(workersCtx, logger) })
+	workers.Go(func() { srv.runDomainRulesRefresher(workersCtx, logger) })
+	workers.Go(func() { srv.runIdempotencyKeyPurger(workersCtx, logger) })
+	workers.Go(func() { srv.webhooks.Run(workersCtx) })
+	workersDone := make(chan struct{})
+	go func() {
+		defer close(workersDone)
+		workers.Wait()
+	}()
+
+	shutdown := func(ctx context.Context) error {
+		stopWorkers()
+
+		select {
+		case <-workersDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		return srv.clicks.Shutdown(ctx)
+	}
+
 	logger.Info("server started on port", slog.Int("port", srv.cfg.Server.Port))
 
-	return server
+	return server, shutdown
+}
+
+// inTx runs fn with queries bound to a new transaction, which is committed if fn succeeds
+func (s *Server) inTx(ctx context.Context, fn func(qtx *repository.Queries) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := fn(s.rep.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+type UserSettingsDTO struct {
+	// Return the existing link for the same destination instead of creating a new one, can be overridden per request
+	DedupeUrls bool `json:"dedupeUrls"`
+}
+
+// getUserSettings returns the settings of the user, the defaults are returned if the user has never saved them
+func (s *Server) getUserSettings(ctx context.Context, userID string) (repository.UserSetting, error) {
+	settings, err := s.rep.GetUserSettings(ctx, userID)
+	if err != nil {
+		if s.rep.IsNotFoundError(err) {
+			return repository.UserSetting{UserID: userID}, nil
+		}
+		return repository.UserSetting{}, err
+	}
+
+	return settings, nil
+}
+
+// getUserSettingsHandler godoc
+//
+//	@Summary		Get User Settings
+//	@Description	Retrieves the settings of the authenticated user, the defaults are returned if they have never been saved
+//	@Tags			Settings
+//	@Produce		json
+//	@Success		200	{object}	repository.UserSetting	"Settings of the user"
+//	@Failure		401	{object}	HTTPError				"Unauthorized"
+//	@Failure		500	{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/settings [get]
+func (s *Server) getUserSettingsHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "settings.GetUserSettingsHandler")
+	defer span.End()
+
+	userID := auth.GetUserID(c)
+
+	settings, err := s.getUserSettings(ctx, *userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get user settings")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get user settings", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// updateUserSettingsHandler godoc
+//
+//	@Summary		Update User Settings
+//	@Description	Saves the settings of the authenticated user
+//	@Tags			Settings
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		UserSettingsDTO			true	"New settings"
+//	@Success		200		{object}	repository.UserSetting	"Updated settings"
+//	@Failure		400		{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401		{object}	HTTPError				"Unauthorized"
+//	@Failure		500		{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/settings [put]
+func (s *Server) updateUserSettingsHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "settings.UpdateUserSettingsHandler")
+	defer span.End()
+
+	dto := new(UserSettingsDTO)
+	if err := c.Bind(dto); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(dto); err != nil {
+		span.SetStatus(codes.Error, "invalid user input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Bool("dedupeUrls", dto.DedupeUrls))
+
+	userID := auth.GetUserID(c)
+
+	settings, err := s.rep.UpsertUserSettings(ctx, repository.UpsertUserSettingsParams{UserID: *userID, DedupeUrls: dto.DedupeUrls})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to update user settings")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to update user settings", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
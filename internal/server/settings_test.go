@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserSettings(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	// serve runs the handler as the user and decodes the returned settings
+	serve := func(t *testing.T, method string, payload any, userID string, handler echo.HandlerFunc) repository.UserSetting {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+
+		req := httptest.NewRequest(method, "/v1/settings", &body)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: userID}})
+
+		require.NoError(t, handler(c))
+		require.Equal(t, http.StatusOK, res.Code)
+
+		var settings repository.UserSetting
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&settings), "error decoding response body")
+		return settings
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		settings := serve(t, http.MethodGet, nil, userID_1, s.getUserSettingsHandler)
+		assert.Equal(t, userID_1, settings.UserID)
+		assert.False(t, settings.DedupeUrls)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		settings := serve(t, http.MethodPut, UserSettingsDTO{DedupeUrls: true}, userID_1, s.updateUserSettingsHandler)
+		assert.True(t, settings.DedupeUrls)
+
+		settings = serve(t, http.MethodGet, nil, userID_1, s.getUserSettingsHandler)
+		assert.True(t, settings.DedupeUrls)
+
+		settings = serve(t, http.MethodGet, nil, userID_2, s.getUserSettingsHandler)
+		assert.False(t, settings.DedupeUrls, "settings of other users should not change")
+
+		settings = serve(t, http.MethodPut, UserSettingsDTO{DedupeUrls: false}, userID_1, s.updateUserSettingsHandler)
+		assert.False(t, settings.DedupeUrls)
+	})
+
+	t.Cleanup(cleanup)
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/cache"
+	"github.com/rousage/shortener/internal/clicks"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// statsBreakdownLimit is the number of top entries returned per breakdown
+	statsBreakdownLimit = 10
+	// clickCountFlushInterval is how often the click counters are moved from the cache to the database
+	clickCountFlushInterval = time.Minute
+	// clickCountFlushBatchSize is the maximum number of codes updated at once
+	clickCountFlushBatchSize = 1000
+)
+
+// recordClick queues a click event for a successful resolution of the code.
+// The event is written asynchronously and dropped under pressure, it must never slow down or fail the resolution itself
+func (s *Server) recordClick(ctx context.Context, c *echo.Context, code string) {
+	req := c.Request()
+	event := clicks.NewEvent(code, req.Referer(), req.UserAgent(), c.RealIP(), time.Now())
+
+	if !s.clicks.Enqueue(ctx, event) {
+		trace.SpanFromContext(ctx).AddEvent("click dropped", trace.WithAttributes(attribute.String("code", code)))
+	}
+
+	if err := s.countClick(ctx, code, c.RealIP(), req.UserAgent(), event.ClickedAt); err != nil {
+		trace.SpanFromContext(ctx).AddEvent("failed to count click", trace.WithAttributes(attribute.String("code", code)))
+		c.Logger().WarnContext(ctx, "failed to count click", "error", err, slog.String("code", code))
+	}
+}
+
+// countClick counts the click in the click counters, the trending links and the unique visitors of the day with one cache round trip.
+// Only a hash of the IP and user agent salted with the salt of the day is stored, the salt is kept in memory for the day
+func (s *Server) countClick(ctx context.Context, code, clientIP, userAgent string, clickedAt time.Time) error {
+	salt, err := s.cache.GetVisitorSalt(ctx, clickedAt)
+	if err != nil {
+		return err
+	}
+
+	return s.cache.RecordClick(ctx, code, clickedAt, clicks.VisitorHash(salt, clientIP, userAgent))
+}
+
+// flushClickCounts moves the click counters accumulated in the cache to the click_count and last_clicked_at columns.
+// Counters that fail to be written are put back to the cache for the next flush
+func (s *Server) flushClickCounts(ctx context.Context, logger *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "stats.FlushClickCounts")
+	defer span.End()
+
+	var flushed int
+	for {
+		counts, err := s.cache.TakeClickCounts(ctx, clickCountFlushBatchSize)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to take click counts")
+			span.RecordError(err)
+			logger.ErrorContext(ctx, "failed to take click counts", "error", err)
+			return
+		}
+		if len(counts) == 0 {
+			break
+		}
+
+		params := repository.AddClickCountsParams{
+			Ids:           make([]string, len(counts)),
+			Clicks:        make([]int64, len(counts)),
+			LastClickedAt: make([]time.Time, len(counts)),
+		}
+		for i, count := range counts {
+			params.Ids[i] = count.Code
+			params.Clicks[i] = count.Clicks
+			params.LastClickedAt[i] = count.LastClickedAt
+		}
+
+		if _, err := s.rep.AddClickCounts(ctx, params); err != nil {
+			span.SetStatus(codes.Error, "failed to add click counts")
+			span.RecordError(err)
+			logger.ErrorContext(ctx, "failed to add click counts", "error", err, slog.Int("codes", len(counts)))
+
+			if err := s.cache.AddClickCounts(ctx, counts); err != nil {
+				span.RecordError(err)
+				logger.ErrorContext(ctx, "failed to put back click counts, the clicks are lost", "error", err, slog.Int("codes", len(counts)))
+			}
+			return
+		}
+
+		flushed += len(counts)
+		if len(counts) < clickCountFlushBatchSize {
+			break
+		}
+	}
+
+	span.SetAttributes(attribute.Int("flushed", flushed))
+}
+
+// runClickCountFlusher flushes the click counters every clickCountFlushInterval until ctx is cancelled
+func (s *Server) runClickCountFlusher(ctx context.Context, logger *slog.Logger) {
+	ticker := time.NewTicker(clickCountFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.flushClickCounts(ctx, logger)
+		}
+	}
+}
+
+type GetUrlStatsParams struct {
+	GetLongUrlParams
+	// Only count clicks of the last number of days, all clicks are counted if not set
+	Days int32 `query:"days" validate:"omitempty,min=1,max=365"`
+}
+type ClicksByDay struct {
+	Day    time.Time `json:"day" example:"2026-01-31T00:00:00Z"`
+	Clicks int64     `json:"clicks"`
+	// Approximate number of distinct visitors on the day
+	UniqueVisitors int64 `json:"uniqueVisitors"`
+}
+type ClicksByKey struct {
+	Key    string `json:"key"`
+	Clicks int64  `json:"clicks"`
+}
+type UrlStatsResponse struct {
+	Code string `json:"code"`
+	// Start of the period the stats are calculated for
+	Since       time.Time `json:"since"`
+	TotalClicks int64     `json:"totalClicks"`
+	// Approximate number of distinct visitors in the period. Visitors are told apart per day,
+	// so a visitor coming back on another day is counted again
+	UniqueVisitors int64         `json:"uniqueVisitors"`
+	ByDay          []ClicksByDay `json:"byDay"`
+	// Top referrer hosts, direct visits have an empty key
+	ByReferrerHost []ClicksByKey `json:"byReferrerHost"`
+	ByBrowser      []ClicksByKey `json:"byBrowser"`
+	// Clicks by device class: desktop, mobile, tablet, bot or unknown
+	ByDevice []ClicksByKey `json:"byDevice"`
+}
+
+// getUrlStatsHandler godoc
+//
+//	@Summary		Get Short URL Stats
+//	@Description	Returns click analytics of a short URL: total clicks, approximate unique visitors and breakdowns by day, referrer host, browser and device. Available to the owner of the URL and to users with the get:url-stats permission.
+//	@Tags			URLs
+//	@Produce		json
+//	@Param			code	path		string				true	"Short code"									maxlength(16)
+//	@Param			days	query		int					false	"Only count clicks of the last number of days"	minimum(1)	maximum(365)
+//	@Success		200		{object}	UrlStatsResponse	"Click stats"
+//	@Failure		400		{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401		{object}	HTTPError			"Unauthorized"
+//	@Failure		404		{object}	HTTPError			"Short URL not found or not owned by user"
+//	@Failure		500		{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/urls/{code}/stats [get]
+func (s *Server) getUrlStatsHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "stats.GetUrlStatsHandler")
+	defer span.End()
+
+	params := new(GetUrlStatsParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code), attribute.Int("days", int(params.Days)))
+
+	url, err := s.rep.GetUrl(ctx, params.Code)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get short url")
+		span.RecordError(err)
+
+		if s.rep.IsNotFoundError(err) {
+			return echo.ErrNotFound
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to get short url", "error", err, slog.String("code", params.Code))
+		return echo.ErrInternalServerError
+	}
+
+	// Users without the stats permission can only see the stats of their own URLs
+	userID := auth.GetUserID(c)
+	if !auth.HasPermission(c, auth.GetURLStats) && (userID == nil || url.UserID == nil || *url.UserID != *userID) {
+		span.AddEvent("short url not owned by user", trace.WithAttributes(attribute.String("code", params.Code)))
+		return echo.ErrNotFound
+	}
+
+	since := url.CreatedAt
+	if params.Days > 0 {
+		since = time.Now().AddDate(0, 0, -int(params.Days))
+	}
+
+	stats, err := s.urlStats(ctx, params.Code, since)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get url stats")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get url stats", "error", err, slog.String("code", params.Code))
+		return echo.ErrInternalServerError
+	}
+
+	// Unique visitors are an estimation on top of the stats, so they are left out if the cache fails
+	if err := s.addUniqueVisitorStats(ctx, stats); err != nil {
+		span.AddEvent("failed to count unique visitors")
+		c.Logger().WarnContext(ctx, "failed to count unique visitors", "error", err, slog.String("code", params.Code))
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// urlStats aggregates the clicks of the code since the given time
+func (s *Server) urlStats(ctx context.Context, code string, since time.Time) (*UrlStatsResponse, error) {
+	total, err := s.rep.CountClicks(ctx, repository.CountClicksParams{UrlID: code, Since: since})
+	if err != nil {
+		return nil, err
+	}
+
+	byDay, err := s.rep.GetClicksByDay(ctx, repository.GetClicksByDayParams{UrlID: code, Since: since})
+	if err != nil {
+		return nil, err
+	}
+
+	byReferrerHost, err := s.rep.GetClicksByReferrerHost(ctx, repository.GetClicksByReferrerHostParams{UrlID: code, Since: since, Limit: statsBreakdownLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	byBrowser, err := s.rep.GetClicksByBrowser(ctx, repository.GetClicksByBrowserParams{UrlID: code, Since: since, Limit: statsBreakdownLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	byDevice, err := s.rep.GetClicksByDevice(ctx, repository.GetClicksByDeviceParams{UrlID: code, Since: since, Limit: statsBreakdownLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &UrlStatsResponse{
+		Code:           code,
+		Since:          since,
+		TotalClicks:    total,
+		ByDay:          make([]ClicksByDay, len(byDay)),
+		ByReferrerHost: make([]ClicksByKey, len(byReferrerHost)),
+		ByBrowser:      make([]ClicksByKey, len(byBrowser)),
+		ByDevice:       make([]ClicksByKey, len(byDevice)),
+	}
+	for i, row := range byDay {
+		stats.ByDay[i] = ClicksByDay{Day: row.Day, Clicks: row.Clicks}
+	}
+	for i, row := range byReferrerHost {
+		stats.ByReferrerHost[i] = ClicksByKey{Key: row.ReferrerHost, Clicks: row.Clicks}
+	}
+	for i, row := range byBrowser {
+		stats.ByBrowser[i] = ClicksByKey{Key: row.Browser, Clicks: row.Clicks}
+	}
+	for i, row := range byDevice {
+		stats.ByDevice[i] = ClicksByKey{Key: row.Device, Clicks: row.Clicks}
+	}
+
+	return stats, nil
+}
+
+// addUniqueVisitorStats fills in the unique visitors of the stats period and of each day of the stats.
+// Days older than the retention of the estimations are not counted
+func (s *Server) addUniqueVisitorStats(ctx context.Context, stats *UrlStatsResponse) error {
+	now := time.Now().UTC()
+	from := stats.Since.UTC()
+	if retentionStart := now.Add(-cache.UniqueVisitorsRetention); from.Before(retentionStart) {
+		from = retentionStart
+	}
+
+	var days []time.Time
+	for day := from.Truncate(24 * time.Hour); !day.After(now); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	total, err := s.cache.CountUniqueVisitors(ctx, stats.Code, days)
+	if err != nil {
+		return err
+	}
+
+	byDay := make([]time.Time, len(stats.ByDay))
+	for i, day := range stats.ByDay {
+		byDay[i] = day.Day
+	}
+	byDayCounts, err := s.cache.CountUniqueVisitorsByDay(ctx, stats.Code, byDay)
+	if err != nil {
+		return err
+	}
+
+	stats.UniqueVisitors = total
+	for i, count := range byDayCounts {
+		stats.ByDay[i].UniqueVisitors = count
+	}
+
+	return nil
+}
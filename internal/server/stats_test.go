@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/clicks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUrlStatsHandler(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	var (
+		ownerID     = "user-id"
+		otherUserID = "other-user-id"
+	)
+	createdUrl := createShortUrl(t, s, e, "https://example.com", ownerID, "")
+
+	visits := []struct {
+		method    string
+		referrer  string
+		userAgent string
+	}{
+		{method: http.MethodGet, referrer: "https://news.example.org/post", userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0"},
+		{method: http.MethodGet, referrer: "https://news.example.org/other", userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"},
+		{method: http.MethodGet, userAgent: "curl/8.7.1"},
+		// HEAD requests are not counted as clicks
+		{method: http.MethodHead, userAgent: "curl/8.7.1"},
+	}
+	for _, visit := range visits {
+		req := httptest.NewRequest(visit.method, fmt.Sprintf("/%s", createdUrl.ID), nil)
+		req.RemoteAddr = "203.0.113.42:1234"
+		req.Header.Set("Referer", visit.referrer)
+		req.Header.Set("User-Agent", visit.userAgent)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPath("/:code")
+		c.SetPathValues(echo.PathValues{{Name: "code", Value: createdUrl.ID}})
+
+		require.NoError(t, s.redirectHandler(c))
+	}
+	// Clicks are written asynchronously, drain the pipeline before reading the stats
+	require.NoError(t, s.clicks.Shutdown(context.Background()))
+
+	tests := []struct {
+		name           string
+		code           string
+		userID         string
+		permissions    []string
+		days           int
+		expectedStatus int
+	}{
+		{name: "owner gets the stats", code: createdUrl.ID, userID: ownerID, expectedStatus: http.StatusOK},
+		{name: "owner gets the stats of the last days", code: createdUrl.ID, userID: ownerID, days: 7, expectedStatus: http.StatusOK},
+		{name: "user with the stats permission gets the stats", code: createdUrl.ID, userID: otherUserID, permissions: []string{string(auth.GetURLStats)}, expectedStatus: http.StatusOK},
+		{name: "another user cannot get the stats", code: createdUrl.ID, userID: otherUserID, expectedStatus: http.StatusNotFound},
+		{name: "unauthenticated user", code: createdUrl.ID, expectedStatus: http.StatusUnauthorized},
+		{name: "non-existent code", code: "non-existent", userID: ownerID, expectedStatus: http.StatusNotFound},
+		{name: "error on days > max", code: createdUrl.ID, userID: ownerID, days: 366, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := fmt.Sprintf("/v1/urls/%s/stats", tt.code)
+			if tt.days > 0 {
+				target += fmt.Sprintf("?days=%d", tt.days)
+			}
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/v1/urls/:code/stats")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: tt.code}})
+
+			if tt.userID != "" {
+				c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{
+					RegisteredClaims: validator.RegisteredClaims{Subject: tt.userID},
+					CustomClaims:     &auth.CustomClaims{Permissions: tt.permissions},
+				})
+			}
+
+			handler := authMw.RequireAuthentication(s.getUrlStatsHandler)
+
+			// Assertions
+			err := handler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+
+				var actual UrlStatsResponse
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+				assert.Equal(t, int64(3), actual.TotalClicks, "total clicks do not match")
+				// Each click comes from the same IP with a different user agent
+				assert.Equal(t, int64(3), actual.UniqueVisitors, "unique visitors do not match")
+				if assert.Len(t, actual.ByDay, 1) {
+					assert.Equal(t, int64(3), actual.ByDay[0].Clicks)
+					assert.Equal(t, int64(3), actual.ByDay[0].UniqueVisitors)
+				}
+				assert.Contains(t, actual.ByReferrerHost, ClicksByKey{Key: "news.example.org", Clicks: 2})
+				assert.Contains(t, actual.ByReferrerHost, ClicksByKey{Key: "", Clicks: 1})
+				assert.Contains(t, actual.ByBrowser, ClicksByKey{Key: clicks.BrowserFirefox, Clicks: 1})
+				assert.Contains(t, actual.ByDevice, ClicksByKey{Key: clicks.DeviceBot, Clicks: 1})
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestFlushClickCounts(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	createdUrl := createShortUrl(t, s, e, "https://example.com", "user-id", "")
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s", createdUrl.ID), nil)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPath("/:code")
+		c.SetPathValues(echo.PathValues{{Name: "code", Value: createdUrl.ID}})
+
+		require.NoError(t, s.redirectHandler(c))
+	}
+
+	// Counters are kept in the cache until they are flushed
+	url, err := s.rep.GetUrl(context.Background(), createdUrl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), url.ClickCount)
+	assert.Nil(t, url.LastClickedAt)
+
+	s.flushClickCounts(context.Background(), e.Logger)
+
+	url, err = s.rep.GetUrl(context.Background(), createdUrl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), url.ClickCount)
+	assert.NotNil(t, url.LastClickedAt)
+
+	// Flushed counters are not added again
+	s.flushClickCounts(context.Background(), e.Logger)
+
+	url, err = s.rep.GetUrl(context.Background(), createdUrl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), url.ClickCount)
+
+	t.Cleanup(cleanup)
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// trashPurgeInterval is how often URLs past the trash retention period are purged
+const trashPurgeInterval = time.Hour
+
+type TrashedURLResponse struct {
+	URLResponse
+	DeletedAt time.Time `json:"deletedAt"`
+	// Time after which the URL is permanently removed and can no longer be restored
+	PurgeAt time.Time `json:"purgeAt"`
+}
+type PaginatedTrashedURLs struct {
+	Items      []TrashedURLResponse `json:"items"`
+	Pagination Pagination           `json:"pagination"`
+}
+
+// getUserDeletedUrls godoc
+//
+//	@Summary		Get User Trash
+//	@Description	Retrieves a paginated list of deleted URLs of the authenticated user that can still be restored. Deleted URLs keep their short code reserved until they are purged.
+//	@Tags			Trash
+//	@Produce		json
+//	@Param			page		query		int						true	"Page number"	minimum(1)	maximum(10000)	default(1)
+//	@Param			pageSize	query		int						true	"Page size"		minimum(1)	maximum(100)	default(20)
+//	@Success		200			{object}	PaginatedTrashedURLs	"Paginated list of deleted user URLs"
+//	@Failure		400			{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401			{object}	HTTPError				"Unauthorized"
+//	@Failure		403			{object}	HTTPError				"Forbidden"
+//	@Failure		500			{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/trash/urls [get]
+func (s *Server) getUserDeletedUrls(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "trash.GetUserDeletedUrls")
+	defer span.End()
+
+	params := new(PaginationFilters)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Int("page", int(params.Page)), attribute.Int("pageSize", int(params.PageSize)))
+
+	userID := auth.GetUserID(c)
+
+	urls, err := s.rep.GetUserDeletedUrls(ctx, repository.GetUserDeletedUrlsParams{UserID: userID, Limit: params.limit(), Offset: params.offset()})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get deleted user urls")
+		span.RecordError(err)
+
+		return echo.ErrInternalServerError
+	}
+
+	var totalCount int
+	if len(urls) > 0 {
+		totalCount = int(urls[0].TotalCount)
+	}
+
+	items := make([]TrashedURLResponse, len(urls))
+	for i, url := range urls {
+		items[i] = TrashedURLResponse{
+			URLResponse: URLResponse{
+				ID:                url.ID,
+				LongUrl:           url.LongUrl,
+				CreatedAt:         url.CreatedAt,
+				IsCustom:          url.IsCustom,
+				ExpiresAt:         url.ExpiresAt,
+				MaxClicks:         url.MaxClicks,
+				RemainingClicks:   url.RemainingClicks,
+				PasswordProtected: url.PasswordProtected,
+				UpdatedAt:         url.UpdatedAt,
+				ClickCount:        url.ClickCount,
+				LastClickedAt:     url.LastClickedAt,
+			},
+			DeletedAt: *url.DeletedAt,
+			PurgeAt:   s.purgeAt(*url.DeletedAt),
+		}
+	}
+
+	response := &PaginatedTrashedURLs{
+		Items:      items,
+		Pagination: calculatePagination(totalCount, int(params.Page), int(params.PageSize)),
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+type RestoreShortUrlParams struct {
+	GetLongUrlParams
+}
+
+// restoreShortUrlHandler godoc
+//
+//	@Summary		Restore Short URL
+//	@Description	Restores a deleted short URL owned by the authenticated user from the trash, so the short code resolves again.
+//	@Tags			Trash
+//	@Produce		json
+//	@Param			code	path		string					true	"Short code to restore"	maxlength(16)
+//	@Success		200		{object}	CreateShortUrlResponse	"Restored short URL"
+//	@Header			200		{string}	ETag					"ETag of the restored URL"
+//	@Failure		400		{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401		{object}	HTTPError				"Unauthorized"
+//	@Failure		403		{object}	HTTPError				"Forbidden"
+//	@Failure		404		{object}	HTTPError				"Short URL not found in the trash or not owned by user"
+//	@Failure		409		{object}	HTTPError				"Short code is not available"
+//	@Failure		500		{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/trash/urls/{code}/restore [post]
+func (s *Server) restoreShortUrlHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "trash.RestoreShortUrlHandler")
+	defer span.End()
+
+	params := new(RestoreShortUrlParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		span.SetStatus(codes.Error, "invalid user input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code))
+
+	return s.restoreUrl(ctx, c, params.Code, auth.GetUserID(c))
+}
+
+// restoreUrl takes the URL of the code out of the trash.
+// If nothing was restored, the code is looked up again to tell a purged or foreign URL (404)
+// apart from a code that is already in use (409).
+// userID restricts the restore to URLs owned by the user, admins pass nil
+func (s *Server) restoreUrl(ctx context.Context, c *echo.Context, code string, userID *string) error {
+	span := trace.SpanFromContext(ctx)
+
+	var (
+		restoredUrl repository.Url
+		err         error
+	)
+	audit := newAuditEntry(c, auditActionURLRestore, auditTargetURL, code)
+	if userID != nil {
+		restoredUrl, err = s.rep.RestoreUserURL(ctx, repository.RestoreUserURLParams{ID: code, UserID: userID})
+	} else {
+		err = s.inTx(ctx, func(qtx *repository.Queries) error {
+			var err error
+			restoredUrl, err = qtx.RestoreURL(ctx, code)
+			if err != nil {
+				return err
+			}
+
+			return recordAudit(ctx, qtx, audit.withSnapshots(nil, restoredUrl))
+		})
+	}
+	if err != nil && !s.rep.IsNotFoundError(err) {
+		span.SetStatus(codes.Error, "failed to restore short url")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to restore short url", "error", err, slog.String("code", code))
+		if userID == nil {
+			s.recordAuditFailure(ctx, c.Logger(), audit, err)
+		}
+		return echo.ErrInternalServerError
+	}
+	if err != nil {
+		url, err := s.rep.GetUrl(ctx, code)
+		if err != nil {
+			if s.rep.IsNotFoundError(err) {
+				span.AddEvent("short url not found in trash", trace.WithAttributes(attribute.String("code", code)))
+				return echo.ErrNotFound
+			}
+
+			span.SetStatus(codes.Error, "failed to get short url")
+			span.RecordError(err)
+			c.Logger().ErrorContext(ctx, "failed to get short url", "error", err, slog.String("code", code))
+			return echo.ErrInternalServerError
+		}
+		if url.DeletedAt == nil {
+			span.AddEvent("short code is not available", trace.WithAttributes(attribute.String("code", code)))
+			return echo.NewHTTPError(http.StatusConflict, "Short code is not available")
+		}
+
+		span.AddEvent("short url not owned by user", trace.WithAttributes(attribute.String("code", code)))
+		return echo.ErrNotFound
+	}
+
+	c.Response().Header().Set(headerETag, urlETag(restoredUrl.UpdatedAt))
+
+	return c.JSON(http.StatusOK, s.newCreateShortUrlResponse(restoredUrl))
+}
+
+// purgeAt returns the time after which a URL deleted at deletedAt is purged from the trash
+func (s *Server) purgeAt(deletedAt time.Time) time.Time {
+	return deletedAt.Add(s.cfg.App.TrashRetention)
+}
+
+// purgeTrash permanently removes the URLs that have been in the trash longer than the retention period
+func (s *Server) purgeTrash(ctx context.Context, logger *slog.Logger) {
+	ctx, span := tracer.Start(ctx, "trash.PurgeTrash")
+	defer span.End()
+
+	deletedBefore := time.Now().Add(-s.cfg.App.TrashRetention)
+	purgedIDs, err := s.rep.PurgeDeletedURLs(ctx, &deletedBefore)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to purge deleted urls")
+		span.RecordError(err)
+		logger.ErrorContext(ctx, "failed to purge deleted urls", "error", err)
+		return
+	}
+
+	span.SetAttributes(attribute.Int("purged", len(purgedIDs)))
+	if len(purgedIDs) > 0 {
+		logger.InfoContext(ctx, "purged deleted urls", slog.Int("purged", len(purgedIDs)))
+	}
+}
+
+// runTrashPurger purges the trash on start and then every trashPurgeInterval until ctx is cancelled
+func (s *Server) runTrashPurger(ctx context.Context, logger *slog.Logger) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.purgeTrash(ctx, logger)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectHandler_Deleted(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	userID := "user-id"
+	createdUrl := createShortUrl(t, s, e, "https://example.com", userID, "")
+	_, err := s.rep.DeleteUserURL(context.Background(), repository.DeleteUserURLParams{ID: createdUrl.ID, UserID: &userID})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s", createdUrl.ID), nil)
+	res := httptest.NewRecorder()
+	c := e.NewContext(req, res)
+	c.SetPath("/:code")
+	c.SetPathValues(echo.PathValues{{Name: "code", Value: createdUrl.ID}})
+
+	// Assertions
+	err = s.redirectHandler(c)
+	if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok, "expected an HTTP error") {
+		assert.Equal(t, http.StatusGone, sc.StatusCode())
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestGetUserDeletedUrlsHandler(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	var (
+		userID_1 = "user-id"
+		userID_2 = "user-id-2"
+	)
+
+	for i := range 3 {
+		createdUrl := createShortUrl(t, s, e, fmt.Sprintf("https://example-%d.com", i), userID_1, "")
+		_, err := s.rep.DeleteUserURL(context.Background(), repository.DeleteUserURLParams{ID: createdUrl.ID, UserID: &userID_1})
+		require.NoError(t, err)
+	}
+	createShortUrl(t, s, e, "https://example.com", userID_1, "")
+
+	tests := []struct {
+		name              string
+		userID            string
+		withoutPermission bool
+		page              int
+		pageSize          int
+		expectedStatus    int
+		expectedUrls      int
+	}{
+		{name: "return deleted user urls", userID: userID_1, page: 1, pageSize: 25, expectedStatus: http.StatusOK, expectedUrls: 3},
+		{name: "return deleted user urls for page=2 and pageSize=2", userID: userID_1, page: 2, pageSize: 2, expectedStatus: http.StatusOK, expectedUrls: 1},
+		{name: "return no urls for user without deleted urls", userID: userID_2, page: 1, pageSize: 25, expectedStatus: http.StatusOK},
+		{name: "error on 0 page", userID: userID_1, page: 0, pageSize: 25, expectedStatus: http.StatusBadRequest},
+		{name: "unauthenticated user", page: 1, pageSize: 25, expectedStatus: http.StatusUnauthorized},
+		{name: "no required permission", userID: userID_1, withoutPermission: true, page: 1, pageSize: 25, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/trash/urls?page=%d&pageSize=%d", tt.page, tt.pageSize), nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/v1/trash/urls")
+
+			if tt.userID != "" {
+				claims := &validator.ValidatedClaims{
+					RegisteredClaims: validator.RegisteredClaims{Subject: tt.userID},
+					CustomClaims:     &auth.CustomClaims{},
+				}
+				if !tt.withoutPermission {
+					claims.CustomClaims.(*auth.CustomClaims).Permissions = []string{string(auth.GetOwnURLs)}
+				}
+
+				c.Set(string(auth.ClaimsContextKey), claims)
+			}
+
+			handler := authMw.RequireAuthentication(authMw.RequirePermission(auth.GetOwnURLs)(s.getUserDeletedUrls))
+
+			// Assertions
+			err := handler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+
+				var actual PaginatedTrashedURLs
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+				assert.Equal(t, tt.expectedUrls, len(actual.Items), "incorrect number of urls")
+				for _, item := range actual.Items {
+					assert.Equal(t, item.DeletedAt.Add(s.cfg.App.TrashRetention), item.PurgeAt, "purgeAt does not match")
+				}
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestRestoreShortUrlHandler(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	var (
+		userID      = "user-id"
+		otherUserID = "other-user-id"
+	)
+	createdUrl := createShortUrl(t, s, e, "https://example.com", userID, "")
+	_, err := s.rep.DeleteUserURL(context.Background(), repository.DeleteUserURLParams{ID: createdUrl.ID, UserID: &userID})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		code              string
+		userID            string
+		withoutPermission bool
+		expectedStatus    int
+	}{
+		{name: "non-existent code", code: "non-existent", userID: userID, expectedStatus: http.StatusNotFound},
+		{name: "unauthenticated user", code: createdUrl.ID, expectedStatus: http.StatusUnauthorized},
+		{name: "no required permission", code: createdUrl.ID, userID: userID, withoutPermission: true, expectedStatus: http.StatusForbidden},
+		{name: "url of another user", code: createdUrl.ID, userID: otherUserID, expectedStatus: http.StatusNotFound},
+		{name: "successful restore", code: createdUrl.ID, userID: userID, expectedStatus: http.StatusOK},
+		{name: "code already in use", code: createdUrl.ID, userID: userID, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/trash/urls/%s/restore", tt.code), nil)
+			res := httptest.NewRecorder()
+
+			c := e.NewContext(req, res)
+			c.SetPath("/v1/trash/urls/:code/restore")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: tt.code}})
+
+			if tt.userID != "" {
+				claims := &validator.ValidatedClaims{
+					RegisteredClaims: validator.RegisteredClaims{Subject: tt.userID},
+					CustomClaims:     &auth.CustomClaims{},
+				}
+				if !tt.withoutPermission {
+					claims.CustomClaims.(*auth.CustomClaims).Permissions = []string{string(auth.DeleteOwnURLs)}
+				}
+
+				c.Set(string(auth.ClaimsContextKey), claims)
+			}
+
+			handler := authMw.RequireAuthentication(authMw.RequirePermission(auth.DeleteOwnURLs)(s.restoreShortUrlHandler))
+
+			// Assertions
+			err := handler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+
+				var actual CreateShortUrlResponse
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+				assert.Nil(t, actual.DeletedAt, "deletedAt should be cleared")
+				assert.Equal(t, urlETag(actual.UpdatedAt), res.Header().Get(headerETag), "etag does not match")
+
+				longUrl, err := s.resolveLongUrl(context.Background(), c, tt.code, resolveOptions{})
+				require.NoError(t, err, "restored url should resolve")
+				assert.Equal(t, createdUrl.LongUrl, longUrl, "long URL does not match")
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	userID := "user-id"
+	createdUrl := createShortUrl(t, s, e, "https://example.com", userID, "")
+	_, err := s.rep.DeleteUserURL(context.Background(), repository.DeleteUserURLParams{ID: createdUrl.ID, UserID: &userID})
+	require.NoError(t, err)
+
+	// Still within the retention period
+	s.purgeTrash(context.Background(), e.Logger)
+	_, err = s.rep.GetUrl(context.Background(), createdUrl.ID)
+	require.NoError(t, err, "url should stay in the trash")
+
+	s.cfg.App.TrashRetention = 0
+	s.purgeTrash(context.Background(), e.Logger)
+	_, err = s.rep.GetUrl(context.Background(), createdUrl.ID)
+	assert.True(t, s.rep.IsNotFoundError(err), "url should be purged")
+
+	t.Cleanup(cleanup)
+}
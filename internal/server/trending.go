@@ -0,0 +1,195 @@
+package server
+
+import (
+	"cmp"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/cache"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const defaultTrendingLimit = 10
+
+type TrendingFilters struct {
+	// Rolling period the clicks are counted in, the last day by default
+	Period string `query:"period" validate:"omitempty,oneof=hour day week"`
+	Limit  int32  `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+func (f TrendingFilters) period() cache.TrendingPeriod {
+	if f.Period == "" {
+		return cache.TrendingDay
+	}
+
+	return cache.TrendingPeriod(f.Period)
+}
+
+func (f TrendingFilters) limit() int {
+	if f.Limit == 0 {
+		return defaultTrendingLimit
+	}
+
+	return int(f.Limit)
+}
+
+type TrendingURLResponse struct {
+	ID      string `json:"id"`
+	LongUrl string `json:"longUrl"`
+	// Number of clicks in the period
+	Clicks int64 `json:"clicks"`
+}
+type UserTrendingURLs struct {
+	Period string                `json:"period" enums:"hour,day,week"`
+	Items  []TrendingURLResponse `json:"items"`
+}
+
+// getUserTrendingUrls godoc
+//
+//	@Summary		Get User Trending URLs
+//	@Description	Returns the URLs of the authenticated user with the most clicks in the last hour, day or week
+//	@Tags			URLs
+//	@Produce		json
+//	@Param			period	query		string				false	"Rolling period, the last day by default"	Enums(hour, day, week)
+//	@Param			limit	query		int					false	"Number of URLs"							minimum(1)	maximum(100)	default(10)
+//	@Success		200		{object}	UserTrendingURLs	"Trending URLs of the user"
+//	@Failure		400		{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401		{object}	HTTPError			"Unauthorized"
+//	@Failure		403		{object}	HTTPError			"Forbidden"
+//	@Failure		500		{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/urls/trending [get]
+func (s *Server) getUserTrendingUrls(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "trending.GetUserTrendingUrls")
+	defer span.End()
+
+	params := new(TrendingFilters)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("period", string(params.period())), attribute.Int("limit", params.limit()))
+
+	userID := auth.GetUserID(c)
+
+	urls, err := s.rep.GetAllUserUrls(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get user urls")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get user urls", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	ids := make([]string, len(urls))
+	longUrls := make(map[string]string, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+		longUrls[url.ID] = url.LongUrl
+	}
+
+	scores, err := s.cache.GetTrendingScores(ctx, params.period(), time.Now(), ids)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get trending scores")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get trending scores", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	scores = slices.DeleteFunc(scores, func(score cache.TrendingScore) bool { return score.Clicks == 0 })
+	slices.SortStableFunc(scores, func(a, b cache.TrendingScore) int { return cmp.Compare(b.Clicks, a.Clicks) })
+	scores = scores[:min(len(scores), params.limit())]
+
+	items := make([]TrendingURLResponse, len(scores))
+	for i, score := range scores {
+		items[i] = TrendingURLResponse{ID: score.Code, LongUrl: longUrls[score.Code], Clicks: score.Clicks}
+	}
+
+	return c.JSON(http.StatusOK, &UserTrendingURLs{Period: string(params.period()), Items: items})
+}
+
+type TrendingURL struct {
+	TrendingURLResponse
+	UserID *string `json:"userId"`
+}
+type TrendingURLs struct {
+	Period string        `json:"period" enums:"hour,day,week"`
+	Items  []TrendingURL `json:"items"`
+}
+
+// getTrendingURLs godoc
+//
+//	@Summary		Get Trending URLs
+//	@Description	Returns the URLs with the most clicks in the last hour, day or week, e.g. to spot links that suddenly go viral
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			period	query		string				false	"Rolling period, the last day by default"	Enums(hour, day, week)
+//	@Param			limit	query		int					false	"Number of URLs"							minimum(1)	maximum(100)	default(10)
+//	@Success		200		{object}	TrendingURLs		"Trending URLs"
+//	@Failure		400		{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401		{object}	HTTPError			"Unauthorized"
+//	@Failure		403		{object}	HTTPError			"Forbidden"
+//	@Failure		500		{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/admin/urls/trending [get]
+func (s *Server) getTrendingURLs(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "admin.GetTrendingURLs")
+	defer span.End()
+
+	params := new(TrendingFilters)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("period", string(params.period())), attribute.Int("limit", params.limit()))
+
+	scores, err := s.cache.GetTrending(ctx, params.period(), time.Now(), int64(params.limit()))
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get trending urls")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get trending urls", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	ids := make([]string, len(scores))
+	for i, score := range scores {
+		ids[i] = score.Code
+	}
+
+	urls, err := s.rep.GetUrlsByIDs(ctx, ids)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get urls")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get urls", "error", err, slog.Any("codes", ids))
+		return echo.ErrInternalServerError
+	}
+
+	items := make([]TrendingURL, 0, len(scores))
+	for _, score := range scores {
+		// URLs deleted since they were clicked are left out
+		idx := slices.IndexFunc(urls, func(url repository.GetUrlsByIDsRow) bool { return url.ID == score.Code })
+		if idx < 0 {
+			continue
+		}
+
+		items = append(items, TrendingURL{
+			TrendingURLResponse: TrendingURLResponse{ID: score.Code, LongUrl: urls[idx].LongUrl, Clicks: score.Clicks},
+			UserID:              urls[idx].UserID,
+		})
+	}
+
+	return c.JSON(http.StatusOK, &TrendingURLs{Period: string(params.period()), Items: items})
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrendingHandlers(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	var (
+		userID      = "user-id"
+		otherUserID = "other-user-id"
+	)
+	popularUrl := createShortUrl(t, s, e, "https://example.com/popular", userID, "")
+	quietUrl := createShortUrl(t, s, e, "https://example.com/quiet", userID, "")
+	otherUrl := createShortUrl(t, s, e, "https://example.com/other", otherUserID, "")
+	createShortUrl(t, s, e, "https://example.com/never-clicked", userID, "")
+
+	clicks := map[string]int{popularUrl.ID: 3, quietUrl.ID: 1, otherUrl.ID: 2}
+	for code, count := range clicks {
+		for range count {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s", code), nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:code")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: code}})
+
+			require.NoError(t, s.redirectHandler(c))
+		}
+	}
+
+	newContext := func(target string, userID string) (*echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: userID}})
+
+		return c, res
+	}
+
+	t.Run("user trending urls", func(t *testing.T) {
+		c, res := newContext("/v1/urls/trending?period=hour", userID)
+		require.NoError(t, s.getUserTrendingUrls(c))
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var actual UserTrendingURLs
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+		assert.Equal(t, "hour", actual.Period)
+		assert.Equal(t, []TrendingURLResponse{
+			{ID: popularUrl.ID, LongUrl: popularUrl.LongUrl, Clicks: 3},
+			{ID: quietUrl.ID, LongUrl: quietUrl.LongUrl, Clicks: 1},
+		}, actual.Items, "only clicked urls of the user should be returned")
+	})
+
+	t.Run("admin trending urls", func(t *testing.T) {
+		c, res := newContext("/v1/admin/urls/trending?limit=2", "admin-id")
+		require.NoError(t, s.getTrendingURLs(c))
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var actual TrendingURLs
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+		assert.Equal(t, "day", actual.Period)
+		assert.Equal(t, []TrendingURL{
+			{TrendingURLResponse: TrendingURLResponse{ID: popularUrl.ID, LongUrl: popularUrl.LongUrl, Clicks: 3}, UserID: &userID},
+			{TrendingURLResponse: TrendingURLResponse{ID: otherUrl.ID, LongUrl: otherUrl.LongUrl, Clicks: 2}, UserID: &otherUserID},
+		}, actual.Items)
+	})
+
+	t.Run("error on invalid period", func(t *testing.T) {
+		c, res := newContext("/v1/admin/urls/trending?period=month", "admin-id")
+		require.NoError(t, s.getTrendingURLs(c))
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Cleanup(cleanup)
+}
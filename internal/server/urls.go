@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"time"
@@ -8,31 +10,71 @@ import (
 	"github.com/labstack/echo/v5"
 	"github.com/rousage/shortener/internal/appvalidator"
 	"github.com/rousage/shortener/internal/auth"
-	"github.com/rousage/shortener/internal/generator"
+	"github.com/rousage/shortener/internal/canonical"
 	"github.com/rousage/shortener/internal/repository"
+	"github.com/rousage/shortener/internal/webhook"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type CreateShortUrlDTO struct {
-	ShortCode string `json:"shortCode" validate:"omitempty,min=5,max=16,shortcode"`
-	URL       string `json:"url" validate:"required,http_url"`
+	ShortCode string `json:"shortCode" validate:"omitempty,min=5,max=16,shortcode,unreserved"`
+	// Destination of the link, at most 2048 characters. Our short links and the links of known shorteners are followed
+	// through at most 3 hops and replaced with where they end, loops and longer chains are rejected.
+	// Internationalized hosts are converted to punycode,
+	// hosts that are or resolve to loopback, link-local, private or reserved addresses are rejected
+	URL string `json:"url" validate:"required,http_url,unshorten=3,idn,max=2048,public_host,destination"`
+	// Strips the tracking params, e.g. utm_source, from the destination, they are kept by default so that campaigns can be measured
+	StripTrackingParams bool `json:"stripTrackingParams"`
+	// Absolute expiration time of the link, cannot be used together with expiresIn
+	ExpiresAt *time.Time `json:"expiresAt" validate:"omitzero,gt,excluded_with=ExpiresIn" example:"2026-12-31T23:59:59Z"`
+	// Lifetime of the link in seconds, cannot be used together with expiresAt
+	ExpiresIn *int64 `json:"expiresIn" validate:"omitzero,min=60,max=315360000,excluded_with=ExpiresAt" example:"86400"`
+	// Number of successful resolutions after which the link stops working, 1 makes it a one-time link
+	MaxClicks *int32 `json:"maxClicks" validate:"omitzero,min=1,max=1000000" example:"1"`
+	// Password required to resolve the link, only available to authenticated users
+	Password string `json:"password" validate:"omitempty,min=4,max=72"`
+	// Return the existing link of the authenticated user for the same canonical destination instead of creating a new one,
+	// defaults to the dedupeUrls setting of the user. Only links without a custom short code, an expiration, a click limit
+	// or a password are reused, and only for requests without them
+	Dedupe *bool `json:"dedupe"`
+}
+
+// expiresAt returns the absolute expiration time of the link, if any
+func (dto *CreateShortUrlDTO) expiresAt() *time.Time {
+	if dto.ExpiresIn != nil {
+		expiresAt := time.Now().Add(time.Duration(*dto.ExpiresIn) * time.Second)
+		return &expiresAt
+	}
+
+	return dto.ExpiresAt
+}
+
+type CreateShortUrlResponse struct {
+	repository.Url
+	ShortUrl          string `json:"shortUrl" example:"https://sho.rt/abc123XY"`
+	PasswordProtected bool   `json:"passwordProtected"`
 }
 
 // createShortURLHandler godoc
 //
 //	@Summary		Create Short URL
-//	@Description	Creates a shortened URL. Authenticated users can provide a custom short code (5-16 characters). Otherwise, a random code is generated.
+//	@Description	Creates a shortened URL. Authenticated users can provide a custom short code (5-16 characters). Otherwise, a random code is generated. The link can optionally expire at a given time (expiresAt) or after a given number of seconds (expiresIn), and can be limited to a number of clicks (maxClicks). Authenticated users can protect the link with a password, and reuse their existing link for the same destination (dedupe). Retries with the same Idempotency-Key return the link created by the first request instead of creating another one.
 //	@Tags			URLs
 //	@Accept			json
 //	@Produce		json
-//	@Param			request	body		CreateShortUrlDTO		true	"URL and optional custom short code"
-//	@Success		201		{object}	repository.Url			"Created short URL"
-//	@Failure		400		{object}	HTTPValidationError		"Validation failed"
-//	@Failure		403		{object}	HTTPError				"Custom short codes require authentication"
-//	@Failure		409		{object}	map[string]interface{}	"Short code already taken or validation failed"
-//	@Failure		500		{object}	HTTPError				"Internal server error"
+//	@Param			Idempotency-Key	header		string					false	"Unique key of the request, retries with the same key and body return the same link for 24 hours"	maxlength(255)
+//	@Param			request			body		CreateShortUrlDTO		true	"URL and optional custom short code"
+//	@Success		200				{object}	CreateShortUrlResponse	"Existing short URL reused"
+//	@Success		201				{object}	CreateShortUrlResponse	"Created short URL"
+//	@Header			200,201			{string}	ETag					"ETag of the URL"
+//	@Header			201				{string}	Idempotent-Replayed		"true if the URL was created by an earlier request with the same Idempotency-Key"
+//	@Failure		400				{object}	HTTPValidationError		"Validation failed"
+//	@Failure		403				{object}	HTTPError				"Custom short codes and passwords require authentication"
+//	@Failure		409				{object}	map[string]interface{}	"Short code already taken or validation failed"
+//	@Failure		422				{object}	HTTPError				"Idempotency-Key already used for a different request"
+//	@Failure		500				{object}	HTTPError				"Internal server error"
 //	@Security		BearerAuth
 //	@Router			/v1/urls [post]
 func (s *Server) createShortURLHandler(c *echo.Context) error {
@@ -46,7 +88,24 @@ func (s *Server) createShortURLHandler(c *echo.Context) error {
 		span.RecordError(err)
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	if err := c.Validate(dto); err != nil {
+
+	// Retries are recognized before the validation, which could reject them if e.g. the domain rules have changed since
+	requestKey, err := newIdempotencyKey(c, *dto)
+	if err != nil {
+		span.SetStatus(codes.Error, "invalid idempotency key")
+		span.RecordError(err)
+		return err
+	}
+	if requestKey != nil {
+		span.SetAttributes(attribute.String("idempotencyKey", requestKey.key))
+		if replayed, err := s.replayIdempotentRequest(ctx, c, requestKey); replayed || err != nil {
+			return err
+		}
+	}
+
+	// Validation rewrites the URL, e.g. short links are replaced with their destination
+	originalUrl := dto.URL
+	if err := s.validateCtx(ctx, c, dto); err != nil {
 		span.SetStatus(codes.Error, "invalid user input")
 		span.RecordError(err)
 		return s.failedValidationError(c, err)
@@ -54,11 +113,62 @@ func (s *Server) createShortURLHandler(c *echo.Context) error {
 	span.SetAttributes(attribute.String("url", dto.URL))
 
 	var (
-		userId   = auth.GetUserID(c)
-		shortUrl string
-		newUrl   repository.Url
-		err      error
+		userId       = auth.GetUserID(c)
+		longUrl      = s.canonicalUrl(dto.URL, dto.StripTrackingParams)
+		expiresAt    = dto.expiresAt()
+		passwordHash *string
+		shortUrl     string
+		newUrl       repository.Url
 	)
+	if expiresAt != nil {
+		span.SetAttributes(attribute.String("expiresAt", expiresAt.Format(time.RFC3339)))
+	}
+	if dto.MaxClicks != nil {
+		span.SetAttributes(attribute.Int("maxClicks", int(*dto.MaxClicks)))
+	}
+	if dto.Password != "" {
+		span.SetAttributes(attribute.Bool("passwordProtected", true))
+
+		if userId == nil || *userId == "" {
+			span.AddEvent("unauthenticated user attempted to create password-protected url")
+			return echo.NewHTTPError(http.StatusForbidden, "Only authenticated users can create password-protected links")
+		}
+
+		hash, err := hashPassword(dto.Password)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to hash password")
+			span.RecordError(err)
+			c.Logger().ErrorContext(ctx, "failed to hash password", "error", err)
+			return echo.ErrInternalServerError
+		}
+		passwordHash = &hash
+	}
+
+	existingUrl, err := s.reusableUrl(ctx, dto, userId, longUrl)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get reusable short url")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get reusable short url", "error", err)
+		return echo.ErrInternalServerError
+	}
+	if existingUrl != nil {
+		span.AddEvent("existing short url reused", trace.WithAttributes(attribute.String("code", existingUrl.ID)))
+		if requestKey != nil {
+			err := storeIdempotencyKey(ctx, s.rep, requestKey, existingUrl.ID, http.StatusOK)
+			if errors.Is(err, errIdempotencyKeyUsed) {
+				return s.replayConcurrentRequest(ctx, c, requestKey)
+			}
+			if err != nil {
+				span.SetStatus(codes.Error, "failed to store idempotency key")
+				span.RecordError(err)
+				c.Logger().ErrorContext(ctx, "failed to store idempotency key", "error", err)
+				return echo.ErrInternalServerError
+			}
+		}
+
+		c.Response().Header().Set(headerETag, urlETag(existingUrl.UpdatedAt))
+		return c.JSON(http.StatusOK, s.newCreateShortUrlResponse(*existingUrl))
+	}
 
 	// Use a custom short code if provided,
 	// otherwise generate a random one.
@@ -71,17 +181,31 @@ func (s *Server) createShortURLHandler(c *echo.Context) error {
 			return echo.NewHTTPError(http.StatusForbidden, "Only authenticated users can create custom short codes")
 		}
 
-		newUrl, err = s.rep.CreateUrl(ctx, repository.CreateUrlParams{
-			ID:       dto.ShortCode,
-			LongUrl:  dto.URL,
-			IsCustom: true,
-			UserID:   userId,
-		})
+		newUrl, err = s.createUrl(ctx, repository.CreateUrlParams{
+			ID:           dto.ShortCode,
+			LongUrl:      longUrl,
+			OriginalUrl:  originalUrl,
+			IsCustom:     true,
+			UserID:       userId,
+			ExpiresAt:    expiresAt,
+			MaxClicks:    dto.MaxClicks,
+			PasswordHash: passwordHash,
+		}, requestKey)
+		if errors.Is(err, errIdempotencyKeyUsed) {
+			return s.replayConcurrentRequest(ctx, c, requestKey)
+		}
 		if err != nil {
 			span.SetStatus(codes.Error, "failed to create short url with custom short code")
 			span.RecordError(err)
 
 			if s.rep.IsDuplicateKeyError(err) {
+				// A concurrent request with the same key may have taken the short code first
+				if requestKey != nil {
+					if replayed, err := s.replayIdempotentRequest(ctx, c, requestKey); replayed || err != nil {
+						return err
+					}
+				}
+
 				return c.JSON(http.StatusConflict, &HTTPValidationError{
 					HTTPError: HTTPError{Message: "Validation failed"},
 					Errors: appvalidator.ValidationError{
@@ -99,23 +223,29 @@ func (s *Server) createShortURLHandler(c *echo.Context) error {
 			return echo.ErrInternalServerError
 		}
 
-		return c.JSON(http.StatusCreated, newUrl)
+		c.Response().Header().Set(headerETag, urlETag(newUrl.UpdatedAt))
+		return c.JSON(http.StatusCreated, s.newCreateShortUrlResponse(newUrl))
 	}
 
 	span.AddEvent("attempting to generate short url")
+	// The generated code can be taken by a custom one even if the generator never repeats itself, the next code is tried then
 	const maxRetries = 3
 	for attempt := range maxRetries {
-		shortUrl, err = generator.ShortUrl(ctx, s.cfg.App.ShortUrlLength)
+		shortUrl, err = s.shortCodes.Generate(ctx)
 		if err != nil {
 			break
 		}
 
-		newUrl, err = s.rep.CreateUrl(ctx, repository.CreateUrlParams{
-			ID:       shortUrl,
-			LongUrl:  dto.URL,
-			IsCustom: false,
-			UserID:   userId,
-		})
+		newUrl, err = s.createUrl(ctx, repository.CreateUrlParams{
+			ID:           shortUrl,
+			LongUrl:      longUrl,
+			OriginalUrl:  originalUrl,
+			IsCustom:     false,
+			UserID:       userId,
+			ExpiresAt:    expiresAt,
+			MaxClicks:    dto.MaxClicks,
+			PasswordHash: passwordHash,
+		}, requestKey)
 		if err == nil {
 			break
 		}
@@ -135,6 +265,9 @@ func (s *Server) createShortURLHandler(c *echo.Context) error {
 		}
 	}
 
+	if errors.Is(err, errIdempotencyKeyUsed) {
+		return s.replayConcurrentRequest(ctx, c, requestKey)
+	}
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to generate short url")
 		span.RecordError(err)
@@ -144,7 +277,90 @@ func (s *Server) createShortURLHandler(c *echo.Context) error {
 
 	span.AddEvent("short url generated")
 
-	return c.JSON(http.StatusCreated, newUrl)
+	c.Response().Header().Set(headerETag, urlETag(newUrl.UpdatedAt))
+	return c.JSON(http.StatusCreated, s.newCreateShortUrlResponse(newUrl))
+}
+
+// canonicalUrl returns the canonical form of the destination that links redirect to,
+// the configured tracking params are only stripped if the user asks for it
+func (s *Server) canonicalUrl(url string, stripTrackingParams bool) string {
+	var opts canonical.Options
+	if stripTrackingParams {
+		opts.StripParams = s.cfg.App.TrackingParams
+	}
+
+	return canonical.URL(url, opts)
+}
+
+// reusableUrl returns the existing link of the user for the canonical destination if the request opts in to deduplication,
+// explicitly or through the settings of the user. Anonymous requests and the ones that ask for a custom short code,
+// an expiration, a click limit or a password always create a new link
+func (s *Server) reusableUrl(ctx context.Context, dto *CreateShortUrlDTO, userId *string, longUrl string) (*repository.Url, error) {
+	if userId == nil || *userId == "" || dto.ShortCode != "" || dto.ExpiresAt != nil || dto.ExpiresIn != nil || dto.MaxClicks != nil || dto.Password != "" {
+		return nil, nil
+	}
+
+	var dedupe bool
+	if dto.Dedupe != nil {
+		dedupe = *dto.Dedupe
+	} else {
+		settings, err := s.getUserSettings(ctx, *userId)
+		if err != nil {
+			return nil, err
+		}
+		dedupe = settings.DedupeUrls
+	}
+	if !dedupe {
+		return nil, nil
+	}
+
+	url, err := s.rep.GetReusableUserURL(ctx, repository.GetReusableUserURLParams{UserID: userId, LongUrl: longUrl})
+	if err != nil {
+		if s.rep.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &url, nil
+}
+
+// createUrl creates the URL and queues its link.created webhook event in the same transaction.
+// The idempotency key, if any, is stored in the transaction as well, errIdempotencyKeyUsed is returned
+// if a concurrent request has stored it first
+func (s *Server) createUrl(ctx context.Context, arg repository.CreateUrlParams, requestKey *idempotencyKey) (repository.Url, error) {
+	var url repository.Url
+	err := s.inTx(ctx, func(qtx *repository.Queries) error {
+		var err error
+		url, err = qtx.CreateUrl(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		if requestKey != nil {
+			if err := storeIdempotencyKey(ctx, qtx, requestKey, url.ID, http.StatusCreated); err != nil {
+				return err
+			}
+		}
+
+		return webhook.Queue(ctx, qtx, webhook.LinkCreated, webhook.NewLinkEvent(url))
+	})
+
+	return url, err
+}
+
+// newCreateShortUrlResponse builds the response for a created or updated URL
+func (s *Server) newCreateShortUrlResponse(url repository.Url) *CreateShortUrlResponse {
+	return &CreateShortUrlResponse{
+		Url:               url,
+		ShortUrl:          s.shortUrl(url.ID),
+		PasswordProtected: url.PasswordHash != nil,
+	}
+}
+
+// shortUrl builds the public short link for the code from the configured base URL
+func (s *Server) shortUrl(code string) string {
+	return s.cfg.App.BaseURL + "/" + code
 }
 
 type GetLongUrlParams struct {
@@ -157,21 +373,83 @@ type GetLongUrlResponse struct {
 // getLongUrlHandler godoc
 //
 //	@Summary		Get Long URL
-//	@Description	Retrieves the original long URL for a given short code. Checks cache first, then database.
+//	@Description	Retrieves the original long URL for a given short code. Checks cache first, then database. Password-protected links require the password in the X-Link-Password header.
 //	@Tags			URLs
 //	@Produce		json
+//	@Param			code			path		string				true	"Short code"	maxlength(16)
+//	@Param			X-Link-Password	header		string				false	"Password of a password-protected link"
+//	@Success		200				{object}	GetLongUrlResponse	"longUrl"
+//	@Failure		400				{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401				{object}	HTTPError			"Password required or invalid"
+//	@Failure		403				{object}	HTTPError			"Short URL has been disabled, is under review or its owner is blocked"
+//	@Failure		404				{object}	HTTPError			"Short URL not found"
+//	@Failure		410				{object}	HTTPError			"Short URL has expired, reached its click limit or has been deleted"
+//	@Failure		429				{object}	HTTPError			"Too many wrong password attempts"
+//	@Failure		500				{object}	HTTPError			"Internal server error"
+//	@Failure		503				{object}	HTTPError			"Password cannot be checked right now"
+//	@Security		BearerAuth
+//	@Router			/v1/urls/{code} [get]
+func (s *Server) getLongUrlHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "urls.GetLongUrlHandler")
+	defer span.End()
+
+	params := new(GetLongUrlParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code))
+
+	longUrl, err := s.resolveLongUrl(ctx, c, params.Code, resolveOptions{
+		consumeClick: true,
+		password:     c.Request().Header.Get(headerLinkPassword),
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &GetLongUrlResponse{
+		LongUrl: longUrl,
+	})
+}
+
+type UnlockLongUrlDTO struct {
+	Password string `json:"password" validate:"required,max=72"`
+}
+type UnlockLongUrlParams struct {
+	GetLongUrlParams
+	UnlockLongUrlDTO
+}
+
+// unlockLongUrlHandler godoc
+//
+//	@Summary		Unlock Long URL
+//	@Description	Retrieves the original long URL of a password-protected short code. Wrong password attempts are rate limited per short code.
+//	@Tags			URLs
+//	@Accept			json
+//	@Produce		json
 //	@Param			code	path		string				true	"Short code"	maxlength(16)
+//	@Param			request	body		UnlockLongUrlDTO	true	"Password of the link"
 //	@Success		200		{object}	GetLongUrlResponse	"longUrl"
 //	@Failure		400		{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401		{object}	HTTPError			"Invalid password"
+//	@Failure		403		{object}	HTTPError			"Short URL has been disabled, is under review or its owner is blocked"
 //	@Failure		404		{object}	HTTPError			"Short URL not found"
+//	@Failure		410		{object}	HTTPError			"Short URL has expired, reached its click limit or has been deleted"
+//	@Failure		429		{object}	HTTPError			"Too many wrong password attempts"
 //	@Failure		500		{object}	HTTPError			"Internal server error"
+//	@Failure		503		{object}	HTTPError			"Password cannot be checked right now"
 //	@Security		BearerAuth
-//	@Router			/v1/urls/{code} [get]
-func (s *Server) getLongUrlHandler(c *echo.Context) error {
-	ctx, span := tracer.Start(c.Request().Context(), "urls.GetLongUrlHandler")
+//	@Router			/v1/urls/{code}/unlock [post]
+func (s *Server) unlockLongUrlHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "urls.UnlockLongUrlHandler")
 	defer span.End()
 
-	params := new(GetLongUrlParams)
+	params := new(UnlockLongUrlParams)
 	if err := c.Bind(params); err != nil {
 		span.SetStatus(codes.Error, "failed to bind request")
 		span.RecordError(err)
@@ -182,46 +460,174 @@ func (s *Server) getLongUrlHandler(c *echo.Context) error {
 	}
 	span.SetAttributes(attribute.String("code", params.Code))
 
-	longUrl, err := s.cache.GetLongUrl(ctx, params.Code)
+	longUrl, err := s.resolveLongUrl(ctx, c, params.Code, resolveOptions{consumeClick: true, password: params.Password})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &GetLongUrlResponse{
+		LongUrl: longUrl,
+	})
+}
+
+type resolveOptions struct {
+	// consumeClick counts the resolution as a click: a click event is recorded
+	// and the remaining clicks of click-limited links are decremented
+	consumeClick bool
+	// password submitted for password-protected links
+	password string
+}
+
+// resolveLongUrl looks up the long URL for the code in the cache first, then in the database,
+// and records a click for successful resolutions.
+// Database hits are written back to the cache, expired and deleted links are reported as gone.
+// Password-protected and click-limited links are never cached, so the password is checked
+// and a click is consumed (unless disabled, e.g. for HEAD requests) on every resolution.
+// The returned error is an HTTP error that can be returned from the handler as is
+func (s *Server) resolveLongUrl(ctx context.Context, c *echo.Context, code string, opts resolveOptions) (string, error) {
+	longUrl, err := s.lookupLongUrl(ctx, c, code, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.consumeClick {
+		s.recordClick(ctx, c, code)
+	}
+
+	return longUrl, nil
+}
+
+func (s *Server) lookupLongUrl(ctx context.Context, c *echo.Context, code string, opts resolveOptions) (string, error) {
+	span := trace.SpanFromContext(ctx)
+
+	longUrl, err := s.cache.GetLongUrl(ctx, code)
 	if err != nil {
 		span.AddEvent("failed to get long url from cache")
-		c.Logger().WarnContext(ctx, "failed to get long url from cache", "error", err, slog.String("code", params.Code))
+		c.Logger().WarnContext(ctx, "failed to get long url from cache", "error", err, slog.String("code", code))
 	}
 	if longUrl != "" {
-		return c.JSON(http.StatusOK, map[string]string{
-			"longUrl": longUrl,
-		})
+		return longUrl, nil
 	}
 
-	longUrl, err = s.rep.GetLongUrl(ctx, params.Code)
+	url, err := s.rep.GetLongUrl(ctx, code)
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to get long url")
 		span.RecordError(err)
 
 		if s.rep.IsNotFoundError(err) {
-			c.Logger().ErrorContext(ctx, "long url not found", "error", err, slog.String("code", params.Code))
-			return echo.ErrNotFound
+			c.Logger().ErrorContext(ctx, "long url not found", "error", err, slog.String("code", code))
+			return "", echo.ErrNotFound
 		}
 
-		c.Logger().ErrorContext(ctx, "failed to get long url", "error", err, slog.String("code", params.Code))
-		return echo.ErrInternalServerError
+		c.Logger().ErrorContext(ctx, "failed to get long url", "error", err, slog.String("code", code))
+		return "", echo.ErrInternalServerError
+	}
+
+	// Deleted URLs keep their code reserved while they are in the trash
+	if url.DeletedAt != nil {
+		span.AddEvent("short url has been deleted", trace.WithAttributes(attribute.String("deletedAt", url.DeletedAt.Format(time.RFC3339))))
+		return "", echo.NewHTTPError(http.StatusGone, "Short URL has been deleted")
+	}
+
+	// Links of blocked users are disabled while the block is active
+	if url.OwnerBlocked {
+		span.AddEvent("owner of the short url is blocked")
+		return "", echo.NewHTTPError(http.StatusForbidden, "Short URL has been disabled")
+	}
+
+	// Reported links are quarantined until they are reviewed, abusive links are disabled for good
+	if url.DisabledReason != nil {
+		span.AddEvent("short url has been disabled", trace.WithAttributes(attribute.String("reason", *url.DisabledReason)))
+		if *url.DisabledReason == disabledReasonQuarantined {
+			return "", echo.NewHTTPError(http.StatusForbidden, "Short URL is under review")
+		}
+		return "", echo.NewHTTPError(http.StatusForbidden, "Short URL has been disabled")
+	}
+
+	if url.ExpiresAt != nil && !url.ExpiresAt.After(time.Now()) {
+		span.AddEvent("short url has expired", trace.WithAttributes(attribute.String("expiresAt", url.ExpiresAt.Format(time.RFC3339))))
+		return "", echo.NewHTTPError(http.StatusGone, "Short URL has expired")
 	}
 
-	if key, err := s.cache.SetLongUrl(ctx, params.Code, longUrl); err != nil {
+	if url.PasswordHash != nil {
+		if err := s.checkLinkPassword(ctx, c, code, *url.PasswordHash, opts.password); err != nil {
+			return "", err
+		}
+	}
+
+	if url.RemainingClicks != nil {
+		if *url.RemainingClicks <= 0 {
+			span.AddEvent("short url has reached its click limit")
+			return "", echo.NewHTTPError(http.StatusGone, "Short URL has reached its click limit")
+		}
+		if !opts.consumeClick {
+			return url.LongUrl, nil
+		}
+
+		remaining, err := s.rep.ConsumeClick(ctx, code)
+		if err != nil {
+			if s.rep.IsNotFoundError(err) {
+				// Another request consumed the last click in the meantime
+				span.AddEvent("short url has reached its click limit")
+				return "", echo.NewHTTPError(http.StatusGone, "Short URL has reached its click limit")
+			}
+
+			span.SetStatus(codes.Error, "failed to consume click")
+			span.RecordError(err)
+			c.Logger().ErrorContext(ctx, "failed to consume click", "error", err, slog.String("code", code))
+			return "", echo.ErrInternalServerError
+		}
+		span.SetAttributes(attribute.Int("remainingClicks", int(*remaining)))
+
+		return url.LongUrl, nil
+	}
+	if url.PasswordHash != nil {
+		return url.LongUrl, nil
+	}
+
+	if key, err := s.cache.SetLongUrl(ctx, code, url.LongUrl, url.ExpiresAt); err != nil {
 		span.AddEvent("failed to cache long url", trace.WithAttributes(attribute.String("key", key)))
-		c.Logger().WarnContext(ctx, "failed to cache long url", "error", err, slog.String("code", params.Code), slog.String("key", key))
+		c.Logger().WarnContext(ctx, "failed to cache long url", "error", err, slog.String("code", code), slog.String("key", key))
 	}
 
-	return c.JSON(http.StatusOK, &GetLongUrlResponse{
-		LongUrl: longUrl,
-	})
+	return url.LongUrl, nil
+}
+
+// errNotRedirecting is returned for the short links that do not simply redirect to their destination
+var errNotRedirecting = errors.New("short link does not redirect")
+
+// lookupShortLink returns the destination of one of our short links for the short link resolver.
+// Only the links that redirect without a password or a click limit are followed, so creating a link
+// to one of them does not reveal its destination or get around its limits
+func (s *Server) lookupShortLink(ctx context.Context, code string) (string, error) {
+	url, err := s.rep.GetLongUrl(ctx, code)
+	if err != nil {
+		return "", err
+	}
+
+	if url.DeletedAt != nil || url.OwnerBlocked || url.DisabledReason != nil || url.PasswordHash != nil || url.RemainingClicks != nil {
+		return "", errNotRedirecting
+	}
+	if url.ExpiresAt != nil && !url.ExpiresAt.After(time.Now()) {
+		return "", errNotRedirecting
+	}
+
+	return url.LongUrl, nil
 }
 
 type URLResponse struct {
-	ID        string    `json:"id"`
-	LongUrl   string    `json:"longUrl"`
-	CreatedAt time.Time `json:"createdAt"`
-	IsCustom  bool      `json:"isCustom"`
+	ID                string     `json:"id"`
+	LongUrl           string     `json:"longUrl"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	IsCustom          bool       `json:"isCustom"`
+	ExpiresAt         *time.Time `json:"expiresAt"`
+	MaxClicks         *int32     `json:"maxClicks"`
+	RemainingClicks   *int32     `json:"remainingClicks"`
+	PasswordProtected bool       `json:"passwordProtected"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	// Number of clicks, updated periodically
+	ClickCount    int64      `json:"clickCount"`
+	LastClickedAt *time.Time `json:"lastClickedAt"`
 }
 type PaginatedUserURLs struct {
 	Items      []URLResponse `json:"items"`
@@ -231,11 +637,12 @@ type PaginatedUserURLs struct {
 // getUserUrls godoc
 //
 //	@Summary		Get User URLs
-//	@Description	Retrieves a paginated list of URLs created by the authenticated user
+//	@Description	Retrieves a paginated list of URLs created by the authenticated user. Click counts are updated periodically, so they can lag behind the latest clicks.
 //	@Tags			URLs
 //	@Produce		json
-//	@Param			page		query		int					true	"Page number"	minimum(1)	maximum(10000)	default(1)
-//	@Param			pageSize	query		int					true	"Page size"		minimum(1)	maximum(100)	default(20)
+//	@Param			sortBy		query		string				false	"Sort order, newest first by default"	Enums(createdAt, clickCount, lastClickedAt)
+//	@Param			page		query		int					true	"Page number"							minimum(1)	maximum(10000)	default(1)
+//	@Param			pageSize	query		int					true	"Page size"								minimum(1)	maximum(100)	default(20)
 //	@Success		200			{object}	PaginatedUserURLs	"Paginated list of user URLs"
 //	@Failure		400			{object}	HTTPValidationError	"Validation failed"
 //	@Failure		401			{object}	HTTPError			"Unauthorized"
@@ -247,7 +654,7 @@ func (s *Server) getUserUrls(c *echo.Context) error {
 	ctx, span := tracer.Start(c.Request().Context(), "urls.GetUserUrls")
 	defer span.End()
 
-	params := new(PaginationFilters)
+	params := new(UserURLsFilters)
 	if err := c.Bind(params); err != nil {
 		span.SetStatus(codes.Error, "failed to bind request")
 		span.RecordError(err)
@@ -256,11 +663,11 @@ func (s *Server) getUserUrls(c *echo.Context) error {
 	if err := c.Validate(params); err != nil {
 		return s.failedValidationError(c, err)
 	}
-	span.SetAttributes(attribute.Int("page", int(params.Page)), attribute.Int("pageSize", int(params.PageSize)))
+	span.SetAttributes(attribute.Int("page", int(params.Page)), attribute.Int("pageSize", int(params.PageSize)), attribute.String("sortBy", params.SortBy))
 
 	userID := auth.GetUserID(c)
 
-	urls, err := s.rep.GetUserUrls(ctx, repository.GetUserUrlsParams{UserID: userID, Limit: params.limit(), Offset: params.offset()})
+	urls, err := s.rep.GetUserUrls(ctx, repository.GetUserUrlsParams{UserID: userID, SortBy: params.SortBy, Limit: params.limit(), Offset: params.offset()})
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to get user urls")
 		span.RecordError(err)
@@ -276,10 +683,17 @@ func (s *Server) getUserUrls(c *echo.Context) error {
 	items := make([]URLResponse, len(urls))
 	for i, url := range urls {
 		items[i] = URLResponse{
-			ID:        url.ID,
-			LongUrl:   url.LongUrl,
-			CreatedAt: url.CreatedAt,
-			IsCustom:  url.IsCustom,
+			ID:                url.ID,
+			LongUrl:           url.LongUrl,
+			CreatedAt:         url.CreatedAt,
+			IsCustom:          url.IsCustom,
+			ExpiresAt:         url.ExpiresAt,
+			MaxClicks:         url.MaxClicks,
+			RemainingClicks:   url.RemainingClicks,
+			PasswordProtected: url.PasswordProtected,
+			UpdatedAt:         url.UpdatedAt,
+			ClickCount:        url.ClickCount,
+			LastClickedAt:     url.LastClickedAt,
 		}
 	}
 
@@ -291,6 +705,142 @@ func (s *Server) getUserUrls(c *echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+type UpdateShortUrlDTO struct {
+	// Validated and canonicalized the same way as the URL of CreateShortUrlDTO
+	URL string `json:"url" validate:"required,http_url,unshorten=3,idn,max=2048,public_host,destination"`
+	// Strips the tracking params, e.g. utm_source, from the destination, they are kept by default so that campaigns can be measured
+	StripTrackingParams bool `json:"stripTrackingParams"`
+}
+type UpdateShortUrlParams struct {
+	GetLongUrlParams
+	UpdateShortUrlDTO
+}
+
+// updateShortUrlHandler godoc
+//
+//	@Summary		Update Short URL
+//	@Description	Changes the long URL of a short URL owned by the authenticated user, keeping the short code. Also removes it from cache. Supports optimistic concurrency with the If-Match header, the current ETag is returned when the URL is created or updated.
+//	@Tags			URLs
+//	@Accept			json
+//	@Produce		json
+//	@Param			code		path		string					true	"Short code to update"	maxlength(16)
+//	@Param			If-Match	header		string					false	"ETag of the URL the update is based on"
+//	@Param			request		body		UpdateShortUrlDTO		true	"New long URL"
+//	@Success		200			{object}	CreateShortUrlResponse	"Updated short URL"
+//	@Header			200			{string}	ETag					"ETag of the updated URL"
+//	@Failure		400			{object}	HTTPValidationError		"Validation failed"
+//	@Failure		401			{object}	HTTPError				"Unauthorized"
+//	@Failure		403			{object}	HTTPError				"Forbidden"
+//	@Failure		404			{object}	HTTPError				"Short URL not found or not owned by user"
+//	@Failure		412			{object}	HTTPError				"Short URL has been modified"
+//	@Failure		500			{object}	HTTPError				"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/urls/{code} [patch]
+func (s *Server) updateShortUrlHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "urls.UpdateShortUrlHandler")
+	defer span.End()
+
+	params := new(UpdateShortUrlParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	originalUrl := params.URL
+	if err := s.validateCtx(ctx, c, params); err != nil {
+		span.SetStatus(codes.Error, "invalid user input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("code", params.Code), attribute.String("url", params.URL))
+
+	return s.updateLongUrl(ctx, c, params, originalUrl, auth.GetUserID(c))
+}
+
+// updateLongUrl changes the long URL of the code with optimistic concurrency control:
+// the If-Match header is compared against the current ETag, and the update only applies
+// if the URL has not been changed since it was read.
+// The canonical form of the validated URL is stored along with the URL as it was submitted before validation.
+// userID restricts the update to URLs owned by the user, admins pass nil
+func (s *Server) updateLongUrl(ctx context.Context, c *echo.Context, params *UpdateShortUrlParams, originalUrl string, userID *string) error {
+	span := trace.SpanFromContext(ctx)
+
+	url, err := s.rep.GetUrl(ctx, params.Code)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get short url")
+		span.RecordError(err)
+
+		if s.rep.IsNotFoundError(err) {
+			return echo.ErrNotFound
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to get short url", "error", err, slog.String("code", params.Code))
+		return echo.ErrInternalServerError
+	}
+	if userID != nil && (url.UserID == nil || *url.UserID != *userID) {
+		span.AddEvent("short url not owned by user", trace.WithAttributes(attribute.String("code", params.Code)))
+		return echo.ErrNotFound
+	}
+	if url.DeletedAt != nil {
+		span.AddEvent("short url has been deleted", trace.WithAttributes(attribute.String("code", params.Code)))
+		return echo.ErrNotFound
+	}
+
+	if ifMatch := c.Request().Header.Get(headerIfMatch); ifMatch != "" && !etagMatches(ifMatch, urlETag(url.UpdatedAt)) {
+		span.AddEvent("etag does not match", trace.WithAttributes(attribute.String("ifMatch", ifMatch)))
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "Short URL has been modified")
+	}
+
+	longUrl := s.canonicalUrl(params.URL, params.StripTrackingParams)
+	audit := newAuditEntry(c, auditActionURLUpdate, auditTargetURL, params.Code)
+
+	var updatedUrl repository.Url
+	err = s.inTx(ctx, func(qtx *repository.Queries) error {
+		var err error
+		if userID != nil {
+			updatedUrl, err = qtx.UpdateUserURL(ctx, repository.UpdateUserURLParams{LongUrl: longUrl, OriginalUrl: originalUrl, ID: params.Code, UserID: userID, UpdatedAt: url.UpdatedAt})
+		} else {
+			updatedUrl, err = qtx.UpdateURL(ctx, repository.UpdateURLParams{LongUrl: longUrl, OriginalUrl: originalUrl, ID: params.Code, UpdatedAt: url.UpdatedAt})
+		}
+		if err != nil {
+			return err
+		}
+
+		// Only changes made by admins to any URL are audited
+		if userID == nil {
+			if err := recordAudit(ctx, qtx, audit.withSnapshots(url, updatedUrl)); err != nil {
+				return err
+			}
+		}
+
+		return webhook.Queue(ctx, qtx, webhook.LinkUpdated, webhook.NewLinkEvent(updatedUrl))
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to update short url")
+		span.RecordError(err)
+
+		if s.rep.IsNotFoundError(err) {
+			// The URL was changed (or deleted) by another request after it was read
+			return echo.NewHTTPError(http.StatusPreconditionFailed, "Short URL has been modified")
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to update short url", "error", err, slog.String("code", params.Code))
+		if userID == nil {
+			s.recordAuditFailure(ctx, c.Logger(), audit.withSnapshots(url, nil), err)
+		}
+		return echo.ErrInternalServerError
+	}
+
+	if removedKeys, err := s.cache.DeleteLongURL(ctx, params.Code); err != nil {
+		span.AddEvent("failed to delete long url from cache", trace.WithAttributes(attribute.String("code", params.Code), attribute.Int64("removedKeys", removedKeys)))
+		c.Logger().WarnContext(ctx, "failed to delete long url from cache", "error", err, slog.String("code", params.Code), slog.Int64("removedKeys", removedKeys))
+	}
+
+	c.Response().Header().Set(headerETag, urlETag(updatedUrl.UpdatedAt))
+
+	return c.JSON(http.StatusOK, s.newCreateShortUrlResponse(updatedUrl))
+}
+
 type DeleteShortUrlParams struct {
 	GetLongUrlParams
 }
@@ -298,7 +848,7 @@ type DeleteShortUrlParams struct {
 // deletShortUrlHandler godoc
 //
 //	@Summary		Delete Short URL
-//	@Description	Deletes a short URL owned by the authenticated user. Also removes it from cache.
+//	@Description	Moves a short URL owned by the authenticated user to the trash. Also removes it from cache. The short code stays reserved and resolves to 410 Gone until the URL is restored or purged after the trash retention period.
 //	@Tags			URLs
 //	@Produce		json
 //	@Param			code	path	string	true	"Short code to delete"	maxlength(16)
@@ -329,7 +879,16 @@ func (s *Server) deletShortUrlHandler(c *echo.Context) error {
 
 	userID := auth.GetUserID(c)
 
-	rowsAffected, err := s.rep.DeleteUserURL(ctx, repository.DeleteUserURLParams{ID: params.Code, UserID: userID})
+	var rowsAffected int64
+	err := s.inTx(ctx, func(qtx *repository.Queries) error {
+		var err error
+		rowsAffected, err = qtx.DeleteUserURL(ctx, repository.DeleteUserURLParams{ID: params.Code, UserID: userID})
+		if err != nil || rowsAffected == 0 {
+			return err
+		}
+
+		return webhook.Queue(ctx, qtx, webhook.LinkDeleted, webhook.NewDeletedLinkEvent(params.Code, time.Now()))
+	})
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to delete short url")
 		span.RecordError(err)
@@ -6,9 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"log/slog"
 
@@ -17,10 +22,15 @@ import (
 	"github.com/rousage/shortener/internal/appvalidator"
 	"github.com/rousage/shortener/internal/auth"
 	"github.com/rousage/shortener/internal/cache"
+	"github.com/rousage/shortener/internal/clicks"
 	"github.com/rousage/shortener/internal/config"
 	"github.com/rousage/shortener/internal/database"
+	"github.com/rousage/shortener/internal/domainrules"
+	"github.com/rousage/shortener/internal/generator"
 	"github.com/rousage/shortener/internal/repository"
 	"github.com/rousage/shortener/internal/testhelpers"
+	"github.com/rousage/shortener/internal/unshorten"
+	"github.com/rousage/shortener/internal/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -41,6 +51,10 @@ func TestCreateShortURLHandler(t *testing.T) {
 		{name: "invalid URL with www", payload: map[string]string{"url": "www.example.com"}, expectedStatus: http.StatusBadRequest},
 		{name: "invalid URL", payload: map[string]string{"url": "test"}, expectedStatus: http.StatusBadRequest},
 		{name: "invalid payload", payload: map[string]string{"notUrl": "test"}, expectedStatus: http.StatusBadRequest},
+		{name: "IDN host", payload: map[string]string{"url": "https://bücher.de/path"}, expectedStatus: http.StatusCreated, expectedUrl: "https://xn--bcher-kva.de/path", expectedShortUrlLength: 8, expectedIsCustom: false},
+		{name: "loopback", payload: map[string]string{"url": "http://localhost:8080/admin"}, expectedStatus: http.StatusBadRequest},
+		{name: "metadata service", payload: map[string]string{"url": "http://169.254.169.254/latest/meta-data"}, expectedStatus: http.StatusBadRequest},
+		{name: "private network", payload: map[string]string{"url": "http://10.0.0.1"}, expectedStatus: http.StatusBadRequest},
 	}
 
 	for _, tt := range tests {
@@ -59,12 +73,13 @@ func TestCreateShortURLHandler(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, res.Code)
 
 			if tt.expectedStatus == http.StatusCreated {
-				var actual repository.Url
+				var actual CreateShortUrlResponse
 				err = json.NewDecoder(res.Body).Decode(&actual)
 				require.NoError(t, err, "error decoding response body")
 				assert.Len(t, actual.ID, tt.expectedShortUrlLength, fmt.Sprintf("short URL should be %d characters long", tt.expectedShortUrlLength))
 				assert.Equal(t, tt.expectedUrl, actual.LongUrl, "long URL does not match")
 				assert.Equal(t, tt.expectedIsCustom, actual.IsCustom, "isCustom does not match")
+				assert.Equal(t, "http://localhost:3001/"+actual.ID, actual.ShortUrl, "short URL does not match")
 			}
 		})
 	}
@@ -118,6 +133,284 @@ func TestCreateShortURLHandler_IdenticalURLs(t *testing.T) {
 	t.Cleanup(cleanup)
 }
 
+func TestCreateShortURLHandler_Generators(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	tests := []struct {
+		name string
+		cfg  generator.Config
+	}{
+		{name: "nanoid with custom alphabet", cfg: generator.Config{Strategy: generator.StrategyNanoid, Alphabet: "23456789abcdefghijkmnopqrstuvwxyz"}},
+		{name: "sequence", cfg: generator.Config{Strategy: generator.StrategySequence, Salt: "salt"}},
+		{name: "hashids", cfg: generator.Config{Strategy: generator.StrategyHashids, Salt: "salt"}},
+		{name: "pronounceable", cfg: generator.Config{Strategy: generator.StrategyPronounceable}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			s.shortCodes, err = generator.New(tt.cfg, s.rep.NextShortCode)
+			require.NoError(t, err)
+
+			first := createShortUrl(t, s, e, "https://example.com", "", "")
+			second := createShortUrl(t, s, e, "https://example.com", "", "")
+
+			assert.NotEqual(t, first.ID, second.ID, "short codes should differ")
+			assert.Len(t, first.ID, 8)
+			assert.Len(t, second.ID, 8)
+		})
+	}
+
+	t.Run("generated code taken by a custom one", func(t *testing.T) {
+		var value int64
+		next := func(context.Context) (int64, error) {
+			value++
+			return value, nil
+		}
+		gen, err := generator.NewSequence(next, "", 8, "salt")
+		require.NoError(t, err)
+		taken, err := gen.Generate(context.Background())
+		require.NoError(t, err)
+
+		// The counter starts over, so the first generated code is the custom one
+		value = 0
+		s.shortCodes = gen
+		custom := createShortUrl(t, s, e, "https://example.com/custom", userID_1, taken)
+		require.Equal(t, taken, custom.ID)
+
+		generated := createShortUrl(t, s, e, "https://example.com/generated", "", "")
+		assert.NotEmpty(t, generated.ID)
+		assert.NotEqual(t, taken, generated.ID, "the taken code should be skipped")
+	})
+
+	t.Cleanup(cleanup)
+}
+
+func TestCreateShortURLHandler_Dedupe(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	// create sends the payload as the user and returns the status code and the created or reused URL
+	create := func(t *testing.T, payload map[string]any, userID string) (int, CreateShortUrlResponse) {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err, "could not marshal payload")
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/urls", bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		if userID != "" {
+			c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: userID}})
+		}
+
+		require.NoError(t, s.createShortURLHandler(c))
+		var actual CreateShortUrlResponse
+		if res.Code < http.StatusBadRequest {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+		}
+		return res.Code, actual
+	}
+
+	const longUrl = "https://example.com/dedupe?b=2&a=1"
+	status, first := create(t, map[string]any{"url": longUrl}, userID_1)
+	require.Equal(t, http.StatusCreated, status)
+
+	t.Run("not opted in", func(t *testing.T) {
+		status, actual := create(t, map[string]any{"url": longUrl}, userID_1)
+		assert.Equal(t, http.StatusCreated, status)
+		assert.NotEqual(t, first.ID, actual.ID)
+	})
+
+	t.Run("per request", func(t *testing.T) {
+		status, actual := create(t, map[string]any{"url": longUrl, "dedupe": true}, userID_1)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, first.ID, actual.ID, "the oldest link should be reused")
+	})
+
+	t.Run("same canonical URL", func(t *testing.T) {
+		status, actual := create(t, map[string]any{"url": "https://Example.com/dedupe?a=1&b=2&utm_source=x", "dedupe": true, "stripTrackingParams": true}, userID_1)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, first.ID, actual.ID)
+	})
+
+	t.Run("kept tracking params", func(t *testing.T) {
+		status, actual := create(t, map[string]any{"url": "https://Example.com/dedupe?a=1&b=2&utm_source=x", "dedupe": true}, userID_1)
+		assert.Equal(t, http.StatusCreated, status)
+		assert.NotEqual(t, first.ID, actual.ID, "the link should redirect with the tracking params")
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		status, actual := create(t, map[string]any{"url": longUrl, "dedupe": true}, userID_2)
+		assert.Equal(t, http.StatusCreated, status)
+		assert.NotEqual(t, first.ID, actual.ID)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		status, actual := create(t, map[string]any{"url": longUrl, "dedupe": true}, "")
+		assert.Equal(t, http.StatusCreated, status)
+		assert.NotEqual(t, first.ID, actual.ID)
+	})
+
+	t.Run("limited link", func(t *testing.T) {
+		status, actual := create(t, map[string]any{"url": longUrl, "dedupe": true, "maxClicks": 1}, userID_1)
+		assert.Equal(t, http.StatusCreated, status)
+		assert.NotEqual(t, first.ID, actual.ID, "links with a click limit should not be reused")
+	})
+
+	t.Run("user setting", func(t *testing.T) {
+		_, err := s.rep.UpsertUserSettings(context.Background(), repository.UpsertUserSettingsParams{UserID: userID_1, DedupeUrls: true})
+		require.NoError(t, err)
+
+		status, actual := create(t, map[string]any{"url": longUrl}, userID_1)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, first.ID, actual.ID)
+
+		status, actual = create(t, map[string]any{"url": longUrl, "dedupe": false}, userID_1)
+		assert.Equal(t, http.StatusCreated, status, "the request should override the setting")
+		assert.NotEqual(t, first.ID, actual.ID)
+	})
+
+	t.Run("deleted link", func(t *testing.T) {
+		_, err := s.rep.DeleteUserURL(context.Background(), repository.DeleteUserURLParams{ID: first.ID, UserID: &userID_1})
+		require.NoError(t, err)
+
+		status, actual := create(t, map[string]any{"url": longUrl, "dedupe": true}, userID_1)
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEqual(t, first.ID, actual.ID, "deleted links should not be reused")
+	})
+
+	t.Cleanup(cleanup)
+}
+
+func TestCreateShortURLHandler_IdempotencyKey(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	create := func(t *testing.T, payload CreateShortUrlDTO, key string, userID string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err, "could not marshal payload")
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/urls", bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(headerIdempotencyKey, key)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		if userID != "" {
+			c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: userID}})
+		}
+
+		err = s.createShortURLHandler(c)
+		if sc, ok := err.(echo.HTTPStatusCoder); ok {
+			res.Code = sc.StatusCode()
+		} else {
+			require.NoError(t, err)
+		}
+		return res
+	}
+	decode := func(t *testing.T, res *httptest.ResponseRecorder) CreateShortUrlResponse {
+		var actual CreateShortUrlResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+		return actual
+	}
+
+	payload := CreateShortUrlDTO{URL: "https://example.com/idempotent"}
+	res := create(t, payload, "key-1", userID_1)
+	require.Equal(t, http.StatusCreated, res.Code)
+	assert.Empty(t, res.Header().Get(headerIdempotentReplayed))
+	first := decode(t, res)
+
+	t.Run("retry", func(t *testing.T) {
+		res := create(t, payload, "key-1", userID_1)
+		require.Equal(t, http.StatusCreated, res.Code)
+		assert.Equal(t, "true", res.Header().Get(headerIdempotentReplayed))
+		assert.Equal(t, first.ID, decode(t, res).ID)
+	})
+
+	t.Run("different request", func(t *testing.T) {
+		res := create(t, CreateShortUrlDTO{URL: "https://example.com/other"}, "key-1", userID_1)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		res := create(t, payload, "key-2", userID_1)
+		require.Equal(t, http.StatusCreated, res.Code)
+		assert.NotEqual(t, first.ID, decode(t, res).ID)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		res := create(t, payload, "key-1", userID_2)
+		require.Equal(t, http.StatusCreated, res.Code)
+		assert.NotEqual(t, first.ID, decode(t, res).ID, "keys should be scoped to the user")
+	})
+
+	t.Run("too long key", func(t *testing.T) {
+		res := create(t, payload, strings.Repeat("k", maxIdempotencyKeyLength+1), userID_1)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("retry of a reused link", func(t *testing.T) {
+		dedupe := true
+		reused := CreateShortUrlDTO{URL: payload.URL, Dedupe: &dedupe}
+		res := create(t, reused, "key-reused", userID_1)
+		require.Equal(t, http.StatusOK, res.Code)
+		expected := decode(t, res)
+
+		res = create(t, reused, "key-reused", userID_1)
+		require.Equal(t, http.StatusOK, res.Code, "the status of the first response should be replayed")
+		assert.Equal(t, "true", res.Header().Get(headerIdempotentReplayed))
+		assert.Equal(t, expected.ID, decode(t, res).ID)
+	})
+
+	t.Run("expired key", func(t *testing.T) {
+		res := create(t, payload, "key-expired", userID_1)
+		require.Equal(t, http.StatusCreated, res.Code)
+		expired := decode(t, res)
+
+		_, err := s.db.Exec(context.Background(), "UPDATE idempotency_keys SET created_at = $1 WHERE key = $2", time.Now().Add(-idempotencyKeyTTL-time.Minute), "key-expired")
+		require.NoError(t, err)
+
+		res = create(t, payload, "key-expired", userID_1)
+		require.Equal(t, http.StatusCreated, res.Code)
+		assert.Empty(t, res.Header().Get(headerIdempotentReplayed))
+		assert.NotEqual(t, expired.ID, decode(t, res).ID)
+	})
+
+	t.Run("key of a deleted link", func(t *testing.T) {
+		res := create(t, payload, "key-deleted", userID_1)
+		require.Equal(t, http.StatusCreated, res.Code)
+		deleted := decode(t, res)
+
+		_, err := s.rep.DeleteURL(context.Background(), deleted.ID)
+		require.NoError(t, err)
+
+		res = create(t, payload, "key-deleted", userID_1)
+		require.Equal(t, http.StatusCreated, res.Code)
+		assert.Empty(t, res.Header().Get(headerIdempotentReplayed))
+		assert.NotEqual(t, deleted.ID, decode(t, res).ID)
+	})
+
+	t.Run("concurrent retries", func(t *testing.T) {
+		var (
+			wg  sync.WaitGroup
+			mu  sync.Mutex
+			ids = make(map[string]struct{})
+		)
+		for range 5 {
+			wg.Go(func() {
+				res := create(t, payload, "key-concurrent", userID_1)
+				if assert.Equal(t, http.StatusCreated, res.Code) {
+					mu.Lock()
+					ids[decode(t, res).ID] = struct{}{}
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+
+		assert.Len(t, ids, 1, "concurrent retries should create a single link")
+	})
+
+	t.Cleanup(cleanup)
+}
+
 func TestCreateShortURLHandler_CustomShortCode(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
 
@@ -136,6 +429,7 @@ func TestCreateShortURLHandler_CustomShortCode(t *testing.T) {
 		{name: "too small short code", payload: CreateShortUrlDTO{URL: longUrl, ShortCode: "code"}, userId: "user-id", expectedStatus: http.StatusBadRequest},
 		{name: "too big short code", payload: CreateShortUrlDTO{URL: longUrl, ShortCode: "short-code_1234567891"}, userId: "user-id", expectedStatus: http.StatusBadRequest},
 		{name: "invalid short code", payload: CreateShortUrlDTO{URL: longUrl, ShortCode: "short-code$&*"}, userId: "user-id", expectedStatus: http.StatusBadRequest},
+		{name: "reserved short code", payload: CreateShortUrlDTO{URL: longUrl, ShortCode: "trending"}, userId: "user-id", expectedStatus: http.StatusBadRequest},
 		// if no custom short code is provided, it will be generated, hence isCustom = false
 		{name: "empty short code", payload: CreateShortUrlDTO{URL: longUrl}, userId: "user-id", expectedStatus: http.StatusCreated, expectedUrl: longUrl, expectedShortUrlLen: 8, expectedIsCustom: false},
 		// if user is not authenticated, they cannot create custom short codes
@@ -183,6 +477,274 @@ func TestCreateShortURLHandler_CustomShortCode(t *testing.T) {
 	t.Cleanup(cleanup)
 }
 
+func TestCreateShortURLHandler_Expiration(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	longUrl := "https://example.com"
+	tests := []struct {
+		name            string
+		payload         map[string]any
+		expectedStatus  int
+		expectedExpires time.Duration
+	}{
+		{name: "no expiration", payload: map[string]any{"url": longUrl}, expectedStatus: http.StatusCreated},
+		{name: "absolute expiration", payload: map[string]any{"url": longUrl, "expiresAt": time.Now().Add(time.Hour)}, expectedStatus: http.StatusCreated, expectedExpires: time.Hour},
+		{name: "relative expiration", payload: map[string]any{"url": longUrl, "expiresIn": 7200}, expectedStatus: http.StatusCreated, expectedExpires: 2 * time.Hour},
+		{name: "expiration in the past", payload: map[string]any{"url": longUrl, "expiresAt": time.Now().Add(-time.Hour)}, expectedStatus: http.StatusBadRequest},
+		{name: "too short relative expiration", payload: map[string]any{"url": longUrl, "expiresIn": 10}, expectedStatus: http.StatusBadRequest},
+		{name: "both expirations", payload: map[string]any{"url": longUrl, "expiresAt": time.Now().Add(time.Hour), "expiresIn": 7200}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.payload)
+			require.NoError(t, err, "could not marshal payload")
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/urls", bytes.NewBuffer(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+
+			// Assertions
+			err = s.createShortURLHandler(c)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.Code)
+
+			if tt.expectedStatus == http.StatusCreated {
+				var actual CreateShortUrlResponse
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+
+				if tt.expectedExpires == 0 {
+					assert.Nil(t, actual.ExpiresAt, "expiresAt should be empty")
+				} else if assert.NotNil(t, actual.ExpiresAt, "expiresAt should be set") {
+					assert.WithinDuration(t, time.Now().Add(tt.expectedExpires), *actual.ExpiresAt, time.Minute, "incorrect expiresAt")
+				}
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestCreateShortURLHandler_ShortLinks(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	target := createShortUrl(t, s, e, "https://example.com/target", "", "")
+	protectedHash, err := hashPassword("secret")
+	require.NoError(t, err)
+	_, err = s.rep.CreateUrl(context.Background(), repository.CreateUrlParams{ID: "protected", LongUrl: "https://example.com/protected", PasswordHash: &protectedHash})
+	require.NoError(t, err)
+
+	// A third-party shortener whose links point back at ours
+	shortener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/target":
+			http.Redirect(w, r, "http://localhost:3001/"+target.ID, http.StatusMovedPermanently)
+		case "/loop":
+			http.Redirect(w, r, "http://localhost:3001/loop", http.StatusMovedPermanently)
+		case "/chain":
+			http.Redirect(w, r, "/target", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer shortener.Close()
+
+	shortenerURL, err := url.Parse(shortener.URL)
+	require.NoError(t, err)
+	s.shortLinks, err = unshorten.New(unshorten.Config{BaseURL: s.cfg.App.BaseURL, ShortenerHosts: []string{shortenerURL.Hostname()}}, s.lookupShortLink)
+	require.NoError(t, err)
+	e.Validator = newTestValidator(s)
+
+	// Our link that goes back to the third-party shortener, so the shortener and our links loop
+	_, err = s.rep.CreateUrl(context.Background(), repository.CreateUrlParams{ID: "loop", LongUrl: shortener.URL + "/loop"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedUrl    string
+	}{
+		{name: "own short link", url: "http://localhost:3001/" + target.ID, expectedStatus: http.StatusCreated, expectedUrl: "https://example.com/target"},
+		{name: "third-party short link", url: shortener.URL + "/target", expectedStatus: http.StatusCreated, expectedUrl: "https://example.com/target"},
+		{name: "chain", url: shortener.URL + "/chain", expectedStatus: http.StatusCreated, expectedUrl: "https://example.com/target"},
+		{name: "loop", url: "http://localhost:3001/loop", expectedStatus: http.StatusBadRequest},
+		{name: "missing own short link", url: "http://localhost:3001/missing", expectedStatus: http.StatusBadRequest},
+		{name: "password protected short link", url: "http://localhost:3001/protected", expectedStatus: http.StatusBadRequest},
+		{name: "missing third-party short link", url: shortener.URL + "/missing", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(CreateShortUrlDTO{URL: tt.url})
+			require.NoError(t, err, "could not marshal payload")
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/urls", bytes.NewBuffer(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+
+			// Assertions
+			err = s.createShortURLHandler(c)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.Code)
+
+			if tt.expectedStatus == http.StatusCreated {
+				var actual CreateShortUrlResponse
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+				assert.Equal(t, tt.expectedUrl, actual.LongUrl, "the final destination should be stored")
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
+func TestCreateShortURLHandler_Canonical(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	tests := []struct {
+		name     string
+		payload  CreateShortUrlDTO
+		expected string
+	}{
+		{name: "canonical", payload: CreateShortUrlDTO{URL: "https://example.com/path?a=1"}, expected: "https://example.com/path?a=1"},
+		{name: "normalized", payload: CreateShortUrlDTO{URL: "HTTPS://Example.COM:443/%7Euser?b=2&a=1"}, expected: "https://example.com/~user?a=1&b=2"},
+		{name: "tracking params", payload: CreateShortUrlDTO{URL: "https://example.com/?utm_source=news&id=1"}, expected: "https://example.com/?id=1&utm_source=news"},
+		{
+			name:     "stripped tracking params",
+			payload:  CreateShortUrlDTO{URL: "https://example.com/?utm_source=news&id=1&fbclid=abc&gclid=def", StripTrackingParams: true},
+			expected: "https://example.com/?id=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.payload)
+			require.NoError(t, err, "could not marshal payload")
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/urls", bytes.NewBuffer(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+
+			// Assertions
+			err = s.createShortURLHandler(c)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusCreated, res.Code)
+
+			var actual CreateShortUrlResponse
+			err = json.NewDecoder(res.Body).Decode(&actual)
+			require.NoError(t, err, "error decoding response body")
+			assert.Equal(t, tt.expected, actual.LongUrl, "long URL should be canonical")
+			assert.Equal(t, tt.payload.URL, actual.OriginalUrl, "original URL should be stored as submitted")
+		})
+	}
+
+	t.Run("update", func(t *testing.T) {
+		created := createShortUrl(t, s, e, "https://example.com", userID_1, "")
+		payload := UpdateShortUrlDTO{URL: "https://Example.com/?utm_campaign=x&b=2&a=1", StripTrackingParams: true}
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPatch, "/v1/urls/"+created.ID, bytes.NewBuffer(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPathValues(echo.PathValues{{Name: "code", Value: created.ID}})
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: userID_1}})
+
+		require.NoError(t, s.updateShortUrlHandler(c))
+		require.Equal(t, http.StatusOK, res.Code)
+
+		var actual CreateShortUrlResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&actual), "error decoding response body")
+		assert.Equal(t, "https://example.com/?a=1&b=2", actual.LongUrl, "long URL should be canonical")
+		assert.Equal(t, payload.URL, actual.OriginalUrl, "original URL should be stored as submitted")
+	})
+
+	t.Cleanup(cleanup)
+}
+
+func TestGetLongUrlHandler_Expired(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	expiredUrl, err := s.rep.CreateUrl(context.Background(), repository.CreateUrlParams{ID: "expired", LongUrl: "https://example.com", ExpiresAt: &expiredAt})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/urls/%s", expiredUrl.ID), nil)
+	res := httptest.NewRecorder()
+	c := e.NewContext(req, res)
+	c.SetPath("/v1/urls/:code")
+	c.SetPathValues(echo.PathValues{{Name: "code", Value: expiredUrl.ID}})
+
+	// Assertions
+	err = s.getLongUrlHandler(c)
+	if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok, "expected an HTTP error") {
+		assert.Equal(t, http.StatusGone, sc.StatusCode())
+	}
+
+	actualCache, err := s.cache.GetLongUrl(context.Background(), expiredUrl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "", actualCache, "expired url should not be cached")
+
+	t.Cleanup(cleanup)
+}
+
+func TestGetLongUrlHandler_MaxClicks(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+
+	const maxClicks = 5
+	limit := int32(maxClicks)
+	limitedUrl, err := s.rep.CreateUrl(context.Background(), repository.CreateUrlParams{ID: "limited", LongUrl: "https://example.com", MaxClicks: &limit})
+	require.NoError(t, err)
+
+	// Resolve the link concurrently more times than allowed,
+	// exactly maxClicks resolutions have to succeed
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+		gone      int
+	)
+	for range maxClicks * 4 {
+		wg.Go(func() {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/urls/%s", limitedUrl.ID), nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/v1/urls/:code")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: limitedUrl.ID}})
+
+			err := s.getLongUrlHandler(c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, http.StatusGone, sc.StatusCode())
+				gone++
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, res.Code)
+				succeeded++
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, maxClicks, succeeded)
+	assert.Equal(t, maxClicks*3, gone)
+
+	actualCache, err := s.cache.GetLongUrl(context.Background(), limitedUrl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "", actualCache, "click-limited url should not be cached")
+
+	t.Cleanup(cleanup)
+}
+
 func TestGetLongUrlHandler(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
 	createdUrl := createShortUrl(t, s, e, "https://example.com", "", "")
@@ -276,7 +838,7 @@ func TestGetLongUrlHandler_Cache(t *testing.T) {
 
 func TestGetUserUrlsHandler(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
 
 	var (
 		userID_1 = "user-id"
@@ -351,13 +913,105 @@ func TestGetUserUrlsHandler(t *testing.T) {
 	t.Cleanup(cleanup)
 }
 
+func TestUpdateShortUrlHandler(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
+
+	userID := "user-id"
+	createdUrl := createShortUrl(t, s, e, "https://example.com", userID, "")
+	otherUrl := createShortUrl(t, s, e, "https://example.com", "other-user-id", "")
+	_, err := s.cache.SetLongUrl(context.Background(), createdUrl.ID, createdUrl.LongUrl, nil)
+	require.NoError(t, err)
+
+	// ETag of the latest version of createdUrl, updated after every successful update
+	etag := urlETag(createdUrl.UpdatedAt)
+	staleETag := etag
+
+	tests := []struct {
+		name              string
+		code              string
+		url               string
+		userID            string
+		withoutPermission bool
+		ifMatch           func() string
+		expectedStatus    int
+	}{
+		{name: "unauthenticated user", code: createdUrl.ID, url: "https://new.example.com", expectedStatus: http.StatusUnauthorized},
+		{name: "no required permission", code: createdUrl.ID, url: "https://new.example.com", userID: userID, withoutPermission: true, expectedStatus: http.StatusForbidden},
+		{name: "non-existent code", code: "non-existent", url: "https://new.example.com", userID: userID, expectedStatus: http.StatusNotFound},
+		{name: "url of another user", code: otherUrl.ID, url: "https://new.example.com", userID: userID, expectedStatus: http.StatusNotFound},
+		{name: "invalid url", code: createdUrl.ID, url: "not-a-url", userID: userID, expectedStatus: http.StatusBadRequest},
+		{name: "successful update", code: createdUrl.ID, url: "https://new.example.com", userID: userID, expectedStatus: http.StatusOK},
+		{name: "successful update with matching etag", code: createdUrl.ID, url: "https://newer.example.com", userID: userID, ifMatch: func() string { return etag }, expectedStatus: http.StatusOK},
+		{name: "stale etag", code: createdUrl.ID, url: "https://stale.example.com", userID: userID, ifMatch: func() string { return staleETag }, expectedStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(UpdateShortUrlDTO{URL: tt.url})
+			require.NoError(t, err, "could not marshal payload")
+
+			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/v1/urls/%s", tt.code), bytes.NewBuffer(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.ifMatch != nil {
+				req.Header.Set(headerIfMatch, tt.ifMatch())
+			}
+			res := httptest.NewRecorder()
+
+			c := e.NewContext(req, res)
+			c.SetPath("/v1/urls/:code")
+			c.SetPathValues(echo.PathValues{{Name: "code", Value: tt.code}})
+
+			if tt.userID != "" {
+				claims := &validator.ValidatedClaims{
+					RegisteredClaims: validator.RegisteredClaims{Subject: tt.userID},
+					CustomClaims:     &auth.CustomClaims{},
+				}
+				if !tt.withoutPermission {
+					claims.CustomClaims.(*auth.CustomClaims).Permissions = []string{string(auth.UpdateOwnURLs)}
+				}
+
+				c.Set(string(auth.ClaimsContextKey), claims)
+			}
+
+			handler := authMw.RequireAuthentication(authMw.RequirePermission(auth.UpdateOwnURLs)(s.updateShortUrlHandler))
+
+			// Assertions
+			err = handler(c)
+			if sc, ok := err.(echo.HTTPStatusCoder); ok {
+				assert.Equal(t, tt.expectedStatus, sc.StatusCode())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, res.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var actual CreateShortUrlResponse
+				err = json.NewDecoder(res.Body).Decode(&actual)
+				require.NoError(t, err, "error decoding response body")
+				assert.Equal(t, tt.code, actual.ID, "short code must not change")
+				assert.Equal(t, tt.url, actual.LongUrl, "long URL does not match")
+				assert.Equal(t, urlETag(actual.UpdatedAt), res.Header().Get(headerETag), "etag does not match")
+				assert.NotEqual(t, etag, res.Header().Get(headerETag), "etag must change on update")
+				etag = res.Header().Get(headerETag)
+
+				actualCache, err := s.cache.GetLongUrl(c.Request().Context(), tt.code)
+				require.NoError(t, err)
+				assert.Equal(t, "", actualCache, "cache should be invalidated")
+			}
+		})
+	}
+
+	t.Cleanup(cleanup)
+}
+
 func TestDeleteShortUrlHandler(t *testing.T) {
 	s, e, cleanup := setupTestServer(t)
-	authMw := auth.NewMiddleware(s.cfg.Auth)
+	authMw := auth.NewMiddleware(s.cfg.Auth, s.rep)
 
 	userID := "user-id"
 	createdUrl := createShortUrl(t, s, e, "https://example.com", userID, "")
-	_, err := s.cache.SetLongUrl(context.Background(), createdUrl.ID, createdUrl.LongUrl)
+	_, err := s.cache.SetLongUrl(context.Background(), createdUrl.ID, createdUrl.LongUrl, nil)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -434,24 +1088,41 @@ func setupTestServer(t *testing.T) (*Server, *echo.Echo, func()) {
 		Database: pgContainer.DatabaseConfig,
 		Cache:    cacheContainer.CacheConfig,
 		App: config.App{
-			Env: config.EnvDevelopment,
+			Env:            config.EnvDevelopment,
+			BaseURL:        "http://localhost:3001",
+			RedirectStatus: http.StatusFound,
+			TrashRetention: 30 * 24 * time.Hour,
+			TrackingParams: []string{"utm_*", "fbclid", "gclid"},
 		},
 	}
 
-	e := echo.New()
-	e.Logger = logger
-	e.Validator = appvalidator.New()
-
+	rep := repository.New(db)
 	s := &Server{
 		cfg:            cfg,
 		db:             db,
-		rep:            repository.New(db),
+		rep:            rep,
 		cache:          cache.New(logger, cacheClient),
 		authManagement: &mockAuthManager{},
+		clicks:         clicks.NewPipeline(logger, webhook.NewClickStore(db, rep), clicks.PipelineConfig{}),
+		webhooks:       webhook.NewDispatcher(logger, rep, webhook.DispatcherConfig{MaxAttempts: 2, BaseBackoff: time.Millisecond}),
+		domainRules:    domainrules.NewSet(),
 	}
+	s.shortLinks, err = unshorten.New(unshorten.Config{BaseURL: cfg.App.BaseURL}, s.lookupShortLink)
+	require.NoError(t, err, "could not create short link resolver")
+	s.shortCodes, err = generator.NewNanoid("", cfg.App.ShortUrlLength)
+	require.NoError(t, err, "could not create short code generator")
+	s.clicks.Start()
+
+	e := echo.New()
+	e.Logger = logger
+	e.IPExtractor = clientIPExtractor(nil)
+	e.Validator = newTestValidator(s)
 
 	cleanup := func() {
-		err := pgContainer.Terminate(ctx)
+		err := s.clicks.Shutdown(ctx)
+		require.NoError(t, err, "error draining click events")
+
+		err = pgContainer.Terminate(ctx)
 		require.NoError(t, err, "error terminating postgres container")
 
 		err = cacheContainer.Terminate(ctx)
@@ -461,6 +1132,21 @@ func setupTestServer(t *testing.T) (*Server, *echo.Echo, func()) {
 	return s, e, cleanup
 }
 
+func newTestValidator(s *Server) *appvalidator.AppValidator {
+	return appvalidator.New(
+		appvalidator.WithHostPolicy(s.domainRules),
+		appvalidator.WithResolver(publicResolver{}),
+		appvalidator.WithShortLinkResolver(s.shortLinks),
+	)
+}
+
+// publicResolver resolves every host to a public address, so tests do not depend on DNS
+type publicResolver struct{}
+
+func (publicResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+}
+
 func createShortUrl(t *testing.T, s *Server, e *echo.Echo, url string, userID string, shortCode string) repository.Url {
 	payload := CreateShortUrlDTO{URL: url, ShortCode: shortCode}
 	body, err := json.Marshal(payload)
@@ -0,0 +1,464 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/repository"
+	"github.com/rousage/shortener/internal/webhook"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxWebhooksPerUser = 10
+
+type CreateWebhookDTO struct {
+	// Endpoint the events are posted to, redirects are not followed
+	URL string `json:"url" validate:"required,http_url,max=2048,public_host" example:"https://example.com/webhooks/shortener"`
+	// Event types to subscribe to
+	Events []string `json:"events" validate:"required,min=1,unique,dive,oneof=link.created link.updated link.deleted link.clicked" enums:"link.created,link.updated,link.deleted,link.clicked"`
+}
+type WebhookParams struct {
+	ID int64 `param:"id" validate:"required,min=1"`
+}
+type WebhookWithSecret struct {
+	repository.Webhook
+	// Secret the deliveries are signed with, only returned when the webhook is created or the secret is rotated
+	Secret string `json:"secret" example:"whsec_UKGCSOLNWSJX7DMDQJ5XLJKWAA"`
+}
+type WebhooksResponse struct {
+	Items []repository.Webhook `json:"items"`
+}
+
+// getUserWebhooks godoc
+//
+//	@Summary		Get User Webhooks
+//	@Description	Retrieves the webhooks of the authenticated user
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Success		200	{object}	WebhooksResponse	"Webhooks of the user"
+//	@Failure		401	{object}	HTTPError			"Unauthorized"
+//	@Failure		403	{object}	HTTPError			"Forbidden"
+//	@Failure		500	{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/webhooks [get]
+func (s *Server) getUserWebhooks(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "webhooks.GetUserWebhooks")
+	defer span.End()
+
+	userID := auth.GetUserID(c)
+
+	webhooks, err := s.rep.GetUserWebhooks(ctx, *userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get user webhooks")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get user webhooks", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, &WebhooksResponse{Items: webhooks})
+}
+
+// createWebhookHandler godoc
+//
+//	@Summary		Create Webhook
+//	@Description	Registers an endpoint that receives the subscribed events about the links of the authenticated user. Deliveries are signed with HMAC-SHA256 in the X-Webhook-Signature header (t=<unix timestamp>,v1=<hex signature of "<timestamp>.<body>">). Failed deliveries are retried with exponential backoff and kept as dead letters after the last attempt. The secret is only returned once.
+//	@Tags			Webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateWebhookDTO	true	"Endpoint and subscribed events"
+//	@Success		201		{object}	WebhookWithSecret	"Created webhook"
+//	@Failure		400		{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401		{object}	HTTPError			"Unauthorized"
+//	@Failure		403		{object}	HTTPError			"Forbidden"
+//	@Failure		409		{object}	HTTPError			"Webhook limit reached"
+//	@Failure		500		{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/webhooks [post]
+func (s *Server) createWebhookHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "webhooks.CreateWebhookHandler")
+	defer span.End()
+
+	dto := new(CreateWebhookDTO)
+	if err := c.Bind(dto); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := s.validateCtx(ctx, c, dto); err != nil {
+		span.SetStatus(codes.Error, "invalid user input")
+		span.RecordError(err)
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.String("url", dto.URL), attribute.StringSlice("events", dto.Events))
+
+	userID := auth.GetUserID(c)
+
+	count, err := s.rep.CountUserWebhooks(ctx, *userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to count user webhooks")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to count user webhooks", "error", err)
+		return echo.ErrInternalServerError
+	}
+	if count >= maxWebhooksPerUser {
+		span.AddEvent("webhook limit reached", trace.WithAttributes(attribute.Int64("count", count)))
+		return echo.NewHTTPError(http.StatusConflict, "Webhook limit reached")
+	}
+
+	secret := webhook.NewSecret()
+	newWebhook, err := s.rep.CreateWebhook(ctx, repository.CreateWebhookParams{
+		UserID: *userID,
+		Url:    dto.URL,
+		Events: dto.Events,
+		Secret: secret,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to create webhook")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to create webhook", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusCreated, &WebhookWithSecret{Webhook: newWebhook, Secret: secret})
+}
+
+// deleteWebhookHandler godoc
+//
+//	@Summary		Delete Webhook
+//	@Description	Deletes a webhook of the authenticated user together with its pending deliveries and dead letters
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id	path	int	true	"ID of the webhook"	minimum(1)
+//	@Success		204	"No Content - Webhook successfully deleted"
+//	@Failure		400	{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401	{object}	HTTPError			"Unauthorized"
+//	@Failure		403	{object}	HTTPError			"Forbidden"
+//	@Failure		404	{object}	HTTPError			"Webhook not found"
+//	@Failure		500	{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/webhooks/{id} [delete]
+func (s *Server) deleteWebhookHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "webhooks.DeleteWebhookHandler")
+	defer span.End()
+
+	params := new(WebhookParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Int64("webhookId", params.ID))
+
+	userID := auth.GetUserID(c)
+
+	rowsAffected, err := s.rep.DeleteUserWebhook(ctx, repository.DeleteUserWebhookParams{ID: params.ID, UserID: *userID})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to delete webhook")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to delete webhook", "error", err, slog.Int64("webhookId", params.ID))
+		return echo.ErrInternalServerError
+	}
+	if rowsAffected == 0 {
+		span.AddEvent("webhook not found")
+		return echo.ErrNotFound
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type TestWebhookResponse struct {
+	Delivered bool `json:"delivered"`
+	// Status code of the response, missing if the endpoint could not be reached
+	StatusCode *int    `json:"statusCode"`
+	Error      *string `json:"error"`
+}
+
+// testWebhookHandler godoc
+//
+//	@Summary		Test Webhook
+//	@Description	Sends a signed webhook.test event to the endpoint of the webhook and returns the result. Test events are not retried.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id	path		int					true	"ID of the webhook"	minimum(1)
+//	@Success		200	{object}	TestWebhookResponse	"Result of the delivery"
+//	@Failure		400	{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401	{object}	HTTPError			"Unauthorized"
+//	@Failure		403	{object}	HTTPError			"Forbidden"
+//	@Failure		404	{object}	HTTPError			"Webhook not found"
+//	@Failure		500	{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/webhooks/{id}/test [post]
+func (s *Server) testWebhookHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "webhooks.TestWebhookHandler")
+	defer span.End()
+
+	params := new(WebhookParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Int64("webhookId", params.ID))
+
+	userWebhook, err := s.getUserWebhook(ctx, c, params.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(map[string]int64{"webhookId": userWebhook.ID})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to marshal test event")
+		span.RecordError(err)
+		return echo.ErrInternalServerError
+	}
+
+	// Test events are not stored, so they have no ID
+	statusCode, err := s.webhooks.Send(ctx, userWebhook.Url, userWebhook.Secret, webhook.Envelope{
+		Type:      webhook.Test,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+
+	response := &TestWebhookResponse{Delivered: err == nil}
+	if statusCode != 0 {
+		response.StatusCode = &statusCode
+	}
+	if err != nil {
+		span.AddEvent("test event not delivered", trace.WithAttributes(attribute.String("error", err.Error())))
+		// Send only returns generic errors, nothing about the network or the response of the endpoint is revealed
+		message := err.Error()
+		response.Error = &message
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// rotateWebhookSecretHandler godoc
+//
+//	@Summary		Rotate Webhook Secret
+//	@Description	Replaces the signing secret of the webhook. Deliveries are signed with the new secret right away. The new secret is only returned once.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id	path		int					true	"ID of the webhook"	minimum(1)
+//	@Success		200	{object}	WebhookWithSecret	"Webhook with the new secret"
+//	@Failure		400	{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401	{object}	HTTPError			"Unauthorized"
+//	@Failure		403	{object}	HTTPError			"Forbidden"
+//	@Failure		404	{object}	HTTPError			"Webhook not found"
+//	@Failure		500	{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/webhooks/{id}/rotate-secret [post]
+func (s *Server) rotateWebhookSecretHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "webhooks.RotateWebhookSecretHandler")
+	defer span.End()
+
+	params := new(WebhookParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Int64("webhookId", params.ID))
+
+	userID := auth.GetUserID(c)
+
+	secret := webhook.NewSecret()
+	updatedWebhook, err := s.rep.RotateWebhookSecret(ctx, repository.RotateWebhookSecretParams{ID: params.ID, UserID: *userID, Secret: secret})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to rotate webhook secret")
+		span.RecordError(err)
+
+		if s.rep.IsNotFoundError(err) {
+			return echo.ErrNotFound
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to rotate webhook secret", "error", err, slog.Int64("webhookId", params.ID))
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, &WebhookWithSecret{Webhook: updatedWebhook, Secret: secret})
+}
+
+type WebhookDeadLettersParams struct {
+	WebhookParams
+	PaginationFilters
+}
+type WebhookDeadLetter struct {
+	ID             int64           `json:"id"`
+	EventID        int64           `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	EventCreatedAt time.Time       `json:"eventCreatedAt"`
+	Attempts       int32           `json:"attempts"`
+	LastError      *string         `json:"lastError"`
+	LastStatusCode *int32          `json:"lastStatusCode"`
+	FailedAt       time.Time       `json:"failedAt"`
+}
+type PaginatedWebhookDeadLetters struct {
+	Items      []WebhookDeadLetter `json:"items"`
+	Pagination Pagination          `json:"pagination"`
+}
+
+// getWebhookDeadLetters godoc
+//
+//	@Summary		Get Webhook Dead Letters
+//	@Description	Retrieves a paginated list of the deliveries of the webhook that failed permanently, the most recent first
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id			path		int							true	"ID of the webhook"	minimum(1)
+//	@Param			page		query		int							true	"Page number"		minimum(1)	maximum(10000)	default(1)
+//	@Param			pageSize	query		int							true	"Page size"			minimum(1)	maximum(100)	default(20)
+//	@Success		200			{object}	PaginatedWebhookDeadLetters	"Paginated list of dead letters"
+//	@Failure		400			{object}	HTTPValidationError			"Validation failed"
+//	@Failure		401			{object}	HTTPError					"Unauthorized"
+//	@Failure		403			{object}	HTTPError					"Forbidden"
+//	@Failure		404			{object}	HTTPError					"Webhook not found"
+//	@Failure		500			{object}	HTTPError					"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/webhooks/{id}/dead-letters [get]
+func (s *Server) getWebhookDeadLetters(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "webhooks.GetWebhookDeadLetters")
+	defer span.End()
+
+	params := new(WebhookDeadLettersParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Int64("webhookId", params.ID), attribute.Int("page", int(params.Page)), attribute.Int("pageSize", int(params.PageSize)))
+
+	if _, err := s.getUserWebhook(ctx, c, params.ID); err != nil {
+		return err
+	}
+
+	deadLetters, err := s.rep.GetWebhookDeadLetters(ctx, repository.GetWebhookDeadLettersParams{WebhookID: params.ID, Limit: params.limit(), Offset: params.offset()})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get webhook dead letters")
+		span.RecordError(err)
+		c.Logger().ErrorContext(ctx, "failed to get webhook dead letters", "error", err, slog.Int64("webhookId", params.ID))
+		return echo.ErrInternalServerError
+	}
+
+	var totalCount int
+	if len(deadLetters) > 0 {
+		totalCount = int(deadLetters[0].TotalCount)
+	}
+
+	items := make([]WebhookDeadLetter, len(deadLetters))
+	for i, deadLetter := range deadLetters {
+		items[i] = WebhookDeadLetter{
+			ID:             deadLetter.ID,
+			EventID:        deadLetter.EventID,
+			EventType:      deadLetter.EventType,
+			Payload:        deadLetter.Payload,
+			EventCreatedAt: deadLetter.EventCreatedAt,
+			Attempts:       deadLetter.Attempts,
+			LastError:      deadLetter.LastError,
+			LastStatusCode: deadLetter.LastStatusCode,
+			FailedAt:       deadLetter.FailedAt,
+		}
+	}
+
+	return c.JSON(http.StatusOK, &PaginatedWebhookDeadLetters{
+		Items:      items,
+		Pagination: calculatePagination(totalCount, int(params.Page), int(params.PageSize)),
+	})
+}
+
+type ReplayWebhookDeadLetterParams struct {
+	WebhookParams
+	DeadLetterID int64 `param:"deadLetterId" validate:"required,min=1"`
+}
+
+// replayWebhookDeadLetterHandler godoc
+//
+//	@Summary		Replay Webhook Dead Letter
+//	@Description	Schedules a failed delivery to be sent again with the same event ID and payload. The delivery gets a fresh set of attempts.
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id				path	int	true	"ID of the webhook"		minimum(1)
+//	@Param			deadLetterId	path	int	true	"ID of the dead letter"	minimum(1)
+//	@Success		202				"Accepted - Delivery scheduled"
+//	@Failure		400				{object}	HTTPValidationError	"Validation failed"
+//	@Failure		401				{object}	HTTPError			"Unauthorized"
+//	@Failure		403				{object}	HTTPError			"Forbidden"
+//	@Failure		404				{object}	HTTPError			"Webhook or dead letter not found"
+//	@Failure		500				{object}	HTTPError			"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/v1/webhooks/{id}/dead-letters/{deadLetterId}/replay [post]
+func (s *Server) replayWebhookDeadLetterHandler(c *echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "webhooks.ReplayWebhookDeadLetterHandler")
+	defer span.End()
+
+	params := new(ReplayWebhookDeadLetterParams)
+	if err := c.Bind(params); err != nil {
+		span.SetStatus(codes.Error, "failed to bind request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(params); err != nil {
+		return s.failedValidationError(c, err)
+	}
+	span.SetAttributes(attribute.Int64("webhookId", params.ID), attribute.Int64("deadLetterId", params.DeadLetterID))
+
+	if _, err := s.getUserWebhook(ctx, c, params.ID); err != nil {
+		return err
+	}
+
+	deliveryID, err := s.rep.ReplayWebhookDeadLetter(ctx, repository.ReplayWebhookDeadLetterParams{ID: params.DeadLetterID, WebhookID: params.ID})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to replay webhook dead letter")
+		span.RecordError(err)
+
+		if s.rep.IsNotFoundError(err) {
+			return echo.ErrNotFound
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to replay webhook dead letter", "error", err, slog.Int64("deadLetterId", params.DeadLetterID))
+		return echo.ErrInternalServerError
+	}
+	span.SetAttributes(attribute.Int64("deliveryId", deliveryID))
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+// getUserWebhook returns the webhook if it is owned by the authenticated user.
+// The returned error is an HTTP error that can be returned from the handler as is
+func (s *Server) getUserWebhook(ctx context.Context, c *echo.Context, id int64) (repository.Webhook, error) {
+	span := trace.SpanFromContext(ctx)
+
+	userWebhook, err := s.rep.GetUserWebhook(ctx, repository.GetUserWebhookParams{ID: id, UserID: *auth.GetUserID(c)})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get webhook")
+		span.RecordError(err)
+
+		if s.rep.IsNotFoundError(err) {
+			return repository.Webhook{}, echo.ErrNotFound
+		}
+
+		c.Logger().ErrorContext(ctx, "failed to get webhook", "error", err, slog.Int64("webhookId", id))
+		return repository.Webhook{}, echo.ErrInternalServerError
+	}
+
+	return userWebhook, nil
+}
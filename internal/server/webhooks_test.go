@@ -0,0 +1,229 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/labstack/echo/v5"
+	"github.com/rousage/shortener/internal/auth"
+	"github.com/rousage/shortener/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver records the deliveries it receives and responds with its current status
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	received []receivedWebhook
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received = append(r.received, receivedWebhook{header: req.Header.Clone(), body: body})
+	w.WriteHeader(r.status)
+}
+
+func (r *webhookReceiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// take returns and forgets the received deliveries
+func (r *webhookReceiver) take() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	received := r.received
+	r.received = nil
+	return received
+}
+
+func TestWebhooks(t *testing.T) {
+	s, e, cleanup := setupTestServer(t)
+	userID := "user-id"
+
+	receiver := &webhookReceiver{status: http.StatusNoContent}
+	receiverServer := httptest.NewServer(receiver)
+	defer receiverServer.Close()
+
+	// The receiver stands in for a public endpoint, the dispatcher would refuse to connect to its loopback address
+	receiverURL := "http://hooks.example.com/shortener"
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, receiverServer.Listener.Addr().String())
+	}
+	s.webhooks = webhook.NewDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), s.rep, webhook.DispatcherConfig{
+		MaxAttempts: 2,
+		BaseBackoff: time.Millisecond,
+		Transport:   transport,
+	})
+
+	newContext := func(method, target string, body any, pathValues echo.PathValues) (*echo.Context, *httptest.ResponseRecorder) {
+		var reqBody io.Reader
+		if body != nil {
+			payload, err := json.Marshal(body)
+			require.NoError(t, err, "could not marshal payload")
+			reqBody = bytes.NewBuffer(payload)
+		}
+
+		req := httptest.NewRequest(method, target, reqBody)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		c := e.NewContext(req, res)
+		c.SetPathValues(pathValues)
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: userID}})
+
+		return c, res
+	}
+
+	c, res := newContext(http.MethodPost, "/v1/webhooks", CreateWebhookDTO{
+		URL:    receiverURL,
+		Events: []string{string(webhook.LinkCreated), string(webhook.LinkDeleted)},
+	}, nil)
+	require.NoError(t, s.createWebhookHandler(c))
+	require.Equal(t, http.StatusCreated, res.Code)
+
+	var created WebhookWithSecret
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&created), "error decoding response body")
+	assert.NotEmpty(t, created.Secret)
+	idParam := echo.PathValues{{Name: "id", Value: strconv.FormatInt(created.ID, 10)}}
+
+	t.Run("invalid events", func(t *testing.T) {
+		c, res := newContext(http.MethodPost, "/v1/webhooks", CreateWebhookDTO{URL: receiverURL, Events: []string{"link.purged"}}, nil)
+		require.NoError(t, s.createWebhookHandler(c))
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("private endpoints", func(t *testing.T) {
+		for _, url := range []string{"http://127.0.0.1:8080/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]/hook"} {
+			c, res := newContext(http.MethodPost, "/v1/webhooks", CreateWebhookDTO{URL: url, Events: []string{string(webhook.LinkCreated)}}, nil)
+			require.NoError(t, s.createWebhookHandler(c))
+			assert.Equal(t, http.StatusBadRequest, res.Code, url)
+		}
+	})
+
+	t.Run("secret is not listed", func(t *testing.T) {
+		c, res := newContext(http.MethodGet, "/v1/webhooks", nil, nil)
+		require.NoError(t, s.getUserWebhooks(c))
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.NotContains(t, res.Body.String(), created.Secret)
+		assert.Contains(t, res.Body.String(), receiverURL)
+	})
+
+	t.Run("signed delivery of subscribed events", func(t *testing.T) {
+		url := createShortUrl(t, s, e, "https://example.com", userID, "")
+		// Not subscribed to updates
+		c, res := newContext(http.MethodPatch, "/v1/urls/"+url.ID, UpdateShortUrlDTO{URL: "https://example.com/updated"}, echo.PathValues{{Name: "code", Value: url.ID}})
+		require.NoError(t, s.updateShortUrlHandler(c))
+		require.Equal(t, http.StatusOK, res.Code)
+
+		s.webhooks.Dispatch(t.Context())
+
+		received := receiver.take()
+		require.Len(t, received, 1)
+		assert.Equal(t, string(webhook.LinkCreated), received[0].header.Get(webhook.HeaderEvent))
+		assert.NoError(t, webhook.Verify(created.Secret, received[0].header.Get(webhook.HeaderSignature), received[0].body, time.Minute, time.Now()))
+
+		var envelope webhook.Envelope
+		require.NoError(t, json.Unmarshal(received[0].body, &envelope))
+		assert.Equal(t, webhook.LinkCreated, envelope.Type)
+		assert.Equal(t, received[0].header.Get(webhook.HeaderID), strconv.FormatInt(envelope.ID, 10))
+
+		var link webhook.Link
+		require.NoError(t, json.Unmarshal(envelope.Data, &link))
+		assert.Equal(t, url.ID, link.Code)
+		assert.Equal(t, url.LongUrl, link.LongUrl)
+	})
+
+	t.Run("failed deliveries are retried and dead lettered", func(t *testing.T) {
+		receiver.setStatus(http.StatusInternalServerError)
+		url := createShortUrl(t, s, e, "https://example.com/dead", userID, "")
+
+		// MaxAttempts is 2 in tests, the backoff is a few milliseconds
+		for range 2 {
+			s.webhooks.Dispatch(t.Context())
+			time.Sleep(50 * time.Millisecond)
+		}
+		assert.Len(t, receiver.take(), 2)
+
+		c, res := newContext(http.MethodGet, "/v1/webhooks/1/dead-letters?page=1&pageSize=10", nil, idParam)
+		require.NoError(t, s.getWebhookDeadLetters(c))
+		require.Equal(t, http.StatusOK, res.Code)
+
+		var deadLetters PaginatedWebhookDeadLetters
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&deadLetters), "error decoding response body")
+		require.Len(t, deadLetters.Items, 1)
+		assert.Equal(t, int32(2), deadLetters.Items[0].Attempts)
+		if assert.NotNil(t, deadLetters.Items[0].LastStatusCode) {
+			assert.Equal(t, int32(http.StatusInternalServerError), *deadLetters.Items[0].LastStatusCode)
+		}
+		assert.Contains(t, string(deadLetters.Items[0].Payload), url.ID)
+
+		receiver.setStatus(http.StatusOK)
+		c, res = newContext(http.MethodPost, "/v1/webhooks/1/dead-letters/1/replay", nil, append(idParam, echo.PathValue{Name: "deadLetterId", Value: strconv.FormatInt(deadLetters.Items[0].ID, 10)}))
+		require.NoError(t, s.replayWebhookDeadLetterHandler(c))
+		assert.Equal(t, http.StatusAccepted, res.Code)
+
+		s.webhooks.Dispatch(t.Context())
+
+		received := receiver.take()
+		require.Len(t, received, 1)
+		assert.Equal(t, strconv.FormatInt(deadLetters.Items[0].EventID, 10), received[0].header.Get(webhook.HeaderID), "replays should keep the event id")
+	})
+
+	t.Run("test event and secret rotation", func(t *testing.T) {
+		c, res := newContext(http.MethodPost, "/v1/webhooks/1/rotate-secret", nil, idParam)
+		require.NoError(t, s.rotateWebhookSecretHandler(c))
+		require.Equal(t, http.StatusOK, res.Code)
+
+		var rotated WebhookWithSecret
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&rotated), "error decoding response body")
+		assert.NotEqual(t, created.Secret, rotated.Secret)
+
+		c, res = newContext(http.MethodPost, "/v1/webhooks/1/test", nil, idParam)
+		require.NoError(t, s.testWebhookHandler(c))
+		require.Equal(t, http.StatusOK, res.Code)
+
+		var result TestWebhookResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&result), "error decoding response body")
+		assert.True(t, result.Delivered)
+
+		received := receiver.take()
+		require.Len(t, received, 1)
+		assert.Equal(t, string(webhook.Test), received[0].header.Get(webhook.HeaderEvent))
+		assert.NoError(t, webhook.Verify(rotated.Secret, received[0].header.Get(webhook.HeaderSignature), received[0].body, time.Minute, time.Now()), "test event should be signed with the new secret")
+	})
+
+	t.Run("other users cannot manage the webhook", func(t *testing.T) {
+		c, _ := newContext(http.MethodDelete, fmt.Sprintf("/v1/webhooks/%d", created.ID), nil, idParam)
+		c.Set(string(auth.ClaimsContextKey), &validator.ValidatedClaims{RegisteredClaims: validator.RegisteredClaims{Subject: "other-user-id"}})
+
+		err := s.deleteWebhookHandler(c)
+		if sc, ok := err.(echo.HTTPStatusCoder); assert.True(t, ok) {
+			assert.Equal(t, http.StatusNotFound, sc.StatusCode())
+		}
+	})
+
+	t.Cleanup(cleanup)
+}
@@ -0,0 +1,167 @@
+// Package unshorten follows short links, ours and those of known third-party shorteners, to where they end
+package unshorten
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 5 * time.Second
+
+var (
+	ErrLoop          = errors.New("short links redirect in a loop")
+	ErrTooManyHops   = errors.New("short links redirect through too many hops")
+	ErrNotFollowable = errors.New("short link cannot be followed")
+)
+
+// LookupFunc returns the destination of one of our short links.
+// It fails for the links that do not simply redirect, e.g. the ones that are missing, expired or protected by a password
+type LookupFunc func(ctx context.Context, code string) (string, error)
+
+type Config struct {
+	// BaseURL is the origin our short links are served from
+	BaseURL string
+	// ShortenerHosts are the hosts of the third-party shorteners whose links are followed
+	ShortenerHosts []string
+	// Timeout bounds following a link through all its hops
+	Timeout time.Duration
+}
+
+// Resolver follows short links without following any other redirect, so it only sends requests to the
+// configured shortener hosts. It is safe for concurrent use
+type Resolver struct {
+	ownHost        string
+	shortenerHosts map[string]struct{}
+	lookup         LookupFunc
+	client         *http.Client
+	timeout        time.Duration
+}
+
+func New(cfg Config, lookup LookupFunc) (*Resolver, error) {
+	baseURL, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	shortenerHosts := make(map[string]struct{}, len(cfg.ShortenerHosts))
+	for _, host := range cfg.ShortenerHosts {
+		if host = normalizeHost(host); host != "" {
+			shortenerHosts[host] = struct{}{}
+		}
+	}
+
+	return &Resolver{
+		ownHost:        canonicalHost(baseURL),
+		shortenerHosts: shortenerHosts,
+		lookup:         lookup,
+		timeout:        cfg.Timeout,
+		client: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// canonicalHost returns the normalized host of the URL with the port only if it is not the default one of the scheme,
+// so https://example.com:443 and https://example.com are the same host like after the canonicalization of destinations
+func canonicalHost(u *url.URL) string {
+	host := normalizeHost(u.Hostname())
+	port := u.Port()
+	if port == "" || (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		return host
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// Resolve returns where the URL ends after following at most maxHops short links, or the URL itself if it is not a short link.
+// Our short links are looked up directly, the links of third-party shorteners are requested without following their redirect.
+// All the hops share one deadline, which ends with ctx or after the configured timeout
+func (r *Resolver) Resolve(ctx context.Context, rawURL string, maxHops int) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	visited := make(map[string]struct{})
+	current := rawURL
+
+	for hops := 0; ; hops++ {
+		u, err := url.Parse(current)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return "", ErrNotFollowable
+		}
+
+		host := canonicalHost(u)
+		_, isShortener := r.shortenerHosts[normalizeHost(u.Hostname())]
+		if host != r.ownHost && !isShortener {
+			return current, nil
+		}
+
+		// The scheme and the case of the host do not change where a link goes
+		key := host + u.RequestURI()
+		if _, ok := visited[key]; ok {
+			return "", ErrLoop
+		}
+		visited[key] = struct{}{}
+
+		if hops >= maxHops {
+			return "", ErrTooManyHops
+		}
+
+		if host == r.ownHost {
+			current, err = r.lookupOwn(ctx, u)
+		} else {
+			current, err = r.follow(ctx, u)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func (r *Resolver) lookupOwn(ctx context.Context, u *url.URL) (string, error) {
+	code := strings.TrimSuffix(strings.TrimPrefix(u.Path, "/"), "/")
+	if code == "" || strings.Contains(code, "/") {
+		return "", ErrNotFollowable
+	}
+
+	longURL, err := r.lookup(ctx, code)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNotFollowable, err)
+	}
+
+	return longURL, nil
+}
+
+func (r *Resolver) follow(ctx context.Context, u *url.URL) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNotFollowable, err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNotFollowable, err)
+	}
+	resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil || resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return "", fmt.Errorf("%w: unexpected response status %d", ErrNotFollowable, resp.StatusCode)
+	}
+
+	return location.String(), nil
+}
@@ -0,0 +1,138 @@
+package unshorten
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	// Third-party shortener with a link to a destination, a link to one of our links and a page that does not redirect
+	shortener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dest":
+			http.Redirect(w, r, "https://example.com/final", http.StatusMovedPermanently)
+		case "/own":
+			http.Redirect(w, r, "https://sho.rt/loop", http.StatusFound)
+		case "/relative":
+			http.Redirect(w, r, "/dest", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(shortener.Close)
+
+	ownLinks := map[string]string{
+		"plain":    "https://example.com/plain",
+		"external": shortener.URL + "/dest",
+		"chain1":   "https://sho.rt/chain2",
+		"chain2":   "https://sho.rt/chain3",
+		"chain3":   "https://sho.rt/plain",
+		// The loop goes through the third-party shortener and back
+		"loop": "https://sho.rt/back",
+		"back": shortener.URL + "/own",
+		// The loop only differs by the default port
+		"port": "https://sho.rt:443/port",
+	}
+
+	serverURL, err := url.Parse(shortener.URL)
+	require.NoError(t, err)
+
+	r, err := New(Config{BaseURL: "https://sho.rt", ShortenerHosts: []string{serverURL.Hostname()}}, func(_ context.Context, code string) (string, error) {
+		longURL, ok := ownLinks[code]
+		if !ok {
+			return "", errors.New("not found")
+		}
+		return longURL, nil
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+		err      error
+	}{
+		{name: "not a short link", url: "https://example.com/page", expected: "https://example.com/page"},
+		{name: "own link", url: "https://SHO.RT/plain", expected: "https://example.com/plain"},
+		{name: "third-party link", url: shortener.URL + "/dest", expected: "https://example.com/final"},
+		{name: "relative redirect", url: shortener.URL + "/relative", expected: "https://example.com/final"},
+		{name: "own link to third-party link", url: "https://sho.rt/external", expected: "https://example.com/final"},
+		{name: "chain within the limit", url: "https://sho.rt/chain2", expected: "https://example.com/plain"},
+		{name: "chain over the limit", url: "https://sho.rt/chain1", err: ErrTooManyHops},
+		{name: "loop", url: "https://sho.rt/loop", err: ErrLoop},
+		{name: "own link with the default port", url: "https://sho.rt:443/plain", expected: "https://example.com/plain"},
+		{name: "own link with the default port of http", url: "http://sho.rt:80/plain", expected: "https://example.com/plain"},
+		{name: "loop with the default port", url: "https://sho.rt/port", err: ErrLoop},
+		{name: "another port is not our host", url: "https://sho.rt:8443/plain", expected: "https://sho.rt:8443/plain"},
+		{name: "missing own link", url: "https://sho.rt/missing", err: ErrNotFollowable},
+		{name: "own non-link page", url: "https://sho.rt/docs/index.html", err: ErrNotFollowable},
+		{name: "third-party page without redirect", url: shortener.URL + "/page", err: ErrNotFollowable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := r.Resolve(context.Background(), tt.url, 3)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestResolve_Deadline(t *testing.T) {
+	// Every hop is slow, but faster than the timeout
+	shortener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		switch r.URL.Path {
+		case "/1":
+			http.Redirect(w, r, "/2", http.StatusFound)
+		case "/2":
+			http.Redirect(w, r, "/3", http.StatusFound)
+		default:
+			http.Redirect(w, r, "https://example.com/final", http.StatusFound)
+		}
+	}))
+	t.Cleanup(shortener.Close)
+
+	serverURL, err := url.Parse(shortener.URL)
+	require.NoError(t, err)
+	lookup := func(context.Context, string) (string, error) { return "", errors.New("not found") }
+
+	t.Run("timeout bounds all the hops", func(t *testing.T) {
+		r, err := New(Config{BaseURL: "https://sho.rt", ShortenerHosts: []string{serverURL.Hostname()}, Timeout: 250 * time.Millisecond}, lookup)
+		require.NoError(t, err)
+
+		_, err = r.Resolve(t.Context(), shortener.URL+"/1", 3)
+		assert.ErrorIs(t, err, ErrNotFollowable)
+	})
+
+	t.Run("cancelled context stops following", func(t *testing.T) {
+		r, err := New(Config{BaseURL: "https://sho.rt", ShortenerHosts: []string{serverURL.Hostname()}}, lookup)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		_, err = r.Resolve(ctx, shortener.URL+"/1", 3)
+		assert.ErrorIs(t, err, ErrNotFollowable)
+	})
+
+	t.Run("follows within the timeout", func(t *testing.T) {
+		r, err := New(Config{BaseURL: "https://sho.rt", ShortenerHosts: []string{serverURL.Hostname()}}, lookup)
+		require.NoError(t, err)
+
+		destination, err := r.Resolve(t.Context(), shortener.URL+"/1", 3)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/final", destination)
+	})
+}
@@ -0,0 +1,317 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rousage/shortener/internal/netguard"
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultConcurrency  = 10
+	defaultMaxAttempts  = 8
+	defaultTimeout      = 10 * time.Second
+	defaultBaseBackoff  = 30 * time.Second
+	maxBackoff          = 6 * time.Hour
+	// maxDrainedBodyLength is how much of a response body is read to reuse the connection, larger bodies close it
+	maxDrainedBodyLength = 64 << 10
+)
+
+// The errors of Send only tell whether the endpoint responded, so they can be shown to the owner of the webhook
+// without revealing anything about the network or the response
+var (
+	ErrUnreachable      = errors.New("endpoint could not be reached")
+	ErrUnexpectedStatus = errors.New("endpoint responded with an unexpected status")
+)
+
+type DispatcherConfig struct {
+	// PollInterval is how often the outbox and the due deliveries are checked
+	PollInterval time.Duration
+	// BatchSize is the maximum number of events and deliveries handled per poll
+	BatchSize int32
+	// Concurrency is the number of deliveries claimed and sent at the same time
+	Concurrency int
+	// MaxAttempts is the number of failed attempts after which a delivery is moved to the dead letters
+	MaxAttempts int32
+	// Timeout bounds a single delivery request
+	Timeout time.Duration
+	// BaseBackoff is the delay before the first retry, it doubles with every further attempt
+	BaseBackoff time.Duration
+	// Transport sends the deliveries, the default one only connects to public addresses
+	Transport http.RoundTripper
+}
+
+// Dispatcher moves events from the outbox to the deliveries of the subscribed webhooks
+// and sends the due deliveries. Failed deliveries are retried with exponential backoff
+// and moved to the dead letters after MaxAttempts failures
+type Dispatcher struct {
+	logger *slog.Logger
+	rep    *repository.Queries
+	client *http.Client
+	cfg    DispatcherConfig
+
+	// OTel metrics
+	deliveryCounter metric.Int64Counter
+}
+
+func NewDispatcher(logger *slog.Logger, rep *repository.Queries, cfg DispatcherConfig) *Dispatcher {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaultBaseBackoff
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = newPublicTransport()
+	}
+
+	d := &Dispatcher{
+		logger: logger,
+		rep:    rep,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: cfg.Transport,
+			// Redirects are not followed, the webhook URL must point to the receiver
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg: cfg,
+	}
+
+	var err error
+	d.deliveryCounter, err = meter.Int64Counter(
+		"webhook.deliveries",
+		metric.WithDescription("Number of webhook delivery attempts by result"),
+		metric.WithUnit("{delivery}"),
+	)
+	if err != nil {
+		logger.Warn("failed to create webhook delivery counter", "error", err)
+	}
+
+	return d
+}
+
+// newPublicTransport returns a transport that refuses to connect to private and reserved addresses,
+// whatever the host of the webhook URL resolves to when the delivery is sent. Proxies are not used,
+// the address of the proxy would be checked instead of the one of the endpoint
+func newPublicTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   netguard.Control,
+	}).DialContext
+
+	return transport
+}
+
+// Run dispatches the webhook events every PollInterval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.Dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch fans out a batch of events from the outbox and sends a batch of due deliveries
+func (d *Dispatcher) Dispatch(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "webhook.Dispatch")
+	defer span.End()
+
+	fannedOut, err := d.rep.FanOutWebhookEvents(ctx, d.cfg.BatchSize)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to fan out webhook events")
+		span.RecordError(err)
+		d.logger.ErrorContext(ctx, "failed to fan out webhook events", "error", err)
+	}
+
+	// Claimed deliveries are hidden from other dispatchers until the lease ends,
+	// so a delivery is retried if the instance stops before recording the result.
+	// Only as many deliveries as are sent at once are claimed at a time, so none waits for its turn past its lease
+	var claimed int
+	for claimed < int(d.cfg.BatchSize) {
+		deliveries, err := d.rep.ClaimWebhookDeliveries(ctx, repository.ClaimWebhookDeliveriesParams{
+			LockedUntil: time.Now().Add(2 * d.cfg.Timeout),
+			Limit:       int32(min(d.cfg.Concurrency, int(d.cfg.BatchSize)-claimed)),
+		})
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to claim webhook deliveries")
+			span.RecordError(err)
+			d.logger.ErrorContext(ctx, "failed to claim webhook deliveries", "error", err)
+			break
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Go(func() {
+				d.deliver(ctx, delivery)
+			})
+		}
+		wg.Wait()
+
+		claimed += len(deliveries)
+		if len(deliveries) < d.cfg.Concurrency || ctx.Err() != nil {
+			break
+		}
+	}
+	span.SetAttributes(attribute.Int64("fannedOut", fannedOut), attribute.Int("deliveries", claimed))
+}
+
+// deliver sends the delivery and records the result
+func (d *Dispatcher) deliver(ctx context.Context, delivery repository.ClaimWebhookDeliveriesRow) {
+	logger := d.logger.With(slog.Int64("deliveryId", delivery.ID), slog.Int64("webhookId", delivery.WebhookID))
+
+	statusCode, sendErr := d.Send(ctx, delivery.Url, delivery.Secret, Envelope{
+		ID:        delivery.EventID,
+		Type:      EventType(delivery.EventType),
+		CreatedAt: delivery.EventCreatedAt,
+		Data:      delivery.Payload,
+	})
+	if ctx.Err() != nil {
+		// Shutting down, the delivery is retried once its lease ends
+		return
+	}
+
+	if sendErr == nil {
+		d.deliveryCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "delivered")))
+		if err := d.rep.DeleteWebhookDelivery(ctx, delivery.ID); err != nil {
+			logger.ErrorContext(ctx, "failed to delete webhook delivery", "error", err)
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	lastError := sendErr.Error()
+	var lastStatusCode *int32
+	if statusCode != 0 {
+		code := int32(statusCode)
+		lastStatusCode = &code
+	}
+
+	if attempts >= d.cfg.MaxAttempts {
+		d.deliveryCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "dead_lettered")))
+		logger.WarnContext(ctx, "webhook delivery failed permanently", "error", sendErr, slog.Int("attempts", int(attempts)))
+
+		err := d.rep.DeadLetterWebhookDelivery(ctx, repository.DeadLetterWebhookDeliveryParams{
+			Attempts:       attempts,
+			LastError:      &lastError,
+			LastStatusCode: lastStatusCode,
+			ID:             delivery.ID,
+		})
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to dead letter webhook delivery", "error", err)
+		}
+		return
+	}
+
+	d.deliveryCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "retried")))
+	err := d.rep.RetryWebhookDelivery(ctx, repository.RetryWebhookDeliveryParams{
+		ID:             delivery.ID,
+		Attempts:       attempts,
+		NextAttemptAt:  time.Now().Add(d.Backoff(attempts)),
+		LastError:      &lastError,
+		LastStatusCode: lastStatusCode,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to retry webhook delivery", "error", err)
+	}
+}
+
+// Backoff returns the delay before the next attempt after the given number of failed attempts:
+// BaseBackoff doubled with every attempt, capped at 6 hours, with up to 10% jitter
+// so failed deliveries of the same receiver do not all retry at once
+func (d *Dispatcher) Backoff(attempts int32) time.Duration {
+	backoff := d.cfg.BaseBackoff
+	for i := int32(1); i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxBackoff)
+
+	return backoff + rand.N(backoff/10+1)
+}
+
+// Send signs and posts the envelope to the URL. Any status other than 2xx is an error.
+// The returned status code is 0 if no response was received. The returned errors are ErrUnreachable and
+// ErrUnexpectedStatus, the cause is only recorded on the span and the response body is never returned
+func (d *Dispatcher) Send(ctx context.Context, url, secret string, envelope Envelope) (int, error) {
+	ctx, span := tracer.Start(ctx, "webhook.Send")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("eventId", envelope.ID), attribute.String("eventType", string(envelope.Type)))
+
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "shortener-webhooks/1.0")
+	req.Header.Set(HeaderID, strconv.FormatInt(envelope.ID, 10))
+	req.Header.Set(HeaderEvent, string(envelope.Type))
+	req.Header.Set(HeaderSignature, Sign(secret, time.Now(), body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		return 0, ErrUnreachable
+	}
+	defer func() {
+		// The body is drained, up to a limit, so the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxDrainedBodyLength))
+		res.Body.Close()
+	}()
+
+	span.SetAttributes(attribute.Int("statusCode", res.StatusCode))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err := fmt.Errorf("%w %d", ErrUnexpectedStatus, res.StatusCode)
+		span.RecordError(err)
+		return res.StatusCode, err
+	}
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rousage/shortener/internal/repository"
+)
+
+// Queue writes the events to the outbox. q must be bound to the transaction of the change
+// the events are about, so events are only emitted for committed changes and never lost.
+// Events are only written for links whose owner has a webhook subscribed to the event type
+func Queue(ctx context.Context, q *repository.Queries, eventType EventType, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(events))
+	payloads := make([]string, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event.Data)
+		if err != nil {
+			return err
+		}
+
+		ids[i] = event.Code
+		payloads[i] = string(payload)
+	}
+
+	return q.CreateWebhookEvents(ctx, repository.CreateWebhookEventsParams{
+		EventType: string(eventType),
+		UrlIds:    ids,
+		Payloads:  payloads,
+	})
+}
+
+// ClickStore writes batches of click events together with their link.clicked events
+type ClickStore struct {
+	db  *pgxpool.Pool
+	rep *repository.Queries
+}
+
+func NewClickStore(db *pgxpool.Pool, rep *repository.Queries) *ClickStore {
+	return &ClickStore{db: db, rep: rep}
+}
+
+func (s *ClickStore) CreateClicks(ctx context.Context, arg []repository.CreateClicksParams) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.rep.WithTx(tx)
+
+	written, err := qtx.CreateClicks(ctx, arg)
+	if err != nil {
+		return 0, err
+	}
+
+	events := make([]Event, len(arg))
+	for i, click := range arg {
+		events[i] = NewClickedLinkEvent(click)
+	}
+	if err := Queue(ctx, qtx, LinkClicked, events...); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return written, nil
+}
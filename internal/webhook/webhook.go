@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rousage/shortener/internal/repository"
+	"go.opentelemetry.io/otel"
+)
+
+const name = "github.com/rousage/shortener/internal/webhook"
+
+var (
+	tracer = otel.Tracer(name)
+	meter  = otel.Meter(name)
+)
+
+type EventType string
+
+const (
+	LinkCreated EventType = "link.created"
+	LinkUpdated EventType = "link.updated"
+	LinkDeleted EventType = "link.deleted"
+	LinkClicked EventType = "link.clicked"
+	// Test is only sent by the test endpoint, it cannot be subscribed to
+	Test EventType = "webhook.test"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// secretPrefix makes webhook secrets recognizable, e.g. by secret scanners
+const secretPrefix = "whsec_"
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Link is the data of link.created and link.updated events
+type Link struct {
+	Code      string     `json:"code"`
+	LongUrl   string     `json:"longUrl"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// DeletedLink is the data of link.deleted events
+type DeletedLink struct {
+	Code      string    `json:"code"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// ClickedLink is the data of link.clicked events
+type ClickedLink struct {
+	Code         string    `json:"code"`
+	ClickedAt    time.Time `json:"clickedAt"`
+	ReferrerHost *string   `json:"referrerHost"`
+	Browser      string    `json:"browser"`
+	Device       string    `json:"device"`
+}
+
+// Event is the data of an event about the link with the code
+type Event struct {
+	Code string
+	Data any
+}
+
+func NewLinkEvent(url repository.Url) Event {
+	return Event{Code: url.ID, Data: Link{
+		Code:      url.ID,
+		LongUrl:   url.LongUrl,
+		ExpiresAt: url.ExpiresAt,
+		CreatedAt: url.CreatedAt,
+		UpdatedAt: url.UpdatedAt,
+	}}
+}
+
+func NewDeletedLinkEvent(code string, deletedAt time.Time) Event {
+	return Event{Code: code, Data: DeletedLink{Code: code, DeletedAt: deletedAt}}
+}
+
+func NewClickedLinkEvent(click repository.CreateClicksParams) Event {
+	return Event{Code: click.UrlID, Data: ClickedLink{
+		Code:         click.UrlID,
+		ClickedAt:    click.ClickedAt,
+		ReferrerHost: click.ReferrerHost,
+		Browser:      click.Browser,
+		Device:       click.Device,
+	}}
+}
+
+// Envelope is the body of a delivery
+type Envelope struct {
+	// ID of the event, the same for all retries and replays of a delivery, so receivers can deduplicate
+	ID        int64           `json:"id"`
+	Type      EventType       `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewSecret generates a random signing secret
+func NewSecret() string {
+	return secretPrefix + rand.Text()
+}
+
+// Sign returns the signature header of the body sent at timestamp: t=<unix timestamp>,v1=<hex HMAC-SHA256>.
+// The signed message is "<unix timestamp>.<body>", so a captured request cannot be replayed with another timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+
+	return fmt.Sprintf("t=%s,v1=%s", unix, hex.EncodeToString(signature(secret, unix, body)))
+}
+
+// Verify checks the signature header of the body, as receivers should do.
+// Signatures older than tolerance are rejected
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var unix, v1 string
+	for part := range strings.SplitSeq(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			v1 = value
+		}
+	}
+
+	timestamp, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if now.Sub(time.Unix(timestamp, 0)).Abs() > tolerance {
+		return ErrInvalidSignature
+	}
+
+	expected, err := hex.DecodeString(v1)
+	if err != nil || !hmac.Equal(expected, signature(secret, unix, body)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func signature(secret, unix string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return mac.Sum(nil)
+}
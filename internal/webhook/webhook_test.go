@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	var (
+		secret    = NewSecret()
+		body      = []byte(`{"id":1,"type":"link.created"}`)
+		timestamp = time.Unix(1700000000, 0)
+	)
+
+	header := Sign(secret, timestamp, body)
+	assert.True(t, strings.HasPrefix(header, "t=1700000000,v1="), "header should contain the timestamp and the signature")
+	assert.Equal(t, header, Sign(secret, timestamp, body), "signature should be deterministic")
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid signature", secret: secret, header: header, body: body, now: timestamp.Add(time.Minute)},
+		{name: "other secret", secret: NewSecret(), header: header, body: body, now: timestamp, wantErr: true},
+		{name: "tampered body", secret: secret, header: header, body: []byte(`{"id":2,"type":"link.created"}`), now: timestamp, wantErr: true},
+		{name: "tampered timestamp", secret: secret, header: strings.Replace(header, "t=1700000000", "t=1700000001", 1), body: body, now: timestamp, wantErr: true},
+		{name: "expired signature", secret: secret, header: header, body: body, now: timestamp.Add(10 * time.Minute), wantErr: true},
+		{name: "malformed header", secret: secret, header: "v1=abc", body: body, now: timestamp, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, 5*time.Minute, tt.now)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSignature)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret := NewSecret()
+
+	assert.True(t, strings.HasPrefix(secret, secretPrefix))
+	assert.NotEqual(t, secret, NewSecret(), "secrets should be random")
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{cfg: DispatcherConfig{BaseBackoff: 30 * time.Second}}
+
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 5, want: 8 * time.Minute},
+		{attempts: 12, want: maxBackoff},
+		{attempts: 100, want: maxBackoff},
+	}
+
+	for _, tt := range tests {
+		backoff := d.Backoff(tt.attempts)
+		assert.GreaterOrEqual(t, backoff, tt.want, "attempts %d", tt.attempts)
+		assert.LessOrEqual(t, backoff, tt.want+tt.want/10, "attempts %d", tt.attempts)
+	}
+}
+
+func TestSend(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("internal details"))
+	}))
+	defer server.Close()
+	envelope := Envelope{Type: Test, CreatedAt: time.Now()}
+
+	t.Run("private addresses are not connected to", func(t *testing.T) {
+		d := NewDispatcher(logger, nil, DispatcherConfig{})
+
+		statusCode, err := d.Send(t.Context(), server.URL, NewSecret(), envelope)
+		assert.ErrorIs(t, err, ErrUnreachable)
+		assert.Zero(t, statusCode)
+	})
+
+	t.Run("response body is not returned", func(t *testing.T) {
+		d := NewDispatcher(logger, nil, DispatcherConfig{Transport: http.DefaultTransport})
+
+		statusCode, err := d.Send(t.Context(), server.URL, NewSecret(), envelope)
+		assert.ErrorIs(t, err, ErrUnexpectedStatus)
+		assert.Equal(t, http.StatusInternalServerError, statusCode)
+		assert.NotContains(t, err.Error(), "internal details")
+	})
+
+	t.Run("connections are reused", func(t *testing.T) {
+		var connections atomic.Int32
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(strings.Repeat("ok", 8<<10)))
+		}))
+		server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				connections.Add(1)
+			}
+		}
+		server.Start()
+		defer server.Close()
+
+		d := NewDispatcher(logger, nil, DispatcherConfig{Transport: http.DefaultTransport.(*http.Transport).Clone()})
+		for range 3 {
+			_, err := d.Send(t.Context(), server.URL, NewSecret(), envelope)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), connections.Load())
+	})
+}